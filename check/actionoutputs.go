@@ -0,0 +1,44 @@
+package check
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// writeActionOutputs appends the run's results to the GitHub Actions output
+// file at path, usually $GITHUB_OUTPUT, so later steps can branch on them as
+// steps.<id>.outputs.<name>. reportPath is the first report written to a
+// file, if any.
+func writeActionOutputs(path string, res result, reportPath string) error {
+	failures := countFailures(res.errors)
+	owned, total := res.coverage()
+	unowned := []string{}
+	for _, e := range res.errors {
+		if e.code == codeMissingEntry {
+			unowned = append(unowned, e.path)
+		}
+	}
+	paths, err := json.Marshal(unowned)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "failure_count=%d\n", failures)
+	fmt.Fprintf(&b, "warning_count=%d\n", len(res.errors)-failures)
+	fmt.Fprintf(&b, "coverage_percent=%.1f\n", percent(owned, total))
+	fmt.Fprintf(&b, "report_path=%s\n", reportPath)
+	fmt.Fprintf(&b, "unowned_paths=%s\n", paths)
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(b.String()); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
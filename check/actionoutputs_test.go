@@ -0,0 +1,53 @@
+package check
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteActionOutputs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "output")
+	if err := os.WriteFile(path, []byte("earlier=1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	res := result{
+		checked: []checkedDir{
+			{path: "services/api", rule: &rule{}},
+			{path: "services/new"},
+			{path: "services/other"},
+		},
+		errors: []validationError{
+			{path: "services/new", message: "Not covered by CODEOWNERS.", code: codeMissingEntry},
+			{path: "services/other", message: "Not covered by CODEOWNERS.", code: codeMissingEntry},
+			{path: "services/api", message: "Rule expired.", severity: severityWarning},
+		},
+	}
+
+	if err := writeActionOutputs(path, res, "codeowners.rdjson"); err != nil {
+		t.Fatalf("writeActionOutputs() error = %v", err)
+	}
+	got, _ := os.ReadFile(path)
+	want := `earlier=1
+failure_count=2
+warning_count=1
+coverage_percent=33.3
+report_path=codeowners.rdjson
+unowned_paths=["services/new","services/other"]
+`
+	if string(got) != want {
+		t.Errorf("GITHUB_OUTPUT =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWriteActionOutputsPassing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "output")
+	if err := writeActionOutputs(path, result{}, ""); err != nil {
+		t.Fatalf("writeActionOutputs() error = %v", err)
+	}
+	got, _ := os.ReadFile(path)
+	want := "failure_count=0\nwarning_count=0\ncoverage_percent=100.0\nreport_path=\nunowned_paths=[]\n"
+	if string(got) != want {
+		t.Errorf("GITHUB_OUTPUT = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,119 @@
+package check
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// patternMatch is a path with a name an unanchored pattern matches.
+type patternMatch struct {
+	path  string
+	isDir bool
+}
+
+// pattern returns the anchored pattern owning just m.
+func (m patternMatch) pattern() string {
+	if m.isDir {
+		return "/" + m.path + "/"
+	}
+	return "/" + m.path
+}
+
+// checkPatternAnchoring reports rules whose patterns match more than they
+// look like they do: a bare name like docs/, which matches at any depth,
+// and a directory's path without a trailing slash, which would match a file
+// by that name too. Where the fix is unambiguous, because the pattern only
+// matches one path today, it's offered for --interactive to apply.
+func (c *checker) checkPatternAnchoring() []validationError {
+	var errors []validationError
+	report := func(r *rule, code, message, fix string) {
+		errors = append(errors, validationError{
+			path:     r.RawPattern(),
+			message:  message,
+			code:     code,
+			severity: c.policy.Severity,
+			team:     r.ownerNames(),
+			file:     r.file,
+			line:     r.LineNumber,
+			fix:      fix,
+		})
+	}
+
+	names := make(map[string][]patternMatch)
+	for _, r := range c.rules {
+		if name, ok := unanchoredName(r.RawPattern()); ok {
+			names[name] = nil
+		}
+	}
+	if len(names) > 0 {
+		findNames(c.fsys, ".", names)
+	}
+
+	for i := range c.rules {
+		r := &c.rules[i]
+		pattern := r.RawPattern()
+		if name, ok := unanchoredName(pattern); ok {
+			var matches []patternMatch
+			for _, m := range names[name] {
+				// A pattern ending in a slash only matches directories.
+				if m.isDir || !strings.HasSuffix(pattern, "/") {
+					matches = append(matches, m)
+				}
+			}
+			switch len(matches) {
+			case 0:
+				report(r, codeUnanchoredPattern, fmt.Sprintf("Matches %s at any depth, not just at the root. Anchor it with a leading /, or write **/%s if any depth is meant.", name, name), "")
+			case 1:
+				fix := matches[0].pattern()
+				report(r, codeUnanchoredPattern, fmt.Sprintf("Matches %s at any depth, though today that's only %s. Anchor it: %s", name, matches[0].path, fix), fix)
+			default:
+				report(r, codeUnanchoredPattern, fmt.Sprintf("Matches %d paths named %s at any depth, such as %s and %s. Anchor it with a leading /, or write **/%s if all of them are meant.",
+					len(matches), name, matches[0].path, matches[1].path, name), "")
+			}
+			continue
+		}
+
+		// What's left is anchored, by a leading slash or one in the
+		// middle, like services/foo.
+		if strings.HasSuffix(pattern, "/") || strings.ContainsAny(pattern, `*?[\`) {
+			continue
+		}
+		dir := strings.TrimPrefix(pattern, "/")
+		if info, err := c.fsys.Stat(dir); err == nil && info.IsDir() {
+			fix := "/" + dir + "/"
+			report(r, codeNoTrailingSlash, fmt.Sprintf("Owns the directory %s without a trailing slash, so it would match a file by that name too. Add one: %s", dir, fix), fix)
+		}
+	}
+	return errors
+}
+
+// unanchoredName returns the name a pattern like docs or docs/ matches at any
+// depth. Patterns with a slash before the end are anchored to the root
+// already, and globs like *.go are meant to match anywhere.
+func unanchoredName(pattern string) (string, bool) {
+	name := strings.TrimSuffix(pattern, "/")
+	if name == "" || strings.ContainsAny(name, `/*?[\`) {
+		return "", false
+	}
+	return name, true
+}
+
+// findNames walks dir, adding each path whose name is a key of names to its
+// matches.
+func findNames(fsys fileSystem, dir string, names map[string][]patternMatch) {
+	entries, err := fsys.ReadDir(dir)
+	if err != nil {
+		logger.Debug("reading directory", "path", dir, "error", err)
+		return
+	}
+	for _, e := range entries {
+		name := path.Join(dir, e.Name())
+		if matches, ok := names[e.Name()]; ok {
+			names[e.Name()] = append(matches, patternMatch{path: name, isDir: e.IsDir()})
+		}
+		if e.IsDir() {
+			findNames(fsys, name, names)
+		}
+	}
+}
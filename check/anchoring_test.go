@@ -0,0 +1,60 @@
+package check
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckPatternAnchoring(t *testing.T) {
+	rules, err := parseCodeowners(strings.NewReader(`*.go @org/go
+docs/ @org/docs
+Makefile @org/build
+generated/ @org/platform
+scripts @org/tools
+/services/api @org/api
+/services/web/ @org/web
+/services/api/main.go @org/api
+services/legacy @org/legacy
+services/web/generated @org/web
+`), "CODEOWNERS", providerGitHub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree := newTreeFS(map[string]bool{
+		"docs":                       true,
+		"Makefile":                   false,
+		"services/api/Makefile":      false,
+		"services/api/main.go":       false,
+		"services/api/generated":     true,
+		"services/web/generated":     true,
+		"services/web/docs.md":       false,
+		"services/legacy/scripts.sh": false,
+	})
+
+	c := &checker{rules: rules, fsys: tree, policy: policy{AnchoredPatterns: true}}
+	var got []string
+	for _, e := range c.checkPolicy(nil) {
+		got = append(got, e.code+" "+e.path+" "+e.fix)
+		if e.file != "CODEOWNERS" || e.line == 0 || e.team == "" {
+			t.Errorf("problem %v is not located at its rule", e)
+		}
+	}
+	want := []string{
+		"RCO012 docs/ /docs/",
+		"RCO012 Makefile ",
+		"RCO012 generated/ ",
+		"RCO012 scripts ",
+		"RCO013 /services/api /services/api/",
+		// Anchored by the slash in the middle, but without a trailing one.
+		"RCO013 services/legacy /services/legacy/",
+		"RCO013 services/web/generated /services/web/generated/",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("checkPolicy() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	c.policy.AnchoredPatterns = false
+	if errs := c.checkPolicy(nil); len(errs) != 0 {
+		t.Errorf("checkPolicy() without anchored_patterns = %v, want no errors", errs)
+	}
+}
@@ -0,0 +1,75 @@
+package check
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ruleAnnotations are the dates a CODEOWNERS rule's trailing comment
+// records, like:
+//
+//	/services/payments/ @org/payments # reviewed:2026-06-01 expires:2027-06-01
+//
+// expires is the last day the ownership is valid, and reviewed the day it
+// was last confirmed. Either may be missing.
+type ruleAnnotations struct {
+	expires, reviewed string
+}
+
+// parseRuleAnnotations reads the annotations in comment. A suppression
+// directive and what follows it are its own, so they're left out.
+func parseRuleAnnotations(comment string) ruleAnnotations {
+	comment, _, _ = strings.Cut(comment, suppressionDirective)
+	var a ruleAnnotations
+	for _, field := range strings.Fields(comment) {
+		if v, ok := strings.CutPrefix(field, "expires:"); ok {
+			a.expires = v
+		} else if v, ok := strings.CutPrefix(field, "reviewed:"); ok {
+			a.reviewed = v
+		}
+	}
+	return a
+}
+
+// checkRuleAnnotations reports rules whose expires: date has passed and,
+// with review_max_days, rules that weren't reviewed within that many days.
+func (c *checker) checkRuleAnnotations(now time.Time) []validationError {
+	var errors []validationError
+	report := func(r *rule, code, message string) {
+		errors = append(errors, validationError{
+			path:     r.RawPattern(),
+			message:  fmt.Sprintf("%s %s: %s", message, r.location(), r.RawPattern()),
+			code:     code,
+			severity: c.policy.Severity,
+			team:     r.ownerNames(),
+			file:     r.file,
+			line:     r.LineNumber,
+		})
+	}
+	today := now.Format(suppressionDateLayout)
+	for i := range c.rules {
+		r := &c.rules[i]
+		a := parseRuleAnnotations(r.Comment)
+		if a.expires != "" {
+			if _, err := time.Parse(suppressionDateLayout, a.expires); err != nil {
+				report(r, codeRuleExpired, fmt.Sprintf("expires:%s is not a date like 2006-01-02. Fix it in", a.expires))
+			} else if a.expires < today {
+				report(r, codeRuleExpired, fmt.Sprintf("Ownership expired on %s. Confirm the owners and move the expires: date in", a.expires))
+			}
+		}
+		days := c.policy.ReviewMaxDays
+		if days == 0 {
+			continue
+		}
+		switch reviewed, err := time.Parse(suppressionDateLayout, a.reviewed); {
+		case a.reviewed == "":
+			report(r, codeReviewOverdue, fmt.Sprintf("Has no reviewed: date, and owners must be confirmed every %d days. Add # reviewed:%s once they are, in", days, today))
+		case err != nil:
+			report(r, codeReviewOverdue, fmt.Sprintf("reviewed:%s is not a date like 2006-01-02. Fix it in", a.reviewed))
+		case reviewed.AddDate(0, 0, days).Format(suppressionDateLayout) < today:
+			report(r, codeReviewOverdue, fmt.Sprintf("Last reviewed on %s, more than %d days ago. Confirm the owners and update the reviewed: date in", a.reviewed, days))
+		}
+	}
+	return errors
+}
@@ -0,0 +1,78 @@
+package check
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRuleAnnotations(t *testing.T) {
+	tests := []struct {
+		comment string
+		want    ruleAnnotations
+	}{
+		{"", ruleAnnotations{}},
+		{"payments team", ruleAnnotations{}},
+		{"reviewed:2026-06-01 expires:2027-06-01", ruleAnnotations{expires: "2027-06-01", reviewed: "2026-06-01"}},
+		{"owned until the migration expires:2027-01-01", ruleAnnotations{expires: "2027-01-01"}},
+		{"reviewed:2026-06-01 requirecodeowners:ignore RCO006 expires:2026-12-31", ruleAnnotations{reviewed: "2026-06-01"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.comment, func(t *testing.T) {
+			if got := parseRuleAnnotations(tt.comment); got != tt.want {
+				t.Errorf("parseRuleAnnotations(%q) = %+v, want %+v", tt.comment, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckRuleAnnotations(t *testing.T) {
+	now := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+	rules, err := parseCodeowners(strings.NewReader(`/services/expired/ @org/a # expires:2026-10-13
+/services/today/ @org/a # expires:2026-10-14 reviewed:2026-10-14
+/services/stale/ @org/b # reviewed:2025-10-13
+/services/recent/ @org/b # reviewed:2025-10-14
+/services/unreviewed/ @org/c
+/services/typo/ @org/c # reviewed:2026-13-01
+`), "CODEOWNERS", providerGitHub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		policy policy
+		want   []string
+	}{
+		{
+			name: "expiry only",
+			want: []string{"RCO026 /services/expired/"},
+		},
+		{
+			name:   "with review_max_days",
+			policy: policy{ReviewMaxDays: 365},
+			want: []string{
+				"RCO026 /services/expired/",
+				"RCO027 /services/expired/",
+				"RCO027 /services/stale/",
+				"RCO027 /services/unreviewed/",
+				"RCO027 /services/typo/",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &checker{rules: rules, policy: tt.policy}
+			var got []string
+			for _, e := range c.checkRuleAnnotations(now) {
+				got = append(got, e.code+" "+e.path)
+				if e.file != "CODEOWNERS" || e.line == 0 || e.team == "" {
+					t.Errorf("problem %v is not located at its rule", e)
+				}
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("problems = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
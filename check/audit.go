@@ -0,0 +1,159 @@
+package check
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Thresholds for audit's --fail-on: fail on any failure, on any finding, or
+// never.
+const (
+	failOnError   = "error"
+	failOnWarning = "warning"
+	failOnNever   = "never"
+)
+
+// auditSections are the parts of the audit report, in order, each with the
+// findings it collects.
+var auditSections = []struct {
+	title string
+	has   func(code string) bool
+}{
+	{"Coverage", func(code string) bool { return auditSection(code) == "coverage" }},
+	{"CODEOWNERS lint and policy", func(code string) bool { return auditSection(code) == "lint" }},
+	{"Stale rules", func(code string) bool { return auditSection(code) == "stale" }},
+	{"Owner verification", func(code string) bool { return auditSection(code) == "owners" }},
+}
+
+// auditSection returns which part of the audit report a finding with code
+// belongs in.
+func auditSection(code string) string {
+	switch {
+	case code == codeStaleRule || code == codeRuleExpired || code == codeReviewOverdue:
+		return "stale"
+	case code == codeInvalidOwner || code == codeRateLimited || code == codeVerifyFailed:
+		return "owners"
+	case code >= "RCO010" && code < "RCO030":
+		return "lint"
+	default:
+		return "coverage"
+	}
+}
+
+// staleRules reports the rules for paths that don't exist anymore, which
+// own nothing. Only patterns naming one path are checked; globs and bare
+// names match wherever they can. Rules already reported in known, like those
+// whose case doesn't match the disk, are left out.
+func staleRules(fsys fileSystem, rules ruleset, known []validationError) []validationError {
+	reported := make(map[string]bool)
+	for _, e := range known {
+		if e.line > 0 {
+			reported[fmt.Sprintf("%s:%d", e.file, e.line)] = true
+		}
+	}
+	var errors []validationError
+	for i := range rules {
+		r := &rules[i]
+		if reported[fmt.Sprintf("%s:%d", r.file, r.LineNumber)] {
+			continue
+		}
+		pattern := r.RawPattern()
+		p := strings.Trim(strings.TrimSuffix(pattern, "/**"), "/")
+		if p == "" || strings.ContainsAny(p, `*?[\`) {
+			continue
+		}
+		if !strings.HasPrefix(pattern, "/") && !strings.Contains(p, "/") {
+			continue
+		}
+		if _, err := fsys.Stat(p); !os.IsNotExist(err) {
+			continue
+		}
+		errors = append(errors, validationError{
+			path:     pattern,
+			message:  fmt.Sprintf("Names %s, which doesn't exist, so the rule owns nothing. Remove it from %s, or fix its path.", p, r.location()),
+			code:     codeStaleRule,
+			severity: severityWarning,
+			team:     r.ownerNames(),
+			file:     r.file,
+			line:     r.LineNumber,
+		})
+	}
+	return errors
+}
+
+// writeAudit writes the audit report's sections for errors, then the
+// ownership statistics. verification, if set, is why owners weren't
+// verified.
+func writeAudit(w io.Writer, errors []validationError, stats ownershipStats, verification string) error {
+	sortErrors(errors)
+	for _, s := range auditSections {
+		var found []validationError
+		for _, e := range errors {
+			if s.has(e.code) {
+				found = append(found, e)
+			}
+		}
+		failures := countFailures(found)
+		switch {
+		case s.title == "Owner verification" && verification != "" && len(found) == 0:
+			fmt.Fprintf(w, "%s: skipped (%s)\n\n", s.title, verification)
+			continue
+		case len(found) == 0:
+			fmt.Fprintf(w, "%s\n", console.mark(w, markOK, s.title))
+		default:
+			m := markFail
+			if failures == 0 {
+				m = markWarn
+			}
+			fmt.Fprintf(w, "%s: %s\n", console.mark(w, m, s.title), auditCounts(failures, len(found)-failures))
+			printFindings(w, found, 0)
+		}
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintln(w, "Statistics")
+	var b strings.Builder
+	if err := writeStatsText(&b, stats); err != nil {
+		return err
+	}
+	for _, line := range strings.Split(strings.TrimRight(b.String(), "\n"), "\n") {
+		if line == "" {
+			fmt.Fprintln(w)
+			continue
+		}
+		fmt.Fprintf(w, "  %s\n", line)
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
+
+// auditCounts describes how many failures and warnings there are, like "2
+// failures, 1 warning".
+func auditCounts(failures, warnings int) string {
+	var parts []string
+	if failures > 0 {
+		parts = append(parts, fmt.Sprintf("%d %s", failures, pluralize(failures, "failure", "failures")))
+	}
+	if warnings > 0 {
+		parts = append(parts, fmt.Sprintf("%d %s", warnings, pluralize(warnings, "warning", "warnings")))
+	}
+	if len(parts) == 0 {
+		return "no problems"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// auditFails reports whether errors fail the audit at the --fail-on
+// threshold.
+func auditFails(errors []validationError, failOn string) bool {
+	switch failOn {
+	case failOnNever:
+		return false
+	case failOnWarning:
+		return len(errors) > 0
+	default:
+		return countFailures(errors) > 0
+	}
+}
@@ -0,0 +1,113 @@
+package check
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestStaleRules(t *testing.T) {
+	rules, err := parseCodeowners(strings.NewReader(`* @org/everyone
+/services/ @org/services
+/services/gone/ @org/old
+services/api/main.go @org/api
+/services/old.go @org/old
+/docs/** @org/docs
+/services/*/vendor/ @org/vendor
+docs/ @org/docs
+/Services/web/ @org/web
+`), "CODEOWNERS", providerGitHub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree := newTreeFS(map[string]bool{
+		"services/api/main.go": false,
+		"services/web":         true,
+	})
+	// The case mismatch is reported as RCO014 already.
+	known := []validationError{{path: "/Services/web/", code: codePatternCase, file: "CODEOWNERS", line: 9}}
+
+	var got []string
+	for _, e := range staleRules(tree, rules, known) {
+		got = append(got, e.path)
+		if e.code != codeStaleRule || !e.isWarning() || e.file != "CODEOWNERS" || e.line == 0 {
+			t.Errorf("problem %v isn't a %s warning at its rule", e, codeStaleRule)
+		}
+	}
+	want := []string{"/services/gone/", "/services/old.go", "/docs/**"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("staleRules() = %v, want %v", got, want)
+	}
+}
+
+func TestAuditSection(t *testing.T) {
+	tests := map[string]string{
+		codeMissingEntry:     "coverage",
+		codeRegression:       "coverage",
+		codeCatalogUnowned:   "coverage",
+		codeTooFewApprovals:  "coverage",
+		codeTruncatedTree:    "coverage",
+		codeOverlappingSpecs: "lint",
+		codeOwnerSpelling:    "lint",
+		codeParentConflict:   "lint",
+		codeStaleRule:        "stale",
+		codeRuleExpired:      "stale",
+		codeReviewOverdue:    "stale",
+		codeInvalidOwner:     "owners",
+		codeRateLimited:      "owners",
+	}
+	for code, want := range tests {
+		if got := auditSection(code); got != want {
+			t.Errorf("auditSection(%s) = %q, want %q", code, got, want)
+		}
+	}
+}
+
+func TestAuditFails(t *testing.T) {
+	failure := validationError{path: "a", code: codeMissingEntry}
+	warning := validationError{path: "b", code: codeStaleRule, severity: severityWarning}
+	tests := []struct {
+		errors []validationError
+		failOn string
+		want   bool
+	}{
+		{nil, failOnError, false},
+		{[]validationError{warning}, failOnError, false},
+		{[]validationError{warning, failure}, failOnError, true},
+		{[]validationError{warning}, failOnWarning, true},
+		{nil, failOnWarning, false},
+		{[]validationError{failure}, failOnNever, false},
+	}
+	for _, tt := range tests {
+		if got := auditFails(tt.errors, tt.failOn); got != tt.want {
+			t.Errorf("auditFails(%d problems, %s) = %v, want %v", len(tt.errors), tt.failOn, got, tt.want)
+		}
+	}
+}
+
+func TestWriteAudit(t *testing.T) {
+	errors := []validationError{
+		{path: "services/web", message: "Not covered.", code: codeMissingEntry},
+		{path: "/services/gone/", message: "Names services/gone.", code: codeStaleRule, severity: severityWarning},
+	}
+	stats := computeStats([]checkedDir{{path: "services/api"}, {path: "services/web"}})
+	var b bytes.Buffer
+	if err := writeAudit(&b, errors, stats, "--offline"); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"Coverage: 1 failure\n  ✗ services/web\n",
+		"CODEOWNERS lint and policy\n",
+		"Stale rules: 1 warning\n  ⚠ /services/gone/\n",
+		"Owner verification: skipped (--offline)\n",
+		"Statistics\n  Checked directories: 2\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("writeAudit() output is missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Index(out, "Coverage") > strings.Index(out, "Stale rules") {
+		t.Errorf("writeAudit() sections are out of order:\n%s", out)
+	}
+}
@@ -0,0 +1,51 @@
+package check
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+)
+
+// badgeColors are the shields.io colors for coverage, from the highest
+// threshold down: coverage at or above min gets color.
+var badgeColors = []struct {
+	min   float64
+	color string
+}{
+	{100, "brightgreen"},
+	{90, "green"},
+	{75, "yellow"},
+	{50, "orange"},
+	{0, "red"},
+}
+
+// shieldsBadge is a shields.io endpoint badge:
+// https://shields.io/badges/endpoint-badge
+type shieldsBadge struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Label         string `json:"label"`
+	Message       string `json:"message"`
+	Color         string `json:"color"`
+}
+
+// writeBadge writes ownership coverage as shields.io endpoint JSON, to be
+// published from CI and shown as a badge. Directories checked by more than
+// one spec are counted once.
+func writeBadge(w io.Writer, res result, _ reportOptions) error {
+	stats := computeStats(res.checked)
+	coverage := percent(stats.Owned, stats.Checked)
+	b := shieldsBadge{
+		SchemaVersion: 1,
+		Label:         "codeowners coverage",
+		// Rounded down, so 99.6% doesn't read as full coverage.
+		Message: fmt.Sprintf("%.0f%%", math.Floor(coverage)),
+	}
+	for _, c := range badgeColors {
+		if coverage >= c.min {
+			b.Color = c.color
+			break
+		}
+	}
+	return json.NewEncoder(w).Encode(b)
+}
@@ -0,0 +1,47 @@
+package check
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestWriteBadge(t *testing.T) {
+	owned := &rule{}
+	dirs := func(ownedCount, total int) []checkedDir {
+		var checked []checkedDir
+		for i := 0; i < total; i++ {
+			d := checkedDir{path: fmt.Sprintf("services/s%d", i)}
+			if i < ownedCount {
+				d.rule = owned
+			}
+			checked = append(checked, d)
+		}
+		return checked
+	}
+
+	tests := []struct {
+		name    string
+		checked []checkedDir
+		want    string
+	}{
+		{"full", dirs(4, 4), `{"schemaVersion":1,"label":"codeowners coverage","message":"100%","color":"brightgreen"}`},
+		{"nothing checked", nil, `{"schemaVersion":1,"label":"codeowners coverage","message":"100%","color":"brightgreen"}`},
+		{"rounded down", dirs(249, 250), `{"schemaVersion":1,"label":"codeowners coverage","message":"99%","color":"green"}`},
+		{"yellow", dirs(8, 10), `{"schemaVersion":1,"label":"codeowners coverage","message":"80%","color":"yellow"}`},
+		{"orange", dirs(1, 2), `{"schemaVersion":1,"label":"codeowners coverage","message":"50%","color":"orange"}`},
+		{"red", dirs(1, 10), `{"schemaVersion":1,"label":"codeowners coverage","message":"10%","color":"red"}`},
+		{"counted once", append(dirs(1, 2), checkedDir{path: "services/s1"}), `{"schemaVersion":1,"label":"codeowners coverage","message":"50%","color":"orange"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := writeBadge(&buf, result{checked: tt.checked}, reportOptions{}); err != nil {
+				t.Fatalf("writeBadge() error = %v", err)
+			}
+			if got := buf.String(); got != tt.want+"\n" {
+				t.Errorf("writeBadge() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package check
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// runBatch checks each repository root in turn, each with its own config and
+// CODEOWNERS, and prints one combined report. configPath and codeownersPaths
+// are resolved inside every repository. Once ctx is done, the repositories
+// not yet reached are left out.
+func runBatch(ctx context.Context, repos []string, configPath string, codeownersPaths []string, filter specFilter, opts reportOptions) int {
+	var all []validationError
+	failed := 0
+	for _, repo := range repos {
+		errs := checkRepoAt(ctx, repo, configPath, codeownersPaths, filter)
+		if countFailures(errs) > 0 {
+			failed++
+		}
+		all = append(all, errs...)
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	if len(all) > 0 {
+		printErrors(all, opts)
+	}
+	if failed > 0 {
+		fmt.Fprintln(os.Stderr, console.mark(os.Stderr, markFail, fmt.Sprintf("%d of %d %s failed CODEOWNERS check", failed, len(repos), pluralize(len(repos), "repository", "repositories"))))
+		return 1
+	}
+
+	if len(all) == 0 && !opts.quiet {
+		fmt.Println(console.mark(os.Stdout, markOK, fmt.Sprintf("all %d %s have CODEOWNERS coverage", len(repos), pluralize(len(repos), "repository", "repositories"))))
+	}
+	return 0
+}
+
+// checkRepoAt runs checkRepo inside repo and returns its findings with paths
+// prefixed by the repository. A check that can't run is reported as a
+// finding against the repository itself, so one broken repository doesn't
+// hide the results of the others.
+func checkRepoAt(ctx context.Context, repo string, configPath string, codeownersPaths []string, filter specFilter) []validationError {
+	wd, err := os.Getwd()
+	if err != nil {
+		return []validationError{{path: repo, message: fmt.Sprintf("Cannot check: %v", err), code: codeCheckFailed}}
+	}
+	defer func() { _ = os.Chdir(wd) }()
+
+	if err := os.Chdir(repo); err != nil {
+		return []validationError{{path: repo, message: fmt.Sprintf("Cannot check: %v", err), code: codeCheckFailed}}
+	}
+	res, err := checkRepo(ctx, configPath, codeownersPaths, filter)
+	if err != nil {
+		return []validationError{{path: repo, message: fmt.Sprintf("Cannot check: %v", err), code: codeCheckFailed}}
+	}
+	errs := res.errors
+	for i := range errs {
+		errs[i].path = filepath.ToSlash(filepath.Join(repo, errs[i].path))
+	}
+	return errs
+}
+
+// readReposFile reads a manifest of repository roots, one per line. Blank
+// lines and lines starting with # are ignored, and relative paths are
+// resolved against the manifest's directory.
+func readReposFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening repos file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var repos []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !filepath.IsAbs(line) {
+			line = filepath.Join(filepath.Dir(path), line)
+		}
+		repos = append(repos, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading repos file: %w", err)
+	}
+	return repos, nil
+}
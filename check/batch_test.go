@@ -0,0 +1,81 @@
+package check
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckRepoAt(t *testing.T) {
+	tmpDir, _ := filepath.EvalSymlinks(t.TempDir())
+
+	// good has full coverage, bad is missing an owner for pkg, and broken has
+	// no config at all
+	for _, repo := range []string{"good", "bad", "broken"} {
+		os.MkdirAll(filepath.Join(tmpDir, repo, "src"), 0755)
+		os.MkdirAll(filepath.Join(tmpDir, repo, "pkg"), 0755)
+		os.MkdirAll(filepath.Join(tmpDir, repo, ".git"), 0755)
+	}
+	config := []byte("directories:\n  - path: src\n  - path: pkg\n")
+	os.WriteFile(filepath.Join(tmpDir, "good", ".requirecodeowners.yml"), config, 0644)
+	os.WriteFile(filepath.Join(tmpDir, "good", "CODEOWNERS"), []byte("* @team\n"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "bad", ".requirecodeowners.yml"), config, 0644)
+	os.WriteFile(filepath.Join(tmpDir, "bad", "CODEOWNERS"), []byte("/src/ @team\n"), 0644)
+
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	tests := []struct {
+		repo     string
+		wantPath string
+		wantMsg  string
+	}{
+		{repo: "good"},
+		{repo: "bad", wantPath: filepath.Join("bad", "pkg"), wantMsg: "Not covered by CODEOWNERS"},
+		{repo: "broken", wantPath: "broken", wantMsg: "Cannot check: reading config file"},
+		{repo: "missing", wantPath: "missing", wantMsg: "Cannot check"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.repo, func(t *testing.T) {
+			errs := checkRepoAt(context.Background(), tt.repo, "", nil, specFilter{})
+			if wd, _ := os.Getwd(); wd != tmpDir {
+				t.Errorf("checkRepoAt() left working directory at %s", wd)
+			}
+			if tt.wantPath == "" {
+				if len(errs) != 0 {
+					t.Errorf("checkRepoAt() errors = %v, want none", errs)
+				}
+				return
+			}
+			if len(errs) != 1 {
+				t.Fatalf("checkRepoAt() errors = %v, want 1 error", errs)
+			}
+			if errs[0].path != tt.wantPath || !strings.Contains(errs[0].message, tt.wantMsg) {
+				t.Errorf("checkRepoAt() error = %v, want %s: %s", errs[0], tt.wantPath, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestReadReposFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	manifest := filepath.Join(tmpDir, "repos.txt")
+	os.WriteFile(manifest, []byte(`# platform repos
+repoA
+
+/abs/repoB
+`), 0644)
+
+	repos, err := readReposFile(manifest)
+	if err != nil {
+		t.Fatalf("readReposFile() error = %v", err)
+	}
+	want := []string{filepath.Join(tmpDir, "repoA"), "/abs/repoB"}
+	if strings.Join(repos, ",") != strings.Join(want, ",") {
+		t.Errorf("readReposFile() = %v, want %v", repos, want)
+	}
+}
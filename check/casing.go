@@ -0,0 +1,90 @@
+package check
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+)
+
+// checkPatternCase reports rules whose patterns name a path in a different
+// case than it has on disk, like /Services/ for services. Patterns match
+// case-sensitively, so such a rule seems to work on a case-insensitive file
+// system, as on macOS, but owns nothing on GitHub or in Linux CI. The fix is
+// the pattern with the on-disk case, which --interactive can apply.
+func (c *checker) checkPatternCase() []validationError {
+	var errors []validationError
+	listings := make(map[string][]fs.DirEntry)
+	readDir := func(dir string) []fs.DirEntry {
+		entries, ok := listings[dir]
+		if !ok {
+			entries, _ = c.fsys.ReadDir(dir)
+			listings[dir] = entries
+		}
+		return entries
+	}
+
+	for i := range c.rules {
+		r := &c.rules[i]
+		pattern := r.RawPattern()
+		segs := literalPrefix(pattern)
+		if len(segs) == 0 {
+			continue
+		}
+		actual, ok := resolveCase(readDir, segs)
+		if !ok || slices.Equal(actual, segs) {
+			continue
+		}
+		parts := strings.Split(pattern, "/")
+		offset := 0
+		if parts[0] == "" {
+			offset = 1
+		}
+		copy(parts[offset:], actual)
+		fix := strings.Join(parts, "/")
+		onDisk := strings.Join(actual, "/")
+		errors = append(errors, validationError{
+			path: pattern,
+			message: fmt.Sprintf("Names %s, which is %s on disk. Patterns match case-sensitively, so the rule only applies on case-insensitive file systems like macOS's, not on GitHub. Use: %s",
+				strings.Join(segs, "/"), onDisk, fix),
+			code:     codePatternCase,
+			severity: severityWarning,
+			team:     r.ownerNames(),
+			file:     r.file,
+			line:     r.LineNumber,
+			fix:      fix,
+		})
+	}
+	return errors
+}
+
+// resolveCase returns segs, the path segments of a pattern, in the case
+// they have on disk. A segment with no entry by that name in any case, or
+// with several differing only in case, can't be resolved, and ok is false.
+func resolveCase(readDir func(string) []fs.DirEntry, segs []string) (actual []string, ok bool) {
+	dir := "."
+	for _, seg := range segs {
+		var exact bool
+		var folded []string
+		for _, e := range readDir(dir) {
+			if e.Name() == seg {
+				exact = true
+				break
+			}
+			if strings.EqualFold(e.Name(), seg) {
+				folded = append(folded, e.Name())
+			}
+		}
+		switch {
+		case exact:
+		case len(folded) == 1:
+			seg = folded[0]
+		default:
+			return nil, false
+		}
+		actual = append(actual, seg)
+		dir = path.Join(dir, seg)
+	}
+	return actual, true
+}
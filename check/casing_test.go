@@ -0,0 +1,44 @@
+package check
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckPatternCase(t *testing.T) {
+	rules, err := parseCodeowners(strings.NewReader(`* @org/everyone
+/Services/ @org/services
+/services/API/*.go @org/api
+services/Web/README.md @org/web
+/services/web/ @org/web
+/Docs/ @org/docs
+/missing/ @org/nobody
+`), "CODEOWNERS", providerGitHub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree := newTreeFS(map[string]bool{
+		"services/api/main.go":   false,
+		"services/web/README.md": false,
+		// docs and DOCS both exist, so /Docs/ can't be resolved.
+		"docs": true,
+		"DOCS": true,
+	})
+
+	c := &checker{rules: rules, fsys: tree}
+	var got []string
+	for _, e := range c.checkPatternCase() {
+		got = append(got, e.path+" "+e.fix)
+		if e.code != codePatternCase || e.file != "CODEOWNERS" || e.line == 0 {
+			t.Errorf("problem %v isn't a %s at its rule", e, codePatternCase)
+		}
+	}
+	want := []string{
+		"/Services/ /services/",
+		"/services/API/*.go /services/api/*.go",
+		"services/Web/README.md services/web/README.md",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("checkPatternCase() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,238 @@
+package check
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/hmarr/codeowners"
+	"gopkg.in/yaml.v3"
+)
+
+// catalog cross-checks CODEOWNERS against a Backstage software catalog, so
+// ownership recorded in both places can't drift apart unnoticed.
+type catalog struct {
+	// Files are globs for catalog-info.yaml files, or for a catalog export
+	// listing many entities.
+	Files []string `yaml:"files"`
+
+	// Owners maps catalog owner refs, such as group:default/payments, to
+	// the CODEOWNERS owners they stand for. Refs without an entry match an
+	// owner with the same user or team name.
+	Owners map[string]string `yaml:"owners"`
+
+	// Severity applies to every catalog finding.
+	Severity string `yaml:"severity"`
+}
+
+// catalogEntity is the part of a Backstage entity the cross-check reads.
+type catalogEntity struct {
+	Kind     string `yaml:"kind"`
+	Metadata struct {
+		Name        string            `yaml:"name"`
+		Annotations map[string]string `yaml:"annotations"`
+	} `yaml:"metadata"`
+	Spec struct {
+		Owner string `yaml:"owner"`
+	} `yaml:"spec"`
+}
+
+// sourceLocationAnnotation points an entity at its source when it isn't the
+// directory of the file that describes it.
+const sourceLocationAnnotation = "backstage.io/source-location"
+
+// checkCatalog reports catalog entities whose source directory has no owner
+// in CODEOWNERS, or whose CODEOWNERS owners don't include the catalog owner.
+func (c *checker) checkCatalog() []validationError {
+	var errs []validationError
+	report := func(e validationError) {
+		e.severity = c.catalog.Severity
+		errs = append(errs, e)
+	}
+
+	for _, pattern := range c.catalog.Files {
+		files, err := c.fsys.Glob(pattern)
+		if err != nil {
+			report(validationError{path: pattern, message: fmt.Sprintf("Cannot expand catalog files: %v", err), code: codeCatalogUnreadable, file: c.configPath})
+			continue
+		}
+		if len(files) == 0 {
+			report(validationError{path: pattern, message: fmt.Sprintf("No catalog files match this pattern. Check %s.", c.configPath), code: codeCatalogUnreadable, file: c.configPath})
+			continue
+		}
+		for _, file := range files {
+			file = filepath.ToSlash(file)
+			entities, err := readCatalogEntities(c.fsys, file)
+			if err != nil {
+				report(validationError{path: file, message: fmt.Sprintf("Cannot read catalog: %v", err), code: codeCatalogUnreadable, file: file})
+				continue
+			}
+			for _, ent := range entities {
+				if e, ok := c.checkCatalogEntity(file, ent); ok {
+					report(e)
+				}
+			}
+		}
+	}
+	return errs
+}
+
+// checkCatalogEntity checks one entity read from file, returning the problem
+// with it, if any.
+func (c *checker) checkCatalogEntity(file string, ent catalogEntity) (validationError, bool) {
+	dir, ok := entitySourceDir(file, ent)
+	if !ok || ent.Spec.Owner == "" {
+		return validationError{}, false
+	}
+	label := fmt.Sprintf("%s (%s %s)", dir, strings.ToLower(ent.Kind), ent.Metadata.Name)
+	team := c.catalogOwner(ent.Spec.Owner)
+
+	if info, err := c.fsys.Stat(dir); err != nil || !info.IsDir() {
+		return validationError{
+			path:    label,
+			message: fmt.Sprintf("Catalog source directory does not exist. Fix the entity's %s annotation in %s.", sourceLocationAnnotation, file),
+			code:    codeCatalogMissingSource,
+			team:    team,
+			file:    file,
+		}, true
+	}
+
+	r := matchDirectory(c.matcher(), dir)
+	if r == nil {
+		return validationError{
+			path:    label,
+			message: fmt.Sprintf("Owned by %s in the catalog, but has no owner in CODEOWNERS. Add a rule for %s to %s.", ent.Spec.Owner, team, c.codeownersPath),
+			code:    codeCatalogUnowned,
+			team:    team,
+			file:    c.codeownersPath,
+		}, true
+	}
+	for _, o := range r.Owners {
+		if c.catalogOwnerMatches(ent.Spec.Owner, o) {
+			return validationError{}, false
+		}
+	}
+	return validationError{
+		path: label,
+		message: fmt.Sprintf("Owned by %s in the catalog, but by %s in CODEOWNERS. Update %s or %s: %s",
+			ent.Spec.Owner, r.ownerNames(), file, r.location(), r.RawPattern()),
+		code: codeCatalogMismatch,
+		team: team,
+		file: r.file,
+		line: r.LineNumber,
+	}, true
+}
+
+// catalogOwner returns the CODEOWNERS owner a catalog owner ref is mapped
+// to, or the ref itself if it has no mapping.
+func (c *checker) catalogOwner(ref string) string {
+	if o, ok := c.catalog.Owners[ref]; ok {
+		return o
+	}
+	return ref
+}
+
+// catalogOwnerMatches reports whether the CODEOWNERS owner o is the catalog
+// owner ref. A mapped ref must be the mapped owner; otherwise the ref's name
+// must be o's user or team name. Both compare case-insensitively.
+func (c *checker) catalogOwnerMatches(ref string, o codeowners.Owner) bool {
+	if mapped, ok := c.catalog.Owners[ref]; ok {
+		return strings.EqualFold(mapped, o.String())
+	}
+	if o.Type == codeowners.EmailOwner {
+		return false
+	}
+	name := ref
+	if _, rest, ok := strings.Cut(name, ":"); ok {
+		name = rest
+	}
+	if _, rest, ok := strings.Cut(name, "/"); ok {
+		name = rest
+	}
+	slug := o.Value
+	if _, rest, ok := strings.Cut(slug, "/"); ok {
+		slug = rest
+	}
+	return strings.EqualFold(name, slug)
+}
+
+// entitySourceDir returns the repository directory an entity described in
+// file lives in: the file's own directory, unless its source-location
+// annotation points elsewhere in the repository. Entities whose source is
+// somewhere else entirely are skipped.
+func entitySourceDir(file string, ent catalogEntity) (string, bool) {
+	loc, ok := ent.Metadata.Annotations[sourceLocationAnnotation]
+	if !ok {
+		return path.Dir(file), true
+	}
+	target, ok := strings.CutPrefix(loc, "url:")
+	if !ok {
+		target, ok = strings.CutPrefix(loc, "dir:")
+		if !ok {
+			return "", false
+		}
+		return path.Join(path.Dir(file), target), true
+	}
+	// A repository URL, such as https://github.com/org/repo/tree/main/services/api.
+	for _, marker := range []string{"/tree/", "/blob/", "/-/tree/", "/-/blob/"} {
+		if _, rest, found := strings.Cut(target, marker); found {
+			_, dir, _ := strings.Cut(rest, "/")
+			return path.Join(".", strings.Trim(dir, "/")), true
+		}
+	}
+	return "", false
+}
+
+// readCatalogEntities reads the entities in a catalog file: one or more YAML
+// documents, each an entity, a list of entities, or an export object with
+// the entities under "items".
+func readCatalogEntities(fsys fileSystem, file string) ([]catalogEntity, error) {
+	data, err := fsys.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var entities []catalogEntity
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var doc yaml.Node
+		err := dec.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(doc.Content) == 0 {
+			continue
+		}
+		node := doc.Content[0]
+		if node.Kind == yaml.MappingNode && !hasKey(node, "kind") {
+			var export struct {
+				Items []catalogEntity `yaml:"items"`
+			}
+			if err := node.Decode(&export); err != nil {
+				return nil, err
+			}
+			entities = append(entities, export.Items...)
+			continue
+		}
+		if node.Kind == yaml.SequenceNode {
+			var list []catalogEntity
+			if err := node.Decode(&list); err != nil {
+				return nil, err
+			}
+			entities = append(entities, list...)
+			continue
+		}
+		var ent catalogEntity
+		if err := node.Decode(&ent); err != nil {
+			return nil, err
+		}
+		entities = append(entities, ent)
+	}
+	return entities, nil
+}
@@ -0,0 +1,96 @@
+package check
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckCatalog(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	files := map[string]string{
+		"services/api/catalog-info.yaml": `apiVersion: backstage.io/v1alpha1
+kind: Component
+metadata:
+  name: api
+spec:
+  owner: group:default/payments
+`,
+		"services/web/catalog-info.yaml": `apiVersion: backstage.io/v1alpha1
+kind: Component
+metadata:
+  name: web
+spec:
+  owner: frontend
+---
+apiVersion: backstage.io/v1alpha1
+kind: Location
+metadata:
+  name: web-docs
+spec:
+  targets: [./docs/catalog-info.yaml]
+`,
+		"services/jobs/catalog-info.yaml": `kind: Component
+metadata:
+  name: jobs
+spec:
+  owner: group:data
+`,
+		"catalog/export.json": `{"items": [
+  {"kind": "Component", "metadata": {"name": "billing", "annotations": {"backstage.io/source-location": "url:https://github.com/org/repo/tree/main/services/billing/"}}, "spec": {"owner": "platform-team"}},
+  {"kind": "Component", "metadata": {"name": "gone", "annotations": {"backstage.io/source-location": "url:https://github.com/org/repo/tree/main/services/gone"}}, "spec": {"owner": "group:payments"}},
+  {"kind": "Component", "metadata": {"name": "other", "annotations": {"backstage.io/source-location": "url:https://example.com/other.tar.gz"}}, "spec": {"owner": "group:payments"}}
+]}`,
+		"services/billing/main.go": "package main\n",
+		".github/CODEOWNERS": `/services/api/ @org/payments
+/services/web/ @org/platform
+/services/billing/ @org/platform
+`,
+	}
+	for name, content := range files {
+		os.MkdirAll(filepath.Join(tmpDir, filepath.Dir(name)), 0755)
+		os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644)
+	}
+
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	ruleset, err := loadCodeowners(context.Background(), nil, providerGitHub)
+	if err != nil {
+		t.Fatalf("loading CODEOWNERS: %v", err)
+	}
+
+	c := &checker{
+		fsys:           localFS{},
+		rules:          ruleset,
+		configPath:     ".requirecodeowners.yml",
+		codeownersPath: ".github/CODEOWNERS",
+		catalog: catalog{
+			Files:  []string{"services/*/catalog-info.yaml", "catalog/export.json"},
+			Owners: map[string]string{"platform-team": "@org/platform"},
+		},
+	}
+	errs := c.checkCatalog()
+
+	want := map[string]string{
+		"services/web (component web)":   "but by @org/platform in CODEOWNERS",
+		"services/jobs (component jobs)": "has no owner in CODEOWNERS",
+		"services/gone (component gone)": "does not exist",
+	}
+	got := make(map[string]string)
+	for _, e := range errs {
+		got[e.path] = e.message
+	}
+	for path, msg := range want {
+		if !strings.Contains(got[path], msg) {
+			t.Errorf("finding for %s = %q, want it to contain %q", path, got[path], msg)
+		}
+	}
+	if len(errs) != len(want) {
+		t.Errorf("checkCatalog() = %v, want %d findings", errs, len(want))
+	}
+}
@@ -0,0 +1,70 @@
+package check
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/ext"
+)
+
+// Custom policies are written in CEL (https://cel.dev) and evaluated by
+// cel-go, with the standard functions and macros and cel-go's string
+// extensions, like lowerAscii and split. Expressions are parsed and type
+// checked when they're compiled, so a policy can't fail halfway through a
+// run on a typo.
+
+// celCostLimit bounds the work one evaluation may do, so a policy with
+// nested macros can't stall a run.
+const celCostLimit = 1_000_000
+
+// celProgram is a compiled expression.
+type celProgram struct {
+	program cel.Program
+
+	// used are the variables the expression refers to, so costly ones
+	// need only be computed when they're used.
+	used map[string]bool
+}
+
+// compileCEL parses and checks source, which may refer to vars and must
+// evaluate to a bool.
+func compileCEL(source string, vars []cel.EnvOption) (*celProgram, error) {
+	env, err := cel.NewEnv(append([]cel.EnvOption{ext.Strings()}, vars...)...)
+	if err != nil {
+		return nil, err
+	}
+	ast, iss := env.Compile(source)
+	if errs := iss.Errors(); len(errs) > 0 {
+		// Later errors usually follow from the first.
+		e := errs[0]
+		return nil, fmt.Errorf("column %d: %s", e.Location.Column()+1, strings.TrimSuffix(e.Message, " (in container '')"))
+	}
+	if t := ast.OutputType(); !t.IsExactType(cel.BoolType) && !t.IsExactType(cel.DynType) {
+		return nil, fmt.Errorf("evaluates to %s, not a bool", t)
+	}
+	program, err := env.Program(ast, cel.CostLimit(celCostLimit))
+	if err != nil {
+		return nil, err
+	}
+	used := make(map[string]bool)
+	for _, ref := range ast.NativeRep().ReferenceMap() {
+		if ref.Name != "" {
+			used[ref.Name] = true
+		}
+	}
+	return &celProgram{program: program, used: used}, nil
+}
+
+// run evaluates the program with vars, requiring a bool result.
+func (p *celProgram) run(vars map[string]any) (bool, error) {
+	v, _, err := p.program.Eval(vars)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("evaluates to %s, not a bool", v.Type().TypeName())
+	}
+	return b, nil
+}
@@ -0,0 +1,104 @@
+package check
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCEL(t *testing.T) {
+	vars := map[string]any{
+		"path":   "services/payments/api",
+		"owners": []string{"@org/payments", "@org/platform"},
+		"rule":   "/services/payments/",
+		"depth":  int64(3),
+	}
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`true`, true},
+		{`path.startsWith("services/") && depth == 3`, true},
+		{`!path.startsWith("services/payments") || size(owners) >= 2`, true},
+		{`size(owners) > 2 || rule == ""`, false},
+		{`"@org/platform" in owners`, true},
+		{`"@org/web" in owners`, false},
+		{`owners.exists(o, o.startsWith("@org/pay"))`, true},
+		{`owners.all(o, o.matches("^@org/[a-z]+$"))`, true},
+		{`owners.exists_one(o, o.contains("pay"))`, true},
+		{`owners.filter(o, o.endsWith("form")) == ["@org/platform"]`, true},
+		{`owners.map(o, o.size()) == [13, 13]`, true},
+		{`owners[1] == "@org/platform"`, true},
+		{`depth > 2 ? rule.endsWith("/") : false`, true},
+		{`(depth + 1) * 2 - 8 / 4 % 3 == 6`, true},
+		{`-depth < 0 && "a" < "b" && "B".lowerAscii() == "b"`, true},
+		{`string(depth) + "x" == "3x" && int("42") == 42`, true},
+		{`[1, 2] + [3] == [1, 2, 3] && size("héllo") == 5`, true},
+		{"path.startsWith('services/')", true},
+		{`path.split("/")[1] == "payments" && 1.5 * 2.0 == 3.0`, true},
+		{`{"a": depth}.a == 3 && has({"a": 1}.a) && !has({"a": 1}.b)`, true},
+		// && and || absorb an error on either side when the other decides.
+		{`false && 1 / 0 == 1`, false},
+		{`1 / 0 == 1 && false`, false},
+		{`true || owners[5] == ""`, true},
+		{`owners[5] == "" || true`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			p, err := compileCEL(tt.expr, customPolicyVars)
+			if err != nil {
+				t.Fatalf("compileCEL() error = %v", err)
+			}
+			got, err := p.run(vars)
+			if err != nil {
+				t.Fatalf("run() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("run() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCELErrors(t *testing.T) {
+	compileErrors := []struct {
+		expr, want string
+	}{
+		{`paht == "x"`, "column 1: undeclared reference to 'paht'"},
+		{`path ==`, "column 8: Syntax error"},
+		{`path.foo()`, "undeclared reference to 'foo'"},
+		{`path.owner`, "type 'string' does not support field selection"},
+		{`path.startsWith()`, "found no matching overload for 'startsWith'"},
+		{`owners.exists(1, true)`, "argument must be a simple name"},
+		{`o.size() > 0`, "undeclared reference to 'o'"},
+		{`"abc`, "token recognition error"},
+		{`(true`, "missing ')'"},
+		// Types are checked up front too.
+		{`depth`, "evaluates to int, not a bool"},
+		{`depth == "3"`, "found no matching overload for '_==_' applied to '(int, string)'"},
+		{`path + 1 == "x"`, "found no matching overload for '_+_'"},
+		{`depth && true`, "expected type 'bool' but found 'int'"},
+	}
+	for _, tt := range compileErrors {
+		if _, err := compileCEL(tt.expr, customPolicyVars); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("compileCEL(%q) error = %v, want %q", tt.expr, err, tt.want)
+		}
+	}
+
+	runErrors := []struct {
+		expr, want string
+	}{
+		{`depth / 0 == 1`, "division by zero"},
+		{`owners[5] == ""`, "index out of bounds"},
+		{`path.matches("(")`, "error parsing regexp"},
+		{`9223372036854775807 + depth > 0`, "overflow"},
+	}
+	for _, tt := range runErrors {
+		p, err := compileCEL(tt.expr, customPolicyVars)
+		if err != nil {
+			t.Fatalf("compileCEL(%q) error = %v", tt.expr, err)
+		}
+		if _, err := p.run(map[string]any{"path": "a", "owners": []string{}, "rule": "", "depth": int64(1)}); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("run(%q) error = %v, want %q", tt.expr, err, tt.want)
+		}
+	}
+}
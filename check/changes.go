@@ -0,0 +1,145 @@
+package check
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// changeFlags limit the check to the directories touched by a set of
+// changes, which keeps it fast enough for git hooks. The changes are paths
+// given as arguments, the staged changes, or the changes since a ref.
+type changeFlags struct {
+	staged bool
+	since  string
+}
+
+// changes holds the change selection from the command line. Paths are
+// absolute, since the check may run from the config's directory rather than
+// where they were given.
+var changes struct {
+	limited bool
+	paths   []string
+	staged  bool
+	since   string
+}
+
+func (f *changeFlags) register(fs *flag.FlagSet) {
+	fs.BoolVar(&f.staged, "staged", false, "only check directories with changes staged for commit")
+	fs.StringVar(&f.since, "changed-since", "", "only check directories changed between this git ref and HEAD")
+}
+
+// apply validates the flags and sets the change selection from them and the
+// paths given as arguments. It must run after any -C, so relative paths
+// resolve where the user meant them to.
+func (f *changeFlags) apply(args []string) error {
+	changes.limited = f.staged || f.since != "" || len(args) > 0
+	changes.staged = f.staged
+	changes.since = f.since
+	changes.paths = nil
+	if f.staged && f.since != "" {
+		return fmt.Errorf("--staged and --changed-since can't be used together")
+	}
+	for _, arg := range args {
+		abs, err := filepath.Abs(arg)
+		if err != nil {
+			return err
+		}
+		changes.paths = append(changes.paths, abs)
+	}
+	return nil
+}
+
+// changedPaths returns the selected changes as slash-separated paths
+// relative to the working directory, and whether the check is limited to
+// them at all. Changes outside the working directory are left out.
+func changedPaths() ([]string, bool, error) {
+	if !changes.limited {
+		return nil, false, nil
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, false, err
+	}
+
+	var paths []string
+	for _, abs := range changes.paths {
+		rel, err := filepath.Rel(wd, abs)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		paths = append(paths, filepath.ToSlash(rel))
+	}
+
+	var diffArgs []string
+	switch {
+	case changes.staged:
+		diffArgs = []string{"--cached"}
+	case changes.since != "":
+		diffArgs = []string{changes.since + "...HEAD"}
+	default:
+		return paths, true, nil
+	}
+	args := append([]string{"diff", "--name-only", "-z", "--relative", "--diff-filter=ACMR"}, diffArgs...)
+	out, err := exec.Command("git", args...).Output()
+	if err != nil {
+		if ee, ok := err.(*exec.ExitError); ok && len(ee.Stderr) > 0 {
+			return nil, false, fmt.Errorf("listing changes: %s", strings.TrimSpace(string(ee.Stderr)))
+		}
+		return nil, false, fmt.Errorf("listing changes: %w", err)
+	}
+	for _, name := range bytes.Split(out, []byte{0}) {
+		if len(name) > 0 {
+			paths = append(paths, string(name))
+		}
+	}
+	return paths, true, nil
+}
+
+// baseCodeowners returns the rules of the local CODEOWNERS files in sources
+// as they were at the merge base of --changed-since and HEAD, so the check
+// can tell coverage a change removed from coverage that was never there. It
+// returns nil without --changed-since. Files that didn't exist at the base
+// are left out.
+func baseCodeowners(sources []string, provider string) (ruleset, error) {
+	if changes.since == "" {
+		return nil, nil
+	}
+	base, err := gitLine("merge-base", changes.since, "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("finding the merge base with %s: %w", changes.since, err)
+	}
+	var rules ruleset
+	for _, source := range sources {
+		if isURL(source) || isGitHubSource(source) || filepath.IsAbs(source) {
+			continue
+		}
+		data, err := gitOutput("show", base+":./"+filepath.ToSlash(source))
+		if err != nil {
+			continue
+		}
+		rs, err := parseCodeowners(bytes.NewReader(data), filepath.ToSlash(source)+"@"+changes.since, provider)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rs...)
+	}
+	return rules, nil
+}
+
+// touches reports whether any of the changed paths is dir or inside it.
+func touches(dir string, changed []string) bool {
+	dir = path.Clean(dir)
+	for _, p := range changed {
+		p = path.Clean(p)
+		if dir == "." || p == dir || strings.HasPrefix(p, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
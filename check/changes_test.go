@@ -0,0 +1,185 @@
+package check
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// gitRepo makes a git repository in a temporary directory with files
+// committed, and changes into it.
+func gitRepo(t *testing.T, files map[string]string) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	tmpDir := t.TempDir()
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	t.Cleanup(func() { os.Chdir(oldWd) })
+
+	git(t, "init", "-q")
+	writeFiles(t, files)
+	git(t, "add", "-A")
+	git(t, "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "initial")
+	return tmpDir
+}
+
+func git(t *testing.T, args ...string) {
+	t.Helper()
+	if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+}
+
+func writeFiles(t *testing.T, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		os.MkdirAll(filepath.Dir(name), 0755)
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestValidateChanged(t *testing.T) {
+	gitRepo(t, map[string]string{
+		".requirecodeowners.yml":  "directories:\n  - path: services\n    level: 1\n",
+		".github/CODEOWNERS":      "/services/api/ @org/api\n",
+		"services/api/main.go":    "package main\n",
+		"services/web/index.html": "",
+		"services/jobs/run.sh":    "",
+	})
+
+	old := changes
+	defer func() { changes = old }()
+
+	uncovered := func(t *testing.T) []string {
+		t.Helper()
+		res, err := checkRepo(context.Background(), "", nil, specFilter{})
+		if err != nil {
+			t.Fatalf("checkRepo() error = %v", err)
+		}
+		var paths []string
+		for _, e := range res.errors {
+			paths = append(paths, e.path)
+		}
+		return paths
+	}
+
+	var f changeFlags
+	if err := f.apply(nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := uncovered(t); len(got) != 2 {
+		t.Errorf("full check uncovered = %v, want both unowned services", got)
+	}
+
+	// Only the staged change is checked.
+	writeFiles(t, map[string]string{"services/web/app.js": ""})
+	git(t, "add", "services/web/app.js")
+	f = changeFlags{staged: true}
+	f.apply(nil)
+	if got := uncovered(t); !reflect.DeepEqual(got, []string{"services/web"}) {
+		t.Errorf("--staged uncovered = %v, want [services/web]", got)
+	}
+
+	// Paths given as arguments.
+	f = changeFlags{}
+	f.apply([]string{"services/jobs/run.sh", "services/api/main.go"})
+	if got := uncovered(t); !reflect.DeepEqual(got, []string{"services/jobs"}) {
+		t.Errorf("path arguments uncovered = %v, want [services/jobs]", got)
+	}
+
+	// A CODEOWNERS change can affect every directory.
+	f.apply([]string{".github/CODEOWNERS"})
+	if got := uncovered(t); len(got) != 2 {
+		t.Errorf("CODEOWNERS change uncovered = %v, want the full check", got)
+	}
+
+	// Nothing staged, nothing to check.
+	git(t, "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "web")
+	f = changeFlags{staged: true}
+	f.apply(nil)
+	if got := uncovered(t); len(got) != 0 {
+		t.Errorf("no staged changes uncovered = %v, want none", got)
+	}
+
+	f = changeFlags{staged: true, since: "HEAD~1"}
+	if err := f.apply(nil); err == nil {
+		t.Errorf("apply() with --staged and --changed-since should fail")
+	}
+}
+
+func TestCoverageRegression(t *testing.T) {
+	gitRepo(t, map[string]string{
+		".requirecodeowners.yml":  "directories:\n  - path: services\n    level: 1\n",
+		".github/CODEOWNERS":      "/services/api/ @org/api\n/services/web @org/web\n",
+		"services/api/main.go":    "package main\n",
+		"services/web/index.html": "",
+		"services/jobs/run.sh":    "",
+	})
+	git(t, "checkout", "-q", "-b", "feature")
+	writeFiles(t, map[string]string{".github/CODEOWNERS": "/services/api/ @org/api\n"})
+	git(t, "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-am", "drop web")
+
+	old := changes
+	defer func() { changes = old }()
+
+	check := func(t *testing.T) map[string]validationError {
+		t.Helper()
+		res, err := checkRepo(context.Background(), "", nil, specFilter{})
+		if err != nil {
+			t.Fatalf("checkRepo() error = %v", err)
+		}
+		errs := make(map[string]validationError)
+		for _, e := range res.errors {
+			errs[e.path] = e
+		}
+		return errs
+	}
+
+	f := changeFlags{since: "HEAD~1"}
+	if err := f.apply(nil); err != nil {
+		t.Fatal(err)
+	}
+	errs := check(t)
+	web := errs["services/web"]
+	if web.code != codeRegression || web.team != "@org/web" || !strings.Contains(web.message, "owned by @org/web through /services/web at HEAD~1") {
+		t.Errorf("services/web = %+v, want a coverage regression from @org/web", web)
+	}
+	if jobs := errs["services/jobs"]; jobs.code != codeMissingEntry {
+		t.Errorf("services/jobs = %+v, want a plain missing entry, since it was never covered", jobs)
+	}
+
+	// Without a base to compare with, it's just uncovered.
+	f = changeFlags{}
+	f.apply(nil)
+	if web := check(t)["services/web"]; web.code != codeMissingEntry {
+		t.Errorf("full check services/web = %+v, want a missing entry", web)
+	}
+}
+
+func TestTouches(t *testing.T) {
+	changed := []string{"services/api/main.go", "docs"}
+	tests := []struct {
+		dir  string
+		want bool
+	}{
+		{"services/api", true},
+		{"services", true},
+		{"services/ap", false},
+		{"docs", true},
+		{"docs/guide", false},
+		{".", true},
+	}
+	for _, tt := range tests {
+		if got := touches(tt.dir, changed); got != tt.want {
+			t.Errorf("touches(%q) = %v, want %v", tt.dir, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,1191 @@
+package check
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/kpurdon/requirecodeowners/validate"
+)
+
+// sourceFlag appends CODEOWNERS sources to a shared list, so the order of
+// --codeowners-path, --codeowners-url, and --codeowners-repo flags is the
+// order the files are merged in.
+type sourceFlag struct {
+	list   *stringList
+	prefix string
+	url    bool
+}
+
+func (f *sourceFlag) String() string {
+	if f.list == nil {
+		return ""
+	}
+	return f.list.String()
+}
+
+func (f *sourceFlag) Set(value string) error {
+	if f.url && !isURL(value) {
+		return fmt.Errorf("%q is not an http(s) URL", value)
+	}
+	return f.list.Set(f.prefix + value)
+}
+
+type validationError struct {
+	path     string
+	message  string
+	severity string
+
+	// code identifies the kind of problem; see codes.go.
+	code string
+
+	// spec is the name of the spec that found the problem, if it has one,
+	// and specPath its path pattern.
+	spec, specPath string
+
+	// team is who is most likely responsible for fixing the problem, or
+	// empty if nobody could be guessed.
+	team string
+
+	// file and line locate the fix: the config entry for problems with a
+	// spec, or the CODEOWNERS file (and rule, if one matched) for coverage
+	// problems. line is 0 when unknown.
+	file string
+	line int
+
+	// fix, if set, is the pattern to replace the rule's at file and line
+	// with, for lints whose fix is unambiguous.
+	fix string
+
+	// mandatory is set on problems the org policy requires, which neither
+	// suppressions nor --disable drop.
+	mandatory bool
+}
+
+func (e validationError) isWarning() bool {
+	return e.severity == severityWarning
+}
+
+// label is the path, followed by the spec's name if the spec has one.
+func (e validationError) label() string {
+	if e.spec == "" {
+		return e.path
+	}
+	return fmt.Sprintf("%s (%s)", e.path, e.spec)
+}
+
+// codedMessage is the message, led by the code if there is one.
+func (e validationError) codedMessage() string {
+	if e.code == "" {
+		return e.message
+	}
+	return e.code + " " + e.message
+}
+
+func runCheck(args []string) int {
+	fs := flag.NewFlagSet("check", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: requirecodeowners [check] [flags] [PATH...]")
+		fmt.Fprintln(fs.Output(), "Checks that the configured directories have CODEOWNERS coverage, or only those changed in PATHs.")
+		fs.PrintDefaults()
+	}
+	var repos stringList
+	var reposFile string
+	var format string
+	var templatePath string
+	var outputPath string
+	var metricsPath string
+	var historyPath string
+	var otelEndpoint string
+	var reports reportFlag
+	var quiet, verbose, debugMatch bool
+	var groupTeams, groupSpecs bool
+	var maxErrors int
+	var verify bool
+	var minMembers int
+	var cacheFile string
+	var cacheTTL time.Duration
+	var noCache bool
+	var suggest bool
+	var noProgress bool
+	var requestPR int
+	var timeout time.Duration
+	var interactive, dryRun bool
+
+	var rf repoFlags
+	rf.register(fs)
+	var lf configFlags
+	lf.register(fs)
+	fs.Var(&sourceFlag{list: &rf.codeowners, url: true}, "codeowners-url", "fetch CODEOWNERS over HTTP(S) from this URL, repeatable")
+	fs.Var(&sourceFlag{list: &rf.codeowners, prefix: githubSourcePrefix}, "codeowners-repo", "fetch CODEOWNERS from a GitHub repository as owner/repo[/path][@ref], repeatable")
+	fs.Var(&repos, "repo", "check this repository root with its own config, repeatable for a combined report")
+	fs.StringVar(&reposFile, "repos-file", "", "file listing repository roots to check, one per line")
+	fs.StringVar(&format, "format", "markdown", "report format written to stdout: "+strings.Join(formatNames(), ", "))
+	fs.StringVar(&templatePath, "template", "", "text/template file to render with --format template")
+	fs.StringVar(&outputPath, "output", "", "write the --format report to this file instead of stdout")
+	fs.Var(&reports, "report", "also write a report to a file as format=path, repeatable")
+	fs.StringVar(&otelEndpoint, "otel-endpoint", "", "export OpenTelemetry traces of the run to this OTLP/HTTP endpoint, e.g. http://localhost:4318 (default: OTEL_EXPORTER_OTLP_ENDPOINT)")
+	fs.StringVar(&metricsPath, "metrics-file", "", "write Prometheus metrics to this file for the node-exporter textfile collector")
+	fs.StringVar(&historyPath, "history-file", "", "append a summary of this run to this JSON Lines file, for the trend command")
+	fs.BoolVar(&quiet, "quiet", false, "only print failures, without warnings or the success message")
+	fs.BoolVar(&verbose, "v", false, "log each directory checked and the rule that matched it")
+	fs.BoolVar(&verbose, "verbose", false, "alias for -v")
+	fs.BoolVar(&debugMatch, "debug-match", false, "like -v, and also log each path probed while matching rules")
+	fs.DurationVar(&timeout, "timeout", 0, "stop after this long, e.g. 10m, reporting what was checked so far as a failure (default: no limit)")
+	fs.Var(&disabledCodes, "disable", "drop problems with this code or name, e.g. RCO002 or dir-not-exist, repeatable or comma-separated")
+	fs.BoolVar(&noProgress, "no-progress", false, "don't show progress on a terminal during long runs")
+	fs.BoolVar(&interactive, "interactive", false, "go through uncovered directories one at a time, choosing a fix for each, and write the fixes at the end")
+	fs.BoolVar(&dryRun, "dry-run", false, "with --interactive, print the fixes to stdout as a unified diff instead of writing them")
+	fs.BoolVar(&groupTeams, "group-by-team", false, "group reports by the team most likely responsible for each problem: markdown sections, jsonl team records, and a console summary")
+	fs.BoolVar(&groupSpecs, "group-by-spec", false, "group the markdown report into a collapsible section for each spec, with its pass and fail counts")
+	fs.IntVar(&maxErrors, "max-errors", 0, "list at most this many problems in console text and markdown, summarizing the rest (0 for no limit)")
+	fs.BoolVar(&verify, "verify-owners", false, "check through the GitHub API that owners exist and teams have members")
+	fs.IntVar(&minMembers, "min-team-members", 1, "with --verify-owners, the fewest members a team may have")
+	fs.StringVar(&cacheFile, "cache-file", defaultOwnerCachePath(), "where --verify-owners caches results between runs")
+	fs.DurationVar(&cacheTTL, "cache-ttl", 24*time.Hour, "how long cached --verify-owners results are trusted")
+	fs.BoolVar(&noCache, "no-cache", false, "verify every owner against the API, ignoring and not updating the cache")
+	fs.StringVar(&subtreeCacheDir, "cache-dir", "", "reuse check results for directories whose git tree is unchanged, stored in this directory")
+	fs.BoolVar(&suggest, "suggest-reviewers", false, "with --staged, --changed-since or paths, suggest reviewers for changed directories that have no owner")
+	fs.IntVar(&requestPR, "request-reviewers", 0, "request the suggested reviewers on this pull request in GITHUB_REPOSITORY (implies --suggest-reviewers)")
+	var filter specFilter
+	filter.register(fs)
+	var cf consoleFlags
+	cf.register(fs)
+	var gf githubFlags
+	gf.register(fs)
+	var tf traversalFlags
+	tf.register(fs)
+	var chf changeFlags
+	chf.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if err := lf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	if err := cf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	if err := gf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	if err := tf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+
+	if _, ok := formats[format]; !ok {
+		fmt.Fprintf(os.Stderr, "error: unknown format %q (want one of %s)\n", format, strings.Join(formatNames(), ", "))
+		return 2
+	}
+	usesTemplate := format == "template"
+	for _, r := range reports {
+		usesTemplate = usesTemplate || r.format == "template"
+	}
+	if usesTemplate != (templatePath != "") {
+		fmt.Fprintln(os.Stderr, "error: the template format and --template must be used together")
+		return 2
+	}
+	if minMembers < 1 {
+		fmt.Fprintln(os.Stderr, "error: --min-team-members must be at least 1")
+		return 2
+	}
+	if groupTeams && groupSpecs {
+		fmt.Fprintln(os.Stderr, "error: --group-by-team and --group-by-spec can't be used together")
+		return 2
+	}
+	if maxErrors < 0 {
+		fmt.Fprintln(os.Stderr, "error: --max-errors can't be negative")
+		return 2
+	}
+	if timeout < 0 {
+		fmt.Fprintln(os.Stderr, "error: --timeout can't be negative")
+		return 2
+	}
+	if quiet && (verbose || debugMatch) {
+		fmt.Fprintln(os.Stderr, "error: --quiet cannot be used with --verbose or --debug-match")
+		return 2
+	}
+	setupLogging(os.Stderr, logLevel(quiet, verbose, debugMatch))
+	opts := reportOptions{templatePath: templatePath, quiet: quiet, groupByTeam: groupTeams, groupBySpec: groupSpecs, maxErrors: maxErrors}
+
+	if err := rf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	if err := chf.apply(fs.Args()); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	suggest = suggest || requestPR != 0
+	if suggest && !changes.limited {
+		fmt.Fprintln(os.Stderr, "error: --suggest-reviewers needs changes to suggest them for: --staged, --changed-since or paths")
+		return 2
+	}
+	if requestPR != 0 {
+		if offline {
+			fmt.Fprintln(os.Stderr, "error: --request-reviewers requests reviewers through the GitHub API and can't run with --offline")
+			return 2
+		}
+		if _, _, ok := strings.Cut(os.Getenv("GITHUB_REPOSITORY"), "/"); !ok {
+			fmt.Fprintln(os.Stderr, "error: --request-reviewers needs GITHUB_REPOSITORY set to owner/name")
+			return 2
+		}
+	}
+	if dryRun && !interactive {
+		fmt.Fprintln(os.Stderr, "error: --dry-run previews the fixes --interactive makes and needs it")
+		return 2
+	}
+	if interactive {
+		if len(repos) > 0 || reposFile != "" {
+			fmt.Fprintln(os.Stderr, "error: --interactive fixes one repository and can't be used with --repo or --repos-file")
+			return 2
+		}
+		if !isTerminal(os.Stdin) || !isTerminal(os.Stderr) {
+			fmt.Fprintln(os.Stderr, "error: --interactive needs a terminal")
+			return 2
+		}
+	}
+	ctx, cancel := runContext(timeout)
+	defer cancel()
+	if len(repos) > 0 || reposFile != "" {
+		if reposFile != "" {
+			listed, err := readReposFile(reposFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				return 1
+			}
+			repos = append(repos, listed...)
+		}
+		return runBatch(ctx, repos, rf.configPath, rf.codeowners, filter, opts)
+	}
+	if format == "markdown" || slices.ContainsFunc(reports, func(r reportTarget) bool { return r.format == "markdown" }) {
+		opts.links = detectRepoLinks()
+	}
+
+	if tracingEnabled(otelEndpoint) {
+		t, err := newTracer(ctx, otelEndpoint)
+		if err != nil {
+			logger.Warn("starting tracing", "error", err)
+		}
+		tracing = t
+	}
+	if format == "jsonl" && outputPath == "" {
+		stream = newJSONLStream(os.Stdout)
+		stream.teams = groupTeams
+	}
+	if !noProgress && !quiet && !verbose && !debugMatch && isTerminal(os.Stderr) {
+		progress = startProgress(os.Stderr)
+		setupLogging(progress, logLevel(quiet, verbose, debugMatch))
+	}
+	run := startSpan("requirecodeowners")
+	res, err := checkRepo(ctx, rf.configPath, rf.codeowners, filter)
+	if err != nil {
+		progress.finish()
+		run.set("error", err.Error())
+		finishTrace(run)
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	if verify && offline {
+		res.errors = append(res.errors, res.suppress([]validationError{{
+			path:     "--verify-owners",
+			message:  "Skipped owner verification because of --offline.",
+			code:     codeOfflineSkipped,
+			severity: severityWarning,
+		}})...)
+	} else if verify && ctx.Err() == nil {
+		var cache *ownerCache
+		if !noCache && cacheFile != "" {
+			cache = loadOwnerCache(cacheFile, cacheTTL)
+		}
+		sp := startSpan("verify owners")
+		res.errors = append(res.errors, res.suppress(verifyOwners(newGitHubClient().withContext(ctx), res.checked, minMembers, cache))...)
+		sp.finish()
+		if err := cache.save(); err != nil {
+			logger.Warn("saving owner cache", "path", cacheFile, "error", err)
+		}
+	}
+	owned, total := res.coverage()
+	run.set("directories.checked", total)
+	run.set("directories.owned", owned)
+	run.set("failures", countFailures(res.errors))
+	finishTrace(run)
+	progress.finish()
+	if err := stream.finish(res); err != nil {
+		fmt.Fprintf(os.Stderr, "error: writing report: %v\n", err)
+		return 1
+	}
+
+	sortErrors(res.errors)
+	if shown := consoleErrors(res.errors, opts); len(shown) > 0 {
+		printText(os.Stderr, shown, opts.maxErrors)
+		if groupTeams {
+			printTeamSummary(os.Stderr, shown)
+		}
+	}
+	if len(res.skipped) > 0 && !opts.quiet {
+		printSkipped(os.Stderr, res.skipped)
+	}
+	if len(res.suppressed) > 0 && !opts.quiet {
+		printSuppressed(os.Stderr, res.suppressed)
+	}
+	if suggest {
+		if suggestions := suggestReviewers(res); len(suggestions) > 0 {
+			printReviewerSuggestions(os.Stderr, suggestions)
+			if requestPR != 0 && ctx.Err() == nil {
+				requested, err := requestReviewers(newGitHubClient().withContext(ctx), os.Getenv("GITHUB_REPOSITORY"), requestPR, suggestions)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "error: %v\n", err)
+					return 1
+				}
+				if len(requested) > 0 {
+					fmt.Fprintf(os.Stderr, "Requested review on #%d from %s.\n", requestPR, strings.Join(requested, ", "))
+				}
+			}
+		}
+	}
+	if outputPath != "" {
+		reports = append(reportFlag{{format: format, path: outputPath}}, reports...)
+	} else if stream == nil { // a stream has written it already
+		if err := writeReport(os.Stdout, format, res, opts); err != nil {
+			fmt.Fprintf(os.Stderr, "error: writing report: %v\n", err)
+			return 1
+		}
+	}
+	for _, r := range reports {
+		if err := writeReportFile(r.path, r.format, res, opts); err != nil {
+			fmt.Fprintf(os.Stderr, "error: writing %s report: %v\n", r.format, err)
+			return 1
+		}
+	}
+	if outputs := os.Getenv("GITHUB_OUTPUT"); outputs != "" {
+		var reportPath string
+		if len(reports) > 0 {
+			reportPath = reports[0].path
+		}
+		if err := writeActionOutputs(outputs, res, reportPath); err != nil {
+			fmt.Fprintf(os.Stderr, "error: writing GITHUB_OUTPUT: %v\n", err)
+			return 1
+		}
+	}
+	if historyPath != "" {
+		if err := appendHistory(historyPath, newHistoryEntry(res, time.Now(), historyCommit())); err != nil {
+			fmt.Fprintf(os.Stderr, "error: writing history: %v\n", err)
+			return 1
+		}
+	}
+	if metricsPath != "" {
+		if err := writeMetricsFile(metricsPath, res); err != nil {
+			fmt.Fprintf(os.Stderr, "error: writing metrics: %v\n", err)
+			return 1
+		}
+	}
+	if interactive && ctx.Err() == nil {
+		fixes := promptRemediations(os.Stdin, os.Stderr, res.errors)
+		if !fixes.empty() {
+			var diff io.Writer
+			if dryRun {
+				diff = os.Stdout
+			}
+			if err := applyRemediation(os.Stderr, fixes, res.configPath, diff); err != nil {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				return 1
+			}
+			if !dryRun {
+				fmt.Fprintln(os.Stderr, "Run again to check the changes.")
+			}
+		}
+	}
+	if countFailures(res.errors) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// checkRepo validates the repository in the working directory. An error
+// means the check couldn't run at all, as opposed to finding problems. Once
+// ctx is done the check stops, returning what it found so far with an error
+// saying so.
+func checkRepo(ctx context.Context, configPath string, codeownersPaths []string, filter specFilter) (result, error) {
+	return checkRepoFrom(ctx, localConfig, configPath, codeownersPaths, filter)
+}
+
+// checkRepoFrom is checkRepo for a repository whose config is from origin.
+// A remote config's hooks aren't run.
+func checkRepoFrom(ctx context.Context, origin configOrigin, configPath string, codeownersPaths []string, filter specFilter) (result, error) {
+	if err := readStdinSources(os.Stdin, configPath, codeownersPaths); err != nil {
+		return result{}, err
+	}
+	if err := enterConfigRoot(configPath); err != nil {
+		return result{}, err
+	}
+
+	sp := startSpan("load config")
+	cfg, err := loadConfigFrom(configPath, origin)
+	sp.finish()
+	if err != nil {
+		return result{}, err
+	}
+	logger.Info("loaded config", "path", resolveConfigPath(configPath), "specs", len(cfg.Directories))
+
+	if len(cfg.Directories) == 0 {
+		return result{}, fmt.Errorf("no directories configured")
+	}
+	specs, err := filter.apply(cfg.Directories)
+	if err != nil {
+		return result{}, err
+	}
+
+	// The flag takes precedence over the config file
+	if len(codeownersPaths) == 0 {
+		codeownersPaths = cfg.Codeowners
+	}
+	var skipped []validationError
+	if offline {
+		var remote []string
+		codeownersPaths, remote = splitRemoteSources(codeownersPaths)
+		for _, source := range remote {
+			skipped = append(skipped, validationError{
+				path:     source,
+				message:  "Skipped remote CODEOWNERS because of --offline. Its rules aren't part of this check.",
+				code:     codeOfflineSkipped,
+				severity: severityWarning,
+			})
+		}
+	}
+	if len(codeownersPaths) == 0 {
+		path, err := findCodeowners()
+		if err != nil {
+			return result{}, err
+		}
+		codeownersPaths = []string{path}
+	}
+
+	sp = startSpan("parse CODEOWNERS", "sources", len(codeownersPaths))
+	rules, err := loadCodeowners(ctx, codeownersPaths, cfg.Provider)
+	sp.set("rules", len(rules))
+	sp.finish()
+	if err != nil {
+		return result{}, err
+	}
+
+	suppressions, err := ruleSuppressions(rules)
+	if err != nil {
+		return result{}, err
+	}
+
+	changed, onlyChanged, err := changedPaths()
+	if err != nil {
+		return result{}, err
+	}
+	if onlyChanged {
+		// Any rule can change with CODEOWNERS, and any spec with the config.
+		for _, p := range slices.Concat([]string{resolveConfigPath(configPath)}, cfg.nested, codeownersPaths) {
+			if slices.Contains(changed, path.Clean(filepath.ToSlash(p))) {
+				logger.Info("checking every directory", "changed", p)
+				onlyChanged = false
+			}
+		}
+	}
+
+	baseRules, err := baseCodeowners(codeownersPaths, cfg.Provider)
+	if err != nil {
+		return result{}, err
+	}
+
+	hooks := cfg.Hooks
+	if origin == remoteConfig && len(hooks) > 0 {
+		logger.Info("skipping hooks in a remote config", "hooks", len(hooks))
+		hooks = nil
+	}
+	c := &checker{
+		fsys:           contextFS{fileSystem: withExcludes(withSymlinks(newLocalFS(), cfg.Symlinks), cfg.globalExcludes()), ctx: ctx},
+		rules:          rules,
+		configPath:     filepath.ToSlash(sourceLabel(resolveConfigPath(configPath))),
+		codeownersPath: filepath.ToSlash(sourceLabel(codeownersPaths[len(codeownersPaths)-1])),
+		policy:         cfg.Policy,
+		customPolicies: cfg.Policies,
+		hooks:          hooks,
+		aliases:        cfg.Aliases,
+		allowUnowned:   cfg.AllowUnowned,
+		catalog:        cfg.Catalog,
+		suppressions:   append(cfg.Suppressions, suppressions...),
+		org:            activePolicy,
+		pruneCovered:   cfg.PruneCovered,
+		changed:        changed,
+		onlyChanged:    onlyChanged,
+		baseRules:      baseRules,
+		ctx:            ctx,
+	}
+	if traversal.sparse {
+		c.sparse = loadSparseCheckout()
+	}
+	if activePolicy != nil {
+		c.orgFS = contextFS{fileSystem: withExcludes(withSymlinks(newLocalFS(), cfg.Symlinks), defaultGlobalExcludes), ctx: ctx}
+	}
+	// Cached results can't be trusted when expansion reaches what git
+	// doesn't track, or when coverage is compared with a base ref, and
+	// aren't worth it when only changes are checked.
+	if subtreeCacheDir != "" && traversal.gitignore && cfg.Symlinks != symlinksFollow && !onlyChanged && baseRules == nil {
+		c.cache, err = newSubtreeCache(subtreeCacheDir, cfg.raw, rules)
+		if err != nil {
+			logger.Warn("not using the subtree cache", "error", err)
+		}
+	}
+	res := c.validate(specs)
+	c.cache.prune()
+	res.codeowners = codeownersPaths
+	res.provider = cfg.Provider
+	res.errors = append(res.errors, res.suppress(skipped)...)
+	return res, nil
+}
+
+// repoFlags say where a command runs and which config and CODEOWNERS it
+// reads. Commands register the ones they use: registerDir for just -C,
+// registerConfig to add --config, and register for --codeowners-path too.
+type repoFlags struct {
+	dir        string
+	configPath string
+	codeowners stringList
+}
+
+func (f *repoFlags) registerDir(fs *flag.FlagSet) {
+	fs.StringVar(&f.dir, "C", "", "run as if started in this directory")
+	fs.StringVar(&f.dir, "chdir", "", "alias for -C")
+}
+
+func (f *repoFlags) registerConfig(fs *flag.FlagSet) {
+	f.registerDir(fs)
+	fs.StringVar(&f.configPath, "config", "", "path to config file, or - for stdin (default: .requirecodeowners.{yml,yaml,toml,json})")
+}
+
+func (f *repoFlags) register(fs *flag.FlagSet) {
+	f.registerConfig(fs)
+	fs.Var(&f.codeowners, "codeowners-path", "path to CODEOWNERS file or - for stdin, repeatable to merge several (overrides config; auto-detected if neither is set)")
+}
+
+// apply changes to the -C directory.
+func (f *repoFlags) apply() error {
+	return changeDir(f.dir)
+}
+
+// changeDir moves into dir, if set, before anything else runs, so every
+// relative path (config, CODEOWNERS, specs) resolves against it.
+func changeDir(dir string) error {
+	if dir == "" {
+		return nil
+	}
+	if err := os.Chdir(dir); err != nil {
+		return fmt.Errorf("changing to %s: %w", dir, err)
+	}
+	return nil
+}
+
+// consoleErrors returns the errors to print as console text: all of them, or
+// only the failures with --quiet.
+func consoleErrors(errors []validationError, opts reportOptions) []validationError {
+	if !opts.quiet {
+		return errors
+	}
+	var failures []validationError
+	for _, e := range errors {
+		if !e.isWarning() {
+			failures = append(failures, e)
+		}
+	}
+	return failures
+}
+
+// countFailures returns the number of errors that should fail the check;
+// warnings are reported but don't count.
+func countFailures(errors []validationError) int {
+	n := 0
+	for _, e := range errors {
+		if !e.isWarning() {
+			n++
+		}
+	}
+	return n
+}
+
+func pluralize(n int, singular, plural string) string {
+	if n == 1 {
+		return singular
+	}
+	return plural
+}
+
+// expandPath returns the directories matching pattern. Like every path the
+// checks report, they're slash-separated on all platforms, as in CODEOWNERS.
+func expandPath(fsys fileSystem, pattern string) ([]string, error) {
+	matches, err := fsys.Glob(pattern)
+	if errors.Is(err, filepath.ErrBadPattern) {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Filter to only directories
+	var dirs []string
+	for _, match := range matches {
+		info, err := fsys.Stat(match)
+		if err != nil {
+			continue
+		}
+		if info.IsDir() {
+			dirs = append(dirs, filepath.ToSlash(match))
+		}
+	}
+	return dirs, nil
+}
+
+// checker runs the coverage checks for one repository.
+type checker struct {
+	fsys       fileSystem
+	rules      ruleset
+	index      *ruleIndex             // built from rules by matcher, if they're many
+	positions  map[*rule]int          // each rule's index in rules, built by rulePosition
+	locations  map[ruleLocation]*rule // rules by file and line, built by ruleFor
+	configPath string
+
+	// sectionRules are the rules in each GitLab section, by lowercased
+	// name, built by requiredApprovals.
+	sectionRules map[string]ruleset
+
+	// codeownersPath is where new rules should be added, used to locate
+	// findings for uncovered directories.
+	codeownersPath string
+
+	policy         policy
+	customPolicies []customPolicy
+	hooks          []string
+	aliases        map[string]string
+	allowUnowned   []string
+	catalog        catalog
+	suppressions   []suppression
+
+	// org is the org policy, if there is one, and orgFS the file system its
+	// specs expand in, which the config's global excludes don't apply to.
+	org   *orgPolicy
+	orgFS fileSystem
+
+	// cache holds results for unchanged subtrees, if caching is on.
+	cache *subtreeCache
+
+	// With pruneCovered, expansion doesn't descend into directories that
+	// fullyCovered reports, whose results are kept in coveredDirs.
+	pruneCovered bool
+	coveredDirs  map[string]bool
+
+	// With onlyChanged, only directories touched by the changed paths are
+	// checked.
+	changed     []string
+	onlyChanged bool
+
+	// baseRules, with --changed-since, are the CODEOWNERS rules at the base
+	// ref. An uncovered directory they covered is a coverage regression.
+	baseRules ruleset
+
+	// sparse, with --sparse-aware, is the sparse checkout whose left-out
+	// directories are skipped rather than missing.
+	sparse *sparseCheckout
+
+	// ctx ends the check early when it's done, leaving a partial result.
+	ctx context.Context
+}
+
+// result is the outcome of checking a set of specs.
+type result struct {
+	errors  []validationError
+	checked []checkedDir
+
+	// skipped are uncovered directories that are allowed to be, through
+	// allow_unowned or a marker file, and with --sparse-aware, directories
+	// the sparse checkout left out. They aren't counted as checked.
+	skipped []skippedDir
+
+	// suppressions are the exceptions problems are checked against as
+	// they're added, and suppressed the problems they left out.
+	suppressions []suppression
+	suppressed   []suppressedError
+
+	// The config the check ran with, for reports that describe it.
+	configPath string
+	codeowners []string
+	provider   string
+	specs      []dirSpec
+}
+
+// skippedDir is an uncovered directory exempt from the coverage check, with
+// what exempted it.
+type skippedDir struct {
+	path   string
+	reason string
+}
+
+// checkedDir is a directory that was checked for coverage, with the rule that
+// covers it, if any.
+type checkedDir struct {
+	path string
+	rule *rule
+
+	// spec and specPath are the name and path pattern of the spec that
+	// checked it.
+	spec, specPath string
+}
+
+// coverage returns how many of the checked directories have an owner.
+func (r result) coverage() (owned, total int) {
+	for _, d := range r.checked {
+		if d.rule != nil {
+			owned++
+		}
+	}
+	return owned, len(r.checked)
+}
+
+func (c *checker) validate(specs []dirSpec) result {
+	res := result{configPath: c.configPath, specs: specs, suppressions: c.suppressions}
+
+	progress.setSpecs(len(specs))
+	for _, spec := range specs {
+		if c.stopped() {
+			break
+		}
+		progress.startSpec()
+		sp := startSpan("spec", "spec.path", spec.Path, "spec.name", spec.Name)
+		checked, skipped, errs := len(res.checked), len(res.skipped), len(res.errors)
+		c.validateSpec(&res, spec)
+		res.errors = append(res.errors[:errs], res.suppress(res.errors[errs:])...)
+		stream.add(res.checked[checked:], res.skipped[skipped:], res.errors[errs:])
+		sp.finish()
+	}
+
+	if c.stopped() {
+		// Policy and the catalog are about the whole tree, which wasn't
+		// reached.
+		stop := res.suppress([]validationError{stoppedError(c.ctx)})
+		stream.add(nil, nil, stop)
+		res.errors = append(res.errors, stop...)
+		return res
+	}
+
+	sp := startSpan("policy")
+	policyErrs := res.suppress(c.checkPolicy(res.checked))
+	stream.add(nil, nil, policyErrs)
+	res.errors = append(res.errors, policyErrs...)
+	sp.finish()
+	// When only changed directories are checked, their coverage says
+	// nothing about the repository's.
+	if c.org != nil && c.org.MinCoverage > 0 && !c.onlyChanged {
+		if e, ok := c.checkMinCoverage(res.checked); ok {
+			stream.add(nil, nil, []validationError{e})
+			res.errors = append(res.errors, e)
+		}
+	}
+	if len(c.catalog.Files) > 0 {
+		sp = startSpan("catalog")
+		catalogErrs := res.suppress(c.checkCatalog())
+		stream.add(nil, nil, catalogErrs)
+		res.errors = append(res.errors, catalogErrs...)
+		sp.finish()
+	}
+	return res
+}
+
+// validateSpec checks the directories one spec matches, adding the results
+// to res.
+func (c *checker) validateSpec(res *result, spec dirSpec) {
+	sp := startSpan("expand", "pattern", spec.Path)
+	matchedDirs, err := expandPath(c.expansionFS(spec), spec.Path)
+	sp.set("directories", len(matchedDirs))
+	sp.finish()
+	if err != nil && c.stopped() {
+		return
+	}
+	if err != nil {
+		res.errors = append(res.errors, validationError{
+			path:      spec.Path,
+			message:   fmt.Sprintf("Cannot expand path: %v", err),
+			code:      codeUnreadable,
+			severity:  spec.Severity,
+			spec:      spec.Name,
+			specPath:  spec.Path,
+			file:      c.specFile(spec),
+			line:      spec.line,
+			mandatory: spec.mandatory,
+		})
+		return
+	}
+	if len(matchedDirs) == 0 {
+		if c.sparse.excludes(spec.Path) {
+			c.skipSparse(res, spec.Path)
+			return
+		}
+		res.errors = append(res.errors, validationError{
+			path:      spec.Path,
+			message:   fmt.Sprintf("No directories match this path. Check %s.", c.specFile(spec)),
+			code:      codeNoMatch,
+			severity:  spec.Severity,
+			spec:      spec.Name,
+			specPath:  spec.Path,
+			team:      spec.DefaultOwner,
+			file:      c.specFile(spec),
+			line:      spec.line,
+			mandatory: spec.mandatory,
+		})
+		return
+	}
+
+	for _, dir := range matchedDirs {
+		if c.stopped() {
+			return
+		}
+		checked := len(res.checked)
+		errs := c.validateDirectoryCached(res, dir, spec)
+		for i := range res.checked[checked:] {
+			res.checked[checked+i].spec, res.checked[checked+i].specPath = spec.Name, spec.Path
+		}
+		for i := range errs {
+			if errs[i].severity == "" {
+				errs[i].severity = spec.Severity
+			}
+			errs[i].spec, errs[i].specPath = spec.Name, spec.Path
+			errs[i].mandatory = spec.mandatory
+			if spec.DefaultOwner != "" {
+				errs[i].team = spec.DefaultOwner
+			}
+		}
+		progress.addFailures(countFailures(errs))
+		res.errors = append(res.errors, errs...)
+	}
+}
+
+func (c *checker) validateDirectory(res *result, path string, spec dirSpec) []validationError {
+	var errors []validationError
+	configPath := c.specFile(spec)
+
+	info, err := c.fsys.Stat(path)
+	if err != nil && c.stopped() {
+		return nil
+	}
+	if os.IsNotExist(err) && c.sparse.excludes(path) {
+		c.skipSparse(res, path)
+		return nil
+	}
+	if os.IsNotExist(err) {
+		errors = append(errors, validationError{
+			path:    path,
+			message: fmt.Sprintf("Directory not found. Create it or remove from %s.", configPath),
+			code:    codeDirNotExist,
+			file:    configPath,
+			line:    spec.line,
+		})
+		return errors
+	}
+	if err != nil {
+		errors = append(errors, validationError{path: path, message: fmt.Sprintf("Cannot access: %v", err), code: codeUnreadable, file: configPath, line: spec.line})
+		return errors
+	}
+	if !info.IsDir() {
+		errors = append(errors, validationError{
+			path:    path,
+			message: fmt.Sprintf("Expected a directory but found a file. Check %s.", configPath),
+			code:    codeNotDirectory,
+			file:    configPath,
+			line:    spec.line,
+		})
+		return errors
+	}
+
+	sp := startSpan("expand", "path", path)
+	dirsToCheck, errs := c.dirsToCheck(path, spec)
+	sp.set("directories", len(dirsToCheck))
+	sp.finish()
+	progress.addExpanded(len(dirsToCheck))
+	errors = append(errors, errs...)
+
+	sp = startSpan("match", "path", path, "directories", len(dirsToCheck))
+	defer sp.finish()
+	vs := c.dirValidators(spec)
+	for _, d := range dirsToCheck {
+		if c.stopped() {
+			break
+		}
+		if c.onlyChanged && !touches(d, c.changed) {
+			continue
+		}
+		if isExcluded(d, spec.Excludes) {
+			logger.Debug("excluded directory", "path", d, "spec", spec.Path)
+			continue
+		}
+		if len(spec.Contains) > 0 && !containsFile(c.expansionFS(spec), d, spec.Contains) {
+			logger.Debug("excluded directory", "path", d, "spec", spec.Path, "reason", "contains")
+			continue
+		}
+		match := matchDirectory(c.matcher(), d)
+		if match == nil {
+			reason := c.unownedReason(d, spec)
+			if c.stopped() {
+				// The reason may come from reads that failed.
+				break
+			}
+			if reason != "" {
+				logger.Debug("skipped directory", "path", d, "reason", reason)
+				res.skipped = append(res.skipped, skippedDir{path: d, reason: reason})
+				continue
+			}
+		}
+		res.checked = append(res.checked, checkedDir{path: d, rule: match})
+		progress.addChecked()
+		if match == nil {
+			logger.Debug("checked directory", "path", d, "rule", "none")
+		} else {
+			logger.Debug("checked directory", "path", d, "rule", match.location(), "pattern", match.RawPattern(), "owners", len(match.Owners))
+		}
+		errors = append(errors, c.checkDir(vs, checkedDir{path: d, rule: match})...)
+	}
+
+	return errors
+}
+
+// coverageValidator reports directories that no rule owns, as coverage
+// regressions when the base ref's rules owned them.
+func (c *checker) coverageValidator() validate.Validator {
+	return validate.Func{N: "coverage", F: func(_ context.Context, d validate.DirResult) []validate.Finding {
+		if d.Rule != "" {
+			return nil
+		}
+		if was := matchDirectory(c.baseRules, d.Path); c.baseRules != nil && was != nil {
+			return []validate.Finding{{
+				Message: fmt.Sprintf("Lost CODEOWNERS coverage: owned by %s through %s at %s. Restore the rule or add: /%s/ @your-team",
+					was.ownerNames(), was.RawPattern(), changes.since, d.Path),
+				Severity: severityError,
+				Code:     codeRegression,
+				File:     c.codeownersPath,
+				Team:     was.ownerNames(),
+			}}
+		}
+		message := fmt.Sprintf("Not covered by CODEOWNERS. Add: /%s/ @your-team", d.Path)
+		if r := c.suggestRule(d.Path); r != nil {
+			message = fmt.Sprintf("Not covered by CODEOWNERS. Did you mean %s: %s? Otherwise add: /%s/ @your-team", r.location(), r.RawPattern(), d.Path)
+		}
+		return []validate.Finding{{
+			Message: message,
+			Code:    codeMissingEntry,
+			File:    c.codeownersPath,
+			Team:    c.ancestorOwners(d.Path),
+		}}
+	}}
+}
+
+// minOwnersValidator reports owned directories whose rule lists fewer than
+// min owners.
+func (c *checker) minOwnersValidator(min int) validate.Validator {
+	return validate.Func{N: "min_owners", F: func(_ context.Context, d validate.DirResult) []validate.Finding {
+		match := c.ruleFor(d)
+		if match == nil || len(match.Owners) >= min {
+			return nil
+		}
+		return []validate.Finding{{
+			Message: fmt.Sprintf("Has %d %s but at least %d required. Add owners to %s: %s",
+				len(match.Owners), pluralize(len(match.Owners), "owner", "owners"), min, match.location(), match.RawPattern()),
+			Code: codeTooFewOwners,
+		}}
+	}}
+}
+
+// dirsToCheck returns the directories spec checks in path, one of the
+// directories its path matched, along with any problems finding them.
+func (c *checker) dirsToCheck(path string, spec dirSpec) ([]string, []validationError) {
+	var errors []validationError
+	configPath := c.specFile(spec)
+
+	fsys := c.expansionFS(spec)
+	submodules, _ := fsys.(*submoduleFS)
+	var pruning *pruneFS
+	if c.pruneCovered {
+		pruning = &pruneFS{fileSystem: fsys, covered: c.fullyCovered}
+		fsys = pruning
+	}
+
+	var dirsToCheck []string
+	if mode, ok := discoveryModes[spec.Mode]; ok {
+		var err error
+		dirsToCheck, err = mode.discover(fsys, path, spec)
+		if err != nil && c.stopped() {
+			return nil, nil
+		}
+		if err != nil {
+			errors = append(errors, validationError{path: path, message: fmt.Sprintf("Cannot read: %v", err), code: codeUnreadable, file: configPath, line: spec.line})
+			return nil, errors
+		}
+		if len(dirsToCheck) == 0 && !pruning.prunedAny() && !submodules.cutAny() {
+			errors = append(errors, validationError{
+				path:    path,
+				message: fmt.Sprintf("No %s found. Check the path or mode in %s.", mode.units, configPath),
+				code:    codeEmptyLevel,
+				file:    configPath,
+				line:    spec.line,
+			})
+			return nil, errors
+		}
+	}
+	for _, level := range spec.depths() {
+		dirs, err := getDirsAtLevel(fsys, path, level)
+		if err != nil && c.stopped() {
+			return nil, nil
+		}
+		if err != nil {
+			errors = append(errors, validationError{path: path, message: fmt.Sprintf("Cannot read: %v", err), code: codeUnreadable, file: configPath, line: spec.line})
+			return nil, errors
+		}
+		if level > 0 && len(dirs) == 0 && !pruning.prunedAny() && !submodules.cutAny() {
+			errors = append(errors, validationError{
+				path:    path,
+				message: fmt.Sprintf("No subdirectories found at level %d. Add subdirectories or set level to 0 in %s.", level, configPath),
+				code:    codeEmptyLevel,
+				file:    configPath,
+				line:    spec.line,
+			})
+			// Deeper levels can't have any either.
+			break
+		}
+		dirsToCheck = append(dirsToCheck, dirs...)
+	}
+	if pruning.prunedAny() {
+		// A pruned directory is checked in place of its subtree, whose
+		// directories would all have had its owners.
+		for _, d := range pruning.pruned {
+			if !slices.Contains(dirsToCheck, d) {
+				logger.Debug("pruned covered subtree", "path", d, "spec", spec.Path)
+				dirsToCheck = append(dirsToCheck, d)
+			}
+		}
+	}
+	if submodules.cutAny() {
+		// A submodule is owned as a whole, by one rule, since its contents
+		// come from another repository.
+		for _, d := range submodules.units {
+			if !slices.Contains(dirsToCheck, d) {
+				logger.Debug("submodule checked as a unit", "path", d, "spec", spec.Path)
+				dirsToCheck = append(dirsToCheck, d)
+			}
+		}
+	}
+	return dirsToCheck, errors
+}
+
+// ancestorOwners returns the owners of dir's nearest covered ancestor, the
+// team most likely to be responsible for an uncovered directory, or "" if no
+// ancestor is covered.
+func (c *checker) ancestorOwners(dir string) string {
+	for parent := path.Dir(dir); parent != "." && parent != "/"; parent = path.Dir(parent) {
+		if match := matchDirectory(c.matcher(), parent); match != nil {
+			return match.ownerNames()
+		}
+	}
+	return ""
+}
+
+// isExcluded reports whether dir matches one of the exclude patterns. Patterns
+// containing a slash are matched against the whole path, others against the
+// directory's base name.
+func isExcluded(dir string, excludes []string) bool {
+	dir = path.Clean(filepath.ToSlash(dir))
+	for _, pattern := range excludes {
+		target := path.Base(dir)
+		if strings.Contains(pattern, "/") {
+			target = dir
+			pattern = strings.Trim(pattern, "/")
+		}
+		if ok, _ := path.Match(pattern, target); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// unownedMarker is a file that exempts the directory it's in from having an
+// owner, so teams can opt out in a change that's visible in review.
+const unownedMarker = ".codeowners-ignore"
+
+// unownedReason returns why the uncovered directory dir, checked by spec,
+// may stay unowned, or "" if it must have an owner. Only the spec's own
+// settings exempt the directories an org policy spec checks.
+func (c *checker) unownedReason(dir string, spec dirSpec) string {
+	if !spec.mandatory {
+		if matchesPathPatterns(dir, c.allowUnowned) {
+			return "allow_unowned"
+		}
+		if hasFile(c.fsys, path.Join(dir, unownedMarker)) {
+			return unownedMarker
+		}
+	}
+	fsys := c.expansionFS(spec)
+	if spec.MinFiles > 0 {
+		if n := countFiles(fsys, dir, spec.MinFiles); n < spec.MinFiles {
+			return fmt.Sprintf("min_files: %d %s", n, pluralize(n, "file", "files"))
+		}
+	}
+	if spec.MinLOC > 0 {
+		if n, ok := countLines(fsys, dir, spec.MinLOC); ok && n < spec.MinLOC {
+			return fmt.Sprintf("min_loc: %d %s", n, pluralize(n, "line", "lines"))
+		}
+	}
+	return ""
+}
+
+// matchesPathPatterns reports whether dir, or one of its parents, matches one
+// of patterns, like allow_unowned. Patterns are matched against the whole path.
+func matchesPathPatterns(dir string, patterns []string) bool {
+	for d := path.Clean(filepath.ToSlash(dir)); d != "." && d != "/"; d = path.Dir(d) {
+		for _, pattern := range patterns {
+			if ok, _ := path.Match(strings.Trim(pattern, "/"), d); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// getDirsAtLevel returns the directories level levels below dir, as
+// slash-separated paths.
+func getDirsAtLevel(fsys fileSystem, dir string, level int) ([]string, error) {
+	dir = filepath.ToSlash(dir)
+	if level == 0 {
+		return []string{dir}, nil
+	}
+
+	entries, err := fsys.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading directory: %w", err)
+	}
+
+	var results []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		subdirs, err := getDirsAtLevel(fsys, path.Join(dir, entry.Name()), level-1)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, subdirs...)
+	}
+	return results, nil
+}
@@ -0,0 +1,94 @@
+package check
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+)
+
+// command is one of the CLI's subcommands. run gets the arguments after the
+// command's name and returns the exit status: 0 for success, 1 for problems
+// found or a failed run, and 2 for bad usage.
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) int
+}
+
+// commands are the subcommands, in the order help lists them.
+var commands = []command{
+	{"check", "check that the configured directories have CODEOWNERS coverage (the default)", runCheck},
+	{"lint", "lint CODEOWNERS: pattern problems, policy and stale rules", runLint},
+	{"fix", "fix the lint findings that have one unambiguous fix", runFix},
+	{"fmt", "normalize how owners are spelled in CODEOWNERS", runFmt},
+	{"list", "list the checked directories and their owners", runList},
+	{"explain", "show which CODEOWNERS rules match a path, and which one wins", runExplain},
+	{"audit", "run every check in one pass with one report, and file issues", runAudit},
+	{"init", "write a starter config for the repository", runInit},
+	{"config", "lint or validate the config, or print its JSON Schema", runConfig},
+	{"stats", "summarize who owns the configured directories", runStats},
+	{"export", "write the ownership of each checked directory as JSON or YAML", runExport},
+	{"diff", "compare the ownership of two CODEOWNERS revisions", runDiff},
+	{"merge", "combine CODEOWNERS fragments into one file", runMerge},
+	{"trend", "show coverage over time from a history file", runTrend},
+	{"scan-org", "check every repository in a GitHub organization", runScanOrg},
+	{"hook", "install a git hook that runs the check", runHook},
+	{"serve", "serve checks over HTTP, or as a GitHub App", runServe},
+	{"version", "print the version and build metadata", runVersion},
+}
+
+// Main runs the requirecodeowners command line with args, which don't
+// include the program's name, and returns its exit status. A program that
+// adds validators with validate.Register calls it from main to get the
+// whole tool with them.
+func Main(args []string) int {
+	return run(args)
+}
+
+// run runs the command named by the first argument. Anything else, like a
+// flag, a path, or nothing at all, runs check, so invocations from before
+// there were subcommands keep working.
+func run(args []string) int {
+	if len(args) == 0 {
+		return runCheck(args)
+	}
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		return runHelp(os.Stdout, args[1:])
+	case "--version", "-version":
+		return runVersion(args[1:])
+	}
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			return cmd.run(args[1:])
+		}
+	}
+	return runCheck(args)
+}
+
+// runHelp lists the commands, or shows a command's own usage.
+func runHelp(w io.Writer, args []string) int {
+	if len(args) > 0 {
+		for _, cmd := range commands {
+			if cmd.name == args[0] {
+				cmd.run([]string{"-h"})
+				return 0
+			}
+		}
+		fmt.Fprintf(os.Stderr, "error: unknown command %q\n", args[0])
+		return 2
+	}
+	fmt.Fprintln(w, "usage: requirecodeowners [command] [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, cmd := range commands {
+		fmt.Fprintf(tw, "  %s\t%s\n", cmd.name, cmd.summary)
+	}
+	tw.Flush()
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Without a command, requirecodeowners runs check.")
+	fmt.Fprintln(w, "Run requirecodeowners help <command> for a command's flags.")
+	return 0
+}
@@ -0,0 +1,39 @@
+package check
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRunHelp(t *testing.T) {
+	var b bytes.Buffer
+	if status := runHelp(&b, nil); status != 0 {
+		t.Errorf("runHelp() = %d, want 0", status)
+	}
+	for _, cmd := range commands {
+		if !strings.Contains(b.String(), "  "+cmd.name+" ") {
+			t.Errorf("help doesn't list %s:\n%s", cmd.name, b.String())
+		}
+	}
+	if status := runHelp(&b, []string{"nope"}); status != 2 {
+		t.Errorf("runHelp(nope) = %d, want 2", status)
+	}
+}
+
+func TestCommandNames(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, cmd := range commands {
+		if seen[cmd.name] {
+			t.Errorf("command %s is listed twice", cmd.name)
+		}
+		seen[cmd.name] = true
+		if cmd.summary == "" || cmd.run == nil {
+			t.Errorf("command %s needs a summary and a run function", cmd.name)
+		}
+	}
+	// Bare invocation runs check, so check must be a command of its own too.
+	if !seen["check"] {
+		t.Error("there's no check command")
+	}
+}
@@ -0,0 +1,328 @@
+package check
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/hmarr/codeowners"
+)
+
+// rule is a CODEOWNERS rule along with the file it was read from, so findings
+// can point at the right file when several are merged.
+type rule struct {
+	codeowners.Rule
+	file string
+
+	// section is the GitLab section the rule is in, if any.
+	section *codeownersSection
+}
+
+// location returns a file:line reference for the rule.
+func (r *rule) location() string {
+	return fmt.Sprintf("%s:%d", r.file, r.LineNumber)
+}
+
+// ownerNames returns the rule's owners as they're written in CODEOWNERS.
+func (r *rule) ownerNames() string {
+	names := make([]string, len(r.Owners))
+	for i, o := range r.Owners {
+		names[i] = o.String()
+	}
+	return strings.Join(names, " ")
+}
+
+// ruleset is the merged set of rules from one or more CODEOWNERS files, in
+// the order they were loaded.
+type ruleset []rule
+
+// newRuleset wraps rules parsed from file.
+func newRuleset(rules codeowners.Ruleset, file string) ruleset {
+	rs := make(ruleset, len(rules))
+	for i, r := range rules {
+		rs[i] = rule{Rule: r, file: file}
+	}
+	return rs
+}
+
+// Match returns the last rule matching path. As within a single CODEOWNERS
+// file, later rules take precedence, so rules from later files override
+// earlier ones.
+func (rs ruleset) Match(path string) (*rule, error) {
+	for i := len(rs) - 1; i >= 0; i-- {
+		match, err := rs[i].Match(path)
+		if match || err != nil {
+			return &rs[i], err
+		}
+	}
+	return nil, nil
+}
+
+// githubSourcePrefix marks a CODEOWNERS source that is fetched from a GitHub
+// repository through the contents API, as "github:owner/repo[/path][@ref]".
+const githubSourcePrefix = "github:"
+
+// codeownersLocations are the standard places a CODEOWNERS file lives, in the
+// order they're searched. GitLab's own, .gitlab/, comes last, so a repository
+// mirrored between the two finds the same file either way.
+var codeownersLocations = []string{
+	".github/CODEOWNERS",
+	"CODEOWNERS",
+	"docs/CODEOWNERS",
+	".gitlab/CODEOWNERS",
+}
+
+// loadCodeowners parses and merges the CODEOWNERS sources, in order. A source
+// is a local path, an http(s) URL, or a github: repository reference. With no
+// sources it falls back to the first file found in a standard location.
+// Remote sources are fetched under ctx, and every source is parsed in
+// provider's syntax.
+func loadCodeowners(ctx context.Context, sources []string, provider string) (ruleset, error) {
+	if len(sources) == 0 {
+		path, err := findCodeowners()
+		if err != nil {
+			return nil, err
+		}
+		sources = []string{path}
+	}
+
+	var client *githubClient
+	var merged ruleset
+	for _, source := range sources {
+		var rules ruleset
+		var err error
+		switch {
+		case isURL(source):
+			if client == nil {
+				client = newGitHubClient().withContext(ctx)
+			}
+			rules, err = fetchCodeownersURL(client, source, provider)
+		case isGitHubSource(source):
+			if client == nil {
+				client = newGitHubClient().withContext(ctx)
+			}
+			rules, err = fetchCodeownersRepo(client, strings.TrimPrefix(source, githubSourcePrefix), provider)
+		default:
+			rules, err = parseCodeownersFile(source, provider)
+		}
+		if err != nil {
+			return nil, err
+		}
+		logger.Info("loaded CODEOWNERS", "source", source, "rules", len(rules))
+		merged = append(merged, rules...)
+	}
+	return merged, nil
+}
+
+// splitRemoteSources separates the sources that are fetched over the network
+// from the local files, keeping their order.
+func splitRemoteSources(sources []string) (local, remote []string) {
+	for _, source := range sources {
+		if isURL(source) || isGitHubSource(source) {
+			remote = append(remote, source)
+		} else {
+			local = append(local, source)
+		}
+	}
+	return local, remote
+}
+
+func isGitHubSource(source string) bool {
+	return strings.HasPrefix(source, githubSourcePrefix)
+}
+
+func isURL(source string) bool {
+	return strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "http://")
+}
+
+func fetchCodeownersURL(client *githubClient, rawURL, provider string) (ruleset, error) {
+	data, err := client.fetchURL(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("fetching CODEOWNERS: %w", err)
+	}
+	return parseCodeowners(bytes.NewReader(data), rawURL, provider)
+}
+
+// fetchCodeownersRepo fetches CODEOWNERS from a repository reference of the
+// form owner/repo[/path][@ref]. Without a path, the standard locations are
+// tried in order.
+func fetchCodeownersRepo(client *githubClient, ref, provider string) (ruleset, error) {
+	repo, path, gitRef, err := parseRepoRef(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := codeownersLocations
+	if path != "" {
+		paths = []string{path}
+	}
+	for _, p := range paths {
+		data, err := client.getContents(repo, p, gitRef)
+		if isNotFound(err) && path == "" {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("fetching CODEOWNERS from %s: %w", repo, err)
+		}
+		name := repo + "/" + p
+		if gitRef != "" {
+			name += "@" + gitRef
+		}
+		return parseCodeowners(bytes.NewReader(data), name, provider)
+	}
+	return nil, fmt.Errorf("CODEOWNERS not found in standard locations of %s", repo)
+}
+
+// parseRepoRef splits owner/repo[/path][@ref] into its parts.
+func parseRepoRef(ref string) (repo, path, gitRef string, err error) {
+	if i := strings.LastIndex(ref, "@"); i >= 0 {
+		ref, gitRef = ref[:i], ref[i+1:]
+	}
+	parts := strings.SplitN(ref, "/", 3)
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", "", fmt.Errorf("invalid repository %q (want owner/repo[/path][@ref])", ref)
+	}
+	repo = parts[0] + "/" + parts[1]
+	if len(parts) == 3 {
+		path = parts[2]
+	}
+	return repo, path, gitRef, nil
+}
+
+func findCodeowners() (string, error) {
+	for _, loc := range codeownersLocations {
+		if _, err := os.Stat(loc); err == nil {
+			return loc, nil
+		}
+	}
+	return "", fmt.Errorf("CODEOWNERS not found in standard locations (.github/, root, docs/, .gitlab/)")
+}
+
+func parseCodeownersFile(path, provider string) (ruleset, error) {
+	if path == stdinSource {
+		data, err := readStdinCodeowners()
+		if err != nil {
+			return nil, err
+		}
+		return parseCodeowners(bytes.NewReader(data), stdinLabel, provider)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %w", path, err)
+	}
+	defer func() { _ = f.Close() }()
+	return parseCodeowners(f, filepath.ToSlash(path), provider)
+}
+
+// readCodeownersFile reads the CODEOWNERS file at path, or from stdin if
+// path is stdinSource.
+func readCodeownersFile(path string) ([]byte, error) {
+	if path == stdinSource {
+		return readStdinCodeowners()
+	}
+	return os.ReadFile(path)
+}
+
+// parseCodeowners parses CODEOWNERS content read from r in provider's
+// syntax. name identifies the source in findings. With rulesetCache set, content that was parsed before
+// under the same name isn't parsed again.
+func parseCodeowners(r io.Reader, name, provider string) (ruleset, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", name, err)
+	}
+	key := provider + "\x00" + name + "\x00" + fmt.Sprintf("%x", sha256.Sum256(data))
+	if rs, ok := rulesetCache.get(key); ok {
+		return rs, nil
+	}
+	var sections []codeownersSection
+	if provider == providerGitLab {
+		if data, sections, err = splitSections(data); err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", name, err)
+		}
+	}
+	rules, err := codeowners.ParseFile(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", name, err)
+	}
+	rs := newRuleset(rules, name)
+	assignSections(rs, sections)
+	rulesetCache.put(key, rs)
+	return rs, nil
+}
+
+// rulesetCache keeps parsed CODEOWNERS files for a long-running serve
+// process. It's nil, and caches nothing, for a single check.
+var rulesetCache *parsedRulesets
+
+// parsedRulesets caches rulesets by source name and content hash. Rulesets
+// are never modified after parsing, so they're shared between checks.
+type parsedRulesets struct {
+	mu    sync.Mutex
+	max   int
+	rules map[string]ruleset
+}
+
+func newParsedRulesets(max int) *parsedRulesets {
+	return &parsedRulesets{max: max, rules: make(map[string]ruleset)}
+}
+
+func (c *parsedRulesets) get(key string) (ruleset, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	rs, ok := c.rules[key]
+	return rs, ok
+}
+
+// put adds a ruleset, starting over once the cache is full so it can't grow
+// without bound.
+func (c *parsedRulesets) put(key string, rs ruleset) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.rules) >= c.max {
+		c.rules = make(map[string]ruleset)
+	}
+	c.rules[key] = rs
+}
+
+func hasCodeownersCoverage(rules ruleMatcher, dir string) bool {
+	return matchDirectory(rules, dir) != nil
+}
+
+// matchDirectory returns the rule that gives dir its owners, or nil if no
+// rule with owners covers it.
+func matchDirectory(rules ruleMatcher, dir string) *rule {
+	dir = path.Clean(filepath.ToSlash(dir))
+
+	probes := []string{
+		dir,
+		dir + "/",
+		dir + "/file.txt",
+	}
+
+	for _, probe := range probes {
+		r, _ := rules.Match(probe)
+		if r == nil {
+			logger.Log(context.Background(), levelTrace, "match probe", "dir", dir, "probe", probe, "rule", "none")
+			continue
+		}
+		logger.Log(context.Background(), levelTrace, "match probe", "dir", dir, "probe", probe, "rule", r.location(), "pattern", r.RawPattern(), "owners", r.ownerNames())
+		if len(r.Owners) > 0 {
+			return r
+		}
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package check
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+func runLint(args []string) int {
+	fs := flag.NewFlagSet("lint", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: requirecodeowners lint [flags]")
+		fmt.Fprintln(fs.Output(), "Reports problems with the CODEOWNERS rules themselves: pattern problems, policy findings and stale rules.")
+		fs.PrintDefaults()
+	}
+	var rf repoFlags
+	rf.register(fs)
+	var lf configFlags
+	lf.register(fs)
+	var cf consoleFlags
+	cf.register(fs)
+	var gf githubFlags
+	gf.register(fs)
+	var tf traversalFlags
+	tf.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return 2
+	}
+	if err := lf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	if err := cf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	if err := gf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	if err := tf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+
+	if err := rf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	ctx := context.Background()
+	res, err := checkRepo(ctx, rf.configPath, rf.codeowners, specFilter{})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	stale, err := staleFindings(ctx, res)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	findings := lintFindings(append(res.errors, stale...))
+	return printLintFindings(os.Stdout, os.Stderr, findings)
+}
+
+// lintFindings returns the problems in errors that are about the CODEOWNERS
+// rules, rather than about the directories being checked.
+func lintFindings(errors []validationError) []validationError {
+	var findings []validationError
+	for _, e := range errors {
+		if s := auditSection(e.code); s == "lint" || s == "stale" {
+			findings = append(findings, e)
+		}
+	}
+	sortErrors(findings)
+	return findings
+}
+
+// printLintFindings lists findings on w, or says there are none on out,
+// returning lint's exit status.
+func printLintFindings(out, w io.Writer, findings []validationError) int {
+	if len(findings) == 0 {
+		fmt.Fprintln(out, console.mark(out, markOK, "CODEOWNERS has no lint findings"))
+		return 0
+	}
+	fmt.Fprintln(w)
+	printFindings(w, findings, 0)
+	fmt.Fprintln(w)
+	failures := countFailures(findings)
+	m := markFail
+	if failures == 0 {
+		m = markWarn
+	}
+	fmt.Fprintln(w, console.mark(w, m, fmt.Sprintf("%d lint %s found (%s)", len(findings), pluralize(len(findings), "finding", "findings"), auditCounts(failures, len(findings)-failures))))
+	if n := len(lintFixes(findings)); n > 0 {
+		fmt.Fprintf(w, "Run requirecodeowners fix to fix %d of them.\n", n)
+	}
+	if failures > 0 {
+		return 1
+	}
+	return 0
+}
+
+// staleFindings reports the rules in res's local CODEOWNERS files that name
+// paths that don't exist, as staleRules does.
+func staleFindings(ctx context.Context, res result) ([]validationError, error) {
+	local, _ := splitRemoteSources(res.codeowners)
+	rules, err := loadCodeowners(ctx, local, res.provider)
+	if err != nil {
+		return nil, err
+	}
+	return res.suppress(staleRules(localFS{}, rules, res.errors)), nil
+}
+
+// lintFixes returns the pattern fixes the findings in errors offer, one for
+// each rule. A rule with several is fixed one at a time, since each fix
+// replaces the pattern the next one would start from.
+func lintFixes(errors []validationError) []patternFix {
+	var fixes []patternFix
+	seen := make(map[string]bool)
+	for _, e := range errors {
+		if key := fmt.Sprintf("%s:%d", e.file, e.line); e.fix != "" && !seen[key] {
+			seen[key] = true
+			fixes = append(fixes, patternFix{file: e.file, line: e.line, old: e.path, new: e.fix})
+		}
+	}
+	return fixes
+}
+
+func runFix(args []string) int {
+	fs := flag.NewFlagSet("fix", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: requirecodeowners fix [flags]")
+		fmt.Fprintln(fs.Output(), "Rewrites the CODEOWNERS patterns that lint findings have one unambiguous fix for.")
+		fs.PrintDefaults()
+	}
+	var dryRun bool
+	var rf repoFlags
+	rf.register(fs)
+	var lf configFlags
+	lf.register(fs)
+	fs.BoolVar(&dryRun, "dry-run", false, "print the fixes to stdout as a unified diff instead of writing them")
+	var tf traversalFlags
+	tf.register(fs)
+	var gf githubFlags
+	gf.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return 2
+	}
+	if err := lf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	if err := tf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	if err := gf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+
+	if err := rf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	res, err := checkRepo(context.Background(), rf.configPath, rf.codeowners, specFilter{})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	fixes := lintFixes(lintFindings(res.errors))
+	if len(fixes) == 0 {
+		fmt.Println(console.mark(os.Stdout, markOK, "nothing to fix"))
+		return 0
+	}
+	var diff io.Writer
+	if dryRun {
+		diff = os.Stdout
+	}
+	if err := applyRemediation(os.Stderr, remediation{patterns: fixes}, res.configPath, diff); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	if !dryRun {
+		fmt.Fprintln(os.Stderr, "Run requirecodeowners lint to check the changes.")
+	}
+	return 0
+}
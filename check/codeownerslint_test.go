@@ -0,0 +1,55 @@
+package check
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLintFindings(t *testing.T) {
+	errors := []validationError{
+		{path: "services/web", code: codeMissingEntry},
+		{path: "/services/Web/", code: codePatternCase, severity: severityWarning, file: "CODEOWNERS", line: 3, fix: "/services/web/"},
+		{path: "/services/gone/", code: codeStaleRule, severity: severityWarning, file: "CODEOWNERS", line: 2},
+		{path: "@org/old", code: codeInvalidOwner},
+		{path: "/services/Web", code: codeNoTrailingSlash, severity: severityWarning, file: "CODEOWNERS", line: 4, fix: "/services/Web/"},
+		{path: "/services/Web", code: codePatternCase, severity: severityWarning, file: "CODEOWNERS", line: 4, fix: "/services/web"},
+	}
+	findings := lintFindings(errors)
+	var codes []string
+	for _, e := range findings {
+		codes = append(codes, e.code)
+	}
+	if want := []string{codeStaleRule, codePatternCase, codeNoTrailingSlash, codePatternCase}; len(codes) != len(want) {
+		t.Fatalf("lintFindings() codes = %v, want %v in some order", codes, want)
+	}
+
+	// The rule at line 4 has two fixes, and only one can be made at a time.
+	fixes := lintFixes(findings)
+	if len(fixes) != 2 {
+		t.Fatalf("lintFixes() = %v, want one fix for each of lines 3 and 4", fixes)
+	}
+	lines := []int{fixes[0].line, fixes[1].line}
+	if lines[0] == lines[1] {
+		t.Errorf("lintFixes() = %v, want one fix for each rule", fixes)
+	}
+	if want := (patternFix{file: "CODEOWNERS", line: 3, old: "/services/Web/", new: "/services/web/"}); !reflect.DeepEqual(fixes[0], want) && !reflect.DeepEqual(fixes[1], want) {
+		t.Errorf("lintFixes() = %v, want it to include %v", fixes, want)
+	}
+
+	var out, stderr bytes.Buffer
+	if status := printLintFindings(&out, &stderr, findings); status != 0 {
+		t.Errorf("printLintFindings() with only warnings = %d, want 0", status)
+	}
+	if !strings.Contains(stderr.String(), "4 lint findings found (4 warnings)") || !strings.Contains(stderr.String(), "fix 2 of them") {
+		t.Errorf("printLintFindings() wrote:\n%s", stderr.String())
+	}
+	if status := printLintFindings(&out, &stderr, append(findings, validationError{path: "x", code: codeRuleOrder})); status != 1 {
+		t.Errorf("printLintFindings() with a failure = %d, want 1", status)
+	}
+	out.Reset()
+	if status := printLintFindings(&out, &stderr, nil); status != 0 || !strings.Contains(out.String(), "no lint findings") {
+		t.Errorf("printLintFindings(nil) = %d, %q", status, out.String())
+	}
+}
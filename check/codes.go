@@ -0,0 +1,166 @@
+package check
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Every kind of problem has a stable code, so tooling and suppressions can
+// refer to it without matching messages, which may change. Codes are never
+// reused; a retired code stays retired.
+const (
+	// Coverage
+	codeMissingEntry = "RCO001"
+	codeDirNotExist  = "RCO002"
+	codeNotDirectory = "RCO003"
+	codeNoMatch      = "RCO004"
+	codeEmptyLevel   = "RCO005"
+	codeTooFewOwners = "RCO006"
+	codeUnreadable   = "RCO007"
+	codeSharedRules  = "RCO008"
+	codeRegression   = "RCO009"
+
+	// Lint
+	codeStaleRule         = "RCO010"
+	codeCodeownersLimits  = "RCO011"
+	codeUnanchoredPattern = "RCO012"
+	codeNoTrailingSlash   = "RCO013"
+	codePatternCase       = "RCO014"
+	codeOwnerSpelling     = "RCO015"
+	codeOverlappingSpecs  = "RCO016"
+	codeWildcardOnly      = "RCO017"
+
+	// Policy
+	codeTooManyDirs      = "RCO020"
+	codeOwnerNotAllowed  = "RCO021"
+	codeForbiddenOwner   = "RCO022"
+	codeRawAlias         = "RCO023"
+	codeInvalidTeamOwner = "RCO024"
+	codeEmailOwner       = "RCO025"
+	codeRuleExpired      = "RCO026"
+	codeReviewOverdue    = "RCO027"
+	codeRuleOrder        = "RCO028"
+	codeParentConflict   = "RCO029"
+
+	// Service catalog
+	codeCatalogUnreadable    = "RCO030"
+	codeCatalogMissingSource = "RCO031"
+	codeCatalogUnowned       = "RCO032"
+	codeCatalogMismatch      = "RCO033"
+
+	// Custom policies and hooks
+	codeCustomPolicy = "RCO050"
+	codeHookFailed   = "RCO051"
+
+	// GitLab
+	codeTooFewApprovals = "RCO060"
+
+	// Org policy
+	codeBelowMinCoverage = "RCO070"
+
+	// Owner verification
+	codeInvalidOwner = "RCO040"
+	codeRateLimited  = "RCO041"
+	codeVerifyFailed = "RCO042"
+
+	// The run itself
+	codeOfflineSkipped = "RCO090"
+	codeTruncatedTree  = "RCO091"
+	codeStopped        = "RCO092"
+	codeCheckFailed    = "RCO093"
+)
+
+// codesURL documents every code.
+const codesURL = "https://github.com/kpurdon/requirecodeowners#error-codes"
+
+// codeNames gives each code a name that can be used in its place.
+var codeNames = map[string]string{
+	codeMissingEntry:         "missing-entry",
+	codeDirNotExist:          "dir-not-exist",
+	codeNotDirectory:         "not-a-directory",
+	codeNoMatch:              "no-match",
+	codeEmptyLevel:           "empty-level",
+	codeTooFewOwners:         "too-few-owners",
+	codeUnreadable:           "unreadable",
+	codeSharedRules:          "shared-rules",
+	codeRegression:           "coverage-regression",
+	codeOverlappingSpecs:     "overlapping-specs",
+	codeCodeownersLimits:     "codeowners-limits",
+	codeUnanchoredPattern:    "unanchored-pattern",
+	codeNoTrailingSlash:      "no-trailing-slash",
+	codePatternCase:          "pattern-case",
+	codeOwnerSpelling:        "owner-spelling",
+	codeStaleRule:            "stale-rule",
+	codeWildcardOnly:         "wildcard-only",
+	codeTooManyDirs:          "too-many-dirs",
+	codeOwnerNotAllowed:      "owner-not-allowed",
+	codeForbiddenOwner:       "forbidden-owner",
+	codeRawAlias:             "raw-alias",
+	codeInvalidTeamOwner:     "invalid-team-owner",
+	codeEmailOwner:           "email-owner",
+	codeRuleExpired:          "rule-expired",
+	codeReviewOverdue:        "review-overdue",
+	codeRuleOrder:            "rule-order",
+	codeParentConflict:       "parent-conflict",
+	codeCustomPolicy:         "custom-policy",
+	codeHookFailed:           "hook-failed",
+	codeCatalogUnreadable:    "catalog-unreadable",
+	codeCatalogMissingSource: "catalog-missing-source",
+	codeCatalogUnowned:       "catalog-unowned",
+	codeCatalogMismatch:      "catalog-mismatch",
+	codeTooFewApprovals:      "too-few-approvals",
+	codeBelowMinCoverage:     "below-min-coverage",
+	codeInvalidOwner:         "invalid-owner",
+	codeRateLimited:          "rate-limited",
+	codeVerifyFailed:         "verify-failed",
+	codeOfflineSkipped:       "offline-skipped",
+	codeTruncatedTree:        "truncated-tree",
+	codeStopped:              "stopped",
+	codeCheckFailed:          "check-failed",
+}
+
+// lookupCode returns the code that s, a code or its name, refers to.
+// Codes are matched case-insensitively.
+func lookupCode(s string) (string, bool) {
+	if _, ok := codeNames[strings.ToUpper(s)]; ok {
+		return strings.ToUpper(s), true
+	}
+	for code, name := range codeNames {
+		if strings.EqualFold(name, s) {
+			return code, true
+		}
+	}
+	return "", false
+}
+
+// codeSet collects the codes given to repeated --disable flags, each a
+// comma-separated list of codes or names.
+type codeSet map[string]bool
+
+func (s *codeSet) String() string {
+	codes := make([]string, 0, len(*s))
+	for code := range *s {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return strings.Join(codes, ",")
+}
+
+func (s *codeSet) Set(value string) error {
+	if *s == nil {
+		*s = make(codeSet)
+	}
+	for _, v := range strings.Split(value, ",") {
+		code, ok := lookupCode(strings.TrimSpace(v))
+		if !ok {
+			return fmt.Errorf("unknown code %q", strings.TrimSpace(v))
+		}
+		(*s)[code] = true
+	}
+	return nil
+}
+
+// disabledCodes is set by --disable. Problems with these codes are dropped
+// as they're found, so they're in no report and don't fail the check.
+var disabledCodes codeSet
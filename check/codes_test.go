@@ -0,0 +1,75 @@
+package check
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCodeSet(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{value: "RCO002", want: "RCO002"},
+		{value: "rco002", want: "RCO002"},
+		{value: "dir-not-exist", want: "RCO002"},
+		{value: "RCO001, not-a-directory", want: "RCO001,RCO003"},
+		{value: "RCO999", wantErr: true},
+		{value: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			var s codeSet
+			err := s.Set(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Set(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if !tt.wantErr && s.String() != tt.want {
+				t.Errorf("Set(%q) = %s, want %s", tt.value, s.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestCodeNamesUnique(t *testing.T) {
+	seen := make(map[string]string)
+	for code, name := range codeNames {
+		if other, ok := seen[name]; ok {
+			t.Errorf("%s and %s are both named %s", code, other, name)
+		}
+		seen[name] = code
+	}
+}
+
+func TestValidateDisabledCodes(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tmpDir, "services", "foo"), 0755)
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	rules, _ := parseCodeowners(strings.NewReader("/libs/ @org/libs\n"), "CODEOWNERS", providerGitHub)
+	specs := []dirSpec{{Path: "services", Level: 1}, {Path: "legacy", Level: 0}}
+	codes := func(res result) string {
+		var got []string
+		for _, e := range res.errors {
+			got = append(got, e.code)
+		}
+		return strings.Join(got, ",")
+	}
+
+	c := &checker{fsys: localFS{}, rules: rules, configPath: ".requirecodeowners.yml"}
+	if got, want := codes(c.validate(specs)), codeMissingEntry+","+codeNoMatch; got != want {
+		t.Errorf("codes = %s, want %s", got, want)
+	}
+
+	old := disabledCodes
+	defer func() { disabledCodes = old }()
+	disabledCodes = codeSet{codeNoMatch: true}
+	if got := codes(c.validate(specs)); got != codeMissingEntry {
+		t.Errorf("codes with %s disabled = %s, want %s", codeNoMatch, got, codeMissingEntry)
+	}
+}
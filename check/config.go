@@ -0,0 +1,980 @@
+package check
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+type config struct {
+	// Extends names configs, as paths relative to this one, URLs, or github:
+	// references, that this one builds on. They're merged in before it's
+	// decoded, so it's always empty in a loaded config.
+	Extends stringList `yaml:"extends"`
+
+	// Provider is where the repository is hosted, github (the default) or
+	// gitlab, which decides the CODEOWNERS syntax.
+	Provider string `yaml:"provider"`
+
+	Codeowners  stringList `yaml:"codeowners"`
+	Defaults    dirSpec    `yaml:"defaults"`
+	Directories []dirSpec  `yaml:"directories"`
+	Policy      policy     `yaml:"policy"`
+	Catalog     catalog    `yaml:"catalog"`
+
+	// Aliases map short owner names to the owners they stand for, so the
+	// config can say @payments for @org/payments-core.
+	Aliases map[string]string `yaml:"aliases"`
+
+	// AllowUnowned lists directories, as paths or globs, that are meant to
+	// have no owner. They're skipped instead of failing the check.
+	AllowUnowned []string `yaml:"allow_unowned"`
+
+	// GlobalExcludes are directories no spec expands into, in the same form as
+	// a spec's excludes. They add to defaultGlobalExcludes unless
+	// DefaultExcludes is false.
+	GlobalExcludes  []string `yaml:"global_excludes"`
+	DefaultExcludes *bool    `yaml:"default_excludes"`
+
+	// Symlinks is what expansion does with symlinked directories: skip
+	// (the default), follow, or fail.
+	Symlinks string `yaml:"symlinks"`
+
+	// PruneCovered stops expansion at directories whose whole subtree is
+	// owned by one rule, checking them in place of their subdirectories.
+	PruneCovered bool `yaml:"prune_covered"`
+
+	// Suppressions are temporary exceptions for problems at given paths.
+	Suppressions []suppression `yaml:"suppressions"`
+
+	// Policies are custom checks on every checked directory, for rules the
+	// built-in policy options don't cover.
+	Policies []customPolicy `yaml:"policies"`
+
+	// Hooks are external programs run for every checked directory, as
+	// command lines, that can report problems of their own.
+	Hooks stringList `yaml:"hooks"`
+
+	// NestedConfigs adds the specs of the configs found in directories
+	// throughout the tree, each relative to its own directory.
+	NestedConfigs bool `yaml:"nested_configs"`
+
+	// nested are the nested configs that were loaded.
+	nested []string
+
+	// origin is where the config came from.
+	origin configOrigin
+
+	// raw is the content of the config, the configs it extends, and the org
+	// policy, which together decide everything it says.
+	raw []byte
+}
+
+// defaultGlobalExcludes are well-known directories of tooling state and
+// third-party code that nobody is expected to own.
+var defaultGlobalExcludes = []string{".git", "node_modules", "vendor", ".terraform", "__pycache__"}
+
+// globalExcludes returns the exclude patterns that apply to every spec.
+func (c *config) globalExcludes() []string {
+	if c.DefaultExcludes != nil && !*c.DefaultExcludes {
+		return c.GlobalExcludes
+	}
+	return append(append([]string(nil), defaultGlobalExcludes...), c.GlobalExcludes...)
+}
+
+// policy holds checks that apply across all checked directories rather than
+// to a single spec.
+type policy struct {
+	// MaxDirsPerOwner limits how many checked directories one owner may be
+	// listed on. 0 means no limit.
+	MaxDirsPerOwner int `yaml:"max_dirs_per_owner"`
+
+	// AllowedOwners, if not empty, is the only owners CODEOWNERS may list.
+	// OwnersRegistry names a file of more allowed owners, merged in when the
+	// config is loaded.
+	AllowedOwners  []string `yaml:"allowed_owners"`
+	OwnersRegistry string   `yaml:"owners_registry"`
+
+	// ForbiddenOwners may not own any checked directory, such as teams that
+	// are being decommissioned.
+	ForbiddenOwners []string `yaml:"forbidden_owners"`
+
+	// TeamOrg is the organization every team owner must belong to.
+	// ForbidUserOwners reports bare @name owners, which are usually teams
+	// missing their org prefix.
+	TeamOrg          string `yaml:"team_org"`
+	ForbidUserOwners bool   `yaml:"forbid_user_owners"`
+
+	// ForbidEmailOwners reports every email owner. AllowedEmailDomains
+	// instead only allows email owners at these domains.
+	ForbidEmailOwners   bool     `yaml:"forbid_email_owners"`
+	AllowedEmailDomains []string `yaml:"allowed_email_domains"`
+
+	// RejectAliases reports aliases used as owners in CODEOWNERS, where
+	// GitHub doesn't know about them.
+	RejectAliases bool `yaml:"reject_aliases"`
+
+	// ReviewMaxDays, if set, is how many days may pass after a rule's
+	// reviewed: annotation before its owners must be confirmed again.
+	ReviewMaxDays int `yaml:"review_max_days"`
+
+	// RuleOrder is the order rules must be in within each CODEOWNERS file:
+	// specificity or alphabetical.
+	RuleOrder string `yaml:"rule_order"`
+
+	// ParentConflicts reports directories whose rule shares no owner with
+	// their parent's rule, except those matching AllowedOverrides, as paths
+	// or globs.
+	ParentConflicts  bool     `yaml:"parent_conflicts"`
+	AllowedOverrides []string `yaml:"allowed_overrides"`
+
+	// AnchoredPatterns reports bare-name patterns, which match at any depth,
+	// and directory patterns without a trailing slash.
+	AnchoredPatterns bool `yaml:"anchored_patterns"`
+
+	// ConsistentOwnerSpelling reports owners spelled differently from
+	// elsewhere in CODEOWNERS, such as @Org/Team for @org/team.
+	ConsistentOwnerSpelling bool `yaml:"consistent_owner_spelling"`
+
+	// Severity applies to every policy violation.
+	Severity string `yaml:"severity"`
+}
+
+// UnmarshalYAML decodes each directory entry on top of a copy of the defaults
+// block, so any setting a spec leaves out is inherited and any it sets wins.
+func (c *config) UnmarshalYAML(value *yaml.Node) error {
+	var raw struct {
+		Provider        string            `yaml:"provider"`
+		Codeowners      stringList        `yaml:"codeowners"`
+		Defaults        yaml.Node         `yaml:"defaults"`
+		Directories     []yaml.Node       `yaml:"directories"`
+		Policy          policy            `yaml:"policy"`
+		Catalog         catalog           `yaml:"catalog"`
+		Aliases         map[string]string `yaml:"aliases"`
+		AllowUnowned    []string          `yaml:"allow_unowned"`
+		GlobalExcludes  []string          `yaml:"global_excludes"`
+		DefaultExcludes *bool             `yaml:"default_excludes"`
+		Symlinks        string            `yaml:"symlinks"`
+		PruneCovered    bool              `yaml:"prune_covered"`
+		Suppressions    []yaml.Node       `yaml:"suppressions"`
+		Policies        []customPolicy    `yaml:"policies"`
+		Hooks           stringList        `yaml:"hooks"`
+		NestedConfigs   bool              `yaml:"nested_configs"`
+	}
+	if err := value.Decode(&raw); err != nil {
+		return err
+	}
+	var defaults dirSpec
+	if !raw.Defaults.IsZero() {
+		if err := expandLevelRange(&raw.Defaults); err != nil {
+			return err
+		}
+		if err := raw.Defaults.Decode(&defaults); err != nil {
+			return err
+		}
+	}
+	if defaults.Path != "" {
+		return fmt.Errorf("defaults cannot set a path")
+	}
+	if defaults.Name != "" {
+		return fmt.Errorf("defaults cannot set a name")
+	}
+
+	c.Provider = raw.Provider
+	c.Codeowners = raw.Codeowners
+	c.Defaults = defaults
+	c.Policy = raw.Policy
+	c.Catalog = raw.Catalog
+	c.Aliases = raw.Aliases
+	c.AllowUnowned = raw.AllowUnowned
+	c.GlobalExcludes = raw.GlobalExcludes
+	c.DefaultExcludes = raw.DefaultExcludes
+	c.Symlinks = raw.Symlinks
+	c.PruneCovered = raw.PruneCovered
+	c.Policies = raw.Policies
+	c.Hooks = raw.Hooks
+	c.NestedConfigs = raw.NestedConfigs
+	c.Suppressions = make([]suppression, 0, len(raw.Suppressions))
+	for i := range raw.Suppressions {
+		var s suppression
+		if err := raw.Suppressions[i].Decode(&s); err != nil {
+			return err
+		}
+		s.line = raw.Suppressions[i].Line
+		c.Suppressions = append(c.Suppressions, s)
+	}
+	c.Directories = make([]dirSpec, 0, len(raw.Directories))
+	for i := range raw.Directories {
+		node := &raw.Directories[i]
+		if err := expandLevelRange(node); err != nil {
+			return err
+		}
+		spec := defaults
+		spec.Excludes = append([]string(nil), defaults.Excludes...)
+		spec.Tags = append([]string(nil), defaults.Tags...)
+		if hasKey(node, "level") {
+			// A level set here replaces levels from the defaults.
+			spec.Levels = nil
+		}
+		if err := node.Decode(&spec); err != nil {
+			return err
+		}
+		spec.line = node.Line
+		c.Directories = append(c.Directories, spec)
+	}
+	return nil
+}
+
+// expandLevelRange rewrites a "level: min..max" range in a spec's mapping
+// node as the equivalent "levels:" list.
+func expandLevelRange(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	if hasKey(node, "level") && hasKey(node, "levels") {
+		return fmt.Errorf("line %d: a spec can't set both level and levels", node.Line)
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if key.Value != "level" || value.Kind != yaml.ScalarNode || !strings.Contains(value.Value, "..") {
+			continue
+		}
+		lo, hi, _ := strings.Cut(value.Value, "..")
+		from, err1 := strconv.Atoi(strings.TrimSpace(lo))
+		to, err2 := strconv.Atoi(strings.TrimSpace(hi))
+		if err1 != nil || err2 != nil || from > to {
+			return fmt.Errorf("line %d: invalid level range %q (want min..max)", value.Line, value.Value)
+		}
+		levels := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Line: value.Line, Column: value.Column}
+		for l := from; l <= to; l++ {
+			levels.Content = append(levels.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(l)})
+		}
+		key.Value = "levels"
+		node.Content[i+1] = levels
+	}
+	return nil
+}
+
+// hasKey reports whether the mapping node sets key.
+func hasKey(node *yaml.Node, key string) bool {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return true
+		}
+	}
+	return false
+}
+
+// stringList is a list of strings that can be given as a repeated flag, or in
+// YAML as either a single string or a sequence.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+func (l *stringList) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		*l = stringList{value.Value}
+		return nil
+	}
+	var list []string
+	if err := value.Decode(&list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+type dirSpec struct {
+	// Name identifies the spec in output and for --only and --skip.
+	Name string `yaml:"name"`
+
+	Path      string   `yaml:"path"`
+	Level     int      `yaml:"level"`
+	Excludes  []string `yaml:"excludes"`
+	Severity  string   `yaml:"severity"`
+	MinOwners int      `yaml:"min_owners"`
+
+	// MinApprovals is how many approvals a GitLab section owning each
+	// directory must require. 0 means no minimum.
+	MinApprovals int `yaml:"min_approvals"`
+
+	// ExclusiveRules warns about directories not matched by exactly one
+	// non-wildcard rule: where the rules' order decides who owns them, or
+	// only a wildcard does.
+	ExclusiveRules bool `yaml:"exclusive_rules"`
+
+	// MinFiles and MinLOC exempt directories with fewer files, or fewer
+	// lines across their files, from needing an owner. 0 means no minimum.
+	MinFiles int `yaml:"min_files"`
+	MinLOC   int `yaml:"min_loc"`
+
+	// Contains, if set, limits the check to directories holding a file that
+	// matches one of these patterns, at any depth.
+	Contains []string `yaml:"contains"`
+
+	// Mode is how the spec finds directories: modeLevels, or one of the
+	// discoveryModes. BuildTags, if set, even to an empty list, limits
+	// modeGoPackages to packages with files that build with these tags.
+	Mode      string   `yaml:"mode"`
+	BuildTags []string `yaml:"build_tags"`
+
+	// TerraformRoots limits modeTerraform to root modules, which configure
+	// a provider or backend, leaving out reusable child modules.
+	TerraformRoots bool `yaml:"terraform_roots"`
+
+	// Levels checks several depths below Path at once, in place of Level.
+	// In the config it's a list or a "level: min..max" range.
+	Levels []int `yaml:"levels"`
+
+	// Tags label the spec so --tags can select a subset of the config.
+	Tags []string `yaml:"tags"`
+
+	// DefaultOwner is the team reports attribute this spec's problems to,
+	// instead of guessing from CODEOWNERS.
+	DefaultOwner string `yaml:"default_owner"`
+
+	// Hidden says whether levels and globs expand into dot-directories like
+	// .github and .idea. They do unless it's false.
+	Hidden *bool `yaml:"hidden"`
+
+	// Submodules is what levels and globs do with git submodules: one of
+	// the submodule modes, submodulesUnit if unset.
+	Submodules string `yaml:"submodules"`
+
+	// line is where the spec starts in the config file, or 0 if unknown.
+	line int
+
+	// mandatory is set on the org policy's specs, which the config can't
+	// exempt anything from.
+	mandatory bool
+
+	// file is the nested config the spec came from, or "" if it's the
+	// config's own.
+	file string
+}
+
+// depths returns the levels below Path that the spec checks, shallowest
+// first. Specs in a discovery mode don't check by level and have none.
+func (d dirSpec) depths() []int {
+	if _, ok := discoveryModes[d.Mode]; ok {
+		return nil
+	}
+	if len(d.Levels) > 0 {
+		return d.Levels
+	}
+	return []int{d.Level}
+}
+
+// includesHidden reports whether the spec expands into dot-directories.
+func (d dirSpec) includesHidden() bool {
+	return d.Hidden == nil || *d.Hidden
+}
+
+const (
+	severityError   = "error"
+	severityWarning = "warning"
+)
+
+// defaultConfigPaths are the config files looked for when none is given, in
+// order of preference.
+var defaultConfigPaths = []string{
+	".requirecodeowners.yml",
+	".requirecodeowners.yaml",
+	".requirecodeowners.toml",
+	".requirecodeowners.json",
+}
+
+// resolveConfigPath returns path, or the first default config file that
+// exists when path is empty.
+func resolveConfigPath(path string) string {
+	if path != "" {
+		return path
+	}
+	for _, p := range defaultConfigPaths {
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+	}
+	return defaultConfigPaths[0]
+}
+
+// enterConfigRoot moves into the nearest directory at or above the working
+// directory that holds a default config file, so specs and CODEOWNERS
+// resolve relative to it. The search stops at the git root. It does nothing
+// when an explicit config path is given or no config is found.
+func enterConfigRoot(configPath string) error {
+	if configPath != "" {
+		return nil
+	}
+	root, ok, err := findConfigRoot()
+	if err != nil || !ok {
+		return err
+	}
+	return os.Chdir(root)
+}
+
+func findConfigRoot() (string, bool, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", false, err
+	}
+	for {
+		for _, name := range defaultConfigPaths {
+			if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+				return dir, true, nil
+			}
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return "", false, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false, nil
+		}
+		dir = parent
+	}
+}
+
+func loadConfig(path string) (*config, error) {
+	return loadConfigFrom(path, localConfig)
+}
+
+// loadConfigFrom is loadConfig for a config from origin.
+func loadConfigFrom(path string, origin configOrigin) (*config, error) {
+	path = resolveConfigPath(path)
+
+	data, err := readConfigFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config file %s: %w", path, err)
+	}
+	cfg, err := decodeConfig(data, path, os.ReadFile, origin)
+	if err != nil {
+		return nil, err
+	}
+	if err := loadOwnersRegistry(cfg, os.ReadFile); err != nil {
+		return nil, err
+	}
+	if cfg.NestedConfigs {
+		if err := loadNestedConfigs(cfg); err != nil {
+			return nil, err
+		}
+	}
+	return cfg, nil
+}
+
+// readConfigFile reads the config at path, or from stdin if path is
+// stdinSource.
+func readConfigFile(path string) ([]byte, error) {
+	if path == stdinSource {
+		return readStdinConfig()
+	}
+	return os.ReadFile(path)
+}
+
+// confine checks that the files a remote config names are inside the
+// repository, so it can't have the server read others, like /etc/passwd,
+// or fetch URLs and other repositories for it.
+func (c *config) confine() error {
+	for _, source := range c.Codeowners {
+		if isURL(source) || isGitHubSource(source) {
+			return fmt.Errorf("codeowners %s isn't in the repository; a remote config can only name its own files", source)
+		}
+		if !inRepository(source) {
+			return fmt.Errorf("codeowners %s is outside the repository", source)
+		}
+	}
+	for _, d := range c.Directories {
+		if !inRepository(d.Path) {
+			return fmt.Errorf("directory %s is outside the repository", d.Path)
+		}
+	}
+	for _, pattern := range c.Catalog.Files {
+		if !inRepository(pattern) {
+			return fmt.Errorf("catalog file %s is outside the repository", pattern)
+		}
+	}
+	if path := c.Policy.OwnersRegistry; path != "" && !inRepository(path) {
+		return fmt.Errorf("owners registry %s is outside the repository", path)
+	}
+	return nil
+}
+
+// inRepository reports whether the relative path p stays inside the
+// directory it's relative to.
+func inRepository(p string) bool {
+	return filepath.IsLocal(filepath.FromSlash(p))
+}
+
+// loadOwnersRegistry merges the owners listed in the policy's registry file
+// into its allowed owners. read fetches the file, so remote configs can read
+// it from the same place they came from. The registry is YAML with an
+// owners: list, in the same notation as CODEOWNERS.
+func loadOwnersRegistry(cfg *config, read func(path string) ([]byte, error)) error {
+	path := cfg.Policy.OwnersRegistry
+	if path == "" {
+		return nil
+	}
+	data, err := read(path)
+	if err != nil {
+		return fmt.Errorf("reading owners registry %s: %w", path, err)
+	}
+	var registry struct {
+		Owners []string `yaml:"owners"`
+	}
+	if err := yaml.Unmarshal(data, &registry); err != nil {
+		return fmt.Errorf("parsing owners registry %s: %w", path, err)
+	}
+	if len(registry.Owners) == 0 {
+		return fmt.Errorf("owners registry %s lists no owners", path)
+	}
+	for _, o := range registry.Owners {
+		cfg.Policy.AllowedOwners = append(cfg.Policy.AllowedOwners, cfg.resolveOwner(o))
+	}
+	return nil
+}
+
+// resolveAliases checks the aliases and replaces aliased owners in the config
+// with the owners they stand for. Aliases are matched case-insensitively.
+func (c *config) resolveAliases() error {
+	aliases := make(map[string]string, len(c.Aliases))
+	for alias, owner := range c.Aliases {
+		if owner == "" {
+			return fmt.Errorf("alias %s has no owner", alias)
+		}
+		aliases[strings.ToLower(alias)] = owner
+	}
+	for alias, owner := range aliases {
+		if _, ok := aliases[strings.ToLower(owner)]; ok {
+			return fmt.Errorf("alias %s refers to another alias, %s", alias, owner)
+		}
+	}
+	c.Aliases = aliases
+
+	for i, o := range c.Policy.AllowedOwners {
+		c.Policy.AllowedOwners[i] = c.resolveOwner(o)
+	}
+	for i, o := range c.Policy.ForbiddenOwners {
+		c.Policy.ForbiddenOwners[i] = c.resolveOwner(o)
+	}
+	for ref, o := range c.Catalog.Owners {
+		c.Catalog.Owners[ref] = c.resolveOwner(o)
+	}
+	c.Defaults.DefaultOwner = c.resolveOwner(c.Defaults.DefaultOwner)
+	for i := range c.Directories {
+		c.Directories[i].DefaultOwner = c.resolveOwner(c.Directories[i].DefaultOwner)
+	}
+	return nil
+}
+
+// resolveOwner returns the owner an alias stands for, or owner itself if it
+// isn't an alias.
+func (c *config) resolveOwner(owner string) string {
+	if resolved, ok := c.Aliases[strings.ToLower(owner)]; ok {
+		return resolved
+	}
+	return owner
+}
+
+// laxConfig, set by --lax-config, makes parseConfig ignore keys the config
+// doesn't have instead of failing on them.
+var laxConfig bool
+
+// configFlags are the flags for how commands that load the config read it.
+type configFlags struct {
+	lax    bool
+	policy string
+}
+
+func (f *configFlags) register(fs *flag.FlagSet) {
+	fs.BoolVar(&f.lax, "lax-config", false, "ignore unknown keys in the config instead of failing on them")
+	fs.StringVar(&f.policy, "policy", "", "org policy file or URL whose specs, forbidden owners, and minimum coverage the config can't weaken")
+}
+
+// apply sets how the config is read, and loads the org policy.
+func (f *configFlags) apply() error {
+	laxConfig = f.lax
+	activePolicy = nil
+	source, err := policySource(f.policy)
+	if err != nil || source == "" {
+		return err
+	}
+	activePolicy, err = loadOrgPolicy(source)
+	return err
+}
+
+// configOrigin is where a config came from, which decides what it's trusted
+// with.
+type configOrigin int
+
+const (
+	// localConfig is read from the working tree, by whoever runs the tool.
+	localConfig configOrigin = iota
+	// remoteConfig is fetched from another repository, or sent to the
+	// server. Its author may not be whoever runs the tool, so it doesn't
+	// get to read their environment or files outside the repository, or
+	// to run hooks.
+	remoteConfig
+)
+
+// parseConfig decodes and validates config content. name is the file the
+// content came from; its extension selects the format. Unless laxConfig is
+// set, a key the config doesn't have is an error, since a misspelled one
+// like exlude: would otherwise quietly do nothing.
+func parseConfig(data []byte, name string) (*config, error) {
+	return decodeConfig(data, name, os.ReadFile, localConfig)
+}
+
+// decodeConfig is parseConfig with read reading the local configs the
+// config extends, for a config from origin.
+func decodeConfig(data []byte, name string, read func(path string) ([]byte, error), origin configOrigin) (*config, error) {
+	cfg, _, err := decodeConfigDocument(data, name, read, origin)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyOrgPolicy(cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// decodeConfigDocument is decodeConfig without the org policy. It also
+// returns the root mapping the config was decoded from, with what it extends
+// merged in, or nil if it's empty.
+func decodeConfigDocument(data []byte, name string, read func(path string) ([]byte, error), origin configOrigin) (*config, *yaml.Node, error) {
+	if !isURL(name) && !isGitHubSource(name) {
+		name = filepath.Clean(name)
+	}
+	r := &configReader{local: read, origin: origin}
+	doc, err := r.document(data, name, []string{name})
+	if err != nil {
+		return nil, nil, fmt.Errorf("parsing config file: %w", err)
+	}
+
+	var cfg config
+	if doc != nil {
+		if err := doc.Decode(&cfg); err != nil {
+			return nil, nil, fmt.Errorf("parsing config file: %w", err)
+		}
+	}
+	if !isYAML(name) {
+		// Line numbers refer to the converted YAML, not the original file
+		for i := range cfg.Directories {
+			cfg.Directories[i].line = 0
+		}
+	}
+
+	cfg.origin = origin
+	if err := cfg.validate(name); err != nil {
+		return nil, nil, err
+	}
+	cfg.raw = append(slices.Clone(data), r.loaded...)
+	return &cfg, doc, nil
+}
+
+// validate checks the decoded config from name and fills in what it leaves
+// to be worked out, like the default provider.
+func (c *config) validate(name string) error {
+	if c.origin == remoteConfig {
+		if err := c.confine(); err != nil {
+			return err
+		}
+	}
+	names := make(map[string]int)
+	for i, d := range c.Directories {
+		if d.Path == "" {
+			return fmt.Errorf("directory at index %d has no path", i)
+		}
+		if d.Name != "" {
+			if j, ok := names[d.Name]; ok {
+				return fmt.Errorf("directories %d and %d are both named %q", j, i, d.Name)
+			}
+			names[d.Name] = i
+		}
+		if d.Level < 0 {
+			return fmt.Errorf("directory %s has invalid level %d (must be >= 0)", d.Path, d.Level)
+		}
+		for _, l := range d.Levels {
+			if l < 0 {
+				return fmt.Errorf("directory %s has invalid level %d (must be >= 0)", d.Path, l)
+			}
+		}
+		if len(d.Levels) > 0 {
+			levels := append([]int(nil), d.Levels...)
+			slices.Sort(levels)
+			c.Directories[i].Levels = slices.Compact(levels)
+		}
+		if !validSeverity(d.Severity) {
+			return fmt.Errorf("directory %s has invalid severity %q (must be %q or %q)", d.Path, d.Severity, severityError, severityWarning)
+		}
+		if d.MinOwners < 0 {
+			return fmt.Errorf("directory %s has invalid min_owners %d (must be >= 0)", d.Path, d.MinOwners)
+		}
+		if d.MinApprovals < 0 {
+			return fmt.Errorf("directory %s has invalid min_approvals %d (must be >= 0)", d.Path, d.MinApprovals)
+		}
+		if d.MinApprovals > 0 && c.Provider != providerGitLab {
+			return fmt.Errorf("directory %s sets min_approvals, which needs provider: %s", d.Path, providerGitLab)
+		}
+		if _, ok := discoveryModes[d.Mode]; ok {
+			if d.Level != 0 || len(d.Levels) > 0 {
+				return fmt.Errorf("directory %s sets a level, which mode %s doesn't use", d.Path, d.Mode)
+			}
+		} else if d.Mode != "" && d.Mode != modeLevels {
+			return fmt.Errorf("directory %s has invalid mode %q (must be one of %s)", d.Path, d.Mode, strings.Join(modeNames(), ", "))
+		}
+		if d.TerraformRoots && d.Mode != modeTerraform {
+			return fmt.Errorf("directory %s sets terraform_roots, which only applies to mode %s", d.Path, modeTerraform)
+		}
+		switch d.Submodules {
+		case "", submodulesUnit, submodulesDescend, submodulesSkip:
+		default:
+			return fmt.Errorf("directory %s has invalid submodules %q (must be %q, %q, or %q)", d.Path, d.Submodules, submodulesUnit, submodulesDescend, submodulesSkip)
+		}
+		if d.MinFiles < 0 {
+			return fmt.Errorf("directory %s has invalid min_files %d (must be >= 0)", d.Path, d.MinFiles)
+		}
+		if d.MinLOC < 0 {
+			return fmt.Errorf("directory %s has invalid min_loc %d (must be >= 0)", d.Path, d.MinLOC)
+		}
+		for _, pattern := range d.Excludes {
+			if _, err := filepath.Match(pattern, ""); err != nil {
+				return fmt.Errorf("directory %s has invalid exclude %q: %w", d.Path, pattern, err)
+			}
+		}
+		for _, pattern := range d.Contains {
+			if _, err := filepath.Match(pattern, ""); err != nil {
+				return fmt.Errorf("directory %s has invalid contains %q: %w", d.Path, pattern, err)
+			}
+		}
+	}
+	for _, pattern := range c.AllowUnowned {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("allow_unowned has invalid pattern %q: %w", pattern, err)
+		}
+	}
+	for _, pattern := range c.Policy.AllowedOverrides {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("policy has invalid allowed_overrides pattern %q: %w", pattern, err)
+		}
+	}
+	for _, pattern := range c.GlobalExcludes {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("global_excludes has invalid pattern %q: %w", pattern, err)
+		}
+	}
+	for i := range c.Suppressions {
+		s := &c.Suppressions[i]
+		s.file = filepath.ToSlash(name)
+		if s.Path == "" {
+			return fmt.Errorf("suppression at line %d needs a path", s.line)
+		}
+		if _, err := filepath.Match(s.Path, ""); err != nil {
+			return fmt.Errorf("suppression at line %d has invalid path %q: %w", s.line, s.Path, err)
+		}
+		if s.Reason == "" {
+			return fmt.Errorf("suppression at line %d for %s needs a reason", s.line, s.Path)
+		}
+		if err := s.validate(); err != nil {
+			return fmt.Errorf("suppression at line %d for %s: %w", s.line, s.Path, err)
+		}
+	}
+	switch c.Provider {
+	case "":
+		c.Provider = providerGitHub
+	case providerGitHub, providerGitLab:
+	default:
+		return fmt.Errorf("invalid provider %q (must be %q or %q)", c.Provider, providerGitHub, providerGitLab)
+	}
+	switch c.Symlinks {
+	case "", symlinksSkip, symlinksFollow, symlinksFail:
+	default:
+		return fmt.Errorf("invalid symlinks %q (must be %q, %q, or %q)", c.Symlinks, symlinksSkip, symlinksFollow, symlinksFail)
+	}
+	if c.Policy.MaxDirsPerOwner < 0 {
+		return fmt.Errorf("policy has invalid max_dirs_per_owner %d (must be >= 0)", c.Policy.MaxDirsPerOwner)
+	}
+	if c.Policy.ReviewMaxDays < 0 {
+		return fmt.Errorf("policy has invalid review_max_days %d (must be >= 0)", c.Policy.ReviewMaxDays)
+	}
+	switch c.Policy.RuleOrder {
+	case "", ruleOrderSpecificity, ruleOrderAlphabetical:
+	default:
+		return fmt.Errorf("policy has invalid rule_order %q (must be %q or %q)", c.Policy.RuleOrder, ruleOrderSpecificity, ruleOrderAlphabetical)
+	}
+	for i, hook := range c.Hooks {
+		if strings.TrimSpace(hook) == "" {
+			return fmt.Errorf("hook at index %d is empty", i)
+		}
+	}
+	policyNames := make(map[string]int)
+	for i := range c.Policies {
+		p := &c.Policies[i]
+		if err := p.compile(); err != nil {
+			if p.Name != "" {
+				return fmt.Errorf("policy %s %w", p.Name, err)
+			}
+			return fmt.Errorf("policy at index %d %w", i, err)
+		}
+		if j, ok := policyNames[p.Name]; ok {
+			return fmt.Errorf("policies %d and %d are both named %q", j, i, p.Name)
+		}
+		policyNames[p.Name] = i
+	}
+	if !validSeverity(c.Policy.Severity) {
+		return fmt.Errorf("policy has invalid severity %q (must be %q or %q)", c.Policy.Severity, severityError, severityWarning)
+	}
+	c.Policy.TeamOrg = strings.TrimPrefix(c.Policy.TeamOrg, "@")
+	if c.Policy.ForbidEmailOwners && len(c.Policy.AllowedEmailDomains) > 0 {
+		return fmt.Errorf("policy can't set both forbid_email_owners and allowed_email_domains")
+	}
+	if !validSeverity(c.Catalog.Severity) {
+		return fmt.Errorf("catalog has invalid severity %q (must be %q or %q)", c.Catalog.Severity, severityError, severityWarning)
+	}
+	for _, pattern := range c.Catalog.Files {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("catalog has invalid files pattern %q: %w", pattern, err)
+		}
+	}
+	return c.resolveAliases()
+}
+
+func validSeverity(s string) bool {
+	return s == "" || s == severityError || s == severityWarning
+}
+
+// toYAML converts TOML and JSON config content to YAML, selected by the file
+// extension, so every format goes through the same decoding and defaults
+// handling. Anything else is assumed to be YAML already.
+func toYAML(data []byte, ext string) ([]byte, error) {
+	var doc map[string]any
+	switch strings.ToLower(ext) {
+	case ".toml":
+		if err := toml.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+	case ".json":
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+	default:
+		return data, nil
+	}
+	return yaml.Marshal(doc)
+}
+
+// checkUnknownKeys reports the keys in the config document doc that the
+// config doesn't have, with their lines if lines is set. Anything else wrong
+// with doc is left for decoding to report.
+func checkUnknownKeys(doc *yaml.Node, lines bool) error {
+	var unknown []string
+	for _, p := range checkConfigNode(doc) {
+		if !p.unknown {
+			continue
+		}
+		if lines && p.line > 0 {
+			unknown = append(unknown, fmt.Sprintf("line %d: %s", p.line, p))
+		} else {
+			unknown = append(unknown, p.String())
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s (pass --lax-config to ignore unknown keys)", strings.Join(unknown, "; "))
+}
+
+func isYAML(name string) bool {
+	switch strings.ToLower(sourceExt(name)) {
+	case ".toml", ".json":
+		return false
+	}
+	return true
+}
+
+// expandConfigPaths expands the variables in the paths the config mapping
+// root sets, its CODEOWNERS sources, spec paths, and owners registry, in
+// place.
+func expandConfigPaths(root *yaml.Node) error {
+	expand := func(n *yaml.Node, what string) error {
+		if n.Kind != yaml.ScalarNode || n.Tag == "!!null" {
+			return nil
+		}
+		expanded, err := expandVars(n.Value)
+		if err != nil {
+			return fmt.Errorf("%s %s: %w", what, n.Value, err)
+		}
+		n.Value = expanded
+		return nil
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		value := root.Content[i+1]
+		switch root.Content[i].Value {
+		case "codeowners":
+			for _, n := range append([]*yaml.Node{value}, value.Content...) {
+				if err := expand(n, "codeowners"); err != nil {
+					return err
+				}
+			}
+		case "directories":
+			for _, d := range value.Content {
+				if j := mappingIndex(d, "path"); d.Kind == yaml.MappingNode && j >= 0 {
+					if err := expand(d.Content[j+1], "directory"); err != nil {
+						return err
+					}
+				}
+			}
+		case "policy":
+			if j := mappingIndex(value, "owners_registry"); value.Kind == yaml.MappingNode && j >= 0 {
+				if err := expand(value.Content[j+1], "owners_registry"); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+var envVarPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expandVars resolves ${VAR} references and a leading ~ in a config path. A
+// reference to an unset variable is an error rather than an empty string, so
+// a missing variable can't silently turn "${ROOT}/services" into "/services".
+func expandVars(path string) (string, error) {
+	var missing []string
+	path = envVarPattern.ReplaceAllStringFunc(path, func(ref string) string {
+		name := envVarPattern.FindStringSubmatch(ref)[1]
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			missing = append(missing, name)
+		}
+		return value
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("environment %s %s not set", pluralize(len(missing), "variable", "variables"), strings.Join(missing, ", "))
+	}
+
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("expanding ~: %w", err)
+		}
+		path = filepath.Join(home, path[1:])
+	}
+	return path, nil
+}
@@ -0,0 +1,66 @@
+package check
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// checkParentConflicts reports checked directories whose rule shares no
+// owner with the rule for their nearest owned ancestor, which often means a
+// rule overrides its parent by accident. Each pair of rules is reported
+// once, at the first directory where it shows up, and directories matching
+// allowed_overrides are left alone.
+func (c *checker) checkParentConflicts(checked []checkedDir) []validationError {
+	type pair struct{ rule, parent *rule }
+	var errors []validationError
+	seen := make(map[pair]bool)
+	for _, d := range checked {
+		if d.rule == nil || matchesPathPatterns(d.path, c.policy.AllowedOverrides) {
+			continue
+		}
+		parentDir, parent := c.parentRule(d.path, d.rule)
+		if parent == nil || seen[pair{d.rule, parent}] {
+			continue
+		}
+		seen[pair{d.rule, parent}] = true
+		if sharesOwner(d.rule, parent) {
+			continue
+		}
+		errors = append(errors, validationError{
+			path: d.path,
+			message: fmt.Sprintf("Owned by %s, who share no owner with %s, the owners of %s in %s. Add it to allowed_overrides if that's intended, or add the parent's owners in %s: %s",
+				d.rule.ownerNames(), parent.ownerNames(), parentDir, parent.location(), d.rule.location(), d.rule.RawPattern()),
+			code:     codeParentConflict,
+			severity: c.policy.Severity,
+			team:     d.rule.ownerNames(),
+			file:     d.rule.file,
+			line:     d.rule.LineNumber,
+		})
+	}
+	return errors
+}
+
+// parentRule returns dir's nearest ancestor that a rule other than r
+// matches, and that rule, or nil if there isn't one.
+func (c *checker) parentRule(dir string, r *rule) (string, *rule) {
+	for parent := path.Dir(dir); parent != "." && parent != "/"; parent = path.Dir(parent) {
+		if match := matchDirectory(c.matcher(), parent); match != nil && match != r {
+			return parent, match
+		}
+	}
+	return "", nil
+}
+
+// sharesOwner reports whether a and b have an owner in common, compared
+// case-insensitively.
+func sharesOwner(a, b *rule) bool {
+	for _, x := range a.Owners {
+		for _, y := range b.Owners {
+			if strings.EqualFold(x.String(), y.String()) {
+				return true
+			}
+		}
+	}
+	return false
+}
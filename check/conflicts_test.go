@@ -0,0 +1,42 @@
+package check
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckParentConflicts(t *testing.T) {
+	rules, err := parseCodeowners(strings.NewReader(`/services/ @org/platform
+/services/api @org/api @Org/Platform
+/services/web @org/web
+/services/web/legacy @org/legacy
+/services/vendor @org/security
+`), "CODEOWNERS", providerGitHub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checked := []checkedDir{
+		{path: "services/api", rule: &rules[1]},
+		{path: "services/web", rule: &rules[2]},
+		{path: "services/web/assets", rule: &rules[2]},
+		{path: "services/web/legacy", rule: &rules[3]},
+		{path: "services/vendor", rule: &rules[4]},
+		{path: "services/new", rule: &rules[0]},
+		{path: "libs"},
+	}
+
+	c := &checker{fsys: newTreeFS(nil), rules: rules, policy: policy{ParentConflicts: true, AllowedOverrides: []string{"services/vendor"}}}
+	var got []string
+	for _, e := range c.checkPolicy(checked) {
+		got = append(got, e.path)
+		if e.code != codeParentConflict {
+			t.Errorf("code = %s, want %s", e.code, codeParentConflict)
+		}
+	}
+	if want := "services/web services/web/legacy"; strings.Join(got, " ") != want {
+		t.Errorf("checkPolicy() = %v, want %s", got, want)
+	}
+	if errs := c.checkParentConflicts(checked[3:4]); len(errs) != 1 || !strings.Contains(errs[0].message, "@org/web, the owners of services/web in CODEOWNERS:3") {
+		t.Errorf("checkParentConflicts() = %v, want the parent's owners and rule", errs)
+	}
+}
@@ -0,0 +1,172 @@
+package check
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// mark is the kind of status a line of console output reports.
+type mark int
+
+const (
+	markFail mark = iota
+	markWarn
+	markOK
+)
+
+var markGlyphs = map[mark]struct{ emoji, plain, color string }{
+	markFail: {"✗", "FAIL", "\x1b[31m"},
+	markWarn: {"⚠", "WARN", "\x1b[33m"},
+	markOK:   {"✓", "OK", "\x1b[32m"},
+}
+
+const ansiReset = "\x1b[0m"
+
+// consoleStyle controls how status lines look in a terminal.
+type consoleStyle struct {
+	color      string // "auto", "always", or "never"
+	emoji      bool
+	hyperlinks string // "auto", "always", or "never"
+}
+
+// console is the style for all human-readable output, set from the command
+// line flags.
+var console = consoleStyle{color: "never", emoji: true}
+
+// mark prefixes text with a status glyph, colored if w is a terminal that
+// should get color.
+func (s consoleStyle) mark(w io.Writer, m mark, text string) string {
+	g := markGlyphs[m]
+	glyph := g.emoji
+	if !s.emoji {
+		glyph = g.plain
+	}
+	if s.useColor(w) {
+		return g.color + glyph + ansiReset + " " + text
+	}
+	return glyph + " " + text
+}
+
+// useColor reports whether escape codes should be written to w. In auto
+// mode that's when w is a terminal and NO_COLOR isn't set.
+func (s consoleStyle) useColor(w io.Writer) bool {
+	switch s.color {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	return isTerminal(w)
+}
+
+// link makes text a hyperlink to target, with an OSC 8 escape, if w is a
+// terminal that should get them. An empty target leaves text as it is.
+func (s consoleStyle) link(w io.Writer, target, text string) string {
+	if target == "" || !s.useHyperlinks(w) {
+		return text
+	}
+	return "\x1b]8;;" + target + "\x1b\\" + text + "\x1b]8;;\x1b\\"
+}
+
+// useHyperlinks reports whether hyperlinks should be written to w. In auto
+// mode that's when w is a terminal known to show them, since others can
+// print the escapes as garbage.
+func (s consoleStyle) useHyperlinks(w io.Writer) bool {
+	switch s.hyperlinks {
+	case "always":
+		return true
+	case "auto":
+		return isTerminal(w) && terminalSupportsHyperlinks()
+	}
+	return false
+}
+
+// terminalSupportsHyperlinks guesses from the environment whether the
+// terminal shows OSC 8 hyperlinks. FORCE_HYPERLINK overrides the guess.
+func terminalSupportsHyperlinks() bool {
+	if force, ok := os.LookupEnv("FORCE_HYPERLINK"); ok {
+		return force != "0"
+	}
+	if os.Getenv("CI") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	switch os.Getenv("TERM_PROGRAM") {
+	case "iTerm.app", "WezTerm", "vscode", "ghostty", "Hyper":
+		return true
+	}
+	for _, v := range []string{"WT_SESSION", "KITTY_WINDOW_ID", "KONSOLE_VERSION", "DOMTERM"} {
+		if os.Getenv(v) != "" {
+			return true
+		}
+	}
+	if vte, err := strconv.Atoi(os.Getenv("VTE_VERSION")); err == nil && vte >= 5000 {
+		return true
+	}
+	term := os.Getenv("TERM")
+	return strings.Contains(term, "kitty") || strings.Contains(term, "alacritty") || strings.HasPrefix(term, "foot")
+}
+
+// fileURL returns the file:// URL of path, or "" if it doesn't exist.
+func fileURL(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return ""
+	}
+	if _, err := os.Stat(abs); err != nil {
+		return ""
+	}
+	abs = filepath.ToSlash(abs)
+	if !strings.HasPrefix(abs, "/") {
+		abs = "/" + abs // a Windows drive, like C:/src
+	}
+	u := url.URL{Scheme: "file", Path: abs}
+	return u.String()
+}
+
+// isTerminal reports whether w is a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// consoleFlags are the output style flags shared by every command.
+type consoleFlags struct {
+	color      string
+	noEmoji    bool
+	hyperlinks string
+}
+
+func (f *consoleFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.color, "color", "auto", "color console output: auto, always, or never")
+	fs.BoolVar(&f.noEmoji, "no-emoji", false, "use plain text instead of ✓/✗/⚠ in console output")
+	fs.StringVar(&f.hyperlinks, "hyperlinks", "auto", "make paths in console output clickable links: auto, always, or never")
+}
+
+// apply validates the flags and sets the console style from them.
+func (f *consoleFlags) apply() error {
+	switch f.color {
+	case "auto", "always", "never":
+	default:
+		return fmt.Errorf("invalid --color %q (want auto, always, or never)", f.color)
+	}
+	switch f.hyperlinks {
+	case "", "auto", "always", "never":
+	default:
+		return fmt.Errorf("invalid --hyperlinks %q (want auto, always, or never)", f.hyperlinks)
+	}
+	console = consoleStyle{color: f.color, emoji: !f.noEmoji, hyperlinks: f.hyperlinks}
+	return nil
+}
@@ -0,0 +1,163 @@
+package check
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConsoleMark(t *testing.T) {
+	tests := []struct {
+		name  string
+		style consoleStyle
+		mark  mark
+		want  string
+	}{
+		{name: "emoji", style: consoleStyle{color: "never", emoji: true}, mark: markFail, want: "✗ services/foo"},
+		{name: "no emoji", style: consoleStyle{color: "never"}, mark: markWarn, want: "WARN services/foo"},
+		{name: "color", style: consoleStyle{color: "always", emoji: true}, mark: markOK, want: "\x1b[32m✓\x1b[0m services/foo"},
+		{name: "auto to a buffer", style: consoleStyle{color: "auto", emoji: true}, mark: markFail, want: "✗ services/foo"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.style.mark(&bytes.Buffer{}, tt.mark, "services/foo"); got != tt.want {
+				t.Errorf("mark() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConsoleUseColor(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	auto := consoleStyle{color: "auto"}
+	if auto.useColor(f) {
+		t.Error("useColor(auto) = true for a regular file, want false")
+	}
+
+	t.Setenv("NO_COLOR", "1")
+	if auto.useColor(os.Stderr) {
+		t.Error("useColor(auto) = true with NO_COLOR set, want false")
+	}
+	if !(consoleStyle{color: "always"}).useColor(f) {
+		t.Error("useColor(always) = false, want true even with NO_COLOR set")
+	}
+}
+
+func TestConsoleFlagsApply(t *testing.T) {
+	defer func() { console = consoleStyle{color: "never", emoji: true} }()
+
+	if err := (&consoleFlags{color: "sometimes"}).apply(); err == nil {
+		t.Error("apply() with an invalid --color succeeded, want error")
+	}
+	if err := (&consoleFlags{color: "auto", hyperlinks: "maybe"}).apply(); err == nil {
+		t.Error("apply() with an invalid --hyperlinks succeeded, want error")
+	}
+	if err := (&consoleFlags{color: "always", noEmoji: true}).apply(); err != nil {
+		t.Fatalf("apply() error = %v", err)
+	}
+	if console.color != "always" || console.emoji {
+		t.Errorf("console = %+v, want color always without emoji", console)
+	}
+}
+
+func TestConsoleLink(t *testing.T) {
+	tests := []struct {
+		name   string
+		style  consoleStyle
+		target string
+		want   string
+	}{
+		{name: "always", style: consoleStyle{hyperlinks: "always"}, target: "file:///src/services", want: "\x1b]8;;file:///src/services\x1b\\services\x1b]8;;\x1b\\"},
+		{name: "never", style: consoleStyle{hyperlinks: "never"}, target: "file:///src/services", want: "services"},
+		{name: "auto to a buffer", style: consoleStyle{hyperlinks: "auto"}, target: "file:///src/services", want: "services"},
+		{name: "no target", style: consoleStyle{hyperlinks: "always"}, want: "services"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.style.link(&bytes.Buffer{}, tt.target, "services"); got != tt.want {
+				t.Errorf("link() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTerminalSupportsHyperlinks(t *testing.T) {
+	for _, v := range []string{"FORCE_HYPERLINK", "CI", "TERM", "TERM_PROGRAM", "WT_SESSION", "KITTY_WINDOW_ID", "KONSOLE_VERSION", "DOMTERM", "VTE_VERSION"} {
+		t.Setenv(v, "")
+		os.Unsetenv(v)
+	}
+	tests := []struct {
+		name string
+		env  map[string]string
+		want bool
+	}{
+		{name: "unknown", env: map[string]string{"TERM": "xterm-256color"}, want: false},
+		{name: "iTerm", env: map[string]string{"TERM_PROGRAM": "iTerm.app"}, want: true},
+		{name: "recent VTE", env: map[string]string{"VTE_VERSION": "7006"}, want: true},
+		{name: "old VTE", env: map[string]string{"VTE_VERSION": "4601"}, want: false},
+		{name: "kitty", env: map[string]string{"TERM": "xterm-kitty"}, want: true},
+		{name: "CI", env: map[string]string{"CI": "true", "TERM_PROGRAM": "vscode"}, want: false},
+		{name: "forced on", env: map[string]string{"FORCE_HYPERLINK": "1", "TERM": "dumb"}, want: true},
+		{name: "forced off", env: map[string]string{"FORCE_HYPERLINK": "0", "WT_SESSION": "1"}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			if got := terminalSupportsHyperlinks(); got != tt.want {
+				t.Errorf("terminalSupportsHyperlinks() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFileURL(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	t.Cleanup(func() { os.Chdir(oldWd) })
+	os.MkdirAll(filepath.Join("services", "my api"), 0755)
+
+	got := fileURL("services/my api")
+	if !strings.HasPrefix(got, "file:///") || !strings.HasSuffix(got, "/services/my%20api") {
+		t.Errorf("fileURL() = %q, want a file:// URL ending in /services/my%%20api", got)
+	}
+	if got := fileURL("missing"); got != "" {
+		t.Errorf("fileURL(missing) = %q, want empty", got)
+	}
+}
+
+func TestPrintTextHyperlinks(t *testing.T) {
+	defer func() { console = consoleStyle{color: "never", emoji: true} }()
+	console = consoleStyle{color: "never", emoji: true, hyperlinks: "always"}
+
+	tmpDir := t.TempDir()
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	t.Cleanup(func() { os.Chdir(oldWd) })
+	os.MkdirAll("services/api", 0755)
+
+	var buf bytes.Buffer
+	printText(&buf, []validationError{
+		{path: "services/api", spec: "services", message: "Not covered by CODEOWNERS."},
+		{path: "libs", message: "Directory not found."},
+	}, 0)
+	out := buf.String()
+	if want := "\x1b\\services/api\x1b]8;;\x1b\\ (services)"; !strings.Contains(out, want) {
+		t.Errorf("printText() = %q, want services/api linked with its spec after", out)
+	}
+	if !strings.Contains(out, "✗ libs\n") {
+		t.Errorf("printText() = %q, want libs, which doesn't exist, unlinked", out)
+	}
+}
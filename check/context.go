@@ -0,0 +1,87 @@
+package check
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// runContext returns the context a check runs under: canceled by SIGINT or
+// SIGTERM, and after timeout if it's positive. Once it's done, a second
+// signal kills the process as usual.
+func runContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	cancel := stop
+	if timeout > 0 {
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeout(ctx, timeout)
+		cancel = func() { cancelTimeout(); stop() }
+	}
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	return ctx, cancel
+}
+
+// stoppedError reports that the check ended early because ctx is done, so
+// the report is partial. It's a failure: what wasn't reached wasn't checked.
+func stoppedError(ctx context.Context) validationError {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return validationError{
+			path:    "--timeout",
+			message: "Timed out before the check finished. Directories it hadn't reached aren't in this report.",
+			code:    codeStopped,
+		}
+	}
+	return validationError{
+		path:    "interrupt",
+		message: "Interrupted before the check finished. Directories it hadn't reached aren't in this report.",
+		code:    codeStopped,
+	}
+}
+
+// contextFS fails every read once ctx is done, so a walk of a large tree
+// stops promptly instead of running to the end.
+type contextFS struct {
+	fileSystem
+	ctx context.Context
+}
+
+func (c contextFS) Stat(name string) (fs.FileInfo, error) {
+	if err := c.ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.fileSystem.Stat(name)
+}
+
+func (c contextFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if err := c.ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.fileSystem.ReadDir(name)
+}
+
+func (c contextFS) Glob(pattern string) ([]string, error) {
+	if err := c.ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.fileSystem.Glob(pattern)
+}
+
+func (c contextFS) ReadFile(name string) ([]byte, error) {
+	if err := c.ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.fileSystem.ReadFile(name)
+}
+
+// stopped reports whether the check should end early. A checker without a
+// context runs to the end.
+func (c *checker) stopped() bool {
+	return c.ctx != nil && c.ctx.Err() != nil
+}
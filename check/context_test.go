@@ -0,0 +1,107 @@
+package check
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// cancelingFS cancels the check after a number of directory reads, as if
+// it were interrupted partway through the walk.
+type cancelingFS struct {
+	fileSystem
+	reads  int
+	cancel context.CancelFunc
+}
+
+func (c *cancelingFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if c.reads--; c.reads < 0 {
+		c.cancel()
+	}
+	return c.fileSystem.ReadDir(name)
+}
+
+func TestValidateStopped(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, d := range []string{"services/foo", "services/bar", "libs/util"} {
+		os.MkdirAll(filepath.Join(tmpDir, d), 0755)
+	}
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	rules, _ := parseCodeowners(strings.NewReader("/services/ @org/services\n"), "CODEOWNERS", providerGitHub)
+	specs := []dirSpec{{Path: "services", Level: 1}, {Path: "libs", Level: 1}}
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, cancelExpired := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancelExpired()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{"interrupted", canceled, "interrupt"},
+		{"timed out", expired, "--timeout"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &checker{fsys: contextFS{fileSystem: localFS{}, ctx: tt.ctx}, rules: rules, configPath: ".requirecodeowners.yml", ctx: tt.ctx}
+			res := c.validate(specs)
+			if len(res.checked) != 0 {
+				t.Errorf("checked = %v, want nothing", res.checked)
+			}
+			if len(res.errors) != 1 || res.errors[0].path != tt.want || res.errors[0].isWarning() {
+				t.Fatalf("errors = %v, want one failure for %s", res.errors, tt.want)
+			}
+		})
+	}
+
+	t.Run("partway through", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		fsys := &cancelingFS{fileSystem: localFS{}, reads: 1, cancel: cancel}
+		c := &checker{fsys: contextFS{fileSystem: fsys, ctx: ctx}, rules: rules, configPath: ".requirecodeowners.yml", ctx: ctx}
+		res := c.validate(specs)
+
+		// The first spec's directories were read before the cancel, and
+		// its results are kept; the walk for the second failed, but that
+		// isn't reported as a problem with libs.
+		if len(res.checked) != 2 {
+			t.Errorf("checked = %v, want the two services", res.checked)
+		}
+		if len(res.errors) != 1 || res.errors[0].path != "interrupt" {
+			t.Errorf("errors = %v, want only the interruption", res.errors)
+		}
+	})
+}
+
+func TestGitHubClientContext(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("{}"))
+	}))
+	defer srv.Close()
+	t.Setenv("GITHUB_API_URL", srv.URL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	client := newGitHubClient().withContext(ctx)
+	if _, err := client.get("/user", "application/json"); !errors.Is(err, context.Canceled) {
+		t.Errorf("get() error = %v, want context.Canceled", err)
+	}
+	if _, err := client.fetchURL(srv.URL + "/CODEOWNERS"); !errors.Is(err, context.Canceled) {
+		t.Errorf("fetchURL() error = %v, want context.Canceled", err)
+	}
+	if _, err := newGitHubClient().get("/user", "application/json"); err != nil {
+		t.Errorf("get() without a context error = %v", err)
+	}
+}
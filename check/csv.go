@@ -0,0 +1,47 @@
+package check
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+// csvHeader names the columns writeCSV writes.
+var csvHeader = []string{"path", "spec", "code", "severity", "message", "rule", "owners"}
+
+// writeCSV writes one row per problem, for pulling findings into a
+// spreadsheet. spec is the spec's name, or its path if it has none. rule and
+// owners are those of the rule that matched the directory, and empty when
+// none did.
+func writeCSV(w io.Writer, res result, _ reportOptions) error {
+	rules := make(map[string]*rule)
+	for _, d := range res.checked {
+		if d.rule != nil && rules[d.path] == nil {
+			rules[d.path] = d.rule
+		}
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, e := range res.errors {
+		severity := severityError
+		if e.isWarning() {
+			severity = severityWarning
+		}
+		spec := e.spec
+		if spec == "" {
+			spec = e.specPath
+		}
+		var pattern, owners string
+		if r := rules[e.path]; r != nil {
+			pattern = r.RawPattern()
+			owners = r.ownerNames()
+		}
+		if err := cw.Write([]string{e.path, spec, e.code, severity, e.message, pattern, owners}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
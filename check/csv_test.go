@@ -0,0 +1,40 @@
+package check
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/hmarr/codeowners"
+)
+
+func TestWriteCSV(t *testing.T) {
+	parsed, err := codeowners.ParseFile(bytes.NewBufferString("/services/api/ @org/api @alice\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := &newRuleset(parsed, "CODEOWNERS")[0]
+	res := result{
+		checked: []checkedDir{
+			{path: "services/api", rule: api},
+			{path: "services/new"},
+		},
+		errors: []validationError{
+			{path: "services/api", message: "Has 2 owners but at least 3 required.", code: codeTooFewOwners, spec: "services"},
+			{path: "services/new", message: `Not covered by CODEOWNERS. Add: /services/new/ @your-team`, code: codeMissingEntry, specPath: "services/*"},
+			{path: "libs", message: "Directory \"libs\" not found, check it", code: codeDirNotExist, severity: severityWarning},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := writeCSV(&buf, res, reportOptions{}); err != nil {
+		t.Fatalf("writeCSV() error = %v", err)
+	}
+	want := `path,spec,code,severity,message,rule,owners
+services/api,services,RCO006,error,Has 2 owners but at least 3 required.,/services/api/,@org/api @alice
+services/new,services/*,RCO001,error,Not covered by CODEOWNERS. Add: /services/new/ @your-team,,
+libs,,RCO002,warning,"Directory ""libs"" not found, check it",,
+`
+	if buf.String() != want {
+		t.Errorf("writeCSV() =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
@@ -0,0 +1,94 @@
+package check
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+
+	"github.com/kpurdon/requirecodeowners/validate"
+)
+
+// customPolicy is an org-specific check written as a CEL expression, which
+// every checked directory must make true.
+type customPolicy struct {
+	Name     string `yaml:"name"`
+	Expr     string `yaml:"expr"`
+	Message  string `yaml:"message"`
+	Severity string `yaml:"severity"`
+
+	program *celProgram
+}
+
+// customPolicyVars are the variables policy expressions can use for the
+// directory being checked.
+var customPolicyVars = []cel.EnvOption{
+	cel.Variable("path", cel.StringType),                 // the directory, like services/api
+	cel.Variable("owners", cel.ListType(cel.StringType)), // the owners of its rule, or [] if it has none
+	cel.Variable("rule", cel.StringType),                 // the pattern of its rule, or "" if it has none
+	cel.Variable("depth", cel.IntType),                   // how many directories deep it is: 1 for services
+	cel.Variable("fileCount", cel.IntType),               // how many files it holds, at any depth
+}
+
+// compile checks the policy's fields and compiles its expression.
+func (p *customPolicy) compile() error {
+	if p.Name == "" {
+		return fmt.Errorf("has no name")
+	}
+	if p.Expr == "" {
+		return fmt.Errorf("has no expr")
+	}
+	if !validSeverity(p.Severity) {
+		return fmt.Errorf("has invalid severity %q (must be %q or %q)", p.Severity, severityError, severityWarning)
+	}
+	program, err := compileCEL(p.Expr, customPolicyVars)
+	if err != nil {
+		return fmt.Errorf("has invalid expr: %w", err)
+	}
+	p.program = program
+	return nil
+}
+
+// customPoliciesValidator evaluates each custom policy for a checked
+// directory, reporting those it doesn't hold for.
+func (c *checker) customPoliciesValidator() validate.Validator {
+	broken := make(map[string]bool) // policies that failed to evaluate
+	return validate.Func{N: "policies", F: func(_ context.Context, d validate.DirResult) []validate.Finding {
+		var findings []validate.Finding
+		vars := map[string]any{
+			"path":   d.Path,
+			"owners": d.Owners,
+			"rule":   d.Rule,
+			"depth":  int64(strings.Count(d.Path, "/") + 1),
+		}
+		for _, p := range c.customPolicies {
+			if broken[p.Name] {
+				continue
+			}
+			if _, ok := vars["fileCount"]; !ok && p.program.used["fileCount"] {
+				// Counting walks the whole subtree, so it's only done for
+				// policies that ask.
+				vars["fileCount"] = int64(countFiles(c.fsys, d.Path, math.MaxInt))
+			}
+			f := validate.Finding{Code: codeCustomPolicy, Severity: p.Severity}
+			ok, err := p.program.run(vars)
+			switch {
+			case err != nil:
+				// Reported once, since the fix is the same everywhere.
+				broken[p.Name] = true
+				f.Message = fmt.Sprintf("Policy %s failed to evaluate: %v. Fix its expr in %s.", p.Name, err, c.configPath)
+				f.File = c.configPath
+			case ok:
+				continue
+			case p.Message != "":
+				f.Message = fmt.Sprintf("%s (policy %s)", p.Message, p.Name)
+			default:
+				f.Message = fmt.Sprintf("Fails policy %s: %s", p.Name, p.Expr)
+			}
+			findings = append(findings, f)
+		}
+		return findings
+	}}
+}
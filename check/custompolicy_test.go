@@ -0,0 +1,108 @@
+package check
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseConfigPolicies(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantErr string
+	}{
+		{
+			name:   "valid",
+			config: "policies:\n  - name: two-owners\n    expr: size(owners) >= 2\n    severity: warning\n",
+		},
+		{
+			name:    "no name",
+			config:  "policies:\n  - expr: \"true\"\n",
+			wantErr: "policy at index 0 has no name",
+		},
+		{
+			name:    "no expr",
+			config:  "policies:\n  - name: empty\n",
+			wantErr: "policy empty has no expr",
+		},
+		{
+			name:    "bad expr",
+			config:  "policies:\n  - name: typo\n    expr: size(ownres) > 0\n",
+			wantErr: "policy typo has invalid expr: column 6: undeclared reference to 'ownres'",
+		},
+		{
+			name:    "bad severity",
+			config:  "policies:\n  - name: p\n    expr: \"true\"\n    severity: fatal\n",
+			wantErr: `policy p has invalid severity "fatal"`,
+		},
+		{
+			name:    "duplicate names",
+			config:  "policies:\n  - name: p\n    expr: \"true\"\n  - name: p\n    expr: \"false\"\n",
+			wantErr: `policies 0 and 1 are both named "p"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseConfig([]byte("directories:\n  - path: services\n"+tt.config), ".requirecodeowners.yml")
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("parseConfig() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseConfig() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckRepoCustomPolicies(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, d := range []string{"services/payments", "services/web", "services/empty"} {
+		os.MkdirAll(filepath.Join(tmpDir, d), 0755)
+	}
+	os.WriteFile(filepath.Join(tmpDir, "services/payments/main.go"), []byte("package main\n"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "services/web/index.js"), []byte("\n"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, ".requirecodeowners.yml"), []byte(`directories:
+  - path: services
+    level: 1
+policies:
+  - name: payments-two-owners
+    expr: '!path.startsWith("services/payments") || size(owners) >= 2'
+    message: Payments directories need a second owner.
+  - name: no-empty-dirs
+    expr: fileCount > 0
+    severity: warning
+  - name: broken
+    expr: owners[3] == "" && rule.size() > depth
+`), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "CODEOWNERS"), []byte("/services/ @org/services\n"), 0644)
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	res, err := checkRepo(context.Background(), "", nil, specFilter{})
+	if err != nil {
+		t.Fatalf("checkRepo() error = %v", err)
+	}
+	var got []string
+	for _, e := range res.errors {
+		severity := "error"
+		if e.isWarning() {
+			severity = "warning"
+		}
+		got = append(got, e.path+": "+severity+" "+e.message)
+	}
+	want := []string{
+		"services/empty: warning Fails policy no-empty-dirs: fileCount > 0",
+		"services/empty: error Policy broken failed to evaluate: index out of bounds: 3. Fix its expr in .requirecodeowners.yml.",
+		"services/payments: error Payments directories need a second owner. (policy payments-two-owners)",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("errors =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,161 @@
+package check
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// diffContext is how many unchanged lines surround each hunk.
+const diffContext = 3
+
+// diffOp is one line of a line diff: kept (' '), removed ('-') or added
+// ('+').
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// writeUnifiedDiff writes the changes from old to new in the file at path as
+// a unified diff, like git diff, or nothing if they're the same. A nil old
+// is a file that doesn't exist yet.
+func writeUnifiedDiff(w io.Writer, path string, old, new []byte) error {
+	ops := diffLines(splitLines(string(old)), splitLines(string(new)))
+	var b strings.Builder
+	for start := 0; start < len(ops); {
+		// Find the next change, then extend the hunk until the changes are
+		// more than twice the context apart.
+		first := start
+		for first < len(ops) && ops[first].kind == ' ' {
+			first++
+		}
+		if first == len(ops) {
+			break
+		}
+		end := first
+		for i := first; i < len(ops); i++ {
+			if ops[i].kind != ' ' {
+				end = i + 1
+			} else if i-end >= 2*diffContext {
+				break
+			}
+		}
+		from, to := max(first-diffContext, start), min(end+diffContext, len(ops))
+
+		oldLine, newLine := 1, 1
+		for _, op := range ops[:from] {
+			if op.kind != '+' {
+				oldLine++
+			}
+			if op.kind != '-' {
+				newLine++
+			}
+		}
+		var oldCount, newCount int
+		for _, op := range ops[from:to] {
+			if op.kind != '+' {
+				oldCount++
+			}
+			if op.kind != '-' {
+				newCount++
+			}
+		}
+		if b.Len() == 0 && old == nil {
+			fmt.Fprintf(&b, "--- /dev/null\n+++ b/%s\n", path)
+		} else if b.Len() == 0 {
+			fmt.Fprintf(&b, "--- a/%s\n+++ b/%s\n", path, path)
+		}
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n", hunkRange(oldLine, oldCount), hunkRange(newLine, newCount))
+		for _, op := range ops[from:to] {
+			fmt.Fprintf(&b, "%c%s\n", op.kind, op.line)
+		}
+		start = to
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeOrDiff writes data to the file at path, which holds old, or nil if it
+// doesn't exist yet. With diff set, nothing is written: the change goes to
+// diff as a unified diff instead.
+func writeOrDiff(path string, old, data []byte, diff io.Writer) error {
+	if diff != nil {
+		return writeUnifiedDiff(diff, filepath.ToSlash(path), old, data)
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// hunkRange formats a hunk header's line range. An empty range names the
+// line before it, as diff does.
+func hunkRange(line, count int) string {
+	if count == 0 {
+		line--
+	}
+	if count == 1 {
+		return fmt.Sprint(line)
+	}
+	return fmt.Sprintf("%d,%d", line, count)
+}
+
+// splitLines splits s into lines, without their newlines.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// diffLines returns a shortest edit from a to b. The common prefix and
+// suffix are matched first, so the quadratic longest common subsequence
+// only runs on the lines in between, which for the edits this tool makes
+// are few.
+func diffLines(a, b []string) []diffOp {
+	var ops []diffOp
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		ops = append(ops, diffOp{' ', a[prefix]})
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	x, y := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+
+	// lcs[i][j] is the length of the longest common subsequence of x[i:]
+	// and y[j:].
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(x) || j < len(y) {
+		switch {
+		case i < len(x) && j < len(y) && x[i] == y[j]:
+			ops = append(ops, diffOp{' ', x[i]})
+			i++
+			j++
+		case j == len(y) || (i < len(x) && lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, diffOp{'-', x[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', y[j]})
+			j++
+		}
+	}
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return ops
+}
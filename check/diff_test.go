@@ -0,0 +1,62 @@
+package check
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestWriteUnifiedDiff(t *testing.T) {
+	var lines []string
+	for i := 1; i <= 20; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	twenty := strings.Join(lines, "\n") + "\n"
+
+	tests := []struct {
+		name     string
+		old, new string
+		want     string
+	}{
+		{"unchanged", twenty, twenty, ""},
+		{
+			name: "one change",
+			old:  twenty,
+			new:  strings.Replace(twenty, "line 5\n", "changed\n", 1),
+			want: "--- a/f\n+++ b/f\n@@ -2,7 +2,7 @@\n line 2\n line 3\n line 4\n-line 5\n+changed\n line 6\n line 7\n line 8\n",
+		},
+		{
+			name: "two hunks",
+			old:  twenty,
+			new:  "first\n" + twenty + "last\n",
+			want: "--- a/f\n+++ b/f\n@@ -1,3 +1,4 @@\n+first\n line 1\n line 2\n line 3\n" +
+				"@@ -18,3 +19,4 @@\n line 18\n line 19\n line 20\n+last\n",
+		},
+		{
+			name: "empty file",
+			new:  "a\nb\n",
+			want: "--- a/f\n+++ b/f\n@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := writeUnifiedDiff(&buf, "f", []byte(tt.old), []byte(tt.new)); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("diff =\n%s\nwant\n%s", buf.String(), tt.want)
+			}
+		})
+	}
+
+	// A file that doesn't exist yet is created, as git apply expects.
+	var buf bytes.Buffer
+	if err := writeUnifiedDiff(&buf, "f", nil, []byte("a\n")); err != nil {
+		t.Fatal(err)
+	}
+	if want := "--- /dev/null\n+++ b/f\n@@ -0,0 +1 @@\n+a\n"; buf.String() != want {
+		t.Errorf("diff of a new file =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
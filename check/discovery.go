@@ -0,0 +1,101 @@
+package check
+
+import (
+	"io/fs"
+	"path"
+	"slices"
+	"sort"
+)
+
+// Spec modes say how a spec finds the directories it checks.
+const (
+	modeLevels     = "levels"     // the directories at the spec's levels (the default)
+	modeGoPackages = "gopackages" // every Go package under the spec's path
+	modeWorkspaces = "workspaces" // every npm, yarn, or pnpm workspace package
+	modeBazel      = "bazel"      // every Bazel package under the spec's path
+	modeTerraform  = "terraform"  // every Terraform module under the spec's path
+)
+
+// discoveryMode finds the directories a spec checks from how the repository
+// is structured, rather than by level.
+type discoveryMode struct {
+	units    string // what it finds, for messages
+	discover func(fsys fileSystem, root string, spec dirSpec) ([]string, error)
+}
+
+var discoveryModes = map[string]discoveryMode{
+	modeGoPackages: {
+		units: "Go packages",
+		discover: func(fsys fileSystem, root string, spec dirSpec) ([]string, error) {
+			return goPackages(fsys, root, spec.BuildTags)
+		},
+	},
+	modeWorkspaces: {
+		units: "workspace packages",
+		discover: func(fsys fileSystem, root string, _ dirSpec) ([]string, error) {
+			return workspacePackages(fsys, root)
+		},
+	},
+	modeBazel: {
+		units: "Bazel packages",
+		discover: func(fsys fileSystem, root string, _ dirSpec) ([]string, error) {
+			return findDirs(fsys, root, func(_ string, entries []fs.DirEntry) bool {
+				return hasEntry(entries, "BUILD", "BUILD.bazel")
+			})
+		},
+	},
+	modeTerraform: {
+		units: "Terraform modules",
+		discover: func(fsys fileSystem, root string, spec dirSpec) ([]string, error) {
+			return terraformModules(fsys, root, spec.TerraformRoots)
+		},
+	},
+}
+
+// findDirs returns root and the directories below it, at any depth, for
+// which keep reports true, given the directory's entries.
+func findDirs(fsys fileSystem, root string, keep func(dir string, entries []fs.DirEntry) bool) ([]string, error) {
+	var dirs []string
+	var walk func(dir string) error
+	walk = func(dir string) error {
+		entries, err := fsys.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+		if keep(dir, entries) {
+			dirs = append(dirs, dir)
+		}
+		for _, e := range entries {
+			if e.IsDir() {
+				if err := walk(path.Join(dir, e.Name())); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err := walk(root); err != nil {
+		return nil, err
+	}
+	return dirs, nil
+}
+
+// hasEntry reports whether entries include a file with one of names.
+func hasEntry(entries []fs.DirEntry, names ...string) bool {
+	for _, e := range entries {
+		if !e.IsDir() && slices.Contains(names, e.Name()) {
+			return true
+		}
+	}
+	return false
+}
+
+// modeNames returns every valid spec mode, sorted.
+func modeNames() []string {
+	names := []string{modeLevels}
+	for name := range discoveryModes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
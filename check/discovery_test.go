@@ -0,0 +1,69 @@
+package check
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestBazelPackages(t *testing.T) {
+	tree := newTreeFS(map[string]bool{
+		"WORKSPACE":                      false,
+		"BUILD.bazel":                    false,
+		"src/server/BUILD":               false,
+		"src/server/main.cc":             false,
+		"src/server/internal/auth/BUILD": false,
+		"src/lib/util.cc":                false,
+		"docs/BUILD.md":                  false,
+	})
+
+	pkgs, err := discoveryModes[modeBazel].discover(tree, ".", dirSpec{})
+	if err != nil {
+		t.Fatalf("discover() error = %v", err)
+	}
+	if want := []string{".", "src/server", "src/server/internal/auth"}; !reflect.DeepEqual(pkgs, want) {
+		t.Errorf("discover() = %v, want %v", pkgs, want)
+	}
+}
+
+func TestTerraformModules(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	files := map[string]string{
+		"infra/prod/main.tf":             "terraform {\n  backend \"s3\" {\n    bucket = \"state\"\n  }\n}\n",
+		"infra/staging/providers.tf":     "provider \"aws\" {\n  region = \"us-east-1\"\n}\n",
+		"infra/cloud/main.tf":            "terraform {\n  cloud {\n    organization = \"acme\"\n  }\n}\n",
+		"infra/modules/vpc/main.tf":      "resource \"aws_vpc\" \"this\" {}\n",
+		"infra/modules/vpc/variables.tf": "variable \"cidr\" {}\n",
+		"infra/docs/README.md":           "# Infra\n",
+	}
+	for name, content := range files {
+		os.MkdirAll(filepath.Join(tmpDir, filepath.Dir(name)), 0755)
+		os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644)
+	}
+
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	tests := []struct {
+		name string
+		spec dirSpec
+		want []string
+	}{
+		{name: "any module", want: []string{"infra/cloud", "infra/modules/vpc", "infra/prod", "infra/staging"}},
+		{name: "roots only", spec: dirSpec{TerraformRoots: true}, want: []string{"infra/cloud", "infra/prod", "infra/staging"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := discoveryModes[modeTerraform].discover(localFS{}, "infra", tt.spec)
+			if err != nil {
+				t.Fatalf("discover() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("discover() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package check
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/kpurdon/requirecodeowners/validate"
+)
+
+// checkExclusiveRule warns about dir, owned through the rule match, unless
+// exactly one non-wildcard rule matches it. With more, only the rules' order
+// decides who gets the review request; with none, a wildcard like * decides
+// it without naming the directory.
+func (c *checker) checkExclusiveRule(dir string, match *rule) (validate.Finding, bool) {
+	var matching []*rule
+	for i := range c.rules {
+		r := &c.rules[i]
+		if isWildcardRule(r) {
+			continue
+		}
+		for _, probe := range []string{dir, dir + "/", dir + "/file.txt"} {
+			if ok, _ := r.Match(probe); ok {
+				matching = append(matching, r)
+				break
+			}
+		}
+	}
+	switch len(matching) {
+	case 0:
+		owners := match.ownerNames()
+		if owners == "" {
+			owners = "@your-team"
+		}
+		return validate.Finding{
+			Path: dir,
+			Message: fmt.Sprintf("Owned only through the wildcard rule %s (%s). Add a rule naming it: /%s/ %s",
+				match.location(), match.RawPattern(), dir, owners),
+			Code:     codeWildcardOnly,
+			Severity: severityWarning,
+			Team:     match.ownerNames(),
+			File:     match.file,
+			Line:     match.LineNumber,
+		}, true
+	case 1:
+		return validate.Finding{}, false
+	}
+	// The last rule wins. The others either name dir too, which makes them
+	// duplicates, or own a directory above it, whose other paths would lose
+	// their owners if the rule were removed.
+	match = matching[len(matching)-1]
+	lines := make([]string, len(matching))
+	var naming []*rule
+	var advice []string
+	for i, r := range matching {
+		lines[i] = fmt.Sprintf("%s (%s)", r.location(), r.RawPattern())
+		if above, ok := ruleAbove(r, dir); ok {
+			advice = append(advice, fmt.Sprintf("%s also owns the rest of %s, so narrow it to the directories it's meant for rather than removing it.", lines[i], above))
+		} else {
+			naming = append(naming, r)
+		}
+	}
+	if len(naming) > 0 {
+		keep := naming[len(naming)-1]
+		if len(naming) > 1 {
+			dups := make([]string, len(naming)-1)
+			for i, r := range naming[:len(naming)-1] {
+				dups[i] = r.location()
+			}
+			advice = append([]string{fmt.Sprintf("Remove the duplicate %s and keep %s.", pluralize(len(dups), "rule in", "rules in")+" "+strings.Join(dups, ", "), keep.location())}, advice...)
+		}
+		if keep != match {
+			advice = append([]string{fmt.Sprintf("%s names it but comes before %s, which wins; move it below if it's meant to own %s.", keep.location(), match.location(), dir)}, advice...)
+		}
+	}
+	return validate.Finding{
+		Path: dir,
+		Message: fmt.Sprintf("Matched by %d rules, so only their order decides its owners: %s. %s",
+			len(matching), strings.Join(lines, ", "), strings.Join(advice, " ")),
+		Code:     codeSharedRules,
+		Severity: severityWarning,
+		Team:     match.ownerNames(),
+		File:     match.file,
+		Line:     match.LineNumber,
+	}, true
+}
+
+// exclusiveValidator reports owned directories that aren't owned through
+// exactly one non-wildcard rule, as checkExclusiveRule does.
+func (c *checker) exclusiveValidator() validate.Validator {
+	return validate.Func{N: "exclusive_rules", F: func(_ context.Context, d validate.DirResult) []validate.Finding {
+		match := c.ruleFor(d)
+		if match == nil {
+			return nil
+		}
+		if f, ok := c.checkExclusiveRule(d.Path, match); ok {
+			return []validate.Finding{f}
+		}
+		return nil
+	}}
+}
+
+// isWildcardRule reports whether r's pattern uses wildcards to match paths
+// across the tree, like * or *.go, rather than naming a directory. A
+// trailing /** still names the directory it's under.
+func isWildcardRule(r *rule) bool {
+	return strings.ContainsAny(strings.TrimSuffix(r.RawPattern(), "/**"), "*?[")
+}
+
+// ruleAbove returns the directory above dir that r owns, for a rule like
+// /services/ matching services/api, or false if r names dir itself.
+func ruleAbove(r *rule, dir string) (string, bool) {
+	base := strings.TrimSuffix(strings.TrimSuffix(strings.TrimPrefix(r.RawPattern(), "/"), "/**"), "/")
+	if base == "" || !strings.HasPrefix(dir, base+"/") {
+		return "", false
+	}
+	return base, true
+}
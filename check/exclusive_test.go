@@ -0,0 +1,116 @@
+package check
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIsWildcardRule(t *testing.T) {
+	tests := map[string]bool{
+		"*":                true,
+		"*.go":             true,
+		"/services/*/":     true,
+		"/services/":       false,
+		"/services/api/**": false,
+		"docs":             false,
+	}
+	for pattern, want := range tests {
+		rules, err := parseCodeowners(strings.NewReader(pattern+" @org/a\n"), "CODEOWNERS", providerGitHub)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := isWildcardRule(&rules[0]); got != want {
+			t.Errorf("isWildcardRule(%q) = %v, want %v", pattern, got, want)
+		}
+	}
+}
+
+func TestCheckRepoExclusiveRules(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, d := range []string{"services/api", "services/web", "libs/util", "tools/build"} {
+		os.MkdirAll(filepath.Join(tmpDir, d), 0755)
+	}
+	os.WriteFile(filepath.Join(tmpDir, ".requirecodeowners.yml"), []byte(`defaults:
+  exclusive_rules: true
+directories:
+  - path: services
+    level: 1
+  - path: libs
+    level: 1
+    exclusive_rules: false
+  - path: tools
+    level: 1
+`), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "CODEOWNERS"), []byte(`* @org/everyone
+/services/ @org/services
+/services/api/ @org/api
+/libs/ @org/libs
+/libs/util/ @org/util
+`), 0644)
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	res, err := checkRepo(context.Background(), "", nil, specFilter{})
+	if err != nil {
+		t.Fatalf("checkRepo() error = %v", err)
+	}
+	if len(res.errors) != 2 {
+		t.Fatalf("errors = %v, want 2", res.errors)
+	}
+	sortErrors(res.errors)
+	e := res.errors[0]
+	if e.path != "services/api" || e.code != codeSharedRules || !e.isWarning() || e.line != 3 {
+		t.Errorf("error = %+v, want a warning for services/api at line 3", e)
+	}
+	if want := "Matched by 2 rules, so only their order decides its owners: CODEOWNERS:2 (/services/), CODEOWNERS:3 (/services/api/). CODEOWNERS:2 (/services/) also owns the rest of services, so narrow it to the directories it's meant for rather than removing it."; e.message != want {
+		t.Errorf("message = %q, want %q", e.message, want)
+	}
+
+	// A directory only a wildcard owns isn't named by any rule.
+	e = res.errors[1]
+	if e.path != "tools/build" || e.code != codeWildcardOnly || !e.isWarning() || e.line != 1 {
+		t.Errorf("error = %+v, want a warning for tools/build at line 1", e)
+	}
+	if want := "Owned only through the wildcard rule CODEOWNERS:1 (*). Add a rule naming it: /tools/build/ @org/everyone"; e.message != want {
+		t.Errorf("message = %q, want %q", e.message, want)
+	}
+}
+
+func TestCheckExclusiveRuleAdvice(t *testing.T) {
+	tests := []struct {
+		name       string
+		codeowners string
+		want       string
+	}{
+		{
+			name:       "duplicate",
+			codeowners: "/services/api/ @org/old\n/services/api/ @org/api\n",
+			want:       "Remove the duplicate rule in CODEOWNERS:1 and keep CODEOWNERS:2.",
+		},
+		{
+			name:       "broader rule last",
+			codeowners: "/services/api/ @org/api\n/services/ @org/services\n",
+			want:       "CODEOWNERS:1 names it but comes before CODEOWNERS:2, which wins; move it below if it's meant to own services/api. CODEOWNERS:2 (/services/) also owns the rest of services, so narrow it to the directories it's meant for rather than removing it.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := parseCodeowners(strings.NewReader(tt.codeowners), "CODEOWNERS", providerGitHub)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c := &checker{rules: rules}
+			e, ok := c.checkExclusiveRule("services/api", &rules[len(rules)-1])
+			if !ok || e.Code != codeSharedRules {
+				t.Fatalf("checkExclusiveRule() = %+v, %v, want a %s warning", e, ok, codeSharedRules)
+			}
+			if !strings.HasSuffix(e.Message, tt.want) {
+				t.Errorf("message = %q, want it to end %q", e.Message, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package check
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// explanation is how CODEOWNERS applies to one path: every rule that
+// matches it, in file order, and which of them give it its owners.
+type explanation struct {
+	path  string
+	dir   bool
+	rules []*rule
+
+	// winners are the rules that apply: the last match, or with GitLab
+	// sections, the last match in each section.
+	winners map[*rule]bool
+}
+
+func runExplain(args []string) int {
+	fs := flag.NewFlagSet("explain", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: requirecodeowners explain [flags] PATH...")
+		fmt.Fprintln(fs.Output(), "Shows every CODEOWNERS rule that matches each PATH, and which one gives it its owners.")
+		fs.PrintDefaults()
+	}
+	var rf repoFlags
+	rf.register(fs)
+	var lf configFlags
+	lf.register(fs)
+	var cf consoleFlags
+	cf.register(fs)
+	var gf githubFlags
+	gf.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+	if err := lf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	if err := cf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	if err := gf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+
+	if err := rf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	// The paths are relative to where explain was run, but CODEOWNERS
+	// patterns are relative to the config's root.
+	var paths []string
+	for _, p := range fs.Args() {
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return 1
+		}
+		paths = append(paths, abs)
+	}
+	if err := enterConfigRoot(rf.configPath); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	root, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	sources, provider, err := codeownersSources(rf.configPath, rf.codeowners)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	if offline {
+		var remote []string
+		sources, remote = splitRemoteSources(sources)
+		for _, source := range remote {
+			fmt.Fprintf(os.Stderr, "Skipping %s because of --offline. Its rules aren't part of the explanation.\n", source)
+		}
+	}
+	rules, err := loadCodeowners(context.Background(), sources, provider)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+
+	status := 0
+	for i, abs := range paths {
+		p, err := filepath.Rel(root, abs)
+		if err != nil || p == ".." || strings.HasPrefix(p, ".."+string(filepath.Separator)) {
+			fmt.Fprintf(os.Stderr, "error: %s is outside the repository at %s\n", fs.Arg(i), root)
+			return 1
+		}
+		info, err := os.Stat(abs)
+		x := explainPath(rules, filepath.ToSlash(p), err == nil && info.IsDir())
+		if i > 0 {
+			fmt.Println()
+		}
+		if !writeExplanation(os.Stdout, x) {
+			status = 1
+		}
+	}
+	return status
+}
+
+// explainPath finds the rules matching p, a directory if dir is set. A
+// directory matches a rule if any of matchDirectory's probes do, and its
+// owners are found the way the check finds them.
+func explainPath(rules ruleset, p string, dir bool) explanation {
+	p = path.Clean(p)
+	x := explanation{path: p, dir: dir, winners: make(map[*rule]bool)}
+	match := func(rs ruleset) *rule {
+		if dir {
+			return matchDirectory(rs, p)
+		}
+		r, _ := rs.Match(p)
+		return r
+	}
+
+	probes := []string{p}
+	if dir {
+		probes = append(probes, p+"/", p+"/file.txt")
+	}
+	sections := make(map[string]ruleset)
+	var order []string
+	for i := range rules {
+		r := &rules[i]
+		for _, probe := range probes {
+			if ok, _ := r.Match(probe); ok {
+				x.rules = append(x.rules, r)
+				break
+			}
+		}
+		var key string
+		if r.section != nil {
+			key = strings.ToLower(r.section.name)
+		}
+		if _, ok := sections[key]; !ok {
+			order = append(order, key)
+		}
+		sections[key] = append(sections[key], *r)
+	}
+	// match returns a pointer into its own ruleset, so rules are found again
+	// by where they are.
+	for _, key := range order {
+		if w := match(sections[key]); w != nil {
+			for _, r := range x.rules {
+				if r.file == w.file && r.LineNumber == w.LineNumber {
+					x.winners[r] = true
+				}
+			}
+		}
+	}
+	return x
+}
+
+// writeExplanation writes x's owners, then each matching rule, marking the
+// ones that apply. It reports whether x has an owner.
+func writeExplanation(w io.Writer, x explanation) bool {
+	label := x.path
+	if x.dir && label != "." {
+		label += "/"
+	}
+	var owners []string
+	for _, r := range x.rules {
+		if x.winners[r] && len(r.Owners) > 0 {
+			owners = append(owners, r.ownerNames())
+		}
+	}
+	if len(owners) == 0 {
+		fmt.Fprintf(w, "%s\n", console.mark(w, markFail, label+": no owner"))
+	} else {
+		fmt.Fprintf(w, "%s\n", console.mark(w, markOK, label+": "+strings.Join(owners, " ")))
+	}
+	if len(x.rules) == 0 {
+		fmt.Fprintln(w, "  No rule matches it.")
+		return false
+	}
+	for _, r := range x.rules {
+		parts := []string{r.location(), r.RawPattern()}
+		if names := r.ownerNames(); names != "" {
+			parts = append(parts, names)
+		}
+		if r.section != nil {
+			parts = append(parts, r.section.header())
+		}
+		switch {
+		case x.winners[r] && len(r.Owners) == 0:
+			parts = append(parts, "(applies, with no owners)")
+		case x.winners[r]:
+			parts = append(parts, "(applies)")
+		default:
+			parts = append(parts, "(overridden)")
+		}
+		fmt.Fprintf(w, "  %s\n", strings.Join(parts, " "))
+	}
+	return len(owners) > 0
+}
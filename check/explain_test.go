@@ -0,0 +1,117 @@
+package check
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestExplainPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		rules    string
+		path     string
+		dir      bool
+		want     string
+	}{
+		{
+			name:     "last match wins",
+			provider: providerGitHub,
+			rules:    "* @org/everyone\n/services/ @org/services\n/docs/ @org/docs\n",
+			path:     "services/api/main.go",
+			want: `✓ services/api/main.go: @org/services
+  CODEOWNERS:1 * @org/everyone (overridden)
+  CODEOWNERS:2 /services/ @org/services (applies)
+`,
+		},
+		{
+			name:     "directory",
+			provider: providerGitHub,
+			rules:    "/services/api/ @org/api\n",
+			path:     "services/api",
+			dir:      true,
+			want: `✓ services/api/: @org/api
+  CODEOWNERS:1 /services/api/ @org/api (applies)
+`,
+		},
+		{
+			name:     "rule without owners",
+			provider: providerGitHub,
+			rules:    "* @org/everyone\n/generated.go\n",
+			path:     "generated.go",
+			want: `✗ generated.go: no owner
+  CODEOWNERS:1 * @org/everyone (overridden)
+  CODEOWNERS:2 /generated.go (applies, with no owners)
+`,
+		},
+		{
+			name:     "no match",
+			provider: providerGitHub,
+			rules:    "/docs/ @org/docs\n",
+			path:     "services",
+			dir:      true,
+			want:     "✗ services/: no owner\n  No rule matches it.\n",
+		},
+		{
+			name:     "gitlab sections",
+			provider: providerGitLab,
+			rules:    "[Docs]\n/docs/ @org/docs\n/docs/api/ @org/api\n\n[Security][2]\n/docs/ @org/security\n",
+			path:     "docs/api/index.md",
+			want: `✓ docs/api/index.md: @org/api @org/security
+  CODEOWNERS:2 /docs/ @org/docs [Docs] (overridden)
+  CODEOWNERS:3 /docs/api/ @org/api [Docs] (applies)
+  CODEOWNERS:6 /docs/ @org/security [Security][2] (applies)
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := parseCodeowners(strings.NewReader(tt.rules), "CODEOWNERS", tt.provider)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var b bytes.Buffer
+			owned := writeExplanation(&b, explainPath(rules, tt.path, tt.dir))
+			if b.String() != tt.want {
+				t.Errorf("explanation =\n%s\nwant\n%s", b.String(), tt.want)
+			}
+			if want := strings.HasPrefix(tt.want, "✓"); owned != want {
+				t.Errorf("writeExplanation() = %v, want %v", owned, want)
+			}
+		})
+	}
+}
+
+func TestRunExplainGitHubFlags(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/org/meta/contents/CODEOWNERS":
+			w.Write([]byte("/services/ @org/services\n"))
+		case "/repos/org/meta/contents/base.yml":
+			w.Write([]byte("directories:\n  - path: services\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	t.Setenv("GITHUB_API_URL", "")
+	defer func() { githubSettings.apiURL, offline = "", false }()
+	gitRepo(t, map[string]string{
+		".requirecodeowners.yml": "extends: github:org/meta/base.yml\ncodeowners: github:org/meta/CODEOWNERS\n",
+		"services/a/main.go":     "package main\n",
+	})
+
+	// Without --github-api-url these would go to api.github.com.
+	if code := runExplain([]string{"--github-api-url", srv.URL, "services/a"}); code != 0 {
+		t.Errorf("explain with --github-api-url = %d, want 0", code)
+	}
+	if code := runConfigValidate([]string{"--github-api-url", srv.URL}); code != 0 {
+		t.Errorf("config validate with --github-api-url = %d, want 0", code)
+	}
+	if code := runConfigValidate([]string{"--github-api-url", srv.URL, "--offline"}); code == 0 {
+		t.Errorf("config validate with --offline = 0, want it to fail on the base it can't fetch")
+	}
+}
@@ -0,0 +1,213 @@
+package check
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ownershipEntry is one checked directory in an exported ownership manifest.
+type ownershipEntry struct {
+	Path   string       `json:"path" yaml:"path"`
+	Owners []string     `json:"owners" yaml:"owners"`
+	Rule   *ruleLocator `json:"rule,omitempty" yaml:"rule,omitempty"`
+}
+
+// ruleLocator identifies the CODEOWNERS rule that gives a directory its
+// owners.
+type ruleLocator struct {
+	File    string `json:"file" yaml:"file"`
+	Line    int    `json:"line" yaml:"line"`
+	Pattern string `json:"pattern" yaml:"pattern"`
+}
+
+func runExport(args []string) int {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	var format string
+	var rf repoFlags
+	rf.register(fs)
+	var lf configFlags
+	lf.register(fs)
+	fs.StringVar(&format, "format", "json", "output format: json or yaml")
+	var filter specFilter
+	filter.register(fs)
+	var gf githubFlags
+	gf.register(fs)
+	var tf traversalFlags
+	tf.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if err := lf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	if err := gf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	if err := tf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	if format != "json" && format != "yaml" {
+		fmt.Fprintf(os.Stderr, "error: unknown format %q (want json or yaml)\n", format)
+		return 2
+	}
+
+	if err := rf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	res, err := checkRepo(context.Background(), rf.configPath, rf.codeowners, filter)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+
+	manifest := ownershipManifest(res.checked)
+	if format == "yaml" {
+		err = writeManifestYAML(os.Stdout, manifest)
+	} else {
+		err = writeManifestJSON(os.Stdout, manifest)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: writing manifest: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func runList(args []string) int {
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: requirecodeowners list [flags]")
+		fmt.Fprintln(fs.Output(), "Lists each checked directory with its owners and the rule they come from.")
+		fs.PrintDefaults()
+	}
+	var unowned bool
+	var rf repoFlags
+	rf.register(fs)
+	var lf configFlags
+	lf.register(fs)
+	fs.BoolVar(&unowned, "unowned", false, "only list directories without an owner")
+	var filter specFilter
+	filter.register(fs)
+	var gf githubFlags
+	gf.register(fs)
+	var tf traversalFlags
+	tf.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return 2
+	}
+	if err := lf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	if err := gf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	if err := tf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+
+	if err := rf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	res, err := checkRepo(context.Background(), rf.configPath, rf.codeowners, filter)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	manifest := ownershipManifest(res.checked)
+	if unowned {
+		var kept []ownershipEntry
+		for _, e := range manifest {
+			if e.Rule == nil {
+				kept = append(kept, e)
+			}
+		}
+		manifest = kept
+	}
+	if err := writeManifestTable(os.Stdout, manifest); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// ownershipManifest lists each checked directory once, sorted by path, with
+// its owners and the rule they come from. Unowned directories are included
+// with no owners and no rule, so consumers can tell them from unchecked ones.
+func ownershipManifest(checked []checkedDir) []ownershipEntry {
+	seen := make(map[string]bool)
+	entries := make([]ownershipEntry, 0, len(checked))
+	for _, d := range checked {
+		if seen[d.path] {
+			continue
+		}
+		seen[d.path] = true
+		entries = append(entries, newOwnershipEntry(d))
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+	return entries
+}
+
+func newOwnershipEntry(d checkedDir) ownershipEntry {
+	entry := ownershipEntry{Path: d.path, Owners: []string{}}
+	if d.rule != nil {
+		for _, o := range d.rule.Owners {
+			entry.Owners = append(entry.Owners, o.String())
+		}
+		entry.Rule = &ruleLocator{File: d.rule.file, Line: d.rule.LineNumber, Pattern: d.rule.RawPattern()}
+	}
+	return entry
+}
+
+func writeManifestJSON(w io.Writer, manifest []ownershipEntry) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(manifest)
+}
+
+func writeManifestYAML(w io.Writer, manifest []ownershipEntry) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(manifest); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// writeManifestTable writes the manifest as a table, with - for the owners
+// and rule of unowned directories.
+func writeManifestTable(w io.Writer, manifest []ownershipEntry) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "PATH\tOWNERS\tRULE")
+	for _, e := range manifest {
+		owners, rule := "-", "-"
+		if len(e.Owners) > 0 {
+			owners = strings.Join(e.Owners, " ")
+		}
+		if e.Rule != nil {
+			rule = fmt.Sprintf("%s:%d %s", e.Rule.File, e.Rule.Line, e.Rule.Pattern)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", e.Path, owners, rule)
+	}
+	return tw.Flush()
+}
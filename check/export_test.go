@@ -0,0 +1,73 @@
+package check
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestOwnershipManifest(t *testing.T) {
+	rules, err := parseCodeowners(strings.NewReader(`/services/a/ @org/payments @org/platform
+/services/b/ @org/payments
+`), ".github/CODEOWNERS", providerGitHub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checked := []checkedDir{
+		{path: "services/b", rule: &rules[1]},
+		{path: "services/c"},
+		{path: "services/a", rule: &rules[0]},
+		{path: "services/b", rule: &rules[1]}, // checked by two specs
+	}
+
+	got := ownershipManifest(checked)
+	want := []ownershipEntry{
+		{Path: "services/a", Owners: []string{"@org/payments", "@org/platform"}, Rule: &ruleLocator{File: ".github/CODEOWNERS", Line: 1, Pattern: "/services/a/"}},
+		{Path: "services/b", Owners: []string{"@org/payments"}, Rule: &ruleLocator{File: ".github/CODEOWNERS", Line: 2, Pattern: "/services/b/"}},
+		{Path: "services/c", Owners: []string{}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ownershipManifest() = %+v, want %+v", got, want)
+	}
+
+	var out bytes.Buffer
+	if err := writeManifestJSON(&out, got); err != nil {
+		t.Fatalf("writeManifestJSON() error = %v", err)
+	}
+	var fromJSON []ownershipEntry
+	if err := json.Unmarshal(out.Bytes(), &fromJSON); err != nil {
+		t.Fatalf("writeManifestJSON() wrote invalid JSON: %v", err)
+	}
+	if !reflect.DeepEqual(fromJSON, want) {
+		t.Errorf("JSON round trip = %+v, want %+v", fromJSON, want)
+	}
+
+	out.Reset()
+	if err := writeManifestYAML(&out, got); err != nil {
+		t.Fatalf("writeManifestYAML() error = %v", err)
+	}
+	var fromYAML []ownershipEntry
+	if err := yaml.Unmarshal(out.Bytes(), &fromYAML); err != nil {
+		t.Fatalf("writeManifestYAML() wrote invalid YAML: %v", err)
+	}
+	if !reflect.DeepEqual(fromYAML, want) {
+		t.Errorf("YAML round trip = %+v, want %+v", fromYAML, want)
+	}
+
+	out.Reset()
+	if err := writeManifestTable(&out, got); err != nil {
+		t.Fatalf("writeManifestTable() error = %v", err)
+	}
+	wantTable := `PATH        OWNERS                       RULE
+services/a  @org/payments @org/platform  .github/CODEOWNERS:1 /services/a/
+services/b  @org/payments                .github/CODEOWNERS:2 /services/b/
+services/c  -                            -
+`
+	if out.String() != wantTable {
+		t.Errorf("writeManifestTable() =\n%s\nwant\n%s", out.String(), wantTable)
+	}
+}
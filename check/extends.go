@@ -0,0 +1,291 @@
+package check
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configReader reads a config and the configs it extends. local reads the
+// local paths, so a config fetched from a repository can extend the files
+// next to it; URLs and github: references are fetched. origin is where the
+// config came from.
+type configReader struct {
+	local  func(path string) ([]byte, error)
+	client *githubClient
+	origin configOrigin
+
+	// loaded is the content of every base read, in order.
+	loaded []byte
+}
+
+func (r *configReader) read(source string) ([]byte, error) {
+	if r.origin == remoteConfig {
+		// Fetching for it would let whoever wrote it have the server
+		// request internal addresses, or read other repositories with
+		// the server's token.
+		if isURL(source) || isGitHubSource(source) {
+			return nil, fmt.Errorf("a remote config can only extend files in its repository")
+		}
+		if !inRepository(source) {
+			return nil, fmt.Errorf("%s is outside the repository", source)
+		}
+	}
+	if !isURL(source) && !isGitHubSource(source) {
+		return r.local(source)
+	}
+	if offline {
+		return nil, fmt.Errorf("can't fetch it with --offline")
+	}
+	if r.client == nil {
+		r.client = newGitHubClient()
+	}
+	if isURL(source) {
+		return r.client.fetchURL(source)
+	}
+	repo, p, ref, err := parseRepoRef(strings.TrimPrefix(source, githubSourcePrefix))
+	if err != nil {
+		return nil, err
+	}
+	if p == "" {
+		return nil, fmt.Errorf("%s names no file in %s", source, repo)
+	}
+	return r.client.getContents(repo, p, ref)
+}
+
+// document parses data, the config from source, into the root mapping of its
+// YAML, with the configs it extends merged in, or nil if it's empty. chain
+// is the configs that extend it, to catch cycles.
+//
+// Each base is merged over the one before it, and the config over them all:
+// mappings merge key by key, lists are the base's items followed by the
+// config's, and anything else, or a null, replaces the base's value. Two
+// exceptions: codeowners replaces the base's sources rather than adding to
+// them, and a directory with the same name as one of the base's replaces it.
+func (r *configReader) document(data []byte, source string, chain []string) (*yaml.Node, error) {
+	data, err := toYAML(data, sourceExt(source))
+	if err != nil {
+		return nil, err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if !laxConfig {
+		if err := checkUnknownKeys(&doc, isYAML(source)); err != nil {
+			return nil, err
+		}
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return root, nil
+	}
+	extends, err := takeExtends(root)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only a config in the working tree may read the environment: one
+	// from anywhere else could send $GITHUB_TOKEN to a server of its
+	// choosing in a codeowners or extends URL.
+	local := r.origin == localConfig && !isURL(source) && !isGitHubSource(source)
+	if local {
+		if err := expandConfigPaths(root); err != nil {
+			return nil, err
+		}
+	}
+
+	var merged *yaml.Node
+	for _, e := range extends {
+		expanded := e
+		if local {
+			if expanded, err = expandVars(e); err != nil {
+				return nil, fmt.Errorf("extends %s: %w", e, err)
+			}
+		}
+		base := resolveExtends(source, expanded)
+		if slices.Contains(chain, base) {
+			return nil, fmt.Errorf("extends %s: cycle: %s", e, strings.Join(append(chain, base), " -> "))
+		}
+		data, err := r.read(base)
+		if err != nil {
+			return nil, fmt.Errorf("extends %s: %w", e, err)
+		}
+		r.loaded = append(r.loaded, data...)
+		node, err := r.document(data, base, append(slices.Clone(chain), base))
+		if err != nil {
+			return nil, fmt.Errorf("extends %s: %w", e, err)
+		}
+		if node == nil {
+			continue
+		}
+		// Report the base's own type errors while their lines still
+		// point into it.
+		if err := node.Decode(&config{}); err != nil {
+			return nil, fmt.Errorf("extends %s: %w", e, err)
+		}
+		clearLines(node)
+		logger.Info("loaded base config", "source", base)
+		merged = mergeConfigNodes(merged, node, "")
+	}
+	return mergeConfigNodes(merged, root, ""), nil
+}
+
+// takeExtends removes the extends key from the config mapping root and
+// returns the configs it names.
+func takeExtends(root *yaml.Node) (stringList, error) {
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value != "extends" {
+			continue
+		}
+		var extends stringList
+		if err := root.Content[i+1].Decode(&extends); err != nil {
+			return nil, err
+		}
+		root.Content = slices.Delete(root.Content, i, i+2)
+		return extends, nil
+	}
+	return nil, nil
+}
+
+// resolveExtends returns where the base a config at from extends is. A
+// relative path is relative to from, whether that's a local file, a URL, or
+// a file in a repository.
+func resolveExtends(from, base string) string {
+	switch {
+	case isURL(base), isGitHubSource(base), filepath.IsAbs(base):
+		return base
+	case isURL(from):
+		u, err := url.Parse(from)
+		if err != nil {
+			return base
+		}
+		ref, err := url.Parse(base)
+		if err != nil {
+			return base
+		}
+		return u.ResolveReference(ref).String()
+	case isGitHubSource(from):
+		repo, p, gitRef, err := parseRepoRef(strings.TrimPrefix(from, githubSourcePrefix))
+		if err != nil {
+			return base
+		}
+		resolved := githubSourcePrefix + repo + "/" + path.Join(path.Dir(p), filepath.ToSlash(base))
+		if gitRef != "" {
+			resolved += "@" + gitRef
+		}
+		return resolved
+	}
+	return filepath.Join(filepath.Dir(from), base)
+}
+
+// sourceExt returns the extension of the file source names, leaving out a
+// URL's query and a github: reference's ref.
+func sourceExt(source string) string {
+	switch {
+	case isURL(source):
+		if u, err := url.Parse(source); err == nil {
+			return path.Ext(u.Path)
+		}
+	case isGitHubSource(source):
+		if _, p, _, err := parseRepoRef(strings.TrimPrefix(source, githubSourcePrefix)); err == nil {
+			return path.Ext(p)
+		}
+	}
+	return filepath.Ext(source)
+}
+
+// mergeConfigNodes merges over, a value at key path at in a config, into
+// base, as document describes.
+func mergeConfigNodes(base, over *yaml.Node, at string) *yaml.Node {
+	if base == nil {
+		return over
+	}
+	if over.Tag == "!!null" {
+		return over
+	}
+	if over.Kind == yaml.SequenceNode && base.Kind == yaml.ScalarNode && base.Tag != "!!null" {
+		base = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Content: []*yaml.Node{base}}
+	}
+	if base.Kind == yaml.SequenceNode && over.Kind == yaml.ScalarNode {
+		over = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Content: []*yaml.Node{over}}
+	}
+
+	switch {
+	case base.Kind == yaml.MappingNode && over.Kind == yaml.MappingNode:
+		merged := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: slices.Clone(base.Content)}
+		for i := 0; i+1 < len(over.Content); i += 2 {
+			key, value := over.Content[i], over.Content[i+1]
+			if j := mappingIndex(merged, key.Value); j >= 0 {
+				merged.Content[j+1] = mergeConfigNodes(merged.Content[j+1], value, joinKey(at, key.Value))
+			} else {
+				merged.Content = append(merged.Content, key, value)
+			}
+		}
+		return merged
+	case base.Kind == yaml.SequenceNode && over.Kind == yaml.SequenceNode && at != "codeowners":
+		merged := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Content: slices.Clone(base.Content)}
+		for _, item := range over.Content {
+			if at == "directories" {
+				if i := namedIndex(merged.Content, item); i >= 0 {
+					merged.Content[i] = item
+					continue
+				}
+			}
+			merged.Content = append(merged.Content, item)
+		}
+		return merged
+	}
+	return over
+}
+
+// mappingIndex returns the index of key in mapping's content, or -1.
+func mappingIndex(mapping *yaml.Node, key string) int {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// namedIndex returns the index of the directory in items with the same name
+// as item, or -1 if item has no name or none matches.
+func namedIndex(items []*yaml.Node, item *yaml.Node) int {
+	name := func(n *yaml.Node) string {
+		if n.Kind != yaml.MappingNode {
+			return ""
+		}
+		if i := mappingIndex(n, "name"); i >= 0 {
+			return n.Content[i+1].Value
+		}
+		return ""
+	}
+	want := name(item)
+	if want == "" {
+		return -1
+	}
+	for i, n := range items {
+		if name(n) == want {
+			return i
+		}
+	}
+	return -1
+}
+
+// clearLines zeroes the line numbers in node, which came from a base config,
+// so they aren't taken for lines in the config extending it.
+func clearLines(node *yaml.Node) {
+	node.Line, node.Column = 0, 0
+	for _, n := range node.Content {
+		clearLines(n)
+	}
+}
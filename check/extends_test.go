@@ -0,0 +1,230 @@
+package check
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExtendsMerge(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"shared/base.yml": `extends: common.yml
+symlinks: follow
+prune_covered: true
+codeowners: .github/CODEOWNERS
+defaults:
+  excludes: [vendor]
+  min_owners: 2
+directories:
+  - name: services
+    path: services
+    level: 1
+  - path: libs
+policy:
+  allowed_owners: ["@org/platform"]
+  forbid_user_owners: true
+hooks: ./base-hook.sh
+`,
+		"shared/common.yml": `global_excludes: [generated]
+aliases:
+  platform: "@org/platform"
+`,
+		"repo/.requirecodeowners.yml": `extends: ../shared/base.yml
+codeowners: CODEOWNERS
+prune_covered: null
+defaults:
+  excludes: [third_party]
+directories:
+  - name: services
+    path: services
+    level: 2
+  - path: apps
+policy:
+  allowed_owners: ["@org/apps"]
+hooks: [./repo-hook.sh]
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg, err := loadConfig(filepath.Join(tmpDir, "repo/.requirecodeowners.yml"))
+	if err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+	var dirs []string
+	for _, d := range cfg.Directories {
+		dirs = append(dirs, d.Path)
+	}
+	if want := []string{"services", "libs", "apps"}; !reflect.DeepEqual(dirs, want) {
+		t.Errorf("directories = %v, want %v", dirs, want)
+	}
+	if cfg.Directories[0].Level != 2 {
+		t.Errorf("services level = %d, want the repo's 2", cfg.Directories[0].Level)
+	}
+	// services is the repo's own, in the base's place.
+	if cfg.Directories[0].line != 7 || cfg.Directories[1].line != 0 || cfg.Directories[2].line != 10 {
+		t.Errorf("directory lines = %d, %d, %d, want 7, 0, 10", cfg.Directories[0].line, cfg.Directories[1].line, cfg.Directories[2].line)
+	}
+	if want := []string{"vendor", "third_party"}; !reflect.DeepEqual(cfg.Defaults.Excludes, want) {
+		t.Errorf("defaults.excludes = %v, want %v", cfg.Defaults.Excludes, want)
+	}
+	if cfg.Defaults.MinOwners != 2 {
+		t.Errorf("defaults.min_owners = %d, want the base's 2", cfg.Defaults.MinOwners)
+	}
+	if want := []string{"@org/platform", "@org/apps"}; !reflect.DeepEqual(cfg.Policy.AllowedOwners, want) {
+		t.Errorf("policy.allowed_owners = %v, want %v", cfg.Policy.AllowedOwners, want)
+	}
+	if !cfg.Policy.ForbidUserOwners {
+		t.Error("policy.forbid_user_owners = false, want the base's true")
+	}
+	if want := (stringList{"CODEOWNERS"}); !reflect.DeepEqual(cfg.Codeowners, want) {
+		t.Errorf("codeowners = %v, want the repo's %v", cfg.Codeowners, want)
+	}
+	if want := (stringList{"./base-hook.sh", "./repo-hook.sh"}); !reflect.DeepEqual(cfg.Hooks, want) {
+		t.Errorf("hooks = %v, want %v", cfg.Hooks, want)
+	}
+	if cfg.Symlinks != "follow" {
+		t.Errorf("symlinks = %q, want the base's follow", cfg.Symlinks)
+	}
+	if cfg.PruneCovered {
+		t.Error("prune_covered = true, want it cleared by the repo's null")
+	}
+	if want := []string{"generated"}; !reflect.DeepEqual(cfg.GlobalExcludes, want) {
+		t.Errorf("global_excludes = %v, want %v from the base's base", cfg.GlobalExcludes, want)
+	}
+	if len(cfg.Extends) != 0 {
+		t.Errorf("extends = %v, want it resolved away", cfg.Extends)
+	}
+}
+
+func TestExtendsErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		offline bool
+		wantErr string
+	}{
+		{
+			name: "missing",
+			files: map[string]string{
+				".requirecodeowners.yml": "extends: base.yml\ndirectories:\n  - path: services\n",
+			},
+			wantErr: "extends base.yml: open ",
+		},
+		{
+			name: "cycle",
+			files: map[string]string{
+				".requirecodeowners.yml": "extends: a.yml\ndirectories:\n  - path: services\n",
+				"a.yml":                  "extends: b.yml\n",
+				"b.yml":                  "extends: a.yml\n",
+			},
+			wantErr: "extends a.yml: extends b.yml: extends a.yml: cycle: .requirecodeowners.yml -> a.yml -> b.yml -> a.yml",
+		},
+		{
+			name: "unknown key in base",
+			files: map[string]string{
+				".requirecodeowners.yml": "extends: base.yml\ndirectories:\n  - path: services\n",
+				"base.yml":               "policy:\n  max_dirs_per_ownr: 3\n",
+			},
+			wantErr: `extends base.yml: line 2: policy: unknown key "max_dirs_per_ownr"`,
+		},
+		{
+			name: "wrong type in base",
+			files: map[string]string{
+				".requirecodeowners.yml": "extends: base.yml\ndirectories:\n  - path: services\n",
+				"base.yml":               "defaults:\n  min_owners: two\n",
+			},
+			wantErr: "extends base.yml: yaml: unmarshal errors:\n  line 2:",
+		},
+		{
+			name: "offline",
+			files: map[string]string{
+				".requirecodeowners.yml": "extends: https://example.com/base.yml\ndirectories:\n  - path: services\n",
+			},
+			offline: true,
+			wantErr: "extends https://example.com/base.yml: can't fetch it with --offline",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			for name, content := range tt.files {
+				if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			oldWd, _ := os.Getwd()
+			defer os.Chdir(oldWd)
+			os.Chdir(tmpDir)
+			offline = tt.offline
+			defer func() { offline = false }()
+
+			_, err := loadConfig("")
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("loadConfig() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExtendsURL(t *testing.T) {
+	t.Setenv("RCO_ROOT", "services")
+	t.Setenv("RCO_SECRET", "hunter2")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/policies/base.yml":
+			w.Write([]byte("extends: common.yml\ncodeowners: ${RCO_SECRET}/CODEOWNERS\ndirectories:\n  - path: libs\n"))
+		case "/policies/common.yml":
+			w.Write([]byte("policy:\n  forbid_email_owners: true\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	cfg, err := parseConfig([]byte("extends: "+srv.URL+"/policies/base.yml\ndirectories:\n  - path: ${RCO_ROOT}\n"), ".requirecodeowners.yml")
+	if err != nil {
+		t.Fatalf("parseConfig() error = %v", err)
+	}
+	if len(cfg.Directories) != 2 || cfg.Directories[0].Path != "libs" || cfg.Directories[1].Path != "services" {
+		t.Errorf("directories = %v, want libs then services", cfg.Directories)
+	}
+	if !cfg.Policy.ForbidEmailOwners {
+		t.Error("policy.forbid_email_owners = false, want it from common.yml next to base.yml")
+	}
+	// Only the config in the working tree reads the environment.
+	if len(cfg.Codeowners) != 1 || cfg.Codeowners[0] != "${RCO_SECRET}/CODEOWNERS" {
+		t.Errorf("codeowners = %v, want the base's left as written", cfg.Codeowners)
+	}
+}
+
+func TestResolveExtends(t *testing.T) {
+	tests := []struct {
+		from, base string
+		want       string
+	}{
+		{".requirecodeowners.yml", "../shared/base.yml", filepath.Join("..", "shared", "base.yml")},
+		{filepath.Join("shared", "base.yml"), "common.yml", filepath.Join("shared", "common.yml")},
+		{".requirecodeowners.yml", "https://example.com/base.yml", "https://example.com/base.yml"},
+		{"https://example.com/policies/base.yml?v=1", "../common.yml", "https://example.com/common.yml"},
+		{"github:org/meta/policies/base.yml@main", "common.yml", "github:org/meta/policies/common.yml@main"},
+		{"github:org/meta/base.yml", "github:org/other/base.yml", "github:org/other/base.yml"},
+	}
+	for _, tt := range tests {
+		if got := resolveExtends(tt.from, tt.base); got != tt.want {
+			t.Errorf("resolveExtends(%q, %q) = %q, want %q", tt.from, tt.base, got, tt.want)
+		}
+	}
+}
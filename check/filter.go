@@ -0,0 +1,91 @@
+package check
+
+import (
+	"flag"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// specFilter selects which of the configured specs a check runs, so
+// different pipelines can check different parts of one config.
+type specFilter struct {
+	tags stringList
+	only stringList
+	skip stringList
+}
+
+func (f *specFilter) register(fs *flag.FlagSet) {
+	fs.Var(&f.tags, "tags", "only check specs with one of these tags, comma-separated or repeated")
+	fs.Var(&f.only, "only", "only check the specs with these names, comma-separated or repeated")
+	fs.Var(&f.skip, "skip", "don't check the specs with these names, comma-separated or repeated")
+}
+
+// apply returns the specs the filter selects. It's an error for a filter to
+// select nothing, since that's almost always a typo.
+func (f specFilter) apply(specs []dirSpec) ([]dirSpec, error) {
+	tags, only, skip := splitList(f.tags), splitList(f.only), splitList(f.skip)
+	if len(tags)+len(only)+len(skip) == 0 {
+		return specs, nil
+	}
+
+	names := make(map[string]bool)
+	for _, spec := range specs {
+		if spec.Name != "" {
+			names[spec.Name] = true
+		}
+	}
+	for _, name := range append(slices.Clone(only), skip...) {
+		if !names[name] {
+			return nil, fmt.Errorf("no spec is named %q", name)
+		}
+	}
+
+	var selected []dirSpec
+	for _, spec := range specs {
+		if spec.mandatory {
+			// The org policy's specs can't be left out.
+			selected = append(selected, spec)
+			continue
+		}
+		if len(tags) > 0 && !hasAnyTag(spec, tags) {
+			continue
+		}
+		if len(only) > 0 && !slices.Contains(only, spec.Name) {
+			continue
+		}
+		if slices.Contains(skip, spec.Name) {
+			continue
+		}
+		selected = append(selected, spec)
+	}
+	if len(selected) == 0 {
+		if len(tags) > 0 {
+			return nil, fmt.Errorf("no specs selected: none have any of the tags %s", strings.Join(tags, ", "))
+		}
+		return nil, fmt.Errorf("no specs selected")
+	}
+	return selected, nil
+}
+
+func hasAnyTag(spec dirSpec, tags []string) bool {
+	for _, tag := range tags {
+		if slices.Contains(spec.Tags, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitList flattens repeated and comma-separated flag values.
+func splitList(values []string) []string {
+	var out []string
+	for _, v := range values {
+		for _, part := range strings.Split(v, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				out = append(out, part)
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,56 @@
+package check
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSpecFilter(t *testing.T) {
+	specs := []dirSpec{
+		{Name: "web", Path: "web", Tags: []string{"frontend"}},
+		{Name: "payments", Path: "payments", Tags: []string{"backend", "critical"}},
+		{Path: "docs"},
+	}
+
+	tests := []struct {
+		name       string
+		tags       stringList
+		only, skip stringList
+		want       []string
+		wantErr    string
+	}{
+		{name: "no filter", want: []string{"web", "payments", "docs"}},
+		{name: "one tag", tags: stringList{"critical"}, want: []string{"payments"}},
+		{name: "comma-separated", tags: stringList{"frontend,critical"}, want: []string{"web", "payments"}},
+		{name: "repeated", tags: stringList{"frontend", "backend"}, want: []string{"web", "payments"}},
+		{name: "no match", tags: stringList{"mobile"}, wantErr: "none have any of the tags mobile"},
+		{name: "only", only: stringList{"payments"}, want: []string{"payments"}},
+		{name: "skip", skip: stringList{"web,payments"}, want: []string{"docs"}},
+		{name: "tags and skip", tags: stringList{"frontend,backend"}, skip: stringList{"web"}, want: []string{"payments"}},
+		{name: "only and skip", only: stringList{"web"}, skip: stringList{"web"}, wantErr: "no specs selected"},
+		{name: "unknown name", only: stringList{"paymnets"}, wantErr: `no spec is named "paymnets"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := specFilter{tags: tt.tags, only: tt.only, skip: tt.skip}.apply(specs)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("apply() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("apply() error = %v", err)
+			}
+			var paths []string
+			for _, spec := range got {
+				paths = append(paths, spec.Path)
+			}
+			if !reflect.DeepEqual(paths, tt.want) {
+				t.Errorf("apply() = %v, want %v", paths, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package check
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+func runFmt(args []string) int {
+	fs := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: requirecodeowners fmt [flags]")
+		fmt.Fprintln(fs.Output(), "Prints the changes that normalize CODEOWNERS as a diff, or makes them with --write.")
+		fs.PrintDefaults()
+	}
+	var write bool
+	var rf repoFlags
+	rf.register(fs)
+	var lf configFlags
+	lf.register(fs)
+	fs.BoolVar(&write, "write", false, "rewrite the CODEOWNERS files instead of printing a diff")
+	var gf githubFlags
+	gf.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return 2
+	}
+	if err := lf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	if err := gf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+
+	if err := rf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	if err := enterConfigRoot(rf.configPath); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	sources, provider, err := codeownersSources(rf.configPath, rf.codeowners)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	local, remote := splitRemoteSources(sources)
+	for _, source := range remote {
+		fmt.Fprintf(os.Stderr, "Skipping %s, which can't be rewritten here.\n", source)
+	}
+	rules, err := loadCodeowners(context.Background(), local, provider)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+
+	// Owners are normalized across every local file, since the rules merge.
+	canonical := ownerSpellings(rules)
+	changed := 0
+	if write && hasStdinSource(local) {
+		fmt.Fprintln(os.Stderr, "error: --write can't rewrite CODEOWNERS read from stdin")
+		return 2
+	}
+	for _, path := range local {
+		old, err := readCodeownersFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return 1
+		}
+		updated := normalizeOwners(old, canonical)
+		if string(updated) == string(old) {
+			continue
+		}
+		changed++
+		if write {
+			err = os.WriteFile(path, updated, 0o644)
+		} else {
+			err = writeUnifiedDiff(os.Stdout, filepath.ToSlash(sourceLabel(path)), old, updated)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return 1
+		}
+		if write {
+			fmt.Fprintf(os.Stderr, "Formatted %s.\n", path)
+		}
+	}
+	// Without --write, unformatted files fail, so fmt can run as a check.
+	if changed > 0 && !write {
+		return 1
+	}
+	return 0
+}
+
+// codeownersSources returns the CODEOWNERS sources for commands that work on
+// CODEOWNERS alone, and the provider whose syntax they're in: paths if
+// given, or else the config's, or else the file in a standard location. A
+// config is only needed to say where CODEOWNERS is, and which provider's
+// syntax it's in, so without one it's GitHub's.
+func codeownersSources(configPath string, paths []string) ([]string, string, error) {
+	if err := readStdinSources(os.Stdin, configPath, paths); err != nil {
+		return nil, "", err
+	}
+	provider := providerGitHub
+	if _, err := os.Stat(resolveConfigPath(configPath)); err == nil || configPath != "" {
+		cfg, err := loadConfig(configPath)
+		if err != nil {
+			return nil, "", err
+		}
+		provider = cfg.Provider
+		if len(paths) == 0 {
+			paths = cfg.Codeowners
+		}
+	}
+	if len(paths) > 0 {
+		return paths, provider, nil
+	}
+	path, err := findCodeowners()
+	if err != nil {
+		return nil, "", err
+	}
+	return []string{path}, provider, nil
+}
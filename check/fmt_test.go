@@ -0,0 +1,43 @@
+package check
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestCodeownersSources(t *testing.T) {
+	tests := []struct {
+		name         string
+		paths        []string
+		wantSources  string
+		wantProvider string
+	}{
+		{"from the config", nil, "OWNERS", providerGitLab},
+		// The config still decides the syntax when the flag names the file.
+		{"from the flag", []string{"OWNERS"}, "OWNERS", providerGitLab},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gitRepo(t, map[string]string{
+				".requirecodeowners.yml": "provider: gitlab\ncodeowners: OWNERS\ndirectories:\n  - path: services\n",
+				"OWNERS":                 "[Services]\n/services/a/ @org/a\n",
+			})
+			sources, provider, err := codeownersSources("", tt.paths)
+			if err != nil {
+				t.Fatalf("codeownersSources() error = %v", err)
+			}
+			if got := strings.Join(sources, ","); got != tt.wantSources || provider != tt.wantProvider {
+				t.Errorf("codeownersSources() = %s, %s, want %s, %s", got, provider, tt.wantSources, tt.wantProvider)
+			}
+			if _, err := loadCodeowners(context.Background(), sources, provider); err != nil {
+				t.Errorf("loadCodeowners() error = %v", err)
+			}
+		})
+	}
+
+	gitRepo(t, map[string]string{"CODEOWNERS": "* @org/all\n"})
+	if _, provider, err := codeownersSources("", nil); err != nil || provider != providerGitHub {
+		t.Errorf("codeownersSources() without a config = %s, %v, want %s", provider, err, providerGitHub)
+	}
+}
@@ -0,0 +1,385 @@
+package check
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// fileSystem is the view of a repository that directory checks run against:
+// the local disk, or a tree fetched from a remote repository.
+type fileSystem interface {
+	Stat(name string) (fs.FileInfo, error)
+	ReadDir(name string) ([]fs.DirEntry, error)
+	Glob(pattern string) ([]string, error)
+	ReadFile(name string) ([]byte, error)
+}
+
+// localFS reads the local disk, relative to the working directory.
+type localFS struct{}
+
+func (localFS) Stat(name string) (fs.FileInfo, error)      { return os.Stat(name) }
+func (localFS) ReadDir(name string) ([]fs.DirEntry, error) { return os.ReadDir(name) }
+func (localFS) Glob(pattern string) ([]string, error)      { return filepath.Glob(pattern) }
+func (localFS) ReadFile(name string) ([]byte, error)       { return os.ReadFile(name) }
+
+// traversalSettings control which directories levels and globs expand to.
+// They're set from traversalFlags.
+type traversalSettings struct {
+	gitignore bool
+
+	// sparse skips paths that git's sparse-checkout left out of the working
+	// tree, where they'd otherwise be missing.
+	sparse bool
+}
+
+var traversal = traversalSettings{gitignore: true}
+
+// traversalFlags are the directory expansion flags shared by every command
+// that walks the local repository.
+type traversalFlags struct {
+	noGitignore bool
+	sparseAware bool
+}
+
+func (f *traversalFlags) register(fs *flag.FlagSet) {
+	fs.BoolVar(&f.noGitignore, "no-gitignore", false, "expand levels and globs into directories that git ignores")
+	fs.BoolVar(&f.sparseAware, "sparse-aware", false, "skip configured directories that git's sparse-checkout left out, instead of failing them as missing")
+}
+
+func (f *traversalFlags) apply() error {
+	traversal = traversalSettings{gitignore: !f.noGitignore, sparse: f.sparseAware}
+	return nil
+}
+
+// newLocalFS returns the local repository as traversalSettings say it should
+// be walked.
+func newLocalFS() fileSystem {
+	if traversal.gitignore {
+		return newGitignoreFS(localFS{}, os.ReadFile)
+	}
+	return localFS{}
+}
+
+// excludeFS hides directories matching the exclude patterns, and everything
+// inside them, from ReadDir and Glob. Patterns work as in isExcluded. Stat is
+// unchanged, so a directory named in the config is still checked.
+type excludeFS struct {
+	fileSystem
+	excludes []string
+}
+
+// withExcludes wraps fsys in an excludeFS, or returns it as is if there is
+// nothing to exclude.
+func withExcludes(fsys fileSystem, excludes []string) fileSystem {
+	if len(excludes) == 0 {
+		return fsys
+	}
+	return excludeFS{fileSystem: fsys, excludes: excludes}
+}
+
+func (e excludeFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := e.fileSystem.ReadDir(name)
+	if err != nil {
+		return nil, err
+	}
+	kept := entries[:0:0]
+	for _, entry := range entries {
+		if !entry.IsDir() || !isExcluded(filepath.Join(name, entry.Name()), e.excludes) {
+			kept = append(kept, entry)
+		}
+	}
+	return kept, nil
+}
+
+func (e excludeFS) Glob(pattern string) ([]string, error) {
+	matches, err := e.fileSystem.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	kept := matches[:0:0]
+	for _, m := range matches {
+		if !e.excludedPath(pattern, m) {
+			kept = append(kept, m)
+		}
+	}
+	return kept, nil
+}
+
+// excludedPath reports whether a wildcard in pattern expanded to an excluded
+// directory on the way to match.
+func (e excludeFS) excludedPath(pattern, match string) bool {
+	for _, d := range expandedPrefixes(pattern, match) {
+		if isExcluded(d, e.excludes) {
+			return true
+		}
+	}
+	return false
+}
+
+// expandedPrefixes returns the leading parts of match that a wildcard in
+// pattern expanded to, shortest first. Parts written literally in the pattern
+// aren't included: a directory the config names is used as is.
+func expandedPrefixes(pattern, match string) []string {
+	parts := strings.Split(filepath.ToSlash(filepath.Clean(pattern)), "/")
+	names := strings.Split(filepath.ToSlash(filepath.Clean(match)), "/")
+	if len(names) != len(parts) {
+		return nil
+	}
+	var prefixes []string
+	for i := range names {
+		if strings.ContainsAny(parts[i], `*?[\`) {
+			prefixes = append(prefixes, path.Join(names[:i+1]...))
+		}
+	}
+	return prefixes
+}
+
+// specFS returns fsys as spec expands it, without dot-directories if the
+// spec leaves them out, and with its submodule mode applied.
+func specFS(fsys fileSystem, spec dirSpec) fileSystem {
+	if !spec.includesHidden() {
+		fsys = withExcludes(fsys, []string{".*"})
+	}
+	if mode := spec.submoduleMode(); mode != submodulesDescend {
+		fsys = &submoduleFS{fileSystem: fsys, mode: mode}
+	}
+	return fsys
+}
+
+// Symlink modes say what expanding levels and globs does with a symlink to a
+// directory.
+const (
+	symlinksSkip   = "skip"   // leave it out
+	symlinksFollow = "follow" // expand into it, unless it loops back on itself
+	symlinksFail   = "fail"   // report it as an error
+)
+
+// symlinkFS applies a symlink mode to the directories the local disk lists,
+// so the result doesn't depend on how the platform reports links. Only
+// symlinks that expansion finds are affected: a path named literally in the
+// config is used as is.
+type symlinkFS struct {
+	fileSystem
+	mode string
+}
+
+// withSymlinks wraps fsys to handle symlinked directories as mode says.
+func withSymlinks(fsys fileSystem, mode string) fileSystem {
+	if mode == "" {
+		mode = symlinksSkip
+	}
+	return symlinkFS{fileSystem: fsys, mode: mode}
+}
+
+// symlinkError reports a symlinked directory that the fail mode doesn't allow.
+type symlinkError struct {
+	path string
+}
+
+func (e *symlinkError) Error() string {
+	return fmt.Sprintf("%s is a symlink to a directory, which symlinks: %s doesn't allow", e.path, symlinksFail)
+}
+
+func (s symlinkFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := s.fileSystem.ReadDir(name)
+	if err != nil {
+		return nil, err
+	}
+	kept := entries[:0:0]
+	for _, e := range entries {
+		if e.Type()&fs.ModeSymlink == 0 {
+			kept = append(kept, e)
+			continue
+		}
+		p := path.Join(filepath.ToSlash(name), e.Name())
+		info, err := s.Stat(p)
+		if err != nil || !info.IsDir() {
+			kept = append(kept, e)
+			continue
+		}
+		switch s.mode {
+		case symlinksFail:
+			return nil, &symlinkError{path: p}
+		case symlinksFollow:
+			if loops(p, name) {
+				logger.Warn("not following symlink that loops", "path", p)
+				continue
+			}
+			kept = append(kept, fs.FileInfoToDirEntry(info))
+		default:
+			logger.Debug("skipped symlink", "path", p)
+		}
+	}
+	return kept, nil
+}
+
+func (s symlinkFS) Glob(pattern string) ([]string, error) {
+	matches, err := s.fileSystem.Glob(pattern)
+	if err != nil || s.mode == symlinksFollow {
+		return matches, err
+	}
+	kept := matches[:0:0]
+	for _, m := range matches {
+		link, err := s.expandedSymlink(pattern, m)
+		if err != nil {
+			return nil, err
+		}
+		if link != "" {
+			if s.mode == symlinksFail {
+				return nil, &symlinkError{path: link}
+			}
+			logger.Debug("skipped symlink", "path", link)
+			continue
+		}
+		kept = append(kept, m)
+	}
+	return kept, nil
+}
+
+// expandedSymlink returns the first symlinked directory that a wildcard in
+// pattern expanded to on the way to match, or "".
+func (s symlinkFS) expandedSymlink(pattern, match string) (string, error) {
+	for _, p := range expandedPrefixes(pattern, match) {
+		info, err := os.Lstat(p)
+		if err != nil {
+			return "", err
+		}
+		if info.Mode()&fs.ModeSymlink == 0 {
+			continue
+		}
+		if target, err := s.Stat(p); err == nil && target.IsDir() {
+			return p, nil
+		}
+	}
+	return "", nil
+}
+
+// loops reports whether the symlink link, found in dir, points at dir or
+// one of its parents, so following it would never end.
+func loops(link, dir string) bool {
+	target, err := filepath.EvalSymlinks(link)
+	if err != nil {
+		return true
+	}
+	resolved, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return true
+	}
+	return resolved == target || strings.HasPrefix(resolved, target+string(filepath.Separator))
+}
+
+// treeFS is a directory listing built from the paths in a git tree, used to
+// check repositories that aren't on local disk. It knows names and types
+// only, not file contents.
+type treeFS struct {
+	entries map[string][]fs.DirEntry
+	kinds   map[string]bool // path -> is a directory
+}
+
+// newTreeFS builds a treeFS from slash-separated paths, each marked as a
+// directory or not. Parent directories are implied.
+func newTreeFS(paths map[string]bool) *treeFS {
+	t := &treeFS{
+		entries: map[string][]fs.DirEntry{".": nil},
+		kinds:   map[string]bool{".": true},
+	}
+	for p, isDir := range paths {
+		t.add(p, isDir)
+	}
+	for _, children := range t.entries {
+		sort.Slice(children, func(i, j int) bool { return children[i].Name() < children[j].Name() })
+	}
+	return t
+}
+
+func (t *treeFS) add(p string, isDir bool) {
+	p = path.Clean(p)
+	if _, ok := t.kinds[p]; ok || p == "/" {
+		return
+	}
+	t.kinds[p] = isDir
+	parent := path.Dir(p)
+	t.add(parent, true)
+	t.entries[parent] = append(t.entries[parent], treeEntry{name: path.Base(p), dir: isDir})
+}
+
+func (t *treeFS) Open(name string) (fs.File, error) {
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+}
+
+func (t *treeFS) Stat(name string) (fs.FileInfo, error) {
+	name = cleanTreePath(name)
+	isDir, ok := t.kinds[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+	return treeEntry{name: path.Base(name), dir: isDir}, nil
+}
+
+func (t *treeFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	name = cleanTreePath(name)
+	isDir, ok := t.kinds[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	if !isDir {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+	return t.entries[name], nil
+}
+
+// ReadFile always fails: a treeFS has no file contents.
+func (t *treeFS) ReadFile(name string) ([]byte, error) {
+	return nil, &fs.PathError{Op: "read", Path: name, Err: errors.ErrUnsupported}
+}
+
+func (t *treeFS) Glob(pattern string) ([]string, error) {
+	return fs.Glob(treeGlobFS{t}, cleanTreePath(pattern))
+}
+
+// treeGlobFS exposes a treeFS to fs.Glob without its Glob method, which
+// fs.Glob would otherwise call straight back into.
+type treeGlobFS struct{ t *treeFS }
+
+func (g treeGlobFS) Open(name string) (fs.File, error)          { return g.t.Open(name) }
+func (g treeGlobFS) Stat(name string) (fs.FileInfo, error)      { return g.t.Stat(name) }
+func (g treeGlobFS) ReadDir(name string) ([]fs.DirEntry, error) { return g.t.ReadDir(name) }
+
+func cleanTreePath(name string) string {
+	return strings.TrimPrefix(path.Clean(filepath.ToSlash(name)), "/")
+}
+
+// treeEntry is both the fs.DirEntry and fs.FileInfo for a treeFS path.
+type treeEntry struct {
+	name string
+	dir  bool
+}
+
+func (e treeEntry) Name() string               { return e.name }
+func (e treeEntry) IsDir() bool                { return e.dir }
+func (e treeEntry) Info() (fs.FileInfo, error) { return e, nil }
+func (e treeEntry) Size() int64                { return 0 }
+func (e treeEntry) ModTime() time.Time         { return time.Time{} }
+func (e treeEntry) Sys() any                   { return nil }
+
+func (e treeEntry) Type() fs.FileMode {
+	if e.dir {
+		return fs.ModeDir
+	}
+	return 0
+}
+
+func (e treeEntry) Mode() fs.FileMode {
+	if e.dir {
+		return fs.ModeDir | 0o555
+	}
+	return 0o444
+}
@@ -0,0 +1,206 @@
+package check
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hmarr/codeowners"
+)
+
+func TestTreeFS(t *testing.T) {
+	tree := newTreeFS(map[string]bool{
+		"README.md":                 false,
+		"services/api/main.go":      false,
+		"services/web":              true,
+		"apps/a/services/foo/x.txt": false,
+	})
+
+	tests := []struct {
+		name  string
+		fn    func() ([]string, error)
+		want  []string
+		isErr bool
+	}{
+		{
+			name: "glob literal",
+			fn:   func() ([]string, error) { return tree.Glob("services") },
+			want: []string{"services"},
+		},
+		{
+			name: "glob wildcard",
+			fn:   func() ([]string, error) { return tree.Glob("apps/*/services") },
+			want: []string{"apps/a/services"},
+		},
+		{
+			name: "read dir lists implied parents",
+			fn: func() ([]string, error) {
+				entries, err := tree.ReadDir("services")
+				var names []string
+				for _, e := range entries {
+					names = append(names, e.Name())
+				}
+				return names, err
+			},
+			want: []string{"api", "web"},
+		},
+		{
+			name:  "read dir of file",
+			fn:    func() ([]string, error) { _, err := tree.ReadDir("README.md"); return nil, err },
+			isErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.fn()
+			if (err != nil) != tt.isErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.isErr)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if info, err := tree.Stat("services/api/main.go"); err != nil || info.IsDir() {
+		t.Errorf("Stat(file) = %v, %v; want a file", info, err)
+	}
+	if _, err := tree.Stat("missing"); err == nil {
+		t.Error("Stat(missing) expected error")
+	}
+}
+
+func TestValidateTreeFS(t *testing.T) {
+	tree := newTreeFS(map[string]bool{
+		"services/foo/main.go": false,
+		"services/bar/main.go": false,
+	})
+	parsed, err := codeowners.ParseFile(strings.NewReader("/services/foo/ @team-foo\n"))
+	if err != nil {
+		t.Fatalf("parsing CODEOWNERS: %v", err)
+	}
+
+	c := &checker{fsys: tree, rules: newRuleset(parsed, "CODEOWNERS"), configPath: ".requirecodeowners.yml"}
+	res := c.validate([]dirSpec{{Path: "services", Level: 1}})
+	if len(res.errors) != 1 || res.errors[0].path != "services/bar" {
+		t.Errorf("validate() errors = %v, want services/bar uncovered", res.errors)
+	}
+	if owned, total := res.coverage(); owned != 1 || total != 2 {
+		t.Errorf("coverage() = %d/%d, want 1/2", owned, total)
+	}
+}
+
+func TestExcludeFS(t *testing.T) {
+	tree := newTreeFS(map[string]bool{
+		"services/api/main.go":               false,
+		"services/api/vendor/lib/x.go":       false,
+		"services/web/node_modules/pkg/a.js": false,
+		"services/web/dist/out.js":           false,
+		"vendor/github.com/x/y.go":           false,
+	})
+	fsys := withExcludes(tree, []string{"vendor", "node_modules", "services/web/dist"})
+
+	dirs, err := getDirsAtLevel(fsys, "services", 2)
+	if err != nil {
+		t.Fatalf("getDirsAtLevel() error = %v", err)
+	}
+	if len(dirs) != 0 {
+		t.Errorf("getDirsAtLevel() = %v, want no directories", dirs)
+	}
+
+	dirs, err = expandPath(fsys, "*")
+	if err != nil {
+		t.Fatalf("expandPath() error = %v", err)
+	}
+	if strings.Join(dirs, ",") != "services" {
+		t.Errorf("expandPath(*) = %v, want [services]", dirs)
+	}
+	dirs, _ = expandPath(fsys, "*/*/*")
+	if len(dirs) != 0 {
+		t.Errorf("expandPath(*/*/*) = %v, want every match excluded", dirs)
+	}
+	// A directory the config names literally is still used.
+	dirs, _ = expandPath(fsys, "services/*/vendor")
+	if strings.Join(dirs, ",") != "services/api/vendor" {
+		t.Errorf("expandPath(services/*/vendor) = %v, want [services/api/vendor]", dirs)
+	}
+
+	if fsys := withExcludes(tree, nil); fsys != fileSystem(tree) {
+		t.Errorf("withExcludes() with no excludes should return the file system as is")
+	}
+}
+
+func TestSymlinkFS(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	os.MkdirAll(filepath.Join(tmpDir, "services", "api"), 0755)
+	os.MkdirAll(filepath.Join(tmpDir, "shared", "lib"), 0755)
+	if err := os.Symlink(filepath.Join("..", "shared"), filepath.Join(tmpDir, "services", "shared")); err != nil {
+		t.Skipf("creating symlink: %v", err)
+	}
+	// A link back to its own parent would be followed forever.
+	os.Symlink(".", filepath.Join(tmpDir, "shared", "lib", "self"))
+
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	tests := []struct {
+		mode     string
+		wantDirs string
+		wantGlob string
+		wantErr  bool
+	}{
+		{mode: "", wantDirs: "services/api", wantGlob: ""},
+		{mode: symlinksSkip, wantDirs: "services/api", wantGlob: ""},
+		{mode: symlinksFollow, wantDirs: "services/api,services/shared", wantGlob: "services/shared/lib"},
+		{mode: symlinksFail, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			fsys := withSymlinks(localFS{}, tt.mode)
+
+			dirs, err := getDirsAtLevel(fsys, "services", 1)
+			var symErr *symlinkError
+			if tt.wantErr {
+				if !errors.As(err, &symErr) {
+					t.Fatalf("getDirsAtLevel() error = %v, want a symlink error", err)
+				}
+				if _, err := expandPath(fsys, "services/*/lib"); !errors.As(err, &symErr) {
+					t.Fatalf("expandPath() error = %v, want a symlink error", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("getDirsAtLevel() error = %v", err)
+			}
+			if got := strings.Join(dirs, ","); got != tt.wantDirs {
+				t.Errorf("getDirsAtLevel() = %v, want %v", got, tt.wantDirs)
+			}
+
+			dirs, err = expandPath(fsys, "services/*/lib")
+			if err != nil {
+				t.Fatalf("expandPath() error = %v", err)
+			}
+			if got := strings.Join(dirs, ","); got != tt.wantGlob {
+				t.Errorf("expandPath() = %v, want %v", got, tt.wantGlob)
+			}
+		})
+	}
+
+	// A symlink named literally in the config is used whatever the mode.
+	dirs, err := expandPath(withSymlinks(localFS{}, symlinksFail), "services/shared")
+	if err != nil || strings.Join(dirs, ",") != "services/shared" {
+		t.Errorf("expandPath() of a literal symlink = %v, %v", dirs, err)
+	}
+
+	// Following stops at links that loop.
+	dirs, err = getDirsAtLevel(withSymlinks(localFS{}, symlinksFollow), "shared/lib", 1)
+	if err != nil || len(dirs) != 0 {
+		t.Errorf("getDirsAtLevel() through a looping link = %v, %v", dirs, err)
+	}
+}
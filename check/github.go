@@ -0,0 +1,376 @@
+package check
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const defaultGitHubAPIURL = "https://api.github.com"
+
+// githubClient is a minimal client for the parts of the GitHub REST API this
+// tool uses.
+type githubClient struct {
+	baseURL string
+	token   string
+	http    *http.Client
+	ctx     context.Context // cancels requests in flight; nil for none
+}
+
+// offline is set by --offline. Checks that need the network are skipped
+// with a warning instead of run.
+var offline bool
+
+// githubFlags are the network settings shared by every command that can
+// fetch anything remote: where GitHub is, for GitHub Enterprise Server
+// installations, how to trust it, and whether to go online at all.
+type githubFlags struct {
+	apiURL   string
+	caCert   string
+	insecure bool
+	offline  bool
+}
+
+// githubSettings holds the connection settings from the command line.
+var githubSettings struct {
+	apiURL    string
+	tlsConfig *tls.Config
+}
+
+func (f *githubFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.apiURL, "github-api-url", "", "GitHub API URL, e.g. https://ghes.example.com/api/v3 (default: $GITHUB_API_URL or "+defaultGitHubAPIURL+")")
+	fs.StringVar(&f.caCert, "github-ca-cert", "", "PEM file of CA certificates to trust for GitHub, in addition to the system roots")
+	fs.BoolVar(&f.insecure, "github-insecure-skip-verify", false, "don't verify GitHub's TLS certificate (for testing only)")
+	fs.BoolVar(&f.offline, "offline", false, "skip everything that needs the network, reporting it as a warning")
+}
+
+// apply validates the flags and sets the connection settings from them.
+func (f *githubFlags) apply() error {
+	githubSettings.apiURL = f.apiURL
+	offline = f.offline
+	githubSettings.tlsConfig = nil
+	if f.caCert == "" && !f.insecure {
+		return nil
+	}
+
+	cfg := &tls.Config{InsecureSkipVerify: f.insecure}
+	if f.caCert != "" {
+		pem, err := os.ReadFile(f.caCert)
+		if err != nil {
+			return fmt.Errorf("reading --github-ca-cert: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("--github-ca-cert %s has no PEM certificates", f.caCert)
+		}
+		cfg.RootCAs = pool
+	}
+	githubSettings.tlsConfig = cfg
+	return nil
+}
+
+// newGitHubClient returns a client authenticated with GITHUB_TOKEN, if set,
+// against --github-api-url, GITHUB_API_URL, or the public API, in that order.
+func newGitHubClient() *githubClient {
+	baseURL := githubSettings.apiURL
+	if baseURL == "" {
+		baseURL = os.Getenv("GITHUB_API_URL")
+	}
+	if baseURL == "" {
+		baseURL = defaultGitHubAPIURL
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	if githubSettings.tlsConfig != nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = githubSettings.tlsConfig
+		client.Transport = transport
+	}
+	return &githubClient{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		token:   os.Getenv("GITHUB_TOKEN"),
+		http:    client,
+	}
+}
+
+// withContext returns a copy of c whose requests are canceled when ctx is
+// done.
+func (c *githubClient) withContext(ctx context.Context) *githubClient {
+	cc := *c
+	cc.ctx = ctx
+	return &cc
+}
+
+// getContents returns the raw contents of path in repo ("owner/name") at ref.
+// An empty ref means the repository's default branch.
+func (c *githubClient) getContents(repo, path, ref string) ([]byte, error) {
+	u := fmt.Sprintf("/repos/%s/contents/%s", repo, strings.TrimPrefix(path, "/"))
+	if ref != "" {
+		u += "?ref=" + url.QueryEscape(ref)
+	}
+	return c.get(u, "application/vnd.github.raw+json")
+}
+
+// githubRepo is the subset of a repository's API representation used here.
+type githubRepo struct {
+	FullName      string `json:"full_name"`
+	HTMLURL       string `json:"html_url"`
+	DefaultBranch string `json:"default_branch"`
+	Archived      bool   `json:"archived"`
+}
+
+// listOrgRepos returns every repository in org, following pagination.
+func (c *githubClient) listOrgRepos(org string) ([]githubRepo, error) {
+	const perPage = 100
+	var all []githubRepo
+	for page := 1; ; page++ {
+		var repos []githubRepo
+		u := fmt.Sprintf("/orgs/%s/repos?per_page=%d&page=%d", url.PathEscape(org), perPage, page)
+		if err := c.getJSON(u, &repos); err != nil {
+			return nil, err
+		}
+		all = append(all, repos...)
+		if len(repos) < perPage {
+			return all, nil
+		}
+	}
+}
+
+// getTree returns every path in repo at ref, mapped to whether it is a
+// directory. Submodules count as directories, with a .git file in them as a
+// checked-out submodule has, so they're recognized as submodules. truncated
+// reports that the API cut the listing short, which happens for very large
+// repositories.
+func (c *githubClient) getTree(repo, ref string) (paths map[string]bool, truncated bool, err error) {
+	var tree struct {
+		Tree []struct {
+			Path string `json:"path"`
+			Type string `json:"type"`
+		} `json:"tree"`
+		Truncated bool `json:"truncated"`
+	}
+	u := fmt.Sprintf("/repos/%s/git/trees/%s?recursive=1", repo, url.PathEscape(ref))
+	if err := c.getJSON(u, &tree); err != nil {
+		return nil, false, err
+	}
+
+	paths = make(map[string]bool, len(tree.Tree))
+	for _, entry := range tree.Tree {
+		paths[entry.Path] = entry.Type == "tree" || entry.Type == "commit"
+		if entry.Type == "commit" {
+			paths[entry.Path+"/.git"] = false
+		}
+	}
+	return paths, tree.Truncated, nil
+}
+
+// countTeamMembers returns the number of members of org's team slug,
+// counting no further than limit so large teams don't page through every
+// member.
+func (c *githubClient) countTeamMembers(org, slug string, limit int) (int, error) {
+	const perPage = 100
+	count := 0
+	for page := 1; ; page++ {
+		var members []struct {
+			Login string `json:"login"`
+		}
+		u := fmt.Sprintf("/orgs/%s/teams/%s/members?per_page=%d&page=%d", url.PathEscape(org), url.PathEscape(slug), perPage, page)
+		if err := c.getJSON(u, &members); err != nil {
+			return 0, err
+		}
+		count += len(members)
+		if count >= limit || len(members) < perPage {
+			return count, nil
+		}
+	}
+}
+
+// userExists reports whether login is a GitHub user or organization.
+func (c *githubClient) userExists(login string) (bool, error) {
+	var user struct {
+		Login string `json:"login"`
+	}
+	err := c.getJSON("/users/"+url.PathEscape(login), &user)
+	if isNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// githubIssue is the subset of an issue's API representation used here.
+type githubIssue struct {
+	Number  int    `json:"number"`
+	Title   string `json:"title"`
+	Body    string `json:"body"`
+	State   string `json:"state"`
+	HTMLURL string `json:"html_url"`
+}
+
+// listIssues returns the open issues in repo with label, following
+// pagination. Pull requests, which the issues API also returns, are left
+// out.
+func (c *githubClient) listIssues(repo, label string) ([]githubIssue, error) {
+	const perPage = 100
+	var all []githubIssue
+	for page := 1; ; page++ {
+		var issues []struct {
+			githubIssue
+			PullRequest *struct{} `json:"pull_request"`
+		}
+		u := fmt.Sprintf("/repos/%s/issues?state=open&labels=%s&per_page=%d&page=%d", repo, url.QueryEscape(label), perPage, page)
+		if err := c.getJSON(u, &issues); err != nil {
+			return nil, err
+		}
+		for _, issue := range issues {
+			if issue.PullRequest == nil {
+				all = append(all, issue.githubIssue)
+			}
+		}
+		if len(issues) < perPage {
+			return all, nil
+		}
+	}
+}
+
+// createIssue opens an issue in repo with labels.
+func (c *githubClient) createIssue(repo, title, body string, labels []string) (githubIssue, error) {
+	var issue githubIssue
+	req := map[string]any{"title": title, "body": body, "labels": labels}
+	err := c.sendJSON(http.MethodPost, fmt.Sprintf("/repos/%s/issues", repo), req, &issue)
+	return issue, err
+}
+
+// updateIssue changes the given fields of issue number in repo, such as
+// "body" or "state".
+func (c *githubClient) updateIssue(repo string, number int, fields map[string]any) error {
+	return c.sendJSON(http.MethodPatch, fmt.Sprintf("/repos/%s/issues/%d", repo, number), fields, nil)
+}
+
+// sendJSON sends body as JSON with method, decoding the response into v
+// unless v is nil.
+func (c *githubClient) sendJSON(method, path string, body, v any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(method, c.baseURL+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.do(req, "application/vnd.github+json")
+	if err != nil || v == nil {
+		return err
+	}
+	if err := json.Unmarshal(resp, v); err != nil {
+		return fmt.Errorf("decoding %s: %w", path, err)
+	}
+	return nil
+}
+
+func (c *githubClient) getJSON(path string, v any) error {
+	body, err := c.get(path, "application/vnd.github+json")
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("decoding %s: %w", path, err)
+	}
+	return nil
+}
+
+func (c *githubClient) get(path, accept string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.do(req, accept)
+}
+
+// do sends an API request with the standard headers and credentials.
+func (c *githubClient) do(req *http.Request, accept string) ([]byte, error) {
+	req.Header.Set("Accept", accept)
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	return c.send(req)
+}
+
+// fetchURL downloads url. No credentials are sent, since the host is
+// arbitrary.
+func (c *githubClient) fetchURL(rawURL string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.send(req)
+}
+
+// send sends req under the client's context.
+func (c *githubClient) send(req *http.Request) ([]byte, error) {
+	if c.ctx != nil {
+		req = req.WithContext(c.ctx)
+	}
+	return doRequest(c.http, req)
+}
+
+func doRequest(client *http.Client, req *http.Request) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response: %w", err)
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, &statusError{
+			method: req.Method,
+			url:    req.URL.Redacted(),
+			status: resp.Status,
+			code:   resp.StatusCode,
+			rateLimited: resp.StatusCode == http.StatusTooManyRequests ||
+				(resp.StatusCode == http.StatusForbidden && resp.Header.Get("X-RateLimit-Remaining") == "0"),
+		}
+	}
+	return body, nil
+}
+
+// statusError is returned for any non-2xx response.
+type statusError struct {
+	method      string
+	url         string
+	status      string
+	code        int
+	rateLimited bool
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("%s %s: %s", e.method, e.url, e.status)
+}
+
+func isNotFound(err error) bool {
+	var se *statusError
+	return errors.As(err, &se) && se.code == http.StatusNotFound
+}
+
+func isRateLimited(err error) bool {
+	var se *statusError
+	return errors.As(err, &se) && se.rateLimited
+}
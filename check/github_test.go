@@ -0,0 +1,167 @@
+package check
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseRepoRef(t *testing.T) {
+	tests := []struct {
+		ref                string
+		wantRepo, wantPath string
+		wantGitRef         string
+		wantErr            bool
+	}{
+		{ref: "org/meta", wantRepo: "org/meta"},
+		{ref: "org/meta@main", wantRepo: "org/meta", wantGitRef: "main"},
+		{ref: "org/meta/teams/CODEOWNERS@v1.2", wantRepo: "org/meta", wantPath: "teams/CODEOWNERS", wantGitRef: "v1.2"},
+		{ref: "org", wantErr: true},
+		{ref: "/meta", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			repo, path, gitRef, err := parseRepoRef(tt.ref)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseRepoRef(%q) error = %v, wantErr %v", tt.ref, err, tt.wantErr)
+			}
+			if repo != tt.wantRepo || path != tt.wantPath || gitRef != tt.wantGitRef {
+				t.Errorf("parseRepoRef(%q) = (%q, %q, %q), want (%q, %q, %q)", tt.ref, repo, path, gitRef, tt.wantRepo, tt.wantPath, tt.wantGitRef)
+			}
+		})
+	}
+}
+
+func TestLoadCodeownersRemote(t *testing.T) {
+	var gotAuth, gotRef string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/raw/CODEOWNERS":
+			w.Write([]byte("/src/ @team-url\n"))
+		case "/repos/org/meta/contents/CODEOWNERS":
+			gotAuth = r.Header.Get("Authorization")
+			gotRef = r.URL.Query().Get("ref")
+			w.Write([]byte("/pkg/ @team-repo\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	t.Setenv("GITHUB_API_URL", srv.URL)
+	t.Setenv("GITHUB_TOKEN", "secret")
+
+	rules, err := loadCodeowners(context.Background(), []string{
+		srv.URL + "/raw/CODEOWNERS",
+		githubSourcePrefix + "org/meta@main",
+	}, providerGitHub)
+	if err != nil {
+		t.Fatalf("loadCodeowners() error = %v", err)
+	}
+	if !hasCodeownersCoverage(rules, "src") || !hasCodeownersCoverage(rules, "pkg") {
+		t.Errorf("loadCodeowners() rules = %v, want coverage for src and pkg", rules)
+	}
+	if gotAuth != "Bearer secret" {
+		t.Errorf("contents API Authorization = %q, want %q", gotAuth, "Bearer secret")
+	}
+	if gotRef != "main" {
+		t.Errorf("contents API ref = %q, want %q", gotRef, "main")
+	}
+	if want := "org/meta/CODEOWNERS@main"; rules[1].file != want {
+		t.Errorf("rule file = %q, want %q", rules[1].file, want)
+	}
+
+	if _, err := loadCodeowners(context.Background(), []string{githubSourcePrefix + "org/missing"}, providerGitHub); err == nil {
+		t.Error("loadCodeowners() expected error for repository without CODEOWNERS")
+	}
+}
+
+func TestGitHubFlags(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("* @org/team\n"))
+	}))
+	defer srv.Close()
+	defer (&githubFlags{}).apply()
+	t.Setenv("GITHUB_API_URL", "https://unused.example.com")
+
+	caPath := filepath.Join(t.TempDir(), "ca.pem")
+	os.WriteFile(caPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}), 0644)
+	notPEM := filepath.Join(t.TempDir(), "ca.txt")
+	os.WriteFile(notPEM, []byte("not a certificate"), 0644)
+
+	tests := []struct {
+		name     string
+		flags    githubFlags
+		applyErr bool
+		fetchErr bool
+	}{
+		{name: "untrusted certificate", flags: githubFlags{apiURL: srv.URL}, fetchErr: true},
+		{name: "custom CA", flags: githubFlags{apiURL: srv.URL, caCert: caPath}},
+		{name: "skip verify", flags: githubFlags{apiURL: srv.URL, insecure: true}},
+		{name: "missing CA file", flags: githubFlags{caCert: filepath.Join(t.TempDir(), "missing.pem")}, applyErr: true},
+		{name: "CA file without certificates", flags: githubFlags{caCert: notPEM}, applyErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.flags.apply()
+			if (err != nil) != tt.applyErr {
+				t.Fatalf("apply() error = %v, wantErr %v", err, tt.applyErr)
+			}
+			if tt.applyErr {
+				return
+			}
+			client := newGitHubClient()
+			if client.baseURL != srv.URL {
+				t.Errorf("baseURL = %q, want --github-api-url %q", client.baseURL, srv.URL)
+			}
+			_, err = client.getContents("org/repo", "CODEOWNERS", "")
+			if (err != nil) != tt.fetchErr {
+				t.Errorf("getContents() error = %v, wantErr %v", err, tt.fetchErr)
+			}
+		})
+	}
+}
+
+func TestCheckRepoOffline(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tmpDir, "services", "api"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, "CODEOWNERS"), []byte("/services/api/ @org/api\n"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, ".requirecodeowners.yml"), []byte(`codeowners:
+  - https://codeowners.invalid/CODEOWNERS
+  - github:org/meta@main
+  - CODEOWNERS
+directories:
+  - path: services
+    level: 1
+`), 0644)
+
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	offline = true
+	defer func() { offline = false }()
+
+	res, err := checkRepo(context.Background(), "", nil, specFilter{})
+	if err != nil {
+		t.Fatalf("checkRepo() error = %v, want remote sources skipped", err)
+	}
+	if len(res.errors) != 2 || countFailures(res.errors) != 0 {
+		t.Fatalf("checkRepo() errors = %v, want two skip warnings", res.errors)
+	}
+	for _, e := range res.errors {
+		if !strings.Contains(e.message, "--offline") {
+			t.Errorf("checkRepo() error = %q, want it to mention --offline", e.message)
+		}
+	}
+	if owned, total := res.coverage(); owned != 1 || total != 1 {
+		t.Errorf("coverage = %d/%d, want 1/1 from the local CODEOWNERS", owned, total)
+	}
+}
@@ -0,0 +1,234 @@
+package check
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// githubApp authenticates as a GitHub App, which is what the Checks API
+// requires for creating check runs.
+type githubApp struct {
+	id  string
+	key *rsa.PrivateKey
+}
+
+// loadGitHubApp reads the app's private key, in the PKCS #1 PEM form GitHub
+// generates or as PKCS #8.
+func loadGitHubApp(id, keyPath string) (*githubApp, error) {
+	data, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading app private key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("app private key %s is not PEM", keyPath)
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return &githubApp{id: id, key: key}, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing app private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("app private key %s is not an RSA key", keyPath)
+	}
+	return &githubApp{id: id, key: key}, nil
+}
+
+// jwt returns the short-lived token that authenticates as the app itself.
+// It's backdated a minute to allow for clock drift, as GitHub recommends.
+func (a *githubApp) jwt(now time.Time) (string, error) {
+	enc := base64.RawURLEncoding
+	header := enc.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	claims, err := json.Marshal(map[string]any{
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": a.id,
+	})
+	if err != nil {
+		return "", err
+	}
+	signed := header + "." + enc.EncodeToString(claims)
+	sum := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, a.key, crypto.SHA256, sum[:])
+	if err != nil {
+		return "", err
+	}
+	return signed + "." + enc.EncodeToString(sig), nil
+}
+
+// installationClient returns a client authenticated as the app's
+// installation, which can read the installation's repositories and write
+// their check runs.
+func (a *githubApp) installationClient(installationID int64) (*githubClient, error) {
+	client := newGitHubClient()
+	token, err := a.jwt(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	client.token = token
+	var resp struct {
+		Token string `json:"token"`
+	}
+	if err := client.sendJSON(http.MethodPost, fmt.Sprintf("/app/installations/%d/access_tokens", installationID), struct{}{}, &resp); err != nil {
+		return nil, fmt.Errorf("getting installation token: %w", err)
+	}
+	client.token = resp.Token
+	return client, nil
+}
+
+// webhookEvent is the part of a push or pull_request payload the app reads.
+type webhookEvent struct {
+	Action      string `json:"action"`
+	After       string `json:"after"`
+	Deleted     bool   `json:"deleted"`
+	PullRequest struct {
+		Head struct {
+			SHA string `json:"sha"`
+		} `json:"head"`
+	} `json:"pull_request"`
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+	Installation struct {
+		ID int64 `json:"id"`
+	} `json:"installation"`
+}
+
+// checkRunName is the name check runs are reported under on commits.
+const checkRunName = "CODEOWNERS"
+
+// handleWebhook verifies a GitHub webhook delivery and, for pushes and
+// pull request updates, checks the new head commit in the background and
+// reports the result as a check run. GitHub gives up on deliveries that
+// take more than a few seconds, so the response doesn't wait for the check.
+func (s *server) handleWebhook(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 25<<20))
+	if err != nil {
+		http.Error(w, "reading payload", http.StatusBadRequest)
+		return
+	}
+	if !validSignature(s.webhookSecret, body, r.Header.Get("X-Hub-Signature-256")) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	var event webhookEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		http.Error(w, "decoding payload", http.StatusBadRequest)
+		return
+	}
+	var sha string
+	switch r.Header.Get("X-GitHub-Event") {
+	case "pull_request":
+		switch event.Action {
+		case "opened", "synchronize", "reopened":
+			sha = event.PullRequest.Head.SHA
+		}
+	case "push":
+		if !event.Deleted {
+			sha = event.After
+		}
+	}
+	if sha == "" || event.Repository.FullName == "" {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	s.pending.Add(1)
+	go func() {
+		defer s.pending.Done()
+		if err := s.reportCheckRun(event.Installation.ID, event.Repository.FullName, sha); err != nil {
+			logger.Error("reporting check run", "repo", event.Repository.FullName, "sha", sha, "error", err)
+		}
+	}()
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// validSignature reports whether signature, from the X-Hub-Signature-256
+// header, is the HMAC of body with the webhook secret.
+func validSignature(secret string, body []byte, signature string) bool {
+	got, err := hex.DecodeString(strings.TrimPrefix(signature, "sha256="))
+	if err != nil || !strings.HasPrefix(signature, "sha256=") {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+// maxCheckRunText is the most the Checks API accepts in a check run's
+// output text.
+const maxCheckRunText = 65535
+
+// reportCheckRun checks repo at sha through the API and creates a completed
+// check run on the commit with the result.
+func (s *server) reportCheckRun(installationID int64, repo, sha string) error {
+	client, err := s.app.installationClient(installationID)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	scan := scanRepo(client, githubRepo{FullName: repo, DefaultBranch: sha}, nil, "")
+	s.mu.Unlock()
+
+	output := map[string]string{}
+	var conclusion string
+	switch {
+	case scan.err != nil:
+		conclusion = "failure"
+		output["title"] = "Cannot check CODEOWNERS coverage"
+		output["summary"] = scan.err.Error()
+	case scan.skipped != "":
+		conclusion = "neutral"
+		output["title"] = "Not checked"
+		output["summary"] = fmt.Sprintf("The repository has %s, so there's nothing to check. Add %s to enable the check.", scan.skipped, defaultConfigPaths[0])
+	default:
+		res := scan.res
+		sortErrors(res.errors)
+		owned, checked := res.coverage()
+		failures := countFailures(res.errors)
+		conclusion = "success"
+		output["title"] = "All directories have CODEOWNERS coverage"
+		if failures > 0 {
+			conclusion = "failure"
+			output["title"] = fmt.Sprintf("%d CODEOWNERS %s", failures, pluralize(failures, "problem", "problems"))
+		}
+		output["summary"] = fmt.Sprintf("%d of %d checked directories are owned (%.1f%%).", owned, checked, percent(owned, checked))
+		var text bytes.Buffer
+		if err := writeMarkdown(&text, res, reportOptions{}); err != nil {
+			return err
+		}
+		if text.Len() > maxCheckRunText {
+			text.Truncate(maxCheckRunText - 100)
+			text.WriteString("\n\n… (report truncated)")
+		}
+		output["text"] = text.String()
+	}
+
+	return client.sendJSON(http.MethodPost, fmt.Sprintf("/repos/%s/check-runs", repo), map[string]any{
+		"name":       checkRunName,
+		"head_sha":   sha,
+		"status":     "completed",
+		"conclusion": conclusion,
+		"output":     output,
+	}, nil)
+}
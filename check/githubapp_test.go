@@ -0,0 +1,192 @@
+package check
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func testGitHubApp(t *testing.T) *githubApp {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "app.pem")
+	pemBytes := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	if err := os.WriteFile(path, pemBytes, 0600); err != nil {
+		t.Fatal(err)
+	}
+	app, err := loadGitHubApp("1234", path)
+	if err != nil {
+		t.Fatalf("loadGitHubApp() error = %v", err)
+	}
+	return app
+}
+
+func TestGitHubAppJWT(t *testing.T) {
+	app := testGitHubApp(t)
+	now := time.Unix(1700000000, 0)
+	token, err := app.jwt(now)
+	if err != nil {
+		t.Fatalf("jwt() error = %v", err)
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("jwt() = %q, want three parts", token)
+	}
+	sig, _ := base64.RawURLEncoding.DecodeString(parts[2])
+	sum := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(&app.key.PublicKey, crypto.SHA256, sum[:], sig); err != nil {
+		t.Errorf("jwt() signature doesn't verify: %v", err)
+	}
+	payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	var claims struct {
+		IAT int64  `json:"iat"`
+		EXP int64  `json:"exp"`
+		ISS string `json:"iss"`
+	}
+	json.Unmarshal(payload, &claims)
+	if claims.ISS != "1234" || claims.IAT != now.Unix()-60 || claims.EXP != now.Unix()+540 {
+		t.Errorf("jwt() claims = %+v", claims)
+	}
+}
+
+func TestValidSignature(t *testing.T) {
+	body := []byte(`{"action":"opened"}`)
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write(body)
+	good := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	tests := []struct {
+		name, secret, signature string
+		want                    bool
+	}{
+		{"valid", "s3cret", good, true},
+		{"wrong secret", "other", good, false},
+		{"missing prefix", "s3cret", strings.TrimPrefix(good, "sha256="), false},
+		{"missing", "s3cret", "", false},
+		{"not hex", "s3cret", "sha256=zz", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := validSignature(tt.secret, body, tt.signature); got != tt.want {
+				t.Errorf("validSignature() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebhookCheckRun(t *testing.T) {
+	type tree struct {
+		Path string `json:"path"`
+		Type string `json:"type"`
+	}
+	var mu sync.Mutex
+	var checkRuns []map[string]any
+	var checkRunAuth string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "POST /app/installations/42/access_tokens":
+			if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ey") {
+				http.Error(w, "want app JWT", http.StatusUnauthorized)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"token": "installation-token"}`))
+		case "GET /repos/org/repo/contents/.requirecodeowners.yml":
+			w.Write([]byte("directories:\n  - path: services\n    level: 1\n"))
+		case "GET /repos/org/repo/contents/.github/CODEOWNERS":
+			w.Write([]byte("/services/foo/ @org/foo\n"))
+		case "GET /repos/org/repo/git/trees/abc123":
+			json.NewEncoder(w).Encode(map[string]any{
+				"tree": []tree{
+					{Path: "services", Type: "tree"},
+					{Path: "services/foo", Type: "tree"},
+					{Path: "services/bar", Type: "tree"},
+				},
+			})
+		case "POST /repos/org/repo/check-runs":
+			var run map[string]any
+			json.NewDecoder(r.Body).Decode(&run)
+			mu.Lock()
+			checkRuns = append(checkRuns, run)
+			checkRunAuth = r.Header.Get("Authorization")
+			mu.Unlock()
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer api.Close()
+	t.Setenv("GITHUB_API_URL", api.URL)
+
+	s := &server{app: testGitHubApp(t), webhookSecret: "s3cret"}
+	srv := httptest.NewServer(s.handler())
+	defer srv.Close()
+
+	deliver := func(event, payload, secret string) int {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(payload))
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+"/v1/webhook", strings.NewReader(payload))
+		req.Header.Set("X-GitHub-Event", event)
+		req.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	const pr = `{"action": "synchronize", "pull_request": {"head": {"sha": "abc123"}}, "repository": {"full_name": "org/repo"}, "installation": {"id": 42}}`
+	tests := []struct {
+		name, event, payload, secret string
+		want                         int
+	}{
+		{"bad signature", "pull_request", pr, "wrong", http.StatusUnauthorized},
+		{"closed pull request", "pull_request", strings.Replace(pr, "synchronize", "closed", 1), "s3cret", http.StatusNoContent},
+		{"deleted branch", "push", `{"after": "0000000", "deleted": true, "repository": {"full_name": "org/repo"}, "installation": {"id": 42}}`, "s3cret", http.StatusNoContent},
+		{"ping", "ping", `{"zen": "Keep it logically awesome."}`, "s3cret", http.StatusNoContent},
+		{"pull request", "pull_request", pr, "s3cret", http.StatusAccepted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := deliver(tt.event, tt.payload, tt.secret); got != tt.want {
+				t.Errorf("status = %d, want %d", got, tt.want)
+			}
+		})
+	}
+	s.pending.Wait()
+
+	if len(checkRuns) != 1 {
+		t.Fatalf("check runs = %v, want one for the pull request", checkRuns)
+	}
+	run := checkRuns[0]
+	if run["head_sha"] != "abc123" || run["conclusion"] != "failure" || run["name"] != checkRunName {
+		t.Errorf("check run = %v, want a failure on abc123", run)
+	}
+	output, _ := run["output"].(map[string]any)
+	if text, _ := output["text"].(string); !strings.Contains(text, "services/bar") {
+		t.Errorf("check run text = %q, want services/bar listed", text)
+	}
+	if checkRunAuth != "Bearer installation-token" {
+		t.Errorf("check run Authorization = %q, want the installation token", checkRunAuth)
+	}
+}
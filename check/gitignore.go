@@ -0,0 +1,227 @@
+package check
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// gitignoreFile is the per-directory ignore file, and gitExcludeFile the
+// repository-wide one that isn't committed.
+const (
+	gitignoreFile  = ".gitignore"
+	gitExcludeFile = ".git/info/exclude"
+)
+
+// ignorePattern is one line of a gitignore file.
+type ignorePattern struct {
+	base    string // directory of the file it's from, "" for the root
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// parseGitignore parses the patterns in a gitignore file whose paths are
+// relative to base.
+func parseGitignore(data []byte, base string) []ignorePattern {
+	var patterns []ignorePattern
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if !strings.HasSuffix(line, `\ `) {
+			line = strings.TrimRight(line, " ")
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		p := ignorePattern{base: base}
+		if strings.HasPrefix(line, "!") {
+			p.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			p.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		// A pattern with a slash anywhere but the end is relative to base;
+		// without one it matches a name at any depth.
+		anchored := strings.Contains(line, "/")
+		line = strings.TrimPrefix(line, "/")
+		expr := globToRegexp(line)
+		if !anchored {
+			expr = "(?:.*/)?" + expr
+		}
+		re, err := regexp.Compile("^" + expr + "$")
+		if err != nil {
+			continue
+		}
+		p.re = re
+		patterns = append(patterns, p)
+	}
+	return patterns
+}
+
+// globToRegexp translates a gitignore glob into a regular expression. "*"
+// and "?" don't match a slash, and "**" between slashes matches any number of
+// directories.
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "/**") && i+3 == len(glob):
+			b.WriteString("/.*")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case c == '\\' && i+1 < len(glob):
+			i++
+			b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
+
+// gitignoreFS hides directories that git ignores from ReadDir and Glob, so
+// build output and other untracked directories aren't expanded from levels
+// and globs. Stat is unchanged: a directory named in the config is still
+// checked.
+type gitignoreFS struct {
+	fileSystem
+	read     func(name string) ([]byte, error)
+	patterns map[string][]ignorePattern // by ignore file, loaded on first use
+}
+
+func newGitignoreFS(fsys fileSystem, read func(name string) ([]byte, error)) *gitignoreFS {
+	return &gitignoreFS{fileSystem: fsys, read: read, patterns: make(map[string][]ignorePattern)}
+}
+
+func (g *gitignoreFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := g.fileSystem.ReadDir(name)
+	if err != nil {
+		return nil, err
+	}
+	kept := entries[:0:0]
+	for _, e := range entries {
+		if !g.ignored(filepath.Join(name, e.Name()), e.IsDir()) {
+			kept = append(kept, e)
+		}
+	}
+	return kept, nil
+}
+
+func (g *gitignoreFS) Glob(pattern string) ([]string, error) {
+	matches, err := g.fileSystem.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	kept := matches[:0:0]
+	for _, m := range matches {
+		if !g.expandedIgnored(pattern, m) {
+			kept = append(kept, m)
+		}
+	}
+	return kept, nil
+}
+
+// expandedIgnored reports whether a wildcard in pattern expanded to an
+// ignored directory on the way to match. Directories the pattern names
+// literally are used even if git ignores them.
+func (g *gitignoreFS) expandedIgnored(pattern, match string) bool {
+	for _, p := range expandedPrefixes(pattern, match) {
+		if g.match(filepath.ToSlash(p), true) {
+			return true
+		}
+	}
+	return false
+}
+
+// ignored reports whether git ignores name. As in git, nothing inside an
+// ignored directory can be re-included.
+func (g *gitignoreFS) ignored(name string, isDir bool) bool {
+	name = path.Clean(filepath.ToSlash(name))
+	if name == "." || path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+		return false
+	}
+	parts := strings.Split(name, "/")
+	for i := range parts {
+		if g.match(strings.Join(parts[:i+1], "/"), i < len(parts)-1 || isDir) {
+			return true
+		}
+	}
+	return false
+}
+
+// match applies the patterns that can affect name, from the exclude file,
+// then each .gitignore from the root down. The last matching pattern wins.
+func (g *gitignoreFS) match(name string, isDir bool) bool {
+	ignored := false
+	check := func(patterns []ignorePattern) {
+		for _, p := range patterns {
+			if p.dirOnly && !isDir {
+				continue
+			}
+			rel := name
+			if p.base != "" {
+				rel = strings.TrimPrefix(name, p.base+"/")
+			}
+			if p.re.MatchString(rel) {
+				ignored = !p.negate
+			}
+		}
+	}
+	check(g.load(gitExcludeFile, ""))
+	check(g.load(gitignoreFile, ""))
+	parts := strings.Split(path.Dir(name), "/")
+	if parts[0] != "." {
+		for i := range parts {
+			dir := strings.Join(parts[:i+1], "/")
+			check(g.load(dir+"/"+gitignoreFile, dir))
+		}
+	}
+	return ignored
+}
+
+// load returns the patterns in the ignore file at name, reading it the first
+// time. A missing file has no patterns.
+func (g *gitignoreFS) load(name, base string) []ignorePattern {
+	if patterns, ok := g.patterns[name]; ok {
+		return patterns
+	}
+	data, err := g.read(name)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		logger.Warn("reading gitignore", "path", name, "error", err)
+	}
+	patterns := parseGitignore(data, base)
+	g.patterns[name] = patterns
+	return patterns
+}
@@ -0,0 +1,107 @@
+package check
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestGitignoreIgnored(t *testing.T) {
+	files := map[string]string{
+		".gitignore": `# build output
+/dist
+node_modules/
+*.egg-info
+build/**
+!build/keep
+docs/**/generated
+`,
+		".git/info/exclude":     "scratch\n",
+		"services/.gitignore":   "tmp\n!/vendor\n",
+		"services/a/.gitignore": "/local\n",
+	}
+	read := func(name string) ([]byte, error) {
+		if data, ok := files[name]; ok {
+			return []byte(data), nil
+		}
+		return nil, os.ErrNotExist
+	}
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"dist", true, true},
+		{"services/dist", true, false}, // anchored to the root
+		{"node_modules", true, true},
+		{"web/node_modules", true, true},
+		{"web/node_modules/pkg", true, true}, // inside an ignored directory
+		{"node_modules", false, false},       // only directories
+		{"src/foo.egg-info", true, true},
+		{"build/out", true, true},
+		{"build/keep", true, false},
+		{"docs/api/v1/generated", true, true},
+		{"docs/generated", true, true},
+		{"scratch", true, true},
+		{"services/tmp", true, true},
+		{"services/a/tmp", true, true},
+		{"tmp", true, false}, // services/.gitignore doesn't apply here
+		{"services/a/local", true, true},
+		{"services/b/local", true, false},
+		{"services/a", true, false},
+		{"../elsewhere", true, false},
+	}
+
+	g := newGitignoreFS(localFS{}, read)
+	for _, tt := range tests {
+		if got := g.ignored(tt.path, tt.isDir); got != tt.want {
+			t.Errorf("ignored(%q, %v) = %v, want %v", tt.path, tt.isDir, got, tt.want)
+		}
+	}
+}
+
+func TestGitignoreFS(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	for _, dir := range []string{"services/api", "services/web/node_modules", "services/out", ".github"} {
+		os.MkdirAll(filepath.Join(tmpDir, dir), 0755)
+	}
+	os.WriteFile(filepath.Join(tmpDir, ".gitignore"), []byte("node_modules/\nservices/out/\n"), 0644)
+
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	fsys := newGitignoreFS(localFS{}, os.ReadFile)
+	dirs, err := getDirsAtLevel(fsys, "services", 1)
+	if err != nil {
+		t.Fatalf("getDirsAtLevel() error = %v", err)
+	}
+	if want := []string{"services/api", "services/web"}; !reflect.DeepEqual(dirs, want) {
+		t.Errorf("getDirsAtLevel() = %v, want %v", dirs, want)
+	}
+
+	dirs, err = expandPath(fsys, "services/*/*")
+	if err != nil {
+		t.Fatalf("expandPath() error = %v", err)
+	}
+	if len(dirs) != 0 {
+		t.Errorf("expandPath() = %v, want no directories", dirs)
+	}
+
+	// A directory named in the config is checked even if it's ignored.
+	dirs, _ = expandPath(fsys, "services/out")
+	if !reflect.DeepEqual(dirs, []string{"services/out"}) {
+		t.Errorf("expandPath(services/out) = %v, want it kept", dirs)
+	}
+
+	old := traversal
+	defer func() { traversal = old }()
+	traversal.gitignore = false
+	dirs, _ = getDirsAtLevel(newLocalFS(), "services", 1)
+	if len(dirs) != 3 {
+		t.Errorf("getDirsAtLevel() with --no-gitignore = %v, want all 3 directories", dirs)
+	}
+}
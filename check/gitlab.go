@@ -0,0 +1,157 @@
+package check
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hmarr/codeowners"
+
+	"github.com/kpurdon/requirecodeowners/validate"
+)
+
+// Providers the config's provider can name. It decides the CODEOWNERS
+// syntax: GitLab's adds sections.
+const (
+	providerGitHub = "github"
+	providerGitLab = "gitlab"
+)
+
+// codeownersSection is a GitLab CODEOWNERS section, started by a header
+// like [Docs][2] @org/docs and running until the next one.
+type codeownersSection struct {
+	name string
+	// optional is set for ^[Name] sections, whose approval isn't required.
+	optional bool
+	// approvals is how many approvals from the section's owners a change
+	// needs: N for [Name][N], or else 1.
+	approvals int
+	// owners are the defaults for the section's rules that don't list any.
+	owners []codeowners.Owner
+	line   int
+}
+
+// header returns the section's header as it would be written, without its
+// default owners.
+func (s *codeownersSection) header() string {
+	h := "[" + s.name + "]"
+	if s.optional {
+		h = "^" + h
+	}
+	if s.approvals != 1 {
+		h += fmt.Sprintf("[%d]", s.approvals)
+	}
+	return h
+}
+
+var sectionHeader = regexp.MustCompile(`^(\^)?\[([^\]]+)\](?:\[(\d+)\])?(?:\s+(.*))?$`)
+
+// splitSections blanks out the section headers in GitLab CODEOWNERS data,
+// so the rest parses like GitHub's with the same line numbers, and returns
+// the sections in order.
+func splitSections(data []byte) ([]byte, []codeownersSection, error) {
+	lines := bytes.Split(data, []byte("\n"))
+	var sections []codeownersSection
+	for i, line := range lines {
+		m := sectionHeader.FindStringSubmatch(strings.TrimSpace(string(line)))
+		if m == nil {
+			continue
+		}
+		s := codeownersSection{name: m[2], optional: m[1] != "", approvals: 1, line: i + 1}
+		if m[3] != "" {
+			s.approvals, _ = strconv.Atoi(m[3])
+		}
+		if owners, _, _ := strings.Cut(m[4], "#"); strings.TrimSpace(owners) != "" {
+			rules, err := codeowners.ParseFile(strings.NewReader("* " + owners))
+			if err != nil {
+				return nil, nil, fmt.Errorf("line %d: section %s has invalid owners: %w", i+1, s.name, err)
+			}
+			s.owners = rules[0].Owners
+		}
+		sections = append(sections, s)
+		lines[i] = nil
+	}
+	return bytes.Join(lines, []byte("\n")), sections, nil
+}
+
+// assignSections links each rule to the section it's in, giving it the
+// section's default owners if it has none of its own.
+func assignSections(rs ruleset, sections []codeownersSection) {
+	for i := range rs {
+		r := &rs[i]
+		for j := len(sections) - 1; j >= 0; j-- {
+			if sections[j].line < r.LineNumber {
+				r.section = &sections[j]
+				break
+			}
+		}
+		if r.section != nil && len(r.Owners) == 0 {
+			r.Owners = r.section.owners
+		}
+	}
+}
+
+// requiredApprovals returns the required section asking the most approvals
+// of those with a rule owning dir. GitLab applies every section's last
+// matching rule, not only the last rule overall. It returns nil if no
+// required section owns dir.
+func (c *checker) requiredApprovals(dir string) *codeownersSection {
+	if c.sectionRules == nil {
+		c.sectionRules = make(map[string]ruleset)
+		for _, r := range c.rules {
+			if r.section != nil {
+				// Sections with the same name are one section to GitLab.
+				key := strings.ToLower(r.section.name)
+				c.sectionRules[key] = append(c.sectionRules[key], r)
+			}
+		}
+	}
+	var best *codeownersSection
+	for _, rules := range c.sectionRules {
+		r := matchDirectory(rules, dir)
+		if r == nil || r.section.optional {
+			continue
+		}
+		if best == nil || r.section.approvals > best.approvals {
+			best = r.section
+		}
+	}
+	return best
+}
+
+// checkApprovals reports dir, owned by match, if no required section owning
+// it asks for at least min approvals.
+func (c *checker) checkApprovals(dir string, match *rule, min int) (validate.Finding, bool) {
+	s := c.requiredApprovals(dir)
+	if s != nil && s.approvals >= min {
+		return validate.Finding{}, false
+	}
+	f := validate.Finding{Path: dir, Code: codeTooFewApprovals, Team: match.ownerNames(), File: match.file, Line: match.LineNumber}
+	if s == nil {
+		f.Message = fmt.Sprintf("Needs %d approvals, but its rule at %s isn't in a required section, so changes need at most 1. Move it under a section like [Name][%d].",
+			min, match.location(), min)
+		return f, true
+	}
+	f.Line = s.line
+	f.Message = fmt.Sprintf("Needs %d approvals, but section %s at %s:%d requires %d. Raise it: %s",
+		min, s.header(), match.file, s.line, s.approvals, (&codeownersSection{name: s.name, approvals: min}).header())
+	return f, true
+}
+
+// approvalsValidator reports owned directories that changes to can be
+// merged with fewer than min approvals, as checkApprovals does.
+func (c *checker) approvalsValidator(min int) validate.Validator {
+	return validate.Func{N: "min_approvals", F: func(_ context.Context, d validate.DirResult) []validate.Finding {
+		match := c.ruleFor(d)
+		if match == nil {
+			return nil
+		}
+		if f, ok := c.checkApprovals(d.Path, match, min); ok {
+			return []validate.Finding{f}
+		}
+		return nil
+	}}
+}
@@ -0,0 +1,130 @@
+package check
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseGitLabSections(t *testing.T) {
+	rules, err := parseCodeowners(strings.NewReader(`* @org/everyone
+
+[Docs] @org/docs
+/docs/
+/docs/api/ @org/api
+
+^[Style][3] @org/style # optional
+/styles/
+
+[Security][2]
+/auth/ @org/security
+`), "CODEOWNERS", providerGitLab)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, r := range rules {
+		section := "-"
+		if r.section != nil {
+			section = r.section.header()
+		}
+		got = append(got, r.RawPattern()+" "+section+" "+r.ownerNames())
+	}
+	want := []string{
+		"* - @org/everyone",
+		"/docs/ [Docs] @org/docs",
+		"/docs/api/ [Docs] @org/api",
+		"/styles/ ^[Style][3] @org/style",
+		"/auth/ [Security][2] @org/security",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("rules =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if rules[1].LineNumber != 4 {
+		t.Errorf("/docs/ is on line %d, want 4", rules[1].LineNumber)
+	}
+
+	if _, err := parseCodeowners(strings.NewReader("[Docs]\n/docs/ @org/docs\n"), "CODEOWNERS", providerGitHub); err == nil {
+		t.Error("parseCodeowners() with a section for GitHub succeeded, want an error")
+	}
+}
+
+func TestCheckRepoMinApprovals(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, d := range []string{"services/api", "services/auth", "services/billing", "services/web"} {
+		os.MkdirAll(filepath.Join(tmpDir, d), 0755)
+	}
+	os.WriteFile(filepath.Join(tmpDir, ".requirecodeowners.yml"), []byte(`provider: gitlab
+directories:
+  - path: services
+    level: 1
+    min_approvals: 2
+`), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "CODEOWNERS"), []byte(`/services/web/ @org/web
+
+[Services]
+/services/api/ @org/api
+
+[Security][2] @org/security
+/services/auth/
+/services/billing/
+
+^[Finance][2]
+/services/billing/ @org/finance
+`), 0644)
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	res, err := checkRepo(context.Background(), "", nil, specFilter{})
+	if err != nil {
+		t.Fatalf("checkRepo() error = %v", err)
+	}
+	var got []string
+	for _, e := range res.errors {
+		got = append(got, e.code+" "+e.path)
+	}
+	// auth and billing are in [Security][2]; billing's optional section
+	// doesn't count against it.
+	if want := "RCO060 services/api RCO060 services/web"; strings.Join(got, " ") != want {
+		t.Fatalf("errors = %v, want %s", res.errors, want)
+	}
+	for _, want := range []string{"section [Services] at CODEOWNERS:3 requires 1. Raise it: [Services][2]", "isn't in a required section"} {
+		found := false
+		for _, e := range res.errors {
+			found = found || strings.Contains(e.message, want)
+		}
+		if !found {
+			t.Errorf("errors = %v, want one saying %q", res.errors, want)
+		}
+	}
+
+	if _, err := parseConfig([]byte("directories:\n  - path: services\n    min_approvals: 2\n"), ".requirecodeowners.yml"); err == nil || !strings.Contains(err.Error(), "provider: gitlab") {
+		t.Errorf("parseConfig() with min_approvals for GitHub error = %v, want one asking for provider: gitlab", err)
+	}
+}
+
+func TestFindCodeownersGitLab(t *testing.T) {
+	gitRepo(t, map[string]string{
+		".requirecodeowners.yml": "provider: gitlab\ndirectories:\n  - path: services\n    level: 1\n",
+		".gitlab/CODEOWNERS":     "[Services]\n/services/api/ @org/api\n",
+		"services/api/main.go":   "package main\n",
+	})
+	if path, err := findCodeowners(); err != nil || filepath.ToSlash(path) != ".gitlab/CODEOWNERS" {
+		t.Errorf("findCodeowners() = %s, %v, want .gitlab/CODEOWNERS", path, err)
+	}
+	res, err := checkRepo(context.Background(), "", nil, specFilter{})
+	if err != nil {
+		t.Fatalf("checkRepo() error = %v", err)
+	}
+	if len(res.errors) != 0 {
+		t.Errorf("errors = %v, want services/api owned", res.errors)
+	}
+
+	os.Remove(filepath.Join(".gitlab", "CODEOWNERS"))
+	if _, err := findCodeowners(); err == nil || !strings.Contains(err.Error(), ".gitlab/") {
+		t.Errorf("findCodeowners() without CODEOWNERS error = %v, want it to list .gitlab/", err)
+	}
+}
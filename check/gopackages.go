@@ -0,0 +1,101 @@
+package check
+
+import (
+	"bytes"
+	"errors"
+	"go/build"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// goPackages returns every directory at or below root that holds a Go
+// package, skipping the directories the go command ignores: testdata and
+// names starting with "." or "_". With build tags, a directory only counts
+// if some of its files build with them, as go/build decides; without, any
+// .go file makes a package.
+func goPackages(fsys fileSystem, root string, tags []string) ([]string, error) {
+	var ctxt *build.Context
+	if tags != nil {
+		ctxt = goBuildContext(fsys, tags)
+	}
+
+	var pkgs []string
+	var walk func(dir string) error
+	walk = func(dir string) error {
+		entries, err := fsys.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+		hasGo := false
+		var subdirs []string
+		for _, e := range entries {
+			name := e.Name()
+			switch {
+			case e.IsDir():
+				if name != "testdata" && !strings.HasPrefix(name, ".") && !strings.HasPrefix(name, "_") {
+					subdirs = append(subdirs, path.Join(dir, name))
+				}
+			case strings.HasSuffix(name, ".go"):
+				hasGo = true
+			}
+		}
+		if hasGo && (ctxt == nil || buildsWith(ctxt, dir)) {
+			pkgs = append(pkgs, dir)
+		}
+		for _, sub := range subdirs {
+			if err := walk(sub); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(root); err != nil {
+		return nil, err
+	}
+	return pkgs, nil
+}
+
+// goBuildContext returns the default build context for this platform with
+// extra build tags, reading files through fsys.
+func goBuildContext(fsys fileSystem, tags []string) *build.Context {
+	ctxt := build.Default
+	ctxt.BuildTags = append(append([]string(nil), ctxt.BuildTags...), tags...)
+	ctxt.IsDir = func(name string) bool {
+		info, err := fsys.Stat(name)
+		return err == nil && info.IsDir()
+	}
+	ctxt.ReadDir = func(dir string) ([]fs.FileInfo, error) {
+		entries, err := fsys.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		infos := make([]fs.FileInfo, 0, len(entries))
+		for _, e := range entries {
+			info, err := e.Info()
+			if err != nil {
+				return nil, err
+			}
+			infos = append(infos, info)
+		}
+		return infos, nil
+	}
+	ctxt.OpenFile = func(name string) (io.ReadCloser, error) {
+		data, err := fsys.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	return &ctxt
+}
+
+// buildsWith reports whether dir has Go files that build in ctxt. A
+// directory whose files can't be read or parsed still counts, so it isn't
+// silently left unchecked.
+func buildsWith(ctxt *build.Context, dir string) bool {
+	_, err := ctxt.ImportDir(dir, 0)
+	var noGo *build.NoGoError
+	return !errors.As(err, &noGo)
+}
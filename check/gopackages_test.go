@@ -0,0 +1,95 @@
+package check
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGoPackages(t *testing.T) {
+	tree := newTreeFS(map[string]bool{
+		"go.mod":                                false,
+		"cmd/tool/main.go":                      false,
+		"internal/store/sql/deep/query.go":      false,
+		"internal/store/sql/deep/query_test.go": false,
+		"internal/store/README.md":              false,
+		"internal/store/testdata/fixture.go":    false,
+		"internal/_scratch/x.go":                false,
+		"internal/.cache/y.go":                  false,
+		"docs/index.md":                         false,
+	})
+
+	pkgs, err := goPackages(tree, ".", nil)
+	if err != nil {
+		t.Fatalf("goPackages() error = %v", err)
+	}
+	if want := []string{"cmd/tool", "internal/store/sql/deep"}; !reflect.DeepEqual(pkgs, want) {
+		t.Errorf("goPackages() = %v, want %v", pkgs, want)
+	}
+}
+
+func TestGoPackagesBuildTags(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	files := map[string]string{
+		"plain/plain.go":        "package plain\n",
+		"tagged/tagged.go":      "//go:build integration\n\npackage tagged\n",
+		"testonly/x_test.go":    "package testonly\n",
+		"otheros/only_plan9.go": "package otheros\n",
+	}
+	for name, content := range files {
+		os.MkdirAll(filepath.Join(tmpDir, filepath.Dir(name)), 0755)
+		os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644)
+	}
+
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	tests := []struct {
+		name string
+		tags []string
+		want []string
+	}{
+		{name: "file names only", tags: nil, want: []string{"otheros", "plain", "tagged", "testonly"}},
+		{name: "default tags", tags: []string{}, want: []string{"plain", "testonly"}},
+		{name: "extra tag", tags: []string{"integration"}, want: []string{"plain", "tagged", "testonly"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkgs, err := goPackages(localFS{}, ".", tt.tags)
+			if err != nil {
+				t.Fatalf("goPackages() error = %v", err)
+			}
+			if !reflect.DeepEqual(pkgs, tt.want) {
+				t.Errorf("goPackages() = %v, want %v", pkgs, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateGoPackages(t *testing.T) {
+	tree := newTreeFS(map[string]bool{
+		"internal/api/api.go":           false,
+		"internal/api/v2/handlers/h.go": false,
+		"internal/db/db.go":             false,
+		"internal/docs/README.md":       false,
+	})
+	parsed, _ := parseCodeowners(strings.NewReader("/internal/api/ @team-api\n"), "CODEOWNERS", providerGitHub)
+	c := &checker{fsys: tree, rules: parsed, configPath: ".requirecodeowners.yml"}
+
+	res := c.validate([]dirSpec{{Path: "internal", Mode: modeGoPackages}})
+	if len(res.errors) != 1 || res.errors[0].path != "internal/db" {
+		t.Errorf("validate() errors = %v, want only internal/db", res.errors)
+	}
+	if len(res.checked) != 3 {
+		t.Errorf("checked = %v, want 3 packages", res.checked)
+	}
+
+	res = c.validate([]dirSpec{{Path: "internal/docs", Mode: modeGoPackages}})
+	if len(res.errors) != 1 || res.errors[0].message != "No Go packages found. Check the path or mode in .requirecodeowners.yml." {
+		t.Errorf("validate() errors = %v, want no Go packages found", res.errors)
+	}
+}
@@ -0,0 +1,232 @@
+package check
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+// historyEntry is one run's summary in a --history-file, one JSON object per
+// line.
+type historyEntry struct {
+	Time     time.Time `json:"time"`
+	Commit   string    `json:"commit,omitempty"`
+	Checked  int       `json:"checked"`
+	Owned    int       `json:"owned"`
+	Unowned  int       `json:"unowned"`
+	Coverage float64   `json:"coverage_percent"`
+	Failures int       `json:"failures"`
+	Warnings int       `json:"warnings"`
+	// Teams counts the problems attributed to each team, as
+	// --group-by-team does.
+	Teams map[string]int `json:"teams,omitempty"`
+}
+
+// newHistoryEntry summarizes res as of now, at commit.
+func newHistoryEntry(res result, now time.Time, commit string) historyEntry {
+	stats := computeStats(res.checked)
+	failures := countFailures(res.errors)
+	h := historyEntry{
+		Time:     now.UTC().Truncate(time.Second),
+		Commit:   commit,
+		Checked:  stats.Checked,
+		Owned:    stats.Owned,
+		Unowned:  stats.Unowned,
+		Coverage: stats.Coverage,
+		Failures: failures,
+		Warnings: len(res.errors) - failures,
+	}
+	_, groups := groupByTeam(res.errors)
+	for team, errs := range groups {
+		if h.Teams == nil {
+			h.Teams = make(map[string]int)
+		}
+		h.Teams[team] = len(errs)
+	}
+	return h
+}
+
+// historyCommit returns the commit being checked: GITHUB_SHA in GitHub
+// Actions, or else HEAD, or "" outside a git repository.
+func historyCommit() string {
+	if sha := os.Getenv("GITHUB_SHA"); sha != "" {
+		return sha
+	}
+	sha, _ := gitLine("rev-parse", "HEAD")
+	return sha
+}
+
+// appendHistory adds h to the end of the history file at path, creating it
+// if needed.
+func appendHistory(path string, h historyEntry) error {
+	line, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// readHistory reads the entries in the history file at path, oldest first.
+func readHistory(path string) ([]historyEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []historyEntry
+	lines := bufio.NewScanner(f)
+	lines.Buffer(nil, 1<<20)
+	for n := 1; lines.Scan(); n++ {
+		if len(lines.Bytes()) == 0 {
+			continue
+		}
+		var h historyEntry
+		if err := json.Unmarshal(lines.Bytes(), &h); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, n, err)
+		}
+		entries = append(entries, h)
+	}
+	if err := lines.Err(); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Time.Before(entries[j].Time) })
+	return entries, nil
+}
+
+func runTrend(args []string) int {
+	fs := flag.NewFlagSet("trend", flag.ContinueOnError)
+	var historyPath string
+	var last int
+	fs.StringVar(&historyPath, "history-file", "", "history file written by --history-file")
+	fs.IntVar(&last, "last", 0, "only show the last this many runs (0 for all)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if historyPath == "" {
+		fmt.Fprintln(os.Stderr, "error: trend needs --history-file")
+		return 2
+	}
+	if last < 0 {
+		fmt.Fprintln(os.Stderr, "error: --last can't be negative")
+		return 2
+	}
+
+	entries, err := readHistory(historyPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	if last > 0 && len(entries) > last {
+		entries = entries[len(entries)-last:]
+	}
+	if err := writeTrend(os.Stdout, entries); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// writeTrend writes a table of the runs in entries, each with its change
+// from the run before, then the change from the first run to the last for
+// coverage and for each team's problems.
+func writeTrend(w io.Writer, entries []historyEntry) error {
+	if len(entries) == 0 {
+		_, err := fmt.Fprintln(w, "No runs recorded yet.")
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "DATE\tCOMMIT\tCOVERAGE\tUNOWNED\tFAILURES")
+	for i, h := range entries {
+		coverage := fmt.Sprintf("%.1f%%", h.Coverage)
+		unowned, failures := fmt.Sprint(h.Unowned), fmt.Sprint(h.Failures)
+		if i > 0 {
+			prev := entries[i-1]
+			coverage += pointsDelta(h.Coverage - prev.Coverage)
+			unowned += countDelta(h.Unowned - prev.Unowned)
+			failures += countDelta(h.Failures - prev.Failures)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", h.Time.UTC().Format("2006-01-02 15:04"), shortCommit(h.Commit), coverage, unowned, failures)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if len(entries) == 1 {
+		return nil
+	}
+
+	first, last := entries[0], entries[len(entries)-1]
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Since %s: coverage %.1f%% → %.1f%%%s, unowned %d → %d%s\n",
+		first.Time.UTC().Format("2006-01-02"), first.Coverage, last.Coverage, pointsDelta(last.Coverage-first.Coverage),
+		first.Unowned, last.Unowned, countDelta(last.Unowned-first.Unowned))
+
+	teams := make(map[string]bool)
+	for team := range first.Teams {
+		teams[team] = true
+	}
+	for team := range last.Teams {
+		teams[team] = true
+	}
+	if len(teams) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(teams))
+	for team := range teams {
+		names = append(names, team)
+	}
+	sort.Strings(names)
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Problems by team:")
+	tw = tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "TEAM\tFIRST\tLAST\tCHANGE")
+	for _, team := range names {
+		change := last.Teams[team] - first.Teams[team]
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%+d\n", team, first.Teams[team], last.Teams[team], change)
+	}
+	return tw.Flush()
+}
+
+// pointsDelta formats a change in percentage points, like " (+4.6)", or ""
+// for none.
+func pointsDelta(d float64) string {
+	if math.Abs(d) < 0.05 {
+		return ""
+	}
+	return fmt.Sprintf(" (%+.1f)", d)
+}
+
+// countDelta formats a change in a count, like " (-8)", or "" for none.
+func countDelta(d int) string {
+	if d == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (%+d)", d)
+}
+
+// shortCommit abbreviates a commit hash the way git log --oneline does.
+func shortCommit(sha string) string {
+	if sha == "" {
+		return "-"
+	}
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
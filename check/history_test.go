@@ -0,0 +1,89 @@
+package check
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHistoryRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	owned := &rule{}
+	runs := []result{
+		{
+			checked: []checkedDir{{path: "services/api", rule: owned}, {path: "services/new"}, {path: "services/web"}},
+			errors: []validationError{
+				{path: "services/new", message: "Not covered by CODEOWNERS.", team: "@org/services"},
+				{path: "services/web", message: "Not covered by CODEOWNERS."},
+			},
+		},
+		{
+			checked: []checkedDir{{path: "services/api", rule: owned}, {path: "services/new", rule: owned}, {path: "services/web"}},
+			errors:  []validationError{{path: "services/web", message: "Not covered by CODEOWNERS."}},
+		},
+	}
+	start := time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC)
+	for i, res := range runs {
+		h := newHistoryEntry(res, start.AddDate(0, 3*i, 0), "0123456789abcdef")
+		if err := appendHistory(path, h); err != nil {
+			t.Fatalf("appendHistory() error = %v", err)
+		}
+	}
+
+	entries, err := readHistory(path)
+	if err != nil {
+		t.Fatalf("readHistory() error = %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("readHistory() = %d entries, want 2", len(entries))
+	}
+	first := entries[0]
+	if first.Checked != 3 || first.Owned != 1 || first.Unowned != 2 || first.Failures != 2 || first.Commit != "0123456789abcdef" {
+		t.Errorf("first entry = %+v", first)
+	}
+	if first.Teams["@org/services"] != 1 || first.Teams[unassignedTeam] != 1 {
+		t.Errorf("first entry teams = %v, want one problem each for @org/services and Unassigned", first.Teams)
+	}
+	if entries[1].Teams["@org/services"] != 0 {
+		t.Errorf("second entry teams = %v, want none for @org/services", entries[1].Teams)
+	}
+
+	var buf bytes.Buffer
+	if err := writeTrend(&buf, entries); err != nil {
+		t.Fatalf("writeTrend() error = %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"2026-01-05 10:00  0123456  33.3%",
+		"2026-04-05 10:00  0123456  66.7% (+33.3)  1 (-1)",
+		"Since 2026-01-05: coverage 33.3% → 66.7% (+33.3), unowned 2 → 1 (-1)",
+		"@org/services  1      0     -1",
+		"Unassigned     1      1     +0",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("writeTrend() missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestReadHistoryErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	os.WriteFile(path, []byte(`{"time":"2026-01-05T10:00:00Z","checked":1}`+"\n\nnot json\n"), 0644)
+	_, err := readHistory(path)
+	if err == nil || !strings.Contains(err.Error(), "history.jsonl:3:") {
+		t.Errorf("readHistory() error = %v, want one naming line 3", err)
+	}
+}
+
+func TestWriteTrendEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeTrend(&buf, nil); err != nil {
+		t.Fatalf("writeTrend() error = %v", err)
+	}
+	if buf.String() != "No runs recorded yet.\n" {
+		t.Errorf("writeTrend() = %q", buf.String())
+	}
+}
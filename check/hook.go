@@ -0,0 +1,92 @@
+package check
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// hookMarker identifies hooks written by hook install, which it may
+// replace without --force.
+const hookMarker = "# Installed by requirecodeowners hook install."
+
+// hookScripts are the git hooks hook install can write. Each checks only the
+// directories the commit or push touches, and prints just the failures.
+var hookScripts = map[string]string{
+	"pre-commit": `#!/bin/sh
+` + hookMarker + `
+exec requirecodeowners --quiet --staged >/dev/null
+`,
+	"pre-push": `#!/bin/sh
+` + hookMarker + `
+# Set REQUIRECODEOWNERS_BASE to compare against a ref other than origin/HEAD.
+exec requirecodeowners --quiet --changed-since "${REQUIRECODEOWNERS_BASE:-origin/HEAD}" >/dev/null
+`,
+}
+
+func runHook(args []string) int {
+	if len(args) == 0 || args[0] != "install" {
+		fmt.Fprintln(os.Stderr, "usage: requirecodeowners hook install [flags]")
+		return 2
+	}
+	return runHookInstall(args[1:])
+}
+
+func runHookInstall(args []string) int {
+	fs := flag.NewFlagSet("hook install", flag.ContinueOnError)
+	var hook string
+	var force bool
+	var rf repoFlags
+	rf.registerDir(fs)
+	fs.StringVar(&hook, "type", "pre-commit", "hook to install: pre-commit or pre-push")
+	fs.BoolVar(&force, "force", false, "replace an existing hook that wasn't installed by this command")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	script, ok := hookScripts[hook]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "error: unknown hook type %q (want pre-commit or pre-push)\n", hook)
+		return 2
+	}
+
+	if err := rf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	path, err := installHook(hook, script, force)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	fmt.Println(console.mark(os.Stdout, markOK, "installed "+filepath.ToSlash(path)))
+	return 0
+}
+
+// installHook writes script as the named hook of the repository in the
+// working directory, honoring core.hooksPath, and returns where it went. A
+// hook of the same name is only replaced if this command wrote it, or with
+// force.
+func installHook(name, script string, force bool) (string, error) {
+	out, err := exec.Command("git", "rev-parse", "--git-path", "hooks").Output()
+	if err != nil {
+		return "", fmt.Errorf("finding the git hooks directory: %w", err)
+	}
+	hooksDir := strings.TrimSpace(string(out))
+	path := filepath.Join(hooksDir, name)
+
+	existing, err := os.ReadFile(path)
+	if err == nil && !force && !strings.Contains(string(existing), hookMarker) {
+		return "", fmt.Errorf("%s already exists; add requirecodeowners to it by hand, or pass --force to replace it", filepath.ToSlash(path))
+	}
+	if err := os.MkdirAll(hooksDir, 0755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		return "", err
+	}
+	// WriteFile keeps the mode of a file that already exists.
+	return path, os.Chmod(path, 0755)
+}
@@ -0,0 +1,104 @@
+package check
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestInstallHook(t *testing.T) {
+	tests := []struct {
+		name      string
+		hook      string
+		hooksPath string
+		existing  string
+		force     bool
+		wantPath  string
+		wantErr   string
+	}{
+		{
+			name:     "new hook",
+			wantPath: ".git/hooks/pre-commit",
+		},
+		{
+			name:      "core.hooksPath",
+			hook:      "pre-push",
+			hooksPath: "githooks",
+			wantPath:  "githooks/pre-push",
+		},
+		{
+			name:     "replaces its own hook",
+			existing: "#!/bin/sh\n" + hookMarker + "\nexec requirecodeowners --staged\n",
+			wantPath: ".git/hooks/pre-commit",
+		},
+		{
+			name:     "keeps someone else's hook",
+			existing: "#!/bin/sh\nmake lint\n",
+			wantErr:  ".git/hooks/pre-commit already exists; add requirecodeowners to it by hand, or pass --force to replace it",
+		},
+		{
+			name:     "replaces someone else's hook with force",
+			existing: "#!/bin/sh\nmake lint\n",
+			force:    true,
+			wantPath: ".git/hooks/pre-commit",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gitRepo(t, map[string]string{"README": ""})
+			hook := tt.hook
+			if hook == "" {
+				hook = "pre-commit"
+			}
+			script := hookScripts[hook]
+			hooksDir := filepath.Join(".git", "hooks")
+			if tt.hooksPath != "" {
+				git(t, "config", "core.hooksPath", tt.hooksPath)
+				hooksDir = tt.hooksPath
+			}
+			if tt.existing != "" {
+				os.MkdirAll(hooksDir, 0755)
+				// Not executable, so the test sees the mode being set.
+				if err := os.WriteFile(filepath.Join(hooksDir, "pre-commit"), []byte(tt.existing), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			path, err := installHook(hook, script, tt.force)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("installHook() error = %v, want %q", err, tt.wantErr)
+				}
+				if got, _ := os.ReadFile(filepath.Join(hooksDir, "pre-commit")); string(got) != tt.existing {
+					t.Errorf("hook = %q, want it left as %q", got, tt.existing)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("installHook() error = %v", err)
+			}
+			if filepath.ToSlash(path) != tt.wantPath {
+				t.Errorf("installHook() = %s, want %s", filepath.ToSlash(path), tt.wantPath)
+			}
+			got, err := os.ReadFile(tt.wantPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != script {
+				t.Errorf("hook = %q, want %q", got, script)
+			}
+			if runtime.GOOS == "windows" {
+				return
+			}
+			info, err := os.Stat(tt.wantPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if mode := info.Mode().Perm(); mode != 0755 {
+				t.Errorf("hook mode = %v, want -rwxr-xr-x", mode)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package check
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func runInit(args []string) int {
+	fs := flag.NewFlagSet("init", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: requirecodeowners init [flags]")
+		fmt.Fprintln(fs.Output(), "Writes a starter "+defaultConfigPaths[0]+" with a spec for each top-level directory.")
+		fs.PrintDefaults()
+	}
+	var force, dryRun bool
+	var rf repoFlags
+	rf.registerDir(fs)
+	fs.BoolVar(&force, "force", false, "replace an existing config")
+	fs.BoolVar(&dryRun, "dry-run", false, "print the config to stdout as a unified diff instead of writing it")
+	var tf traversalFlags
+	tf.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return 2
+	}
+	if err := tf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+
+	if err := rf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	var diff io.Writer
+	if dryRun {
+		diff = os.Stdout
+	}
+	path, specs, err := writeStarterConfig(force, diff)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	verb := "Wrote"
+	if dryRun {
+		verb = "Would write"
+	}
+	fmt.Fprintf(os.Stderr, "%s %s with %d %s. Review it, then run requirecodeowners check.\n", verb, path, specs, pluralize(specs, "spec", "specs"))
+	return 0
+}
+
+// writeStarterConfig writes a starter config in the working directory, and
+// returns where it went and how many specs it has. An existing config is
+// only replaced with force. With diff set, nothing is written: the config
+// goes to diff as a unified diff instead.
+func writeStarterConfig(force bool, diff io.Writer) (string, int, error) {
+	for _, p := range defaultConfigPaths {
+		if _, err := os.Stat(p); err == nil && !force {
+			return "", 0, fmt.Errorf("%s already exists; pass --force to replace it", p)
+		}
+	}
+	data, specs, err := starterConfig(newLocalFS())
+	if err != nil {
+		return "", 0, err
+	}
+	path := defaultConfigPaths[0]
+	old, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return "", 0, err
+	}
+	return path, specs, writeOrDiff(path, old, data, diff)
+}
+
+// starterConfig returns a config checking the repository's top-level
+// directories, with how many specs it has. A directory with subdirectories
+// is checked at level 1, so each of them needs an owner, and one without is
+// checked itself. Hidden directories, like .github, are left out.
+func starterConfig(fsys fileSystem) ([]byte, int, error) {
+	entries, err := fsys.ReadDir(".")
+	if err != nil {
+		return nil, 0, err
+	}
+	var b strings.Builder
+	b.WriteString("# Directories that need an owner in CODEOWNERS. level: 1 checks each\n")
+	b.WriteString("# subdirectory of path, and level: 0 (the default) checks path itself.\n")
+	b.WriteString("directories:\n")
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() && !strings.HasPrefix(e.Name(), ".") {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	if len(names) == 0 {
+		b.WriteString("  - path: .\n    level: 1\n")
+		return []byte(b.String()), 1, nil
+	}
+	for _, name := range names {
+		path := name
+		if strings.Trim(name, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789._-") != "" {
+			path = strconv.Quote(name) // YAML's double quotes read Go's
+		}
+		fmt.Fprintf(&b, "  - path: %s\n", path)
+		children, err := fsys.ReadDir(name)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, c := range children {
+			if c.IsDir() && !strings.HasPrefix(c.Name(), ".") {
+				b.WriteString("    level: 1\n")
+				break
+			}
+		}
+	}
+	return []byte(b.String()), len(names), nil
+}
@@ -0,0 +1,104 @@
+package check
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStarterConfig(t *testing.T) {
+	tests := []struct {
+		name  string
+		tree  map[string]bool
+		want  string
+		specs int
+	}{
+		{
+			name: "top-level directories",
+			tree: map[string]bool{
+				"services/api/main.go": false,
+				"services/web":         true,
+				"docs/index.md":        false,
+				"docs/.vitepress":      true,
+				".github/CODEOWNERS":   false,
+				"my app/main.go":       false,
+				"README.md":            false,
+			},
+			want: `# Directories that need an owner in CODEOWNERS. level: 1 checks each
+# subdirectory of path, and level: 0 (the default) checks path itself.
+directories:
+  - path: docs
+  - path: "my app"
+  - path: services
+    level: 1
+`,
+			specs: 3,
+		},
+		{
+			name: "no directories",
+			tree: map[string]bool{"main.go": false},
+			want: `# Directories that need an owner in CODEOWNERS. level: 1 checks each
+# subdirectory of path, and level: 0 (the default) checks path itself.
+directories:
+  - path: .
+    level: 1
+`,
+			specs: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, specs, err := starterConfig(newTreeFS(tt.tree))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.want || specs != tt.specs {
+				t.Errorf("starterConfig() = %d specs:\n%s\nwant %d:\n%s", specs, data, tt.specs, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteStarterConfigDryRun(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tmpDir, "services/api"), 0755)
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	var diff bytes.Buffer
+	path, specs, err := writeStarterConfig(false, &diff)
+	if err != nil {
+		t.Fatalf("writeStarterConfig() error = %v", err)
+	}
+	if path != ".requirecodeowners.yml" || specs != 1 {
+		t.Errorf("writeStarterConfig() = %s, %d specs, want .requirecodeowners.yml, 1", path, specs)
+	}
+	if want := "--- /dev/null\n+++ b/.requirecodeowners.yml\n@@ -0,0 +1,5 @@\n"; !strings.HasPrefix(diff.String(), want) {
+		t.Errorf("diff =\n%s\nwant it to start with\n%s", diff.String(), want)
+	}
+	if !strings.Contains(diff.String(), "\n+  - path: services\n+    level: 1\n") {
+		t.Errorf("diff =\n%s\nwant the services spec added", diff.String())
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("dry run wrote %s", path)
+	}
+
+	// Replacing a config shows what changes in it.
+	os.WriteFile(path, []byte("directories:\n  - path: services\n"), 0644)
+	if _, _, err := writeStarterConfig(false, &diff); err == nil || !strings.Contains(err.Error(), "pass --force") {
+		t.Errorf("writeStarterConfig() over an existing config error = %v, want it refused", err)
+	}
+	diff.Reset()
+	if _, _, err := writeStarterConfig(true, &diff); err != nil {
+		t.Fatalf("writeStarterConfig() with force error = %v", err)
+	}
+	if !strings.HasPrefix(diff.String(), "--- a/.requirecodeowners.yml\n+++ b/.requirecodeowners.yml\n") || !strings.Contains(diff.String(), "\n+    level: 1\n") {
+		t.Errorf("diff =\n%s\nwant level: 1 added to the existing config", diff.String())
+	}
+	if data, _ := os.ReadFile(path); string(data) != "directories:\n  - path: services\n" {
+		t.Errorf("dry run rewrote %s:\n%s", path, data)
+	}
+}
@@ -0,0 +1,328 @@
+package check
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// remediation is what an --interactive session decided to change.
+type remediation struct {
+	// rules are new lines for the CODEOWNERS file at codeownersPath, each
+	// owning one directory.
+	rules          []string
+	codeownersPath string
+
+	// allowUnowned are directories to add to the config's allow_unowned.
+	allowUnowned []string
+
+	// patterns are rule patterns to replace, with the fix lints offered.
+	patterns []patternFix
+}
+
+// patternFix replaces the pattern of the rule at file and line.
+type patternFix struct {
+	file     string
+	line     int
+	old, new string
+}
+
+func (r remediation) empty() bool {
+	return len(r.rules) == 0 && len(r.allowUnowned) == 0 && len(r.patterns) == 0
+}
+
+// promptRemediations walks through the uncovered directories in errs one at
+// a time, asking on out and reading answers from in what to do about each:
+// add a rule for them, allow them to be unowned, or skip them. Then it offers
+// the fixes lints found for rule patterns. Quitting, or the end of in, keeps
+// the answers given so far.
+func promptRemediations(in io.Reader, out io.Writer, errs []validationError) remediation {
+	var uncovered, fixable []validationError
+	seen := make(map[string]bool)
+	for _, e := range errs {
+		if e.code == codeMissingEntry && !seen[e.path] {
+			seen[e.path] = true
+			uncovered = append(uncovered, e)
+		}
+	}
+	for _, e := range errs {
+		if key := fmt.Sprintf("%s:%d", e.file, e.line); e.fix != "" && !seen[key] {
+			seen[key] = true
+			fixable = append(fixable, e)
+		}
+	}
+	total := len(uncovered) + len(fixable)
+
+	var r remediation
+	answers := bufio.NewScanner(in)
+	ask := func(prompt string) (string, bool) {
+		fmt.Fprint(out, prompt)
+		if !answers.Scan() {
+			fmt.Fprintln(out)
+			return "", false
+		}
+		return strings.TrimSpace(answers.Text()), true
+	}
+
+	for i, e := range uncovered {
+		fmt.Fprintf(out, "\n[%d/%d] %s\n  %s\n", i+1, total, e.label(), e.codedMessage())
+		for {
+			choice, ok := ask("  (a)dd a rule, allow (u)nowned, (s)kip, (q)uit? ")
+			if !ok {
+				return r
+			}
+			switch strings.ToLower(choice) {
+			case "a", "add":
+				owners, ok := askOwners(out, ask, e.team)
+				if !ok {
+					return r
+				}
+				if owners == "" {
+					continue
+				}
+				r.rules = append(r.rules, fmt.Sprintf("/%s/ %s", e.path, owners))
+				r.codeownersPath = e.file
+			case "u", "unowned":
+				r.allowUnowned = append(r.allowUnowned, e.path)
+			case "s", "skip":
+			case "q", "quit":
+				return r
+			default:
+				fmt.Fprintln(out, "  Answer a, u, s or q.")
+				continue
+			}
+			break
+		}
+	}
+
+	for i, e := range fixable {
+		fmt.Fprintf(out, "\n[%d/%d] %s at %s:%d\n  %s\n", len(uncovered)+i+1, total, e.path, e.file, e.line, e.codedMessage())
+		for {
+			choice, ok := ask(fmt.Sprintf("  (r)eplace with %s, (s)kip, (q)uit? ", e.fix))
+			if !ok {
+				return r
+			}
+			switch strings.ToLower(choice) {
+			case "r", "replace":
+				r.patterns = append(r.patterns, patternFix{file: e.file, line: e.line, old: e.path, new: e.fix})
+			case "s", "skip":
+			case "q", "quit":
+				return r
+			default:
+				fmt.Fprintln(out, "  Answer r, s or q.")
+				continue
+			}
+			break
+		}
+	}
+	return r
+}
+
+// askOwners asks for the owners of a new rule, offering suggested if it's
+// set. It returns "" to go back to the choices.
+func askOwners(out io.Writer, ask func(string) (string, bool), suggested string) (string, bool) {
+	prompt := "  Owners, or blank to go back: "
+	if suggested != "" {
+		prompt = fmt.Sprintf("  Owners [%s], or - to go back: ", suggested)
+	}
+	for {
+		owners, ok := ask(prompt)
+		if !ok {
+			return "", false
+		}
+		switch {
+		case owners == "-":
+			return "", true
+		case owners == "":
+			return suggested, true
+		}
+		if bad := invalidOwner(owners); bad != "" {
+			fmt.Fprintf(out, "  %s isn't an owner; use @user, @org/team or an email.\n", bad)
+			continue
+		}
+		return owners, true
+	}
+}
+
+// ownerPattern matches the owners CODEOWNERS accepts.
+var ownerPattern = regexp.MustCompile(`^(@[\w.-]+(/[\w.-]+)?|[^@\s]+@[^@\s]+)$`)
+
+// invalidOwner returns the first of the space-separated owners that isn't
+// one, or "" if they all are.
+func invalidOwner(owners string) string {
+	for _, o := range strings.Fields(owners) {
+		if !ownerPattern.MatchString(o) {
+			return o
+		}
+	}
+	return ""
+}
+
+// applyRemediation adds r's rules to the end of their CODEOWNERS file,
+// where they take precedence over broader rules, replaces its patterns, and
+// adds its allow_unowned entries to the YAML config at configPath. What can't be written, because
+// the file is remote or the config isn't YAML, is printed to w for adding by
+// hand. With diff set, nothing is written: the changes go to diff as a
+// unified diff instead.
+func applyRemediation(w io.Writer, r remediation, configPath string, diff io.Writer) error {
+	edit := func(path string, change func([]byte) ([]byte, error)) error {
+		old, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		updated, err := change(old)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		return writeOrDiff(path, old, updated, diff)
+	}
+	verb, replaced := "Added", "Replaced"
+	if diff != nil {
+		verb, replaced = "Would add", "Would replace"
+	}
+
+	// Each CODEOWNERS file is changed in one edit, so a diff has one set of
+	// hunks for it.
+	fixes := make(map[string][]patternFix)
+	var files []string
+	for _, f := range r.patterns {
+		if _, ok := fixes[f.file]; !ok {
+			files = append(files, f.file)
+		}
+		fixes[f.file] = append(fixes[f.file], f)
+	}
+	if _, ok := fixes[r.codeownersPath]; !ok && len(r.rules) > 0 {
+		files = append(files, r.codeownersPath)
+	}
+	for _, file := range files {
+		var rules []string
+		if file == r.codeownersPath {
+			rules = r.rules
+		}
+		if _, err := os.Stat(file); err != nil {
+			if len(rules) > 0 {
+				fmt.Fprintf(w, "Add to %s:\n  %s\n", file, strings.Join(rules, "\n  "))
+			}
+			for _, f := range fixes[file] {
+				fmt.Fprintf(w, "Replace %s with %s at %s:%d\n", f.old, f.new, file, f.line)
+			}
+			continue
+		}
+		err := edit(file, func(data []byte) ([]byte, error) {
+			data, err := replacePatterns(data, fixes[file])
+			if err != nil || len(rules) == 0 {
+				return data, err
+			}
+			return appendLines(data, rules), nil
+		})
+		if err != nil {
+			return err
+		}
+		if len(rules) > 0 {
+			fmt.Fprintf(w, "%s %d %s to %s.\n", verb, len(rules), pluralize(len(rules), "rule", "rules"), file)
+		}
+		if n := len(fixes[file]); n > 0 {
+			fmt.Fprintf(w, "%s %d %s in %s.\n", replaced, n, pluralize(n, "pattern", "patterns"), file)
+		}
+	}
+	if len(r.allowUnowned) > 0 {
+		switch filepath.Ext(configPath) {
+		case ".yml", ".yaml":
+			if err := edit(configPath, func(data []byte) ([]byte, error) { return addAllowUnowned(data, r.allowUnowned) }); err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "%s %d %s to allow_unowned in %s.\n", verb, len(r.allowUnowned), pluralize(len(r.allowUnowned), "directory", "directories"), configPath)
+		default:
+			fmt.Fprintf(w, "Add to allow_unowned in %s:\n  %s\n", configPath, strings.Join(r.allowUnowned, "\n  "))
+		}
+	}
+	return nil
+}
+
+// appendLines returns data with lines added to the end.
+func appendLines(data []byte, lines []string) []byte {
+	data = append([]byte(nil), data...)
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		data = append(data, '\n')
+	}
+	return append(data, strings.Join(lines, "\n")+"\n"...)
+}
+
+// replacePatterns returns the CODEOWNERS data with the pattern of each fix's
+// line replaced, leaving its owners and comments as they are. A line that
+// doesn't start with the pattern being replaced anymore is an error.
+func replacePatterns(data []byte, fixes []patternFix) ([]byte, error) {
+	if len(fixes) == 0 {
+		return data, nil
+	}
+	lines := strings.Split(string(data), "\n")
+	for _, f := range fixes {
+		if f.line < 1 || f.line > len(lines) {
+			return nil, fmt.Errorf("line %d doesn't exist", f.line)
+		}
+		line := lines[f.line-1]
+		rest := strings.TrimLeft(line, " \t")
+		after, ok := strings.CutPrefix(rest, f.old)
+		if !ok || (after != "" && !strings.ContainsAny(after[:1], " \t\r")) {
+			return nil, fmt.Errorf("line %d doesn't start with %s", f.line, f.old)
+		}
+		lines[f.line-1] = line[:len(line)-len(rest)] + f.new + after
+	}
+	return []byte(strings.Join(lines, "\n")), nil
+}
+
+// addAllowUnowned returns the YAML config data with dirs added to its
+// allow_unowned list. It edits the text so the rest of the file keeps its
+// layout and comments. The list is created at the end of the file if there
+// isn't one.
+func addAllowUnowned(data []byte, dirs []string) ([]byte, error) {
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	key := -1
+	for i, line := range lines {
+		if strings.HasPrefix(line, "allow_unowned:") {
+			key = i
+			break
+		}
+	}
+
+	switch {
+	case key < 0:
+		lines = append(lines, "allow_unowned:")
+		for _, d := range dirs {
+			lines = append(lines, "  - "+d)
+		}
+	case strings.HasSuffix(strings.TrimSpace(lines[key]), "]"):
+		// A flow list: allow_unowned: [a, b]
+		value := strings.TrimSpace(strings.TrimPrefix(lines[key], "allow_unowned:"))
+		items := strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(value, "["), "]"))
+		if items != "" {
+			items += ", "
+		}
+		lines[key] = "allow_unowned: [" + items + strings.Join(dirs, ", ") + "]"
+	case strings.TrimSpace(strings.TrimPrefix(lines[key], "allow_unowned:")) == "":
+		// A block list. New items go after its last one, indented the same.
+		last, indent := key, "  "
+		for i := key + 1; i < len(lines); i++ {
+			trimmed := strings.TrimLeft(lines[i], " ")
+			if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+				continue
+			}
+			if !strings.HasPrefix(trimmed, "- ") {
+				break
+			}
+			last, indent = i, lines[i][:len(lines[i])-len(trimmed)]
+		}
+		added := make([]string, len(dirs))
+		for i, d := range dirs {
+			added[i] = indent + "- " + d
+		}
+		lines = append(lines[:last+1], append(added, lines[last+1:]...)...)
+	default:
+		return nil, fmt.Errorf("can't add to allow_unowned on line %d; add %s by hand", key+1, strings.Join(dirs, ", "))
+	}
+	return []byte(strings.Join(lines, "\n") + "\n"), nil
+}
@@ -0,0 +1,187 @@
+package check
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPromptRemediations(t *testing.T) {
+	errs := []validationError{
+		{path: "services/api", message: "Not covered.", code: codeMissingEntry, team: "@org/services", file: "CODEOWNERS"},
+		{path: "services/api", message: "Has 1 owner.", code: codeTooFewOwners},
+		{path: "services/web", message: "Not covered.", code: codeMissingEntry, file: "CODEOWNERS"},
+		{path: "services/old", message: "Not covered.", code: codeMissingEntry, file: "CODEOWNERS"},
+		{path: "services/tmp", message: "Not covered.", code: codeMissingEntry, file: "CODEOWNERS"},
+		{path: "services/new", message: "Not covered.", code: codeMissingEntry, file: "CODEOWNERS"},
+	}
+	// services/api takes the suggested owner; services/web gets an invalid
+	// owner first; services/old is allowed unowned; services/tmp is skipped
+	// after an unknown answer; quitting leaves services/new alone.
+	in := strings.NewReader("a\n\na\nnobody\n@org/web dev@example.com\nu\nx\ns\nq\n")
+	var out bytes.Buffer
+	got := promptRemediations(in, &out, errs)
+
+	wantRules := "/services/api/ @org/services\n/services/web/ @org/web dev@example.com"
+	if strings.Join(got.rules, "\n") != wantRules || got.codeownersPath != "CODEOWNERS" {
+		t.Errorf("rules = %q in %q, want %q", got.rules, got.codeownersPath, wantRules)
+	}
+	if strings.Join(got.allowUnowned, ",") != "services/old" {
+		t.Errorf("allowUnowned = %v, want services/old", got.allowUnowned)
+	}
+	for _, want := range []string{"[1/5] services/api", "Owners [@org/services]", "nobody isn't an owner", "Answer a, u, s or q."} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output = %q, want it to contain %q", out.String(), want)
+		}
+	}
+
+	if got := promptRemediations(strings.NewReader("u\n"), &out, errs); strings.Join(got.allowUnowned, ",") != "services/api" {
+		t.Errorf("allowUnowned at end of input = %v, want services/api", got.allowUnowned)
+	}
+}
+
+func TestAddAllowUnowned(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   string
+	}{
+		{
+			name:   "no list",
+			config: "directories:\n  - path: services # the services\n    level: 1",
+			want:   "directories:\n  - path: services # the services\n    level: 1\nallow_unowned:\n  - services/a\n  - services/b\n",
+		},
+		{
+			name:   "block list",
+			config: "allow_unowned:\n    - services/old\n    # generated\n    - services/gen\ndirectories:\n  - path: services\n",
+			want:   "allow_unowned:\n    - services/old\n    # generated\n    - services/gen\n    - services/a\n    - services/b\ndirectories:\n  - path: services\n",
+		},
+		{
+			name:   "unindented block list",
+			config: "allow_unowned:\n- services/old\ndirectories: []\n",
+			want:   "allow_unowned:\n- services/old\n- services/a\n- services/b\ndirectories: []\n",
+		},
+		{
+			name:   "flow list",
+			config: "allow_unowned: [services/old]\n",
+			want:   "allow_unowned: [services/old, services/a, services/b]\n",
+		},
+		{
+			name:   "empty flow list",
+			config: "allow_unowned: []\n",
+			want:   "allow_unowned: [services/a, services/b]\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := addAllowUnowned([]byte(tt.config), []string{"services/a", "services/b"})
+			if err != nil {
+				t.Fatalf("addAllowUnowned() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("config = %q, want %q", got, tt.want)
+			}
+			if _, err := parseConfig(got, ".requirecodeowners.yml"); err != nil {
+				t.Errorf("edited config doesn't parse: %v", err)
+			}
+		})
+	}
+	if _, err := addAllowUnowned([]byte("allow_unowned: services/old\n"), []string{"services/a"}); err == nil {
+		t.Error("addAllowUnowned() with a scalar allow_unowned succeeded, want an error")
+	}
+}
+
+func TestApplyRemediation(t *testing.T) {
+	tmpDir := t.TempDir()
+	codeownersPath := filepath.Join(tmpDir, "CODEOWNERS")
+	os.WriteFile(codeownersPath, []byte("* @org/everyone"), 0644)
+	tomlPath := filepath.Join(tmpDir, ".requirecodeowners.toml")
+
+	var out bytes.Buffer
+	fixes := remediation{rules: []string{"/services/api/ @org/api"}, codeownersPath: codeownersPath, allowUnowned: []string{"services/old"}}
+	if err := applyRemediation(&out, fixes, tomlPath, nil); err != nil {
+		t.Fatalf("applyRemediation() error = %v", err)
+	}
+	if got, _ := os.ReadFile(codeownersPath); string(got) != "* @org/everyone\n/services/api/ @org/api\n" {
+		t.Errorf("CODEOWNERS = %q", got)
+	}
+	if !strings.Contains(out.String(), "Add to allow_unowned in "+tomlPath+":\n  services/old") {
+		t.Errorf("output = %q, want the allow_unowned entries to add by hand", out.String())
+	}
+}
+
+func TestApplyRemediationDryRun(t *testing.T) {
+	tmpDir := t.TempDir()
+	codeownersPath := filepath.Join(tmpDir, "CODEOWNERS")
+	configPath := filepath.Join(tmpDir, ".requirecodeowners.yml")
+	os.WriteFile(codeownersPath, []byte("* @org/everyone\n"), 0644)
+	os.WriteFile(configPath, []byte("directories:\n  - path: services\n    level: 1\n"), 0644)
+
+	var out, diff bytes.Buffer
+	fixes := remediation{
+		rules:          []string{"/services/api/ @org/api"},
+		codeownersPath: codeownersPath,
+		allowUnowned:   []string{"services/old"},
+		patterns:       []patternFix{{file: codeownersPath, line: 1, old: "*", new: "/**"}},
+	}
+	if err := applyRemediation(&out, fixes, configPath, &diff); err != nil {
+		t.Fatalf("applyRemediation() error = %v", err)
+	}
+	if got, _ := os.ReadFile(codeownersPath); string(got) != "* @org/everyone\n" {
+		t.Errorf("CODEOWNERS = %q, want it unchanged", got)
+	}
+	if got, _ := os.ReadFile(configPath); strings.Contains(string(got), "allow_unowned") {
+		t.Errorf("config = %q, want it unchanged", got)
+	}
+	for _, want := range []string{
+		"+++ b/" + filepath.ToSlash(codeownersPath) + "\n@@ -1 +1,2 @@\n-* @org/everyone\n+/** @org/everyone\n+/services/api/ @org/api\n",
+		"@@ -1,3 +1,5 @@\n directories:\n   - path: services\n     level: 1\n+allow_unowned:\n+  - services/old\n",
+	} {
+		if !strings.Contains(diff.String(), want) {
+			t.Errorf("diff = %q, want it to contain %q", diff.String(), want)
+		}
+	}
+	if !strings.Contains(out.String(), "Would add 1 rule") {
+		t.Errorf("output = %q, want it to say what would change", out.String())
+	}
+}
+
+func TestReplacePatterns(t *testing.T) {
+	data := []byte("# owners\ndocs/ @org/docs # the docs\n  /services/api\t@org/api\n")
+	got, err := replacePatterns(data, []patternFix{
+		{line: 2, old: "docs/", new: "/docs/"},
+		{line: 3, old: "/services/api", new: "/services/api/"},
+	})
+	if err != nil {
+		t.Fatalf("replacePatterns() error = %v", err)
+	}
+	if want := "# owners\n/docs/ @org/docs # the docs\n  /services/api/\t@org/api\n"; string(got) != want {
+		t.Errorf("replacePatterns() = %q, want %q", got, want)
+	}
+	for _, f := range []patternFix{{line: 2, old: "doc", new: "/doc/"}, {line: 9, old: "docs/", new: "/docs/"}} {
+		if _, err := replacePatterns(data, []patternFix{f}); err == nil {
+			t.Errorf("replacePatterns(%+v) succeeded, want an error", f)
+		}
+	}
+}
+
+func TestPromptPatternFixes(t *testing.T) {
+	errs := []validationError{
+		{path: "services/api", message: "Not covered.", code: codeMissingEntry, file: "CODEOWNERS"},
+		{path: "docs/", message: "Matches docs at any depth.", code: codeUnanchoredPattern, file: "CODEOWNERS", line: 2, fix: "/docs/"},
+		{path: "Makefile", message: "Matches Makefile at any depth.", code: codeUnanchoredPattern, file: "CODEOWNERS", line: 3},
+		{path: "/libs", message: "Owns the directory libs.", code: codeNoTrailingSlash, file: "CODEOWNERS", line: 4, fix: "/libs/"},
+	}
+	var out bytes.Buffer
+	got := promptRemediations(strings.NewReader("s\nr\ns\n"), &out, errs)
+	if len(got.patterns) != 1 || got.patterns[0] != (patternFix{file: "CODEOWNERS", line: 2, old: "docs/", new: "/docs/"}) {
+		t.Errorf("patterns = %+v, want docs/ replaced on line 2", got.patterns)
+	}
+	for _, want := range []string{"[1/3] services/api", "[2/3] docs/ at CODEOWNERS:2", "(r)eplace with /docs/", "[3/3] /libs"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output = %q, want it to contain %q", out.String(), want)
+		}
+	}
+}
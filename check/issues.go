@@ -0,0 +1,296 @@
+package check
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Issue groupings for audit: one issue per unowned directory, or one per
+// team most likely responsible for a set of them.
+const (
+	issuesPerDirectory = "directory"
+	issuesPerTeam      = "team"
+)
+
+// defaultIssueLabel marks the issues audit files, so later runs can find
+// and update them.
+const defaultIssueLabel = "codeowners"
+
+// plannedIssue is an issue audit wants to exist, identified across runs by
+// key, which is embedded in the body.
+type plannedIssue struct {
+	key   string
+	title string
+	body  string
+}
+
+// issueMarker finds the key audit embeds in the issues it files.
+var issueMarker = regexp.MustCompile(`<!-- requirecodeowners:(.+?) -->`)
+
+func runAudit(args []string) int {
+	fs := flag.NewFlagSet("audit", flag.ContinueOnError)
+	var createIssues, closeResolved bool
+	var repo, groupBy string
+	var labels stringList
+	var verify bool
+	var minMembers int
+	var failOn string
+	var rf repoFlags
+	rf.register(fs)
+	var lf configFlags
+	lf.register(fs)
+	fs.BoolVar(&createIssues, "create-issues", false, "open or update a GitHub issue for the unowned directories")
+	fs.BoolVar(&closeResolved, "close-resolved", false, "with --create-issues, close issues for directories that now have an owner")
+	fs.StringVar(&repo, "repo", os.Getenv("GITHUB_REPOSITORY"), "repository to file issues in, as owner/name")
+	fs.StringVar(&groupBy, "group-by", issuesPerDirectory, "one issue per "+issuesPerDirectory+" or per "+issuesPerTeam)
+	fs.Var(&labels, "label", "label for filed issues, repeatable; the first finds issues from earlier runs (default: "+defaultIssueLabel+")")
+	fs.BoolVar(&verify, "verify-owners", true, "check through the GitHub API that owners exist and teams have members (skipped with --offline)")
+	fs.IntVar(&minMembers, "min-team-members", 1, "with --verify-owners, the fewest members a team may have")
+	fs.StringVar(&failOn, "fail-on", failOnError, "exit 1 on any "+failOnError+", on any "+failOnWarning+" too, or "+failOnNever)
+	var filter specFilter
+	filter.register(fs)
+	var gf githubFlags
+	gf.register(fs)
+	var tf traversalFlags
+	tf.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if err := lf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	if err := gf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	if err := tf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	if groupBy != issuesPerDirectory && groupBy != issuesPerTeam {
+		fmt.Fprintf(os.Stderr, "error: unknown --group-by %q (want %s or %s)\n", groupBy, issuesPerDirectory, issuesPerTeam)
+		return 2
+	}
+	if failOn != failOnError && failOn != failOnWarning && failOn != failOnNever {
+		fmt.Fprintf(os.Stderr, "error: unknown --fail-on %q (want %s, %s or %s)\n", failOn, failOnError, failOnWarning, failOnNever)
+		return 2
+	}
+	if minMembers < 1 {
+		fmt.Fprintln(os.Stderr, "error: --min-team-members must be at least 1")
+		return 2
+	}
+	if len(labels) == 0 {
+		labels = stringList{defaultIssueLabel}
+	}
+	if createIssues {
+		if offline {
+			fmt.Fprintln(os.Stderr, "error: --create-issues files issues through the GitHub API and can't run with --offline")
+			return 2
+		}
+		if _, _, ok := strings.Cut(repo, "/"); !ok {
+			fmt.Fprintln(os.Stderr, "error: --create-issues needs --repo owner/name (or GITHUB_REPOSITORY)")
+			return 2
+		}
+	}
+
+	if err := rf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	ctx := context.Background()
+	res, err := checkRepo(ctx, rf.configPath, rf.codeowners, filter)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	stale, err := staleFindings(ctx, res)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	res.errors = append(res.errors, stale...)
+	var verification string
+	switch {
+	case !verify:
+		verification = "--verify-owners=false"
+	case offline:
+		verification = "--offline"
+	default:
+		cache := loadOwnerCache(defaultOwnerCachePath(), 24*time.Hour)
+		res.errors = append(res.errors, res.suppress(verifyOwners(newGitHubClient().withContext(ctx), res.checked, minMembers, cache))...)
+		if err := cache.save(); err != nil {
+			logger.Warn("saving owner cache", "error", err)
+		}
+	}
+
+	if err := writeAudit(os.Stdout, res.errors, computeStats(res.checked), verification); err != nil {
+		fmt.Fprintf(os.Stderr, "error: writing report: %v\n", err)
+		return 1
+	}
+	status := 0
+	if auditFails(res.errors, failOn) {
+		status = 1
+	}
+
+	fmt.Println("Issues")
+	planned := planIssues(res, groupBy)
+	if !createIssues {
+		if len(planned) == 0 {
+			fmt.Printf("  %s\n", console.mark(os.Stdout, markOK, "no unowned directories"))
+			return status
+		}
+		for _, p := range planned {
+			fmt.Printf("  %s\n", p.title)
+		}
+		fmt.Printf("\nRun with --create-issues to file %d %s.\n", len(planned), pluralize(len(planned), "issue", "issues"))
+		return status
+	}
+
+	if err := syncIssues(os.Stdout, newGitHubClient(), repo, labels, planned, closeResolved); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	return status
+}
+
+// planIssues returns the issues to file for the unowned directories in res,
+// sorted by key. Each suggests CODEOWNERS lines for the team guessed to be
+// responsible, or a placeholder when nobody could be guessed.
+func planIssues(res result, groupBy string) []plannedIssue {
+	teams := make(map[string]string)
+	for _, e := range res.errors {
+		if e.team != "" {
+			if _, ok := teams[e.path]; !ok {
+				teams[e.path] = e.team
+			}
+		}
+	}
+	codeownersPath := "CODEOWNERS"
+	if len(res.codeowners) > 0 {
+		codeownersPath = sourceLabel(res.codeowners[len(res.codeowners)-1])
+	}
+
+	seen := make(map[string]bool)
+	groups := make(map[string][]string)
+	for _, d := range res.checked {
+		if d.rule != nil || seen[d.path] {
+			continue
+		}
+		seen[d.path] = true
+		key := issuesPerDirectory + ":" + d.path
+		if groupBy == issuesPerTeam {
+			key = issuesPerTeam + ":" + teamOrUnassigned(teams[d.path])
+		}
+		groups[key] = append(groups[key], d.path)
+	}
+
+	planned := make([]plannedIssue, 0, len(groups))
+	for key, dirs := range groups {
+		sort.Strings(dirs)
+		var b strings.Builder
+		var title string
+		if groupBy == issuesPerTeam {
+			team := strings.TrimPrefix(key, issuesPerTeam+":")
+			title = fmt.Sprintf("Add CODEOWNERS owners for %d %s (%s)", len(dirs), pluralize(len(dirs), "directory", "directories"), team)
+			fmt.Fprintf(&b, "These directories have no owner in CODEOWNERS, so changes to them don't request review from anyone:\n\n")
+			for _, d := range dirs {
+				fmt.Fprintf(&b, "- `%s`\n", d)
+			}
+			if team != unassignedTeam {
+				fmt.Fprintf(&b, "\nTheir nearest owned parent directories belong to %s.", team)
+			}
+		} else {
+			title = fmt.Sprintf("Add a CODEOWNERS owner for %s", dirs[0])
+			fmt.Fprintf(&b, "`%s` has no owner in CODEOWNERS, so changes to it don't request review from anyone.", dirs[0])
+			if team := teams[dirs[0]]; team != "" {
+				fmt.Fprintf(&b, " Its nearest owned parent directory belongs to %s.", team)
+			}
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "\nAdd to `%s`:\n\n```\n", codeownersPath)
+		for _, d := range dirs {
+			owner := teams[d]
+			if owner == "" {
+				owner = "@your-team"
+			}
+			fmt.Fprintf(&b, "/%s/ %s\n", d, owner)
+		}
+		fmt.Fprintf(&b, "```\n\n<!-- requirecodeowners:%s -->\n", key)
+		planned = append(planned, plannedIssue{key: key, title: title, body: b.String()})
+	}
+	sort.Slice(planned, func(i, j int) bool { return planned[i].key < planned[j].key })
+	return planned
+}
+
+func teamOrUnassigned(team string) string {
+	if team == "" {
+		return unassignedTeam
+	}
+	return team
+}
+
+// syncIssues makes the open issues in repo match planned: it updates the
+// issue filed for each key on an earlier run if it changed, and opens one
+// for each new key. With closeResolved, issues for keys no longer planned
+// are closed. Issues from earlier runs are found by their first label. Each
+// change is reported on w.
+func syncIssues(w io.Writer, client *githubClient, repo string, labels []string, planned []plannedIssue, closeResolved bool) error {
+	open, err := client.listIssues(repo, labels[0])
+	if err != nil {
+		return fmt.Errorf("listing issues in %s: %w", repo, err)
+	}
+	existing := make(map[string]githubIssue)
+	for _, issue := range open {
+		if m := issueMarker.FindStringSubmatch(issue.Body); m != nil {
+			existing[m[1]] = issue
+		}
+	}
+
+	want := make(map[string]bool, len(planned))
+	for _, p := range planned {
+		want[p.key] = true
+		issue, ok := existing[p.key]
+		switch {
+		case !ok:
+			created, err := client.createIssue(repo, p.title, p.body, labels)
+			if err != nil {
+				return fmt.Errorf("creating issue %q: %w", p.title, err)
+			}
+			fmt.Fprintf(w, "created #%d %s\n", created.Number, p.title)
+		case issue.Title != p.title || issue.Body != p.body:
+			if err := client.updateIssue(repo, issue.Number, map[string]any{"title": p.title, "body": p.body}); err != nil {
+				return fmt.Errorf("updating issue #%d: %w", issue.Number, err)
+			}
+			fmt.Fprintf(w, "updated #%d %s\n", issue.Number, p.title)
+		default:
+			fmt.Fprintf(w, "unchanged #%d %s\n", issue.Number, p.title)
+		}
+	}
+
+	if !closeResolved {
+		return nil
+	}
+	keys := make([]string, 0, len(existing))
+	for key := range existing {
+		if !want[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		issue := existing[key]
+		if err := client.updateIssue(repo, issue.Number, map[string]any{"state": "closed", "state_reason": "completed"}); err != nil {
+			return fmt.Errorf("closing issue #%d: %w", issue.Number, err)
+		}
+		fmt.Fprintf(w, "closed #%d %s\n", issue.Number, issue.Title)
+	}
+	return nil
+}
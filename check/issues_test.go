@@ -0,0 +1,117 @@
+package check
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPlanIssues(t *testing.T) {
+	rules, err := parseCodeowners(strings.NewReader("/services/a/ @org/payments\n"), ".github/CODEOWNERS", providerGitHub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := result{
+		codeowners: []string{".github/CODEOWNERS"},
+		checked: []checkedDir{
+			{path: "services/a", rule: &rules[0]},
+			{path: "services/b"},
+			{path: "services/a/jobs"},
+			{path: "libs/x"},
+			{path: "services/b"}, // checked by two specs
+		},
+		errors: []validationError{
+			{path: "services/a/jobs", team: "@org/payments"},
+			{path: "services/b"},
+			{path: "libs/x"},
+		},
+	}
+
+	byDir := planIssues(res, issuesPerDirectory)
+	var keys []string
+	for _, p := range byDir {
+		keys = append(keys, p.key)
+	}
+	if want := "directory:libs/x directory:services/a/jobs directory:services/b"; strings.Join(keys, " ") != want {
+		t.Errorf("planIssues() keys = %v, want %s", keys, want)
+	}
+	jobs := byDir[1]
+	for _, want := range []string{"/services/a/jobs/ @org/payments", "belongs to @org/payments", "<!-- requirecodeowners:directory:services/a/jobs -->"} {
+		if !strings.Contains(jobs.body, want) {
+			t.Errorf("issue body missing %q:\n%s", want, jobs.body)
+		}
+	}
+	if !strings.Contains(byDir[2].body, "/services/b/ @your-team") {
+		t.Errorf("issue without a team should suggest a placeholder:\n%s", byDir[2].body)
+	}
+
+	byTeam := planIssues(res, issuesPerTeam)
+	if len(byTeam) != 2 {
+		t.Fatalf("planIssues() by team = %d issues, want 2", len(byTeam))
+	}
+	if byTeam[1].key != "team:Unassigned" || !strings.Contains(byTeam[1].title, "2 directories") {
+		t.Errorf("unassigned issue = %q (%s), want both untriaged directories", byTeam[1].title, byTeam[1].key)
+	}
+}
+
+func TestSyncIssues(t *testing.T) {
+	type call struct {
+		method, path string
+		body         map[string]any
+	}
+	var calls []call
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		data, _ := io.ReadAll(r.Body)
+		json.Unmarshal(data, &body)
+		calls = append(calls, call{r.Method, r.URL.Path, body})
+		switch {
+		case r.Method == http.MethodGet:
+			if got := r.URL.Query().Get("labels"); got != "codeowners" {
+				t.Errorf("listed issues with label %q, want codeowners", got)
+			}
+			fmt.Fprint(w, `[
+				{"number": 1, "title": "Add a CODEOWNERS owner for services/b", "body": "same\n<!-- requirecodeowners:directory:services/b -->\n"},
+				{"number": 2, "title": "old", "body": "stale <!-- requirecodeowners:directory:libs/x -->"},
+				{"number": 3, "title": "fixed", "body": "<!-- requirecodeowners:directory:services/c -->"},
+				{"number": 4, "title": "a pull request", "body": "<!-- requirecodeowners:directory:services/d -->", "pull_request": {}}
+			]`)
+		case r.Method == http.MethodPost:
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"number": 5}`)
+		default:
+			fmt.Fprint(w, `{}`)
+		}
+	}))
+	defer srv.Close()
+	t.Setenv("GITHUB_API_URL", srv.URL)
+
+	planned := []plannedIssue{
+		{key: "directory:libs/x", title: "Add a CODEOWNERS owner for libs/x", body: "new <!-- requirecodeowners:directory:libs/x -->"},
+		{key: "directory:services/b", title: "Add a CODEOWNERS owner for services/b", body: "same\n<!-- requirecodeowners:directory:services/b -->\n"},
+		{key: "directory:services/d", title: "Add a CODEOWNERS owner for services/d", body: "<!-- requirecodeowners:directory:services/d -->"},
+	}
+	var out bytes.Buffer
+	if err := syncIssues(&out, newGitHubClient(), "org/repo", []string{"codeowners", "triage"}, planned, true); err != nil {
+		t.Fatalf("syncIssues() error = %v", err)
+	}
+
+	want := "updated #2 Add a CODEOWNERS owner for libs/x\nunchanged #1 Add a CODEOWNERS owner for services/b\ncreated #5 Add a CODEOWNERS owner for services/d\nclosed #3 fixed\n"
+	if out.String() != want {
+		t.Errorf("syncIssues() output:\n%s\nwant:\n%s", out.String(), want)
+	}
+	if len(calls) != 4 {
+		t.Fatalf("API calls = %+v, want list, update, create, close", calls)
+	}
+	if c := calls[2]; c.method != http.MethodPost || c.path != "/repos/org/repo/issues" || len(c.body["labels"].([]any)) != 2 {
+		t.Errorf("create call = %+v, want a POST with both labels", c)
+	}
+	if c := calls[3]; c.method != http.MethodPatch || c.path != "/repos/org/repo/issues/3" || c.body["state"] != "closed" {
+		t.Errorf("close call = %+v, want issue 3 closed", c)
+	}
+}
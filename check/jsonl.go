@@ -0,0 +1,136 @@
+package check
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+)
+
+// JSON Lines records, one per line, told apart by their type.
+type (
+	jsonlDirectory struct {
+		Type string `json:"type"` // "directory"
+		ownershipEntry
+	}
+	jsonlError struct {
+		Type string `json:"type"` // "error"
+		responseError
+	}
+	jsonlSkipped struct {
+		Type string `json:"type"` // "skipped"
+		responseSkip
+	}
+	jsonlTeam struct {
+		Type     string   `json:"type"` // "team"
+		Team     string   `json:"team"`
+		Failures int      `json:"failures"`
+		Warnings int      `json:"warnings"`
+		Paths    []string `json:"paths"`
+	}
+	jsonlSummary struct {
+		Type     string  `json:"type"` // "summary"
+		Passed   bool    `json:"passed"`
+		Checked  int     `json:"checked"`
+		Owned    int     `json:"owned"`
+		Coverage float64 `json:"coverage_percent"`
+		Failures int     `json:"failures"`
+		Warnings int     `json:"warnings"`
+	}
+)
+
+// writeJSONL writes the whole report as JSON Lines: a record for each
+// checked directory, skipped directory and error, then, with
+// --group-by-team, one for each team, then a summary.
+func writeJSONL(w io.Writer, res result, opts reportOptions) error {
+	s := newJSONLStream(w)
+	s.teams = opts.groupByTeam
+	s.add(res.checked, res.skipped, res.errors)
+	return s.finish(res)
+}
+
+// jsonlStream writes JSON Lines records as the check produces results,
+// rather than once it's done, so consumers can start on a large run's
+// output right away. Each record is flushed when written. A nil *jsonlStream
+// writes nothing.
+type jsonlStream struct {
+	w       *bufio.Writer
+	enc     *json.Encoder
+	err     error
+	written int // errors written so far
+
+	// teams adds a record for each team's problems before the summary.
+	teams bool
+}
+
+// stream is where the checker writes results as it produces them, with
+// --format jsonl on stdout.
+var stream *jsonlStream
+
+func newJSONLStream(w io.Writer) *jsonlStream {
+	bw := bufio.NewWriter(w)
+	return &jsonlStream{w: bw, enc: json.NewEncoder(bw)}
+}
+
+// add writes records for newly produced results.
+func (s *jsonlStream) add(checked []checkedDir, skipped []skippedDir, errors []validationError) {
+	if s == nil {
+		return
+	}
+	for _, d := range checked {
+		s.encode(jsonlDirectory{Type: "directory", ownershipEntry: newOwnershipEntry(d)})
+	}
+	for _, d := range skipped {
+		s.encode(jsonlSkipped{Type: "skipped", responseSkip: responseSkip{Path: d.path, Reason: d.reason}})
+	}
+	for _, e := range errors {
+		s.encode(jsonlError{Type: "error", responseError: newResponseError(e)})
+	}
+	s.written += len(errors)
+	if s.err == nil {
+		s.err = s.w.Flush()
+	}
+}
+
+func (s *jsonlStream) encode(v any) {
+	if s.err == nil {
+		s.err = s.enc.Encode(v)
+	}
+}
+
+// finish writes the errors in res that came after the streamed ones, such
+// as those from verifying owners, and the summary. It must run before the
+// errors are sorted.
+func (s *jsonlStream) finish(res result) error {
+	if s == nil {
+		return nil
+	}
+	if s.written < len(res.errors) {
+		s.add(nil, nil, res.errors[s.written:])
+	}
+	if s.teams {
+		teams, groups := groupByTeam(res.errors)
+		for _, team := range teams {
+			errs := groups[team]
+			t := jsonlTeam{Type: "team", Team: team, Failures: countFailures(errs), Warnings: len(errs) - countFailures(errs)}
+			for _, e := range errs {
+				t.Paths = append(t.Paths, e.path)
+			}
+			s.encode(t)
+		}
+	}
+	owned, checked := res.coverage()
+	failures := countFailures(res.errors)
+	s.encode(jsonlSummary{
+		Type:     "summary",
+		Passed:   failures == 0,
+		Checked:  checked,
+		Owned:    owned,
+		Coverage: percent(owned, checked),
+		Failures: failures,
+		Warnings: len(res.errors) - failures,
+	})
+	if s.err == nil {
+		s.err = s.w.Flush()
+	}
+	return s.err
+}
@@ -0,0 +1,114 @@
+package check
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// jsonlTypes decodes JSON Lines output and returns each record's type and
+// path, as "type path".
+func jsonlTypes(t *testing.T, out string) []string {
+	t.Helper()
+	var got []string
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if line == "" {
+			continue
+		}
+		var rec struct {
+			Type string `json:"type"`
+			Path string `json:"path"`
+		}
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("line %q is not JSON: %v", line, err)
+		}
+		got = append(got, strings.TrimSpace(rec.Type+" "+rec.Path))
+	}
+	return got
+}
+
+func TestWriteJSONL(t *testing.T) {
+	rules, _ := parseCodeowners(strings.NewReader("/services/foo/ @org/foo\n"), "CODEOWNERS", providerGitHub)
+	res := result{
+		checked: []checkedDir{{path: "services/foo", rule: &rules[0]}, {path: "services/bar"}},
+		skipped: []skippedDir{{path: "services/old", reason: "allow_unowned"}},
+		errors:  []validationError{{path: "services/bar", message: "Not covered by CODEOWNERS."}},
+	}
+	var buf bytes.Buffer
+	if err := writeJSONL(&buf, res, reportOptions{}); err != nil {
+		t.Fatalf("writeJSONL() error = %v", err)
+	}
+	want := []string{"directory services/foo", "directory services/bar", "skipped services/old", "error services/bar", "summary"}
+	if got := jsonlTypes(t, buf.String()); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("records = %v, want %v", got, want)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	var dir jsonlDirectory
+	json.Unmarshal([]byte(lines[0]), &dir)
+	if len(dir.Owners) != 1 || dir.Owners[0] != "@org/foo" || dir.Rule == nil || dir.Rule.Line != 1 {
+		t.Errorf("directory record = %s, want @org/foo from CODEOWNERS:1", lines[0])
+	}
+	var summary jsonlSummary
+	json.Unmarshal([]byte(lines[len(lines)-1]), &summary)
+	if summary.Passed || summary.Checked != 2 || summary.Owned != 1 || summary.Failures != 1 {
+		t.Errorf("summary = %s, want 1 of 2 owned and 1 failure", lines[len(lines)-1])
+	}
+}
+
+func TestJSONLStream(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, d := range []string{"services/foo", "services/bar", "libs/util"} {
+		os.MkdirAll(filepath.Join(tmpDir, d), 0755)
+	}
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	var buf bytes.Buffer
+	old := stream
+	stream = newJSONLStream(&buf)
+	defer func() { stream = old }()
+
+	rules, _ := parseCodeowners(strings.NewReader("/services/foo/ @org/foo\n/libs/ @org/libs\n"), "CODEOWNERS", providerGitHub)
+	c := &checker{fsys: localFS{}, rules: rules, configPath: ".requirecodeowners.yml"}
+	res := c.validate([]dirSpec{{Path: "services", Level: 1}, {Path: "libs", Level: 1}})
+
+	// Everything the check found is written before it returns.
+	want := []string{"directory services/bar", "directory services/foo", "error services/bar", "directory libs/util"}
+	if got := jsonlTypes(t, buf.String()); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("streamed records = %v, want %v", got, want)
+	}
+
+	res.errors = append(res.errors, validationError{path: "@org/libs", message: "Team has no members.", severity: severityWarning})
+	if err := stream.finish(res); err != nil {
+		t.Fatalf("finish() error = %v", err)
+	}
+	want = append(want, "error @org/libs", "summary")
+	if got := jsonlTypes(t, buf.String()); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("records = %v, want %v", got, want)
+	}
+}
+
+func TestWriteJSONLTeams(t *testing.T) {
+	res := result{errors: []validationError{
+		{path: "services/pay", message: "Not covered.", team: "@org/payments"},
+		{path: "services/pay-ui", message: "Has 1 owner.", team: "@org/payments", severity: severityWarning},
+		{path: "tools", message: "Not covered."},
+	}}
+	var buf bytes.Buffer
+	if err := writeJSONL(&buf, res, reportOptions{groupByTeam: true}); err != nil {
+		t.Fatalf("writeJSONL() error = %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	want := []string{
+		`{"type":"team","team":"@org/payments","failures":1,"warnings":1,"paths":["services/pay","services/pay-ui"]}`,
+		`{"type":"team","team":"Unassigned","failures":1,"warnings":0,"paths":["tools"]}`,
+	}
+	if len(lines) != 6 || strings.Join(lines[3:5], "\n") != strings.Join(want, "\n") {
+		t.Errorf("records =\n%s\nwant the team records\n%s\nbefore the summary", buf.String(), strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,103 @@
+package check
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+)
+
+// GitHub ignores a CODEOWNERS file larger than 3 MB, without saying so:
+// https://docs.github.com/en/repositories/managing-your-repositorys-settings-and-features/customizing-your-repository/about-code-owners#codeowners-file-size
+const githubCodeownersMaxBytes = 3 << 20
+
+// codeownersLimits are where CODEOWNERS files start getting warnings: a
+// share of GitHub's size limit, lines too long to review, and rule counts
+// that make every match slow.
+var codeownersLimits = struct {
+	sizeWarning int
+	lineLength  int
+	rules       int
+}{
+	sizeWarning: githubCodeownersMaxBytes * 8 / 10,
+	lineLength:  1000,
+	rules:       5000,
+}
+
+// checkCodeownersLimits reports local CODEOWNERS files that are over or
+// close to GitHub's size limit, that have very long lines, or that have so
+// many rules matching slows down. Only a file over the size limit is an
+// error, since GitHub ignores it.
+func (c *checker) checkCodeownersLimits() []validationError {
+	var errors []validationError
+	counts := make(map[string]int)
+	var files []string
+	for _, r := range c.rules {
+		if counts[r.file] == 0 {
+			files = append(files, r.file)
+		}
+		counts[r.file]++
+	}
+
+	for _, file := range files {
+		if isURL(file) || isGitHubSource(file) {
+			continue
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		size := len(data)
+		mb := float64(size) / (1 << 20)
+		switch {
+		case size > githubCodeownersMaxBytes:
+			errors = append(errors, validationError{
+				path:    file,
+				message: fmt.Sprintf("Is %.1f MB, over GitHub's 3 MB limit, so GitHub ignores it and requests no reviews. Replace per-directory rules with broader patterns.", mb),
+				code:    codeCodeownersLimits,
+				file:    file,
+			})
+		case size >= codeownersLimits.sizeWarning:
+			errors = append(errors, validationError{
+				path:     file,
+				message:  fmt.Sprintf("Is %.1f MB, close to GitHub's 3 MB limit, over which GitHub ignores it. Replace per-directory rules with broader patterns.", mb),
+				code:     codeCodeownersLimits,
+				severity: severityWarning,
+				file:     file,
+			})
+		}
+
+		first, long, longest := 0, 0, 0
+		for i, line := range bytes.Split(data, []byte("\n")) {
+			if len(line) <= codeownersLimits.lineLength {
+				continue
+			}
+			if long == 0 {
+				first = i + 1
+			}
+			long++
+			longest = max(longest, len(line))
+		}
+		if long > 0 {
+			errors = append(errors, validationError{
+				path: file,
+				message: fmt.Sprintf("Has %d %s over %d characters, the longest %d. Split long owner lists into teams.",
+					long, pluralize(long, "line", "lines"), codeownersLimits.lineLength, longest),
+				code:     codeCodeownersLimits,
+				severity: severityWarning,
+				file:     file,
+				line:     first,
+			})
+		}
+
+		if n := counts[file]; n > codeownersLimits.rules {
+			errors = append(errors, validationError{
+				path:     file,
+				message:  fmt.Sprintf("Has %d rules, over %d, and every match checks them all. Merge rules for sibling directories into broader patterns.", n, codeownersLimits.rules),
+				code:     codeCodeownersLimits,
+				severity: severityWarning,
+				file:     file,
+			})
+		}
+	}
+	return errors
+}
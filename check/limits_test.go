@@ -0,0 +1,73 @@
+package check
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckCodeownersLimits(t *testing.T) {
+	// Comment lines just under the line length limit, to pad out the size.
+	padding := func(size int) string {
+		return strings.Repeat("#"+strings.Repeat("-", 998)+"\n", size/1000+1)
+	}
+	tests := []struct {
+		name    string
+		content string
+		want    []string // severity and line of each problem
+	}{
+		{
+			name:    "small",
+			content: "/services/a/ @org/a\n/services/b/ @org/b\n",
+		},
+		{
+			name:    "long line",
+			content: "/services/a/ @org/a\n/services/b/ " + strings.Repeat("@org/b ", 200) + "\n",
+			want:    []string{"warning 2"},
+		},
+		{
+			name:    "near size limit",
+			content: "/services/a/ @org/a\n" + padding(githubCodeownersMaxBytes*9/10),
+			want:    []string{"warning 0"},
+		},
+		{
+			name:    "over size limit",
+			content: "/services/a/ @org/a\n" + padding(githubCodeownersMaxBytes),
+			want:    []string{"error 0"},
+		},
+		{
+			name:    "many rules",
+			content: strings.Repeat("/services/a/ @org/a\n", 5001),
+			want:    []string{"warning 0"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "CODEOWNERS")
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			rules, err := parseCodeownersFile(path, providerGitHub)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c := &checker{rules: rules}
+			var got []string
+			for _, e := range c.checkCodeownersLimits() {
+				if e.code != codeCodeownersLimits || e.file != path {
+					t.Errorf("problem %+v isn't an %s at %s", e, codeCodeownersLimits, path)
+				}
+				severity := severityError
+				if e.isWarning() {
+					severity = severityWarning
+				}
+				got = append(got, fmt.Sprintf("%s %d", severity, e.line))
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("checkCodeownersLimits() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
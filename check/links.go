@@ -0,0 +1,149 @@
+package check
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// repoLinks builds links to files in the repository being checked, so the
+// markdown report can point at the file or line to change.
+type repoLinks struct {
+	// base is the repository's web URL, like https://github.com/owner/repo.
+	base string
+	// ref is the branch, tag or commit links point into.
+	ref string
+	// prefix is the working directory's path within the repository, like
+	// services/, since findings name files relative to it.
+	prefix string
+}
+
+// detectRepoLinks works out where the repository in the working directory
+// lives on GitHub: from GITHUB_SERVER_URL, GITHUB_REPOSITORY and the pull
+// request's branch in GitHub Actions, or else from the origin remote and the
+// checked-out branch. It returns nil when there's no repository to link to.
+func detectRepoLinks() *repoLinks {
+	l := &repoLinks{}
+	if repo := os.Getenv("GITHUB_REPOSITORY"); strings.Contains(repo, "/") {
+		server := os.Getenv("GITHUB_SERVER_URL")
+		if server == "" {
+			server = "https://github.com"
+		}
+		l.base = strings.TrimSuffix(server, "/") + "/" + repo
+		// GITHUB_HEAD_REF is only set for pull requests, whose GITHUB_REF_NAME
+		// is a merge ref that can't be edited.
+		l.ref = os.Getenv("GITHUB_HEAD_REF")
+		if l.ref == "" {
+			l.ref = os.Getenv("GITHUB_REF_NAME")
+		}
+	} else {
+		remote, err := gitLine("remote", "get-url", "origin")
+		if err != nil {
+			return nil
+		}
+		if l.base = remoteWebURL(remote); l.base == "" {
+			return nil
+		}
+	}
+	if l.ref == "" {
+		l.ref, _ = gitLine("rev-parse", "--abbrev-ref", "HEAD")
+		if l.ref == "HEAD" {
+			// A detached HEAD has no branch, so link to the commit.
+			l.ref, _ = gitLine("rev-parse", "HEAD")
+		}
+	}
+	if l.ref == "" || l.ref == "HEAD" {
+		return nil
+	}
+	l.prefix, _ = gitLine("rev-parse", "--show-prefix")
+	return l
+}
+
+// gitLine runs git with args, returning its output without the newline.
+func gitLine(args ...string) (string, error) {
+	out, err := gitOutput(args...)
+	return strings.TrimSpace(string(out)), err
+}
+
+// remoteWebURL turns a git remote URL, over SSH or HTTPS, into the
+// repository's web URL, without any credentials in it. It returns "" for
+// remotes that aren't on a web host, like local paths.
+func remoteWebURL(remote string) string {
+	remote = strings.TrimSuffix(strings.TrimSuffix(remote, "/"), ".git")
+	if !strings.Contains(remote, "://") {
+		// scp-like syntax: git@github.com:owner/repo
+		host, repoPath, ok := strings.Cut(remote, ":")
+		if !ok || strings.Contains(host, "/") {
+			return ""
+		}
+		if _, h, ok := strings.Cut(host, "@"); ok {
+			host = h
+		}
+		return "https://" + host + "/" + strings.TrimPrefix(repoPath, "/")
+	}
+	u, err := url.Parse(remote)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	switch u.Scheme {
+	case "ssh", "git", "http", "https":
+	default:
+		return ""
+	}
+	host := u.Hostname()
+	if u.Scheme == "http" || u.Scheme == "https" {
+		host = u.Host // keep any port, which SSH's wouldn't share
+	}
+	return "https://" + host + "/" + strings.TrimPrefix(u.Path, "/")
+}
+
+// edit returns the link to GitHub's editor for file, or "" if file isn't in
+// the repository.
+func (l *repoLinks) edit(file string) string {
+	p, ok := l.repoPath(file)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("%s/edit/%s/%s", l.base, escapePath(l.ref), escapePath(p))
+}
+
+// tree returns the link to browsing dir.
+func (l *repoLinks) tree(dir string) string {
+	return fmt.Sprintf("%s/tree/%s/%s", l.base, escapePath(l.ref), escapePath(path.Join(l.prefix, dir)))
+}
+
+// line returns the link to line n of file, or "" if file isn't in the
+// repository.
+func (l *repoLinks) line(file string, n int) string {
+	p, ok := l.repoPath(file)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("%s/blob/%s/%s#L%d", l.base, escapePath(l.ref), escapePath(p), n)
+}
+
+// repoPath returns file, relative to the working directory, as a path from
+// the repository root. Remote sources and files outside the working
+// directory aren't in it.
+func (l *repoLinks) repoPath(file string) (string, bool) {
+	if file == "" || filepath.IsAbs(file) || isURL(file) || isGitHubSource(file) {
+		return "", false
+	}
+	p := path.Clean(filepath.ToSlash(file))
+	if p == ".." || strings.HasPrefix(p, "../") || strings.HasPrefix(p, "/") {
+		return "", false
+	}
+	return path.Join(l.prefix, p), true
+}
+
+// escapePath escapes each segment of a slash-separated path for a URL.
+func escapePath(p string) string {
+	parts := strings.Split(p, "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return strings.Join(parts, "/")
+}
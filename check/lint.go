@@ -0,0 +1,220 @@
+package check
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func runConfig(args []string) int {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		fmt.Fprintln(os.Stderr, "usage: requirecodeowners config lint|validate|schema [flags]")
+		return 2
+	}
+
+	switch args[0] {
+	case "lint":
+		return runConfigLint(args[1:])
+	case "validate":
+		return runConfigValidate(args[1:])
+	case "schema":
+		return runConfigSchema(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "error: unknown config command %q\n", args[0])
+		return 2
+	}
+}
+
+func runConfigLint(args []string) int {
+	fs := flag.NewFlagSet("config lint", flag.ContinueOnError)
+	var rf repoFlags
+	rf.registerConfig(fs)
+	var lf configFlags
+	lf.register(fs)
+	var cf consoleFlags
+	cf.register(fs)
+	var tf traversalFlags
+	tf.register(fs)
+	var gf githubFlags
+	gf.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if err := lf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	if err := cf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	if err := tf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	if err := gf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+
+	if err := rf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	if err := enterConfigRoot(rf.configPath); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+
+	cfg, err := loadConfig(rf.configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+
+	actualConfigPath := filepath.ToSlash(sourceLabel(resolveConfigPath(rf.configPath)))
+
+	findings := lintConfig(withExcludes(withSymlinks(newLocalFS(), cfg.Symlinks), cfg.globalExcludes()), cfg.Directories, actualConfigPath)
+	if len(findings) > 0 {
+		fmt.Fprintln(os.Stderr)
+		for _, f := range findings {
+			fmt.Fprintf(os.Stderr, "  %s\n", console.mark(os.Stderr, markFail, f.path))
+			fmt.Fprintf(os.Stderr, "    %s\n", f.codedMessage())
+		}
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, console.mark(os.Stderr, markFail, fmt.Sprintf("%d config %s found", len(findings), pluralize(len(findings), "issue", "issues"))))
+		return 1
+	}
+
+	fmt.Println(console.mark(os.Stdout, markOK, "config has no overlapping or dead specs"))
+	return 0
+}
+
+// lintConfig reports specs that no longer match anything on disk and specs
+// that check the same directories as an earlier spec.
+func lintConfig(fsys fileSystem, specs []dirSpec, configPath string) []validationError {
+	var findings []validationError
+
+	// owner maps each checked directory to the index of the first spec that
+	// checks it, so later specs can be reported as overlapping.
+	owner := make(map[string]int)
+
+	for i, spec := range specs {
+		label := specLabel(i, spec)
+		file := configPath
+		if spec.file != "" {
+			file = spec.file
+		}
+
+		matchedDirs, err := expandPath(specFS(fsys, spec), spec.Path)
+		if err != nil {
+			findings = append(findings, validationError{
+				path:    label,
+				message: fmt.Sprintf("Cannot expand path: %v", err),
+				code:    codeUnreadable,
+			})
+			continue
+		}
+		if len(matchedDirs) == 0 {
+			findings = append(findings, validationError{
+				path:    label,
+				message: deadSpecMessage(spec, file),
+				code:    codeNoMatch,
+			})
+			continue
+		}
+
+		var checked []string
+		for _, dir := range matchedDirs {
+			if mode, ok := discoveryModes[spec.Mode]; ok {
+				pkgs, err := mode.discover(specFS(fsys, spec), dir, spec)
+				if err != nil {
+					findings = append(findings, validationError{path: label, message: fmt.Sprintf("Cannot read %s: %v", dir, err), code: codeUnreadable})
+				}
+				checked = append(checked, pkgs...)
+				continue
+			}
+			for _, level := range spec.depths() {
+				dirs, err := getDirsAtLevel(specFS(fsys, spec), dir, level)
+				if err != nil {
+					findings = append(findings, validationError{path: label, message: fmt.Sprintf("Cannot read %s: %v", dir, err), code: codeUnreadable})
+					continue
+				}
+				checked = append(checked, dirs...)
+			}
+		}
+		if mode, ok := discoveryModes[spec.Mode]; ok && len(checked) == 0 {
+			findings = append(findings, validationError{
+				path:    label,
+				message: fmt.Sprintf("No %s found, so nothing is checked. Fix the path or remove it from %s.", mode.units, file),
+				code:    codeEmptyLevel,
+			})
+			continue
+		}
+		if depths := spec.depths(); len(depths) > 0 && depths[0] > 0 && len(checked) == 0 {
+			findings = append(findings, validationError{
+				path:    label,
+				message: fmt.Sprintf("No subdirectories found at level %d, so nothing is checked. Lower the level or remove it from %s.", depths[0], file),
+				code:    codeEmptyLevel,
+			})
+			continue
+		}
+
+		// Count shared directories per earlier spec, keeping the first
+		// example of each for the message.
+		overlaps := make(map[int]int)
+		examples := make(map[int]string)
+		var order []int
+		for _, d := range checked {
+			if isExcluded(d, spec.Excludes) {
+				continue
+			}
+			j, ok := owner[d]
+			if !ok {
+				owner[d] = i
+				continue
+			}
+			if j == i {
+				continue
+			}
+			if _, seen := overlaps[j]; !seen {
+				order = append(order, j)
+				examples[j] = d
+			}
+			overlaps[j]++
+		}
+		for _, j := range order {
+			findings = append(findings, validationError{
+				path: label,
+				message: fmt.Sprintf("Overlaps with %s: %d %s checked twice (e.g. %s). Narrow or remove one of them in %s.",
+					specLabel(j, specs[j]), overlaps[j], pluralize(overlaps[j], "directory", "directories"), examples[j], file),
+				code: codeOverlappingSpecs,
+			})
+		}
+	}
+
+	return findings
+}
+
+func deadSpecMessage(spec dirSpec, configPath string) string {
+	if isGlob(spec.Path) {
+		return fmt.Sprintf("Glob expands to no directories. Fix the pattern or remove it from %s.", configPath)
+	}
+	if info, err := os.Stat(spec.Path); err == nil && !info.IsDir() {
+		return fmt.Sprintf("Path is a file, not a directory. Remove it from %s.", configPath)
+	}
+	return fmt.Sprintf("Path does not exist. Remove it from %s or create the directory.", configPath)
+}
+
+func specLabel(i int, spec dirSpec) string {
+	if spec.Name != "" {
+		return fmt.Sprintf("directories[%d] (%s)", i, spec.Name)
+	}
+	return fmt.Sprintf("directories[%d] (%s)", i, spec.Path)
+}
+
+func isGlob(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
@@ -0,0 +1,93 @@
+package check
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLintConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	os.MkdirAll(filepath.Join(tmpDir, "services", "foo"), 0755)
+	os.MkdirAll(filepath.Join(tmpDir, "services", "bar"), 0755)
+	os.MkdirAll(filepath.Join(tmpDir, "libs"), 0755)
+	os.MkdirAll(filepath.Join(tmpDir, "empty"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, "file.txt"), []byte("test"), 0644)
+
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	tests := []struct {
+		name     string
+		specs    []dirSpec
+		wantMsgs []string
+	}{
+		{
+			name: "clean config",
+			specs: []dirSpec{
+				{Path: "services", Level: 1},
+				{Path: "libs", Level: 0},
+			},
+		},
+		{
+			name:     "missing path",
+			specs:    []dirSpec{{Path: "nonexistent"}},
+			wantMsgs: []string{"Path does not exist"},
+		},
+		{
+			name:     "path is a file",
+			specs:    []dirSpec{{Path: "file.txt"}},
+			wantMsgs: []string{"Path is a file"},
+		},
+		{
+			name:     "glob expands to nothing",
+			specs:    []dirSpec{{Path: "apps/*/services"}},
+			wantMsgs: []string{"Glob expands to no directories"},
+		},
+		{
+			name:     "level with no subdirectories",
+			specs:    []dirSpec{{Path: "empty", Level: 1}},
+			wantMsgs: []string{"No subdirectories found at level 1"},
+		},
+		{
+			name: "glob overlaps level spec",
+			specs: []dirSpec{
+				{Path: "services", Level: 1},
+				{Path: "services/*", Level: 0},
+			},
+			wantMsgs: []string{"Overlaps with directories[0] (services): 2 directories checked twice"},
+		},
+		{
+			name: "duplicate spec",
+			specs: []dirSpec{
+				{Path: "libs"},
+				{Path: "libs"},
+			},
+			wantMsgs: []string{"Overlaps with directories[0] (libs): 1 directory checked twice"},
+		},
+		{
+			name: "different depths do not overlap",
+			specs: []dirSpec{
+				{Path: "services", Level: 0},
+				{Path: "services", Level: 1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := lintConfig(localFS{}, tt.specs, ".requirecodeowners.yml")
+			if len(findings) != len(tt.wantMsgs) {
+				t.Fatalf("lintConfig() findings = %v, want %d findings", findings, len(tt.wantMsgs))
+			}
+			for i, want := range tt.wantMsgs {
+				if !strings.Contains(findings[i].message, want) {
+					t.Errorf("lintConfig() finding %d = %q, want message containing %q", i, findings[i].message, want)
+				}
+			}
+		})
+	}
+}
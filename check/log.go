@@ -0,0 +1,50 @@
+package check
+
+import (
+	"io"
+	"log/slog"
+)
+
+// levelTrace is below debug, for the per-probe detail of --debug-match.
+const levelTrace = slog.LevelDebug - 4
+
+// logger receives diagnostics about what a check is doing. It only shows
+// warnings unless --verbose or --quiet changes the level.
+var logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// logLevel picks the level for the logging flags. --quiet shows only errors,
+// --verbose adds every checked directory and the rule that matched it, and
+// --debug-match adds each path probed while matching.
+func logLevel(quiet, verbose, debugMatch bool) slog.Level {
+	switch {
+	case debugMatch:
+		return levelTrace
+	case verbose:
+		return slog.LevelDebug
+	case quiet:
+		return slog.LevelError
+	}
+	return slog.LevelWarn
+}
+
+// setupLogging points logger at w, showing records at level and above.
+func setupLogging(w io.Writer, level slog.Level) {
+	logger = slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{
+		Level: level,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) > 0 {
+				return a
+			}
+			switch a.Key {
+			case slog.TimeKey:
+				// Timestamps are noise in a CLI run that lasts a second
+				return slog.Attr{}
+			case slog.LevelKey:
+				if a.Value.Any().(slog.Level) == levelTrace {
+					return slog.String(slog.LevelKey, "TRACE")
+				}
+			}
+			return a
+		},
+	}))
+}
@@ -0,0 +1,128 @@
+package check
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetupLogging(t *testing.T) {
+	defer setupLogging(&bytes.Buffer{}, slog.LevelWarn)
+
+	tests := []struct {
+		name       string
+		quiet      bool
+		verbose    bool
+		debugMatch bool
+		wantLogs   []string
+		noLogs     []string
+	}{
+		{name: "default", wantLogs: []string{"warn message"}, noLogs: []string{"info message", "debug message"}},
+		{name: "quiet", quiet: true, wantLogs: []string{"error message"}, noLogs: []string{"warn message"}},
+		{name: "verbose", verbose: true, wantLogs: []string{"info message", "debug message"}, noLogs: []string{"trace message"}},
+		{name: "debug match", debugMatch: true, wantLogs: []string{"debug message", "level=TRACE msg=\"trace message\""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			setupLogging(&buf, logLevel(tt.quiet, tt.verbose, tt.debugMatch))
+			logger.Log(context.Background(), levelTrace, "trace message")
+			logger.Debug("debug message")
+			logger.Info("info message")
+			logger.Warn("warn message")
+			logger.Error("error message")
+
+			out := buf.String()
+			if strings.Contains(out, "time=") {
+				t.Errorf("log output has timestamps: %s", out)
+			}
+			for _, want := range tt.wantLogs {
+				if !strings.Contains(out, want) {
+					t.Errorf("log output missing %q:\n%s", want, out)
+				}
+			}
+			for _, unwanted := range tt.noLogs {
+				if strings.Contains(out, unwanted) {
+					t.Errorf("log output has %q:\n%s", unwanted, out)
+				}
+			}
+		})
+	}
+}
+
+func TestVerboseLogsMatchedRule(t *testing.T) {
+	var buf bytes.Buffer
+	setupLogging(&buf, slog.LevelDebug)
+	defer setupLogging(&bytes.Buffer{}, slog.LevelWarn)
+
+	tmpDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tmpDir, "services", "foo"), 0755)
+	os.MkdirAll(filepath.Join(tmpDir, "services", "bar"), 0755)
+
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	rules, err := parseCodeowners(strings.NewReader("/services/foo/ @team\n"), "CODEOWNERS", providerGitHub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &checker{fsys: localFS{}, rules: rules, configPath: ".requirecodeowners.yml"}
+	c.validate([]dirSpec{{Path: "services", Level: 1}})
+
+	out := buf.String()
+	for _, want := range []string{"path=services/foo rule=CODEOWNERS:1", "path=services/bar rule=none"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("verbose output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestQuietHidesWarnings(t *testing.T) {
+	errors := []validationError{
+		{path: "a", message: "failure"},
+		{path: "b", message: "warning", severity: severityWarning},
+	}
+	if got := consoleErrors(errors, reportOptions{}); len(got) != 2 {
+		t.Errorf("consoleErrors() = %v, want both errors", got)
+	}
+	if got := consoleErrors(errors, reportOptions{quiet: true}); len(got) != 1 || got[0].path != "a" {
+		t.Errorf("consoleErrors(quiet) = %v, want only the failure", got)
+	}
+
+	var buf bytes.Buffer
+	writeMarkdown(&buf, result{}, reportOptions{quiet: true})
+	if buf.Len() != 0 {
+		t.Errorf("writeMarkdown(quiet) wrote %q, want no success message", buf.String())
+	}
+}
+
+func TestDebugMatchLogsProbes(t *testing.T) {
+	var buf bytes.Buffer
+	setupLogging(&buf, levelTrace)
+	defer setupLogging(&bytes.Buffer{}, slog.LevelWarn)
+
+	rules, err := parseCodeowners(strings.NewReader("/services/foo/ @team @other\n/services/foo/tmp\n"), "CODEOWNERS", providerGitHub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	matchDirectory(rules, "services/foo")
+
+	out := buf.String()
+	for _, want := range []string{
+		"probe=services/foo rule=none",
+		"probe=services/foo/ rule=CODEOWNERS:1 pattern=/services/foo/ owners=\"@team @other\"",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("debug output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "probe=services/foo/file.txt") {
+		t.Errorf("debug output probes past the first match:\n%s", out)
+	}
+}
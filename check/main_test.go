@@ -0,0 +1,1363 @@
+package check
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hmarr/codeowners"
+)
+
+func TestLoadConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+		errMsg  string
+	}{
+		{
+			name: "valid config",
+			content: `directories:
+  - path: src
+    level: 0
+  - path: services
+    level: 1
+`,
+			wantErr: false,
+		},
+		{
+			name: "missing path",
+			content: `directories:
+  - level: 1
+`,
+			wantErr: true,
+			errMsg:  "has no path",
+		},
+		{
+			name: "negative level",
+			content: `directories:
+  - path: src
+    level: -1
+`,
+			wantErr: true,
+			errMsg:  "invalid level",
+		},
+		{
+			name: "invalid severity",
+			content: `directories:
+  - path: src
+    severity: fatal
+`,
+			wantErr: true,
+			errMsg:  "invalid severity",
+		},
+		{
+			name: "suppression without a reason",
+			content: `directories:
+  - path: src
+suppressions:
+  - code: RCO001
+    path: src/legacy
+`,
+			wantErr: true,
+			errMsg:  "needs a reason",
+		},
+		{
+			name: "suppression with an unknown code",
+			content: `directories:
+  - path: src
+suppressions:
+  - code: RCO999
+    path: src/legacy
+    reason: migrating
+`,
+			wantErr: true,
+			errMsg:  "unknown code",
+		},
+		{
+			name: "suppression with a bad date",
+			content: `directories:
+  - path: src
+suppressions:
+  - code: missing-entry
+    path: src/legacy
+    reason: migrating
+    expires: 31/12/2026
+`,
+			wantErr: true,
+			errMsg:  "is not a date",
+		},
+		{
+			name: "defaults with path",
+			content: `defaults:
+  path: src
+directories:
+  - path: services
+`,
+			wantErr: true,
+			errMsg:  "defaults cannot set a path",
+		},
+		{
+			name: "negative max_dirs_per_owner",
+			content: `policy:
+  max_dirs_per_owner: -1
+directories:
+  - path: src
+`,
+			wantErr: true,
+			errMsg:  "invalid max_dirs_per_owner",
+		},
+		{
+			name: "invalid policy severity",
+			content: `policy:
+  severity: fatal
+directories:
+  - path: src
+`,
+			wantErr: true,
+			errMsg:  "policy has invalid severity",
+		},
+		{
+			name: "invalid allow_unowned pattern",
+			content: `allow_unowned: ["sandbox["]
+directories:
+  - path: src
+`,
+			wantErr: true,
+			errMsg:  "allow_unowned has invalid pattern",
+		},
+		{
+			name: "invalid symlinks",
+			content: `symlinks: ignore
+directories:
+  - path: src
+`,
+			wantErr: true,
+			errMsg:  `invalid symlinks "ignore"`,
+		},
+		{
+			name: "invalid mode",
+			content: `directories:
+  - path: src
+    mode: packages
+`,
+			wantErr: true,
+			errMsg:  `invalid mode "packages"`,
+		},
+		{
+			name: "level with gopackages",
+			content: `directories:
+  - path: src
+    mode: gopackages
+    level: 1
+`,
+			wantErr: true,
+			errMsg:  "which mode gopackages doesn't use",
+		},
+		{
+			name: "terraform_roots without terraform mode",
+			content: `directories:
+  - path: infra
+    level: 1
+    terraform_roots: true
+`,
+			wantErr: true,
+			errMsg:  "only applies to mode terraform",
+		},
+		{
+			name: "conflicting email policy",
+			content: `policy:
+  forbid_email_owners: true
+  allowed_email_domains: [example.com]
+directories:
+  - path: src
+`,
+			wantErr: true,
+			errMsg:  "can't set both",
+		},
+		{
+			name: "duplicate names",
+			content: `directories:
+  - name: services
+    path: services
+  - name: services
+    path: apps/*/services
+`,
+			wantErr: true,
+			errMsg:  `directories 0 and 1 are both named "services"`,
+		},
+		{
+			name:    "invalid yaml",
+			content: `not: valid: yaml:`,
+			wantErr: true,
+			errMsg:  "parsing config",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(tmpDir, tt.name+".yml")
+			os.WriteFile(configPath, []byte(tt.content), 0644)
+
+			cfg, err := loadConfig(configPath)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("loadConfig() expected error containing %q, got nil", tt.errMsg)
+				} else if !strings.Contains(err.Error(), tt.errMsg) {
+					t.Errorf("loadConfig() error = %v, want error containing %q", err, tt.errMsg)
+				}
+			} else {
+				if err != nil {
+					t.Errorf("loadConfig() unexpected error: %v", err)
+				}
+				if cfg == nil {
+					t.Error("loadConfig() returned nil config")
+				}
+			}
+		})
+	}
+}
+
+func TestLoadConfigDefaultPath(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, ".requirecodeowners.yml")
+	os.WriteFile(configPath, []byte(`directories:
+  - path: src
+`), 0644)
+
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	cfg, err := loadConfig("")
+	if err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+	if len(cfg.Directories) != 1 {
+		t.Errorf("loadConfig() got %d directories, want 1", len(cfg.Directories))
+	}
+}
+
+func TestLoadConfigDefaults(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, ".requirecodeowners.yml")
+	os.WriteFile(configPath, []byte(`defaults:
+  level: 1
+  excludes: [testdata]
+  severity: warning
+  min_owners: 2
+directories:
+  - path: services
+  - path: libs
+    level: 0
+    excludes: []
+    severity: error
+`), 0644)
+
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+
+	want := []dirSpec{
+		{Path: "services", Level: 1, Excludes: []string{"testdata"}, Severity: "warning", MinOwners: 2},
+		{Path: "libs", Level: 0, Excludes: []string{}, Severity: "error", MinOwners: 2},
+	}
+	if len(cfg.Directories) != len(want) {
+		t.Fatalf("loadConfig() got %d directories, want %d", len(cfg.Directories), len(want))
+	}
+	for i, w := range want {
+		got := cfg.Directories[i]
+		if got.Path != w.Path || got.Level != w.Level || got.Severity != w.Severity || got.MinOwners != w.MinOwners ||
+			strings.Join(got.Excludes, ",") != strings.Join(w.Excludes, ",") {
+			t.Errorf("directories[%d] = %+v, want %+v", i, got, w)
+		}
+	}
+}
+
+func TestLoadConfigLevels(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    [][]int
+		wantErr string
+	}{
+		{
+			name: "list and range",
+			content: `directories:
+  - path: teams
+    levels: [2, 1, 2]
+  - path: services
+    level: 1..3
+  - path: libs
+    level: 1
+`,
+			want: [][]int{{1, 2}, {1, 2, 3}, {1}},
+		},
+		{
+			name: "level overrides default levels",
+			content: `defaults:
+  level: 0..1
+directories:
+  - path: teams
+  - path: libs
+    level: 2
+`,
+			want: [][]int{{0, 1}, {2}},
+		},
+		{
+			name:    "both",
+			content: "directories:\n  - path: teams\n    level: 1\n    levels: [2]\n",
+			wantErr: "can't set both level and levels",
+		},
+		{
+			name:    "backwards range",
+			content: "directories:\n  - path: teams\n    level: 3..1\n",
+			wantErr: `invalid level range "3..1"`,
+		},
+		{
+			name:    "negative",
+			content: "directories:\n  - path: teams\n    levels: [1, -1]\n",
+			wantErr: "invalid level -1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := parseConfig([]byte(tt.content), ".requirecodeowners.yml")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseConfig() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseConfig() error = %v", err)
+			}
+			for i, want := range tt.want {
+				if got := cfg.Directories[i].depths(); !reflect.DeepEqual(got, want) {
+					t.Errorf("directories[%d].depths() = %v, want %v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadConfigFormats(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	tests := []struct {
+		file    string
+		content string
+	}{
+		{
+			file: "config.yaml",
+			content: `defaults:
+  level: 1
+directories:
+  - path: services
+  - path: libs
+    level: 0
+`,
+		},
+		{
+			file: "config.toml",
+			content: `[defaults]
+level = 1
+
+[[directories]]
+path = "services"
+
+[[directories]]
+path = "libs"
+level = 0
+`,
+		},
+		{
+			file:    "config.json",
+			content: `{"defaults": {"level": 1}, "directories": [{"path": "services"}, {"path": "libs", "level": 0}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			configPath := filepath.Join(tmpDir, tt.file)
+			os.WriteFile(configPath, []byte(tt.content), 0644)
+
+			cfg, err := loadConfig(configPath)
+			if err != nil {
+				t.Fatalf("loadConfig() error = %v", err)
+			}
+			if len(cfg.Directories) != 2 {
+				t.Fatalf("loadConfig() got %d directories, want 2", len(cfg.Directories))
+			}
+			if d := cfg.Directories[0]; d.Path != "services" || d.Level != 1 {
+				t.Errorf("directories[0] = %+v, want services at level 1", d)
+			}
+			if d := cfg.Directories[1]; d.Path != "libs" || d.Level != 0 {
+				t.Errorf("directories[1] = %+v, want libs at level 0", d)
+			}
+		})
+	}
+
+	configPath := filepath.Join(tmpDir, "invalid.json")
+	os.WriteFile(configPath, []byte(`{"directories": [`), 0644)
+	if _, err := loadConfig(configPath); err == nil || !strings.Contains(err.Error(), "parsing config") {
+		t.Errorf("loadConfig() error = %v, want parsing error", err)
+	}
+}
+
+func TestLoadConfigAliases(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{
+			name: "aliases resolved",
+			content: `aliases:
+  "@payments": "@org/payments-core"
+defaults:
+  default_owner: "@Payments"
+policy:
+  allowed_owners: ["@payments", "@org/api"]
+directories:
+  - path: services
+  - path: libs
+    default_owner: "@org/libs"
+`,
+		},
+		{
+			name: "alias to an alias",
+			content: `aliases:
+  "@pay": "@payments"
+  "@payments": "@org/payments-core"
+directories:
+  - path: services
+`,
+			wantErr: "refers to another alias",
+		},
+		{
+			name: "alias without owner",
+			content: `aliases:
+  "@pay": ""
+directories:
+  - path: services
+`,
+			wantErr: "has no owner",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(tmpDir, tt.name+".yml")
+			os.WriteFile(configPath, []byte(tt.content), 0644)
+
+			cfg, err := loadConfig(configPath)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("loadConfig() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadConfig() error = %v", err)
+			}
+			if got := cfg.Policy.AllowedOwners; !reflect.DeepEqual(got, []string{"@org/payments-core", "@org/api"}) {
+				t.Errorf("allowed owners = %v, want the alias resolved", got)
+			}
+			if got := cfg.Directories[0].DefaultOwner; got != "@org/payments-core" {
+				t.Errorf("inherited default_owner = %q, want the alias resolved", got)
+			}
+			if got := cfg.Directories[1].DefaultOwner; got != "@org/libs" {
+				t.Errorf("default_owner = %q, want it unchanged", got)
+			}
+		})
+	}
+}
+
+func TestConfigGlobalExcludes(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name:    "defaults",
+			content: "directories:\n  - path: services\n",
+			want:    defaultGlobalExcludes,
+		},
+		{
+			name:    "extended",
+			content: "global_excludes: [dist]\ndirectories:\n  - path: services\n",
+			want:    append(append([]string(nil), defaultGlobalExcludes...), "dist"),
+		},
+		{
+			name:    "replaced",
+			content: "default_excludes: false\nglobal_excludes: [dist]\ndirectories:\n  - path: services\n",
+			want:    []string{"dist"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := parseConfig([]byte(tt.content), ".requirecodeowners.yml")
+			if err != nil {
+				t.Fatalf("parseConfig() error = %v", err)
+			}
+			if got := cfg.globalExcludes(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("globalExcludes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveConfigPath(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	if got := resolveConfigPath(""); got != ".requirecodeowners.yml" {
+		t.Errorf("resolveConfigPath() with no config = %q, want .requirecodeowners.yml", got)
+	}
+
+	os.WriteFile(".requirecodeowners.toml", []byte(""), 0644)
+	if got := resolveConfigPath(""); got != ".requirecodeowners.toml" {
+		t.Errorf("resolveConfigPath() = %q, want .requirecodeowners.toml", got)
+	}
+
+	os.WriteFile(".requirecodeowners.yml", []byte(""), 0644)
+	if got := resolveConfigPath(""); got != ".requirecodeowners.yml" {
+		t.Errorf("resolveConfigPath() = %q, want YAML to take precedence", got)
+	}
+
+	if got := resolveConfigPath("custom.json"); got != "custom.json" {
+		t.Errorf("resolveConfigPath(custom.json) = %q, want custom.json", got)
+	}
+}
+
+func TestEnterConfigRoot(t *testing.T) {
+	tmpDir, _ := filepath.EvalSymlinks(t.TempDir())
+
+	// repo/.git marks the git root; a config above it is never found.
+	repo := filepath.Join(tmpDir, "repo")
+	os.MkdirAll(filepath.Join(repo, ".git"), 0755)
+	os.MkdirAll(filepath.Join(repo, "services", "api", "handlers"), 0755)
+	os.MkdirAll(filepath.Join(repo, "other"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, ".requirecodeowners.yml"), []byte(""), 0644)
+	os.WriteFile(filepath.Join(repo, "services", ".requirecodeowners.yml"), []byte(""), 0644)
+
+	oldWd, _ := os.Getwd()
+	defer os.Chdir(oldWd)
+
+	tests := []struct {
+		name       string
+		wd         string
+		configPath string
+		want       string
+	}{
+		{
+			name: "found in parent",
+			wd:   filepath.Join(repo, "services", "api", "handlers"),
+			want: filepath.Join(repo, "services"),
+		},
+		{
+			name: "stops at git root",
+			wd:   filepath.Join(repo, "other"),
+			want: filepath.Join(repo, "other"),
+		},
+		{
+			name:       "explicit config disables search",
+			wd:         filepath.Join(repo, "services", "api"),
+			configPath: "explicit.yml",
+			want:       filepath.Join(repo, "services", "api"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Chdir(tt.wd)
+			if err := enterConfigRoot(tt.configPath); err != nil {
+				t.Fatalf("enterConfigRoot() error = %v", err)
+			}
+			if wd, _ := os.Getwd(); wd != tt.want {
+				t.Errorf("enterConfigRoot() moved to %s, want %s", wd, tt.want)
+			}
+		})
+	}
+}
+
+func TestChangeDir(t *testing.T) {
+	tmpDir, _ := filepath.EvalSymlinks(t.TempDir())
+
+	oldWd, _ := os.Getwd()
+	defer os.Chdir(oldWd)
+
+	if err := changeDir(tmpDir); err != nil {
+		t.Fatalf("changeDir() error = %v", err)
+	}
+	if wd, _ := os.Getwd(); wd != tmpDir {
+		t.Errorf("changeDir() moved to %s, want %s", wd, tmpDir)
+	}
+
+	if err := changeDir(""); err != nil {
+		t.Fatalf("changeDir(\"\") error = %v", err)
+	}
+	if wd, _ := os.Getwd(); wd != tmpDir {
+		t.Errorf("changeDir(\"\") moved to %s, want to stay in %s", wd, tmpDir)
+	}
+
+	if err := changeDir(filepath.Join(tmpDir, "missing")); err == nil {
+		t.Error("changeDir() expected error for missing directory")
+	}
+}
+
+func TestLoadConfigCodeowners(t *testing.T) {
+	t.Setenv("RCO_TOOLS", "tools")
+
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, ".requirecodeowners.yml")
+	os.WriteFile(configPath, []byte(`codeowners: ${RCO_TOOLS}/CODEOWNERS
+directories:
+  - path: src
+`), 0644)
+
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+	if len(cfg.Codeowners) != 1 || cfg.Codeowners[0] != "tools/CODEOWNERS" {
+		t.Errorf("loadConfig() codeowners = %v, want [tools/CODEOWNERS]", cfg.Codeowners)
+	}
+
+	os.WriteFile(configPath, []byte(`codeowners: [CODEOWNERS, teams/CODEOWNERS]
+directories:
+  - path: src
+`), 0644)
+
+	cfg, err = loadConfig(configPath)
+	if err != nil {
+		t.Fatalf("loadConfig() error = %v", err)
+	}
+	if strings.Join(cfg.Codeowners, ",") != "CODEOWNERS,teams/CODEOWNERS" {
+		t.Errorf("loadConfig() codeowners = %v, want [CODEOWNERS teams/CODEOWNERS]", cfg.Codeowners)
+	}
+}
+
+func TestExpandVars(t *testing.T) {
+	t.Setenv("RCO_ROOT", "apps")
+	t.Setenv("RCO_EMPTY", "")
+	t.Setenv("HOME", "/home/test")
+
+	tests := []struct {
+		name    string
+		path    string
+		want    string
+		wantErr string
+	}{
+		{name: "no references", path: "services", want: "services"},
+		{name: "braced variable", path: "${RCO_ROOT}/services", want: "apps/services"},
+		{name: "empty variable", path: "${RCO_EMPTY}services", want: "services"},
+		{name: "bare dollar is literal", path: "$RCO_ROOT/services", want: "$RCO_ROOT/services"},
+		{name: "home dir", path: "~/src", want: "/home/test/src"},
+		{name: "tilde mid-path is literal", path: "src/~", want: "src/~"},
+		{name: "unset variable", path: "${RCO_UNSET}/x", wantErr: "environment variable RCO_UNSET not set"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expandVars(tt.path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expandVars(%q) error = %v, want error containing %q", tt.path, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expandVars(%q) unexpected error: %v", tt.path, err)
+			}
+			if got != tt.want {
+				t.Errorf("expandVars(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Create test directories
+	os.MkdirAll(filepath.Join(tmpDir, "src"), 0755)
+	os.MkdirAll(filepath.Join(tmpDir, "pkg"), 0755)
+	os.MkdirAll(filepath.Join(tmpDir, ".github"), 0755)
+
+	// Create a file (not a directory)
+	os.WriteFile(filepath.Join(tmpDir, "file.txt"), []byte("test"), 0644)
+
+	// Create CODEOWNERS
+	os.WriteFile(filepath.Join(tmpDir, ".github", "CODEOWNERS"), []byte(`/src/ @team-a
+`), 0644)
+
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	ruleset, err := loadCodeowners(context.Background(), nil, providerGitHub)
+	if err != nil {
+		t.Fatalf("loading CODEOWNERS: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		specs    []dirSpec
+		wantErrs int
+	}{
+		{
+			name:     "covered directory passes",
+			specs:    []dirSpec{{Path: "src", Level: 0}},
+			wantErrs: 0,
+		},
+		{
+			name:     "uncovered directory fails",
+			specs:    []dirSpec{{Path: "pkg", Level: 0}},
+			wantErrs: 1,
+		},
+		{
+			name:     "nonexistent directory fails",
+			specs:    []dirSpec{{Path: "nonexistent", Level: 0}},
+			wantErrs: 1,
+		},
+		{
+			name:     "file instead of directory fails",
+			specs:    []dirSpec{{Path: "file.txt", Level: 0}},
+			wantErrs: 1,
+		},
+		{
+			name: "multiple directories mixed results",
+			specs: []dirSpec{
+				{Path: "src", Level: 0},
+				{Path: "pkg", Level: 0},
+			},
+			wantErrs: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &checker{fsys: localFS{}, rules: ruleset, configPath: ".requirecodeowners.yml"}
+			errs := c.validate(tt.specs).errors
+			if len(errs) != tt.wantErrs {
+				t.Errorf("validate() errors = %v, want %d errors", errs, tt.wantErrs)
+			}
+		})
+	}
+}
+
+func TestValidateWithLevel(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Create directory structure
+	os.MkdirAll(filepath.Join(tmpDir, "services", "foo"), 0755)
+	os.MkdirAll(filepath.Join(tmpDir, "services", "bar"), 0755)
+	os.MkdirAll(filepath.Join(tmpDir, "services", "baz"), 0755)
+	os.MkdirAll(filepath.Join(tmpDir, "empty"), 0755)
+	os.MkdirAll(filepath.Join(tmpDir, ".github"), 0755)
+
+	// Create CODEOWNERS - only foo and bar have owners, bar has two
+	os.WriteFile(filepath.Join(tmpDir, ".github", "CODEOWNERS"), []byte(`/services/foo/ @team-foo
+/services/bar/ @team-bar @team-bar-leads
+`), 0644)
+
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	ruleset, err := loadCodeowners(context.Background(), nil, providerGitHub)
+	if err != nil {
+		t.Fatalf("loading CODEOWNERS: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		specs    []dirSpec
+		wantErrs int
+	}{
+		{
+			name:     "level 0 checks services itself",
+			specs:    []dirSpec{{Path: "services", Level: 0}},
+			wantErrs: 1, // services/ has no owner
+		},
+		{
+			name:     "level 1 checks subdirs - baz missing",
+			specs:    []dirSpec{{Path: "services", Level: 1}},
+			wantErrs: 1, // baz has no owner
+		},
+		{
+			name:     "level 1 with no subdirs errors",
+			specs:    []dirSpec{{Path: "empty", Level: 1}},
+			wantErrs: 1, // no subdirs to check
+		},
+		{
+			name: "mixed levels",
+			specs: []dirSpec{
+				{Path: "services", Level: 1},
+				{Path: "empty", Level: 0},
+			},
+			wantErrs: 2, // baz missing + empty uncovered
+		},
+		{
+			name:     "excluded subdir is skipped",
+			specs:    []dirSpec{{Path: "services", Level: 1, Excludes: []string{"baz"}}},
+			wantErrs: 0,
+		},
+		{
+			name:     "excluded by full path",
+			specs:    []dirSpec{{Path: "services", Level: 1, Excludes: []string{"services/b*"}}},
+			wantErrs: 0,
+		},
+		{
+			name:     "min_owners fails rules with too few owners",
+			specs:    []dirSpec{{Path: "services", Level: 1, MinOwners: 2}},
+			wantErrs: 2, // foo has one owner + baz missing
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &checker{fsys: localFS{}, rules: ruleset, configPath: ".requirecodeowners.yml"}
+			errs := c.validate(tt.specs).errors
+			if len(errs) != tt.wantErrs {
+				t.Errorf("validate() errors = %v, want %d errors", errs, tt.wantErrs)
+			}
+		})
+	}
+}
+
+func TestValidateWithLevels(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	os.MkdirAll(filepath.Join(tmpDir, "teams", "payments", "api"), 0755)
+	os.MkdirAll(filepath.Join(tmpDir, "teams", "search", "indexer"), 0755)
+	os.MkdirAll(filepath.Join(tmpDir, ".github"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, ".github", "CODEOWNERS"), []byte(`/teams/payments @team-payments
+/teams/search/indexer @team-indexer
+`), 0644)
+
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	ruleset, err := loadCodeowners(context.Background(), nil, providerGitHub)
+	if err != nil {
+		t.Fatalf("loading CODEOWNERS: %v", err)
+	}
+
+	c := &checker{fsys: localFS{}, rules: ruleset, configPath: ".requirecodeowners.yml"}
+	res := c.validate([]dirSpec{{Path: "teams", Levels: []int{1, 2}}})
+	var checked []string
+	for _, d := range res.checked {
+		checked = append(checked, d.path)
+	}
+	if want := []string{"teams/payments", "teams/search", "teams/payments/api", "teams/search/indexer"}; !reflect.DeepEqual(checked, want) {
+		t.Errorf("checked = %v, want %v", checked, want)
+	}
+	if len(res.errors) != 1 || res.errors[0].path != "teams/search" {
+		t.Errorf("validate() errors = %v, want only teams/search", res.errors)
+	}
+
+	res = c.validate([]dirSpec{{Path: "teams", Levels: []int{1, 3, 4}}})
+	if len(res.errors) != 2 || !strings.Contains(res.errors[0].message, "No subdirectories found at level 3") {
+		t.Errorf("validate() errors = %v, want teams/search and no subdirectories at level 3", res.errors)
+	}
+}
+
+func TestValidateSeverity(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	os.MkdirAll(filepath.Join(tmpDir, "src"), 0755)
+	os.MkdirAll(filepath.Join(tmpDir, "pkg"), 0755)
+	os.MkdirAll(filepath.Join(tmpDir, ".github"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, ".github", "CODEOWNERS"), []byte("/src/ @team-a\n"), 0644)
+
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	ruleset, err := loadCodeowners(context.Background(), nil, providerGitHub)
+	if err != nil {
+		t.Fatalf("loading CODEOWNERS: %v", err)
+	}
+
+	c := &checker{fsys: localFS{}, rules: ruleset, configPath: ".requirecodeowners.yml"}
+	errs := c.validate([]dirSpec{
+		{Path: "pkg", Severity: "warning"},
+		{Path: "nonexistent", Severity: "warning"},
+	}).errors
+	if len(errs) != 2 {
+		t.Fatalf("validate() errors = %v, want 2 errors", errs)
+	}
+	for _, e := range errs {
+		if !e.isWarning() {
+			t.Errorf("validate() error %v should be a warning", e)
+		}
+	}
+	if n := countFailures(errs); n != 0 {
+		t.Errorf("countFailures() = %d, want 0", n)
+	}
+}
+
+func TestValidateAllowUnowned(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	for _, dir := range []string{"apps/web", "apps/sandbox", "apps/archive/old", "apps/api", ".github"} {
+		os.MkdirAll(filepath.Join(tmpDir, dir), 0755)
+	}
+	os.WriteFile(filepath.Join(tmpDir, ".github", "CODEOWNERS"), []byte("/apps/web/ @team-web\n/apps/sandbox/ @team-sandbox\n"), 0644)
+
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	ruleset, err := loadCodeowners(context.Background(), nil, providerGitHub)
+	if err != nil {
+		t.Fatalf("loading CODEOWNERS: %v", err)
+	}
+
+	c := &checker{fsys: localFS{}, rules: ruleset, configPath: ".requirecodeowners.yml", allowUnowned: []string{"apps/sand*", "/apps/archive/"}}
+	res := c.validate([]dirSpec{
+		{Path: "apps", Level: 1},
+		{Path: "apps/archive", Level: 1},
+	})
+	if len(res.errors) != 1 || res.errors[0].path != "apps/api" {
+		t.Errorf("validate() errors = %v, want only apps/api", res.errors)
+	}
+	// apps/archive is skipped as a child of apps and apps/archive/old as a
+	// descendant; apps/sandbox is owned, so it's checked as usual.
+	want := []skippedDir{{"apps/archive", "allow_unowned"}, {"apps/archive/old", "allow_unowned"}}
+	if !reflect.DeepEqual(res.skipped, want) {
+		t.Errorf("skipped = %v, want %v", res.skipped, want)
+	}
+	if owned, total := res.coverage(); owned != 2 || total != 3 {
+		t.Errorf("coverage() = %d/%d, want 2/3", owned, total)
+	}
+}
+
+func TestValidateUnownedMarker(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	for _, dir := range []string{"services/legacy", "services/new", "services/owned", ".github"} {
+		os.MkdirAll(filepath.Join(tmpDir, dir), 0755)
+	}
+	os.WriteFile(filepath.Join(tmpDir, "services", "legacy", unownedMarker), nil, 0644)
+	os.WriteFile(filepath.Join(tmpDir, "services", "owned", unownedMarker), nil, 0644)
+	os.WriteFile(filepath.Join(tmpDir, ".github", "CODEOWNERS"), []byte("/services/owned/ @team-a\n"), 0644)
+
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	ruleset, err := loadCodeowners(context.Background(), nil, providerGitHub)
+	if err != nil {
+		t.Fatalf("loading CODEOWNERS: %v", err)
+	}
+
+	c := &checker{fsys: localFS{}, rules: ruleset, configPath: ".requirecodeowners.yml"}
+	res := c.validate([]dirSpec{{Path: "services", Level: 1}})
+	if len(res.errors) != 1 || res.errors[0].path != "services/new" {
+		t.Errorf("validate() errors = %v, want only services/new", res.errors)
+	}
+	want := []skippedDir{{"services/legacy", unownedMarker}}
+	if !reflect.DeepEqual(res.skipped, want) {
+		t.Errorf("skipped = %v, want %v", res.skipped, want)
+	}
+}
+
+func TestValidateHidden(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	for _, dir := range []string{"src", ".idea", ".github/workflows"} {
+		os.MkdirAll(filepath.Join(tmpDir, dir), 0755)
+	}
+	os.WriteFile(filepath.Join(tmpDir, ".github", "CODEOWNERS"), []byte("/src/ @team-a\n/.github/workflows/ @team-ci\n"), 0644)
+
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	ruleset, err := loadCodeowners(context.Background(), nil, providerGitHub)
+	if err != nil {
+		t.Fatalf("loading CODEOWNERS: %v", err)
+	}
+
+	hidden := false
+	tests := []struct {
+		name  string
+		specs []dirSpec
+		want  []string
+	}{
+		{
+			name:  "included by default",
+			specs: []dirSpec{{Path: ".", Level: 1}},
+			want:  []string{".github", ".idea", "src"},
+		},
+		{
+			name:  "left out of levels",
+			specs: []dirSpec{{Path: ".", Level: 1, Hidden: &hidden}},
+			want:  []string{"src"},
+		},
+		{
+			name:  "left out of globs",
+			specs: []dirSpec{{Path: "*", Hidden: &hidden}},
+			want:  []string{"src"},
+		},
+		{
+			name:  "named literally",
+			specs: []dirSpec{{Path: ".github/workflows", Hidden: &hidden}},
+			want:  []string{".github/workflows"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &checker{fsys: localFS{}, rules: ruleset, configPath: ".requirecodeowners.yml"}
+			var got []string
+			for _, d := range c.validate(tt.specs).checked {
+				got = append(got, d.path)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("checked = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestValidateSlashPaths checks that paths are reported with forward slashes,
+// as CODEOWNERS writes them, even where the OS separator is a backslash.
+func TestValidateSlashPaths(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	os.MkdirAll(filepath.Join(tmpDir, "apps", "a", "services", "foo"), 0755)
+	os.MkdirAll(filepath.Join(tmpDir, "apps", "a", "services", "bar"), 0755)
+	os.MkdirAll(filepath.Join(tmpDir, ".github"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, ".github", "CODEOWNERS"), []byte("/apps/a/services/foo/ @team-foo\n"), 0644)
+
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	ruleset, err := loadCodeowners(context.Background(), []string{filepath.Join(".github", "CODEOWNERS")}, providerGitHub)
+	if err != nil {
+		t.Fatalf("loading CODEOWNERS: %v", err)
+	}
+
+	c := &checker{fsys: localFS{}, rules: ruleset, configPath: ".requirecodeowners.yml", codeownersPath: ".github/CODEOWNERS"}
+	res := c.validate([]dirSpec{
+		{Path: filepath.Join("apps", "*", "services"), Level: 1, Excludes: []string{"apps/a/services/baz"}},
+	})
+	if len(res.errors) != 1 {
+		t.Fatalf("validate() errors = %v, want 1 error", res.errors)
+	}
+	e := res.errors[0]
+	if e.path != "apps/a/services/bar" || !strings.Contains(e.message, "Add: /apps/a/services/bar/ @your-team") {
+		t.Errorf("validate() error = %+v, want slash-separated paths", e)
+	}
+	for _, d := range res.checked {
+		if strings.Contains(d.path, `\`) {
+			t.Errorf("checked path %q has a backslash", d.path)
+		}
+	}
+	if file := ruleset[0].file; file != ".github/CODEOWNERS" {
+		t.Errorf("rule file = %q, want .github/CODEOWNERS", file)
+	}
+}
+
+func TestValidateWithGlob(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Create directory structure: apps/a/services/foo, apps/b/services/bar
+	os.MkdirAll(filepath.Join(tmpDir, "apps", "a", "services", "foo"), 0755)
+	os.MkdirAll(filepath.Join(tmpDir, "apps", "a", "services", "bar"), 0755)
+	os.MkdirAll(filepath.Join(tmpDir, "apps", "b", "services", "baz"), 0755)
+	os.MkdirAll(filepath.Join(tmpDir, ".github"), 0755)
+
+	// Only foo and bar have owners, baz is missing
+	os.WriteFile(filepath.Join(tmpDir, ".github", "CODEOWNERS"), []byte(`/apps/a/services/foo/ @team-foo
+/apps/a/services/bar/ @team-bar
+`), 0644)
+
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	ruleset, err := loadCodeowners(context.Background(), nil, providerGitHub)
+	if err != nil {
+		t.Fatalf("loading CODEOWNERS: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		specs    []dirSpec
+		wantErrs int
+	}{
+		{
+			name:     "glob matches multiple dirs",
+			specs:    []dirSpec{{Path: "apps/*/services", Level: 1}},
+			wantErrs: 1, // baz in apps/b/services is missing
+		},
+		{
+			name:     "glob with no matches",
+			specs:    []dirSpec{{Path: "nonexistent/*/path", Level: 0}},
+			wantErrs: 1, // no directories match
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &checker{fsys: localFS{}, rules: ruleset, configPath: ".requirecodeowners.yml"}
+			errs := c.validate(tt.specs).errors
+			if len(errs) != tt.wantErrs {
+				t.Errorf("validate() errors = %v, want %d errors", errs, tt.wantErrs)
+			}
+		})
+	}
+}
+
+func TestValidateTeams(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	os.MkdirAll(filepath.Join(tmpDir, "services", "payments", "api"), 0755)
+	os.MkdirAll(filepath.Join(tmpDir, "services", "payments", "worker"), 0755)
+	os.MkdirAll(filepath.Join(tmpDir, "libs", "orphan"), 0755)
+
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	rules, err := parseCodeowners(strings.NewReader(`/services/payments/ @org/payments
+/services/payments/worker @alice
+`), "CODEOWNERS", providerGitHub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		spec     dirSpec
+		wantTeam map[string]string
+	}{
+		{
+			name: "owners of the matching rule",
+			spec: dirSpec{Path: "services/payments", Level: 1, MinOwners: 2},
+			wantTeam: map[string]string{
+				"services/payments/api":    "@org/payments",
+				"services/payments/worker": "@alice",
+			},
+		},
+		{
+			name:     "no covered ancestor",
+			spec:     dirSpec{Path: "libs", Level: 1},
+			wantTeam: map[string]string{"libs/orphan": ""},
+		},
+		{
+			name:     "default owner wins",
+			spec:     dirSpec{Path: "libs", Level: 1, DefaultOwner: "@org/libs"},
+			wantTeam: map[string]string{"libs/orphan": "@org/libs"},
+		},
+		{
+			name:     "default owner on a spec error",
+			spec:     dirSpec{Path: "missing", DefaultOwner: "@org/libs"},
+			wantTeam: map[string]string{"missing": "@org/libs"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &checker{fsys: localFS{}, rules: rules, configPath: ".requirecodeowners.yml"}
+			errs := c.validate([]dirSpec{tt.spec}).errors
+			got := make(map[string]string)
+			for _, e := range errs {
+				got[e.path] = e.team
+			}
+			if !reflect.DeepEqual(got, tt.wantTeam) {
+				t.Errorf("validate() teams = %v, want %v", got, tt.wantTeam)
+			}
+		})
+	}
+}
+
+func TestAncestorOwners(t *testing.T) {
+	rules, err := parseCodeowners(strings.NewReader("/services @org/platform\n/services/payments @org/payments\n"), "CODEOWNERS", providerGitHub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &checker{rules: rules}
+
+	tests := map[string]string{
+		"services/payments/new": "@org/payments",
+		"services/new":          "@org/platform",
+		"libs/new":              "",
+	}
+	for dir, want := range tests {
+		if got := c.ancestorOwners(dir); got != want {
+			t.Errorf("ancestorOwners(%q) = %q, want %q", dir, got, want)
+		}
+	}
+}
+
+func TestGetDirsAtLevel(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Create nested structure
+	os.MkdirAll(filepath.Join(tmpDir, "a", "b", "c"), 0755)
+	os.MkdirAll(filepath.Join(tmpDir, "a", "b", "d"), 0755)
+	os.MkdirAll(filepath.Join(tmpDir, "a", "e"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, "a", "file.txt"), []byte("test"), 0644)
+
+	tests := []struct {
+		name    string
+		dir     string
+		level   int
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:  "level 0 returns dir itself",
+			dir:   filepath.Join(tmpDir, "a"),
+			level: 0,
+			want:  []string{filepath.Join(tmpDir, "a")},
+		},
+		{
+			name:  "level 1 returns immediate subdirs",
+			dir:   filepath.Join(tmpDir, "a"),
+			level: 1,
+			want:  []string{filepath.Join(tmpDir, "a", "b"), filepath.Join(tmpDir, "a", "e")},
+		},
+		{
+			name:  "level 2 returns nested subdirs",
+			dir:   filepath.Join(tmpDir, "a"),
+			level: 2,
+			want:  []string{filepath.Join(tmpDir, "a", "b", "c"), filepath.Join(tmpDir, "a", "b", "d")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getDirsAtLevel(localFS{}, tt.dir, tt.level)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("getDirsAtLevel() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("getDirsAtLevel() = %v, want %v", got, tt.want)
+				return
+			}
+			for _, w := range tt.want {
+				found := false
+				for _, g := range got {
+					if g == w {
+						found = true
+						break
+					}
+				}
+				if !found {
+					t.Errorf("getDirsAtLevel() missing %s, got %v", w, got)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadCodeowners(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	os.MkdirAll(filepath.Join(tmpDir, ".github"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, ".github", "CODEOWNERS"), []byte("/src/ @team\n"), 0644)
+
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	ruleset, err := loadCodeowners(context.Background(), nil, providerGitHub)
+	if err != nil {
+		t.Fatalf("loadCodeowners() error = %v", err)
+	}
+	if ruleset == nil {
+		t.Error("loadCodeowners() returned nil ruleset")
+	}
+}
+
+func TestLoadCodeownersMerged(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	base := filepath.Join(tmpDir, "base")
+	override := filepath.Join(tmpDir, "override")
+	os.WriteFile(base, []byte("/src/ @team-a\n/pkg/ @team-b\n"), 0644)
+	os.WriteFile(override, []byte("/src/ @team-c\n"), 0644)
+
+	rules, err := loadCodeowners(context.Background(), []string{base, override}, providerGitHub)
+	if err != nil {
+		t.Fatalf("loadCodeowners() error = %v", err)
+	}
+	if len(rules) != 3 {
+		t.Fatalf("loadCodeowners() got %d rules, want 3", len(rules))
+	}
+
+	tests := []struct {
+		dir       string
+		wantOwner string
+		wantFile  string
+	}{
+		{"src", "team-c", override}, // later file wins
+		{"pkg", "team-b", base},
+	}
+	for _, tt := range tests {
+		match := matchDirectory(rules, tt.dir)
+		if match == nil {
+			t.Fatalf("matchDirectory(%q) = nil", tt.dir)
+		}
+		if match.Owners[0].Value != tt.wantOwner || match.file != tt.wantFile {
+			t.Errorf("matchDirectory(%q) = %s from %s, want %s from %s", tt.dir, match.Owners[0].Value, match.file, tt.wantOwner, tt.wantFile)
+		}
+	}
+}
+
+func TestHasCodeownersCoverage(t *testing.T) {
+	content := `/src/ @team-a
+/pkg/** @team-b
+internal/ @team-c
+`
+	parsed, err := codeowners.ParseFile(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("parsing CODEOWNERS: %v", err)
+	}
+	ruleset := newRuleset(parsed, "CODEOWNERS")
+
+	tests := []struct {
+		name string
+		dir  string
+		want bool
+	}{
+		{"exact match with slash", "src", true},
+		{"glob pattern", "pkg", true},
+		{"unanchored pattern", "internal", true},
+		{"uncovered", "other", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := hasCodeownersCoverage(ruleset, tt.dir)
+			if got != tt.want {
+				t.Errorf("hasCodeownersCoverage(%q) = %v, want %v", tt.dir, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package check
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// defaultFragments is where merge looks for CODEOWNERS fragments.
+const defaultFragments = ".github/codeowners.d/*.owners"
+
+// fragmentConflict is a rule in one fragment that makes ownership depend on
+// the order fragments are merged in: it repeats, or overrides, a rule from
+// an earlier fragment.
+type fragmentConflict struct {
+	rule, earlier *rule
+	// overrides is set when rule is broader than earlier, rather than the
+	// same pattern.
+	overrides bool
+}
+
+func (c fragmentConflict) String() string {
+	if c.overrides {
+		return fmt.Sprintf("%s: %s overrides %s from %s, since the last matching rule wins. Rename the fragments so the broader one sorts first.",
+			c.rule.location(), c.rule.RawPattern(), c.earlier.RawPattern(), c.earlier.location())
+	}
+	return fmt.Sprintf("%s: %s is also in %s. Keep it in one fragment.", c.rule.location(), c.rule.RawPattern(), c.earlier.location())
+}
+
+func runMerge(args []string) int {
+	fs := flag.NewFlagSet("merge", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: requirecodeowners merge [flags] [FRAGMENT...]")
+		fmt.Fprintf(fs.Output(), "FRAGMENTs are files or globs (default %s), merged in order of path.\n", defaultFragments)
+		fs.PrintDefaults()
+	}
+	var output string
+	var check bool
+	var rf repoFlags
+	rf.registerDir(fs)
+	fs.StringVar(&output, "output", codeownersLocations[0], "CODEOWNERS file to write, or - for stdout")
+	fs.BoolVar(&check, "check", false, "don't write anything; fail if the CODEOWNERS file isn't up to date")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if check && output == "-" {
+		fmt.Fprintln(os.Stderr, "error: --check needs a file to compare, not --output -")
+		return 2
+	}
+
+	if err := rf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	patterns := fs.Args()
+	if len(patterns) == 0 {
+		patterns = []string{defaultFragments}
+	}
+	fragments, err := findFragments(patterns)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	merged, conflicts, err := mergeFragments(fragments)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	if len(conflicts) > 0 {
+		fmt.Fprintf(os.Stderr, "error: %d conflicting %s across fragments:\n", len(conflicts), pluralize(len(conflicts), "rule", "rules"))
+		for _, c := range conflicts {
+			fmt.Fprintf(os.Stderr, "  %s\n", c)
+		}
+		return 1
+	}
+
+	switch {
+	case output == "-":
+		_, err = os.Stdout.Write(merged)
+	case check:
+		old, readErr := os.ReadFile(output)
+		if readErr != nil && !os.IsNotExist(readErr) {
+			err = readErr
+			break
+		}
+		if bytes.Equal(old, merged) {
+			return 0
+		}
+		fmt.Fprintf(os.Stderr, "%s is out of date with its fragments. Run requirecodeowners merge to update it.\n", output)
+		if err := writeUnifiedDiff(os.Stdout, filepath.ToSlash(output), old, merged); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		}
+		return 1
+	default:
+		if err = os.MkdirAll(filepath.Dir(output), 0o755); err == nil {
+			err = os.WriteFile(output, merged, 0o644)
+		}
+		if err == nil {
+			fmt.Fprintf(os.Stderr, "Merged %d %s into %s.\n", len(fragments), pluralize(len(fragments), "fragment", "fragments"), output)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// findFragments returns the files the patterns name, sorted by path so the
+// merge doesn't depend on the order they're given in. A pattern that names
+// nothing is an error, since it's probably a typo.
+func findFragments(patterns []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var files []string
+	for _, p := range patterns {
+		matches, err := filepath.Glob(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fragment pattern %q: %w", p, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no fragments match %s", p)
+		}
+		for _, m := range matches {
+			m = filepath.ToSlash(m)
+			if !seen[m] {
+				seen[m] = true
+				files = append(files, m)
+			}
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// mergeFragments concatenates the CODEOWNERS fragments at paths, in order,
+// under a header saying where each part came from. Fragments are copied as
+// they are, comments and all. It also returns the rules whose ownership
+// would depend on the order of the fragments.
+func mergeFragments(paths []string) ([]byte, []fragmentConflict, error) {
+	var b bytes.Buffer
+	fmt.Fprintln(&b, "# Generated by requirecodeowners merge. Edit the fragments, not this file:")
+	for _, p := range paths {
+		fmt.Fprintf(&b, "#   %s\n", p)
+	}
+
+	var rules ruleset
+	for _, p := range paths {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return nil, nil, err
+		}
+		fragment, err := parseCodeowners(bytes.NewReader(data), p, providerGitHub)
+		if err != nil {
+			return nil, nil, err
+		}
+		rules = append(rules, fragment...)
+		fmt.Fprintf(&b, "\n# From %s\n", p)
+		if s := strings.TrimRight(string(data), "\n"); s != "" {
+			io.WriteString(&b, s+"\n")
+		}
+	}
+	return b.Bytes(), fragmentConflicts(rules), nil
+}
+
+// fragmentConflicts finds rules that repeat a pattern from an earlier
+// fragment, or own a subtree containing an earlier fragment's rule, which
+// they'd override. Rules within one fragment aren't compared: their order is
+// up to the team that owns it.
+func fragmentConflicts(rules ruleset) []fragmentConflict {
+	var conflicts []fragmentConflict
+	for j := range rules {
+		later := &rules[j]
+		root, isSubtree := subtreeRoot(later.RawPattern())
+		for i := 0; i < j; i++ {
+			earlier := &rules[i]
+			if earlier.file == later.file {
+				continue
+			}
+			if samePattern(earlier.RawPattern(), later.RawPattern()) {
+				conflicts = append(conflicts, fragmentConflict{rule: later, earlier: earlier})
+				break
+			}
+			if prefix := literalPrefix(earlier.RawPattern()); isSubtree && (len(root) == 0 || (prefix != nil && isPathPrefix(root, prefix))) {
+				conflicts = append(conflicts, fragmentConflict{rule: later, earlier: earlier, overrides: true})
+				break
+			}
+		}
+	}
+	return conflicts
+}
+
+// samePattern reports whether a and b are the same CODEOWNERS pattern
+// written differently, like services/api/ and /services/api/**.
+func samePattern(a, b string) bool {
+	normalize := func(p string) string {
+		if strings.HasSuffix(p, "/**") {
+			p = strings.TrimSuffix(p, "**")
+		}
+		if !strings.HasPrefix(p, "/") && strings.Contains(strings.TrimSuffix(p, "/"), "/") {
+			p = "/" + p
+		}
+		return p
+	}
+	return normalize(a) == normalize(b)
+}
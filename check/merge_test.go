@@ -0,0 +1,89 @@
+package check
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestMergeFragments(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	writeFiles(t, map[string]string{
+		".github/codeowners.d/00-platform.owners": "* @org/platform\n/services/ @org/platform\n",
+		".github/codeowners.d/api.owners":         "# the API\n/services/api/ @org/api\n\n\n",
+		".github/codeowners.d/web.owners":         "/services/web/ @org/web",
+		".github/codeowners.d/notes.txt":          "not a fragment",
+	})
+	fragments, err := findFragments([]string{defaultFragments, ".github/codeowners.d/api.owners"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(fragments, " "); got != ".github/codeowners.d/00-platform.owners .github/codeowners.d/api.owners .github/codeowners.d/web.owners" {
+		t.Errorf("findFragments() = %s, want the .owners files once each, sorted", got)
+	}
+	if _, err := findFragments([]string{"teams/*.owners"}); err == nil {
+		t.Error("findFragments() with a pattern matching nothing succeeded, want an error")
+	}
+
+	merged, conflicts, err := mergeFragments(fragments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conflicts) != 0 {
+		t.Errorf("mergeFragments() conflicts = %v, want none", conflicts)
+	}
+	want := `# Generated by requirecodeowners merge. Edit the fragments, not this file:
+#   .github/codeowners.d/00-platform.owners
+#   .github/codeowners.d/api.owners
+#   .github/codeowners.d/web.owners
+
+# From .github/codeowners.d/00-platform.owners
+* @org/platform
+/services/ @org/platform
+
+# From .github/codeowners.d/api.owners
+# the API
+/services/api/ @org/api
+
+# From .github/codeowners.d/web.owners
+/services/web/ @org/web
+`
+	if string(merged) != want {
+		t.Errorf("mergeFragments() =\n%s\nwant\n%s", merged, want)
+	}
+	if _, err := parseCodeowners(strings.NewReader(string(merged)), "CODEOWNERS", providerGitHub); err != nil {
+		t.Errorf("merged CODEOWNERS doesn't parse: %v", err)
+	}
+}
+
+func TestFragmentConflicts(t *testing.T) {
+	tmpDir := t.TempDir()
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	writeFiles(t, map[string]string{
+		"api.owners":      "/services/api/ @org/api\n/services/api/internal/ @org/api-core\n",
+		"platform.owners": "/services/ @org/platform\n",
+		"web.owners":      "services/api/** @org/web\n/services/web/ @org/web\n/services/web/ @org/web-2\n",
+	})
+	_, conflicts, err := mergeFragments([]string{"api.owners", "platform.owners", "web.owners"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, c := range conflicts {
+		got = append(got, c.String())
+	}
+	want := []string{
+		"platform.owners:1: /services/ overrides /services/api/ from api.owners:1, since the last matching rule wins. Rename the fragments so the broader one sorts first.",
+		"web.owners:1: services/api/** is also in api.owners:1. Keep it in one fragment.",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("conflicts =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
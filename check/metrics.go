@@ -0,0 +1,76 @@
+package check
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// writePrometheus writes ownership metrics in the Prometheus text exposition
+// format, for node-exporter's textfile collector to pick up. Directories
+// checked by more than one spec are counted once.
+func writePrometheus(w io.Writer, res result, _ reportOptions) error {
+	stats := computeStats(res.checked)
+	ratio := 0.0
+	if stats.Checked > 0 {
+		ratio = float64(stats.Owned) / float64(stats.Checked)
+	}
+	failures := countFailures(res.errors)
+
+	gauges := []struct {
+		name, help string
+		value      float64
+	}{
+		{"requirecodeowners_directories_checked", "Directories checked for a CODEOWNERS owner.", float64(stats.Checked)},
+		{"requirecodeowners_directories_owned", "Checked directories with an owner.", float64(stats.Owned)},
+		{"requirecodeowners_directories_unowned", "Checked directories without an owner.", float64(stats.Unowned)},
+		{"requirecodeowners_directories_skipped", "Unowned directories allowed to be unowned.", float64(len(res.skipped))},
+		{"requirecodeowners_coverage_ratio", "Share of checked directories with an owner, from 0 to 1.", ratio},
+		{"requirecodeowners_failures", "Problems that fail the check.", float64(failures)},
+		{"requirecodeowners_warnings", "Problems reported as warnings.", float64(len(res.errors) - failures)},
+	}
+	for _, g := range gauges {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %g\n", g.name, g.help, g.name, g.name, g.value)
+	}
+
+	fmt.Fprintln(w, "# HELP requirecodeowners_owner_directories Checked directories each owner is listed on.")
+	fmt.Fprintln(w, "# TYPE requirecodeowners_owner_directories gauge")
+	for _, o := range stats.Owners {
+		if _, err := fmt.Fprintf(w, "requirecodeowners_owner_directories{owner=\"%s\"} %d\n", escapeLabel(o.Owner), o.Directories); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escapeLabel escapes a Prometheus label value.
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+// writeMetricsFile writes the metrics to path through a temporary file in
+// the same directory, so a collector reading it mid-run never sees a partial
+// file.
+func writeMetricsFile(path string, res result) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if err := writePrometheus(f, res, reportOptions{}); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Chmod(0644); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
@@ -0,0 +1,66 @@
+package check
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWritePrometheus(t *testing.T) {
+	rules, err := parseCodeowners(strings.NewReader(`/services/a/ @org/payments @org/platform
+/services/b/ @org/payments
+`), "CODEOWNERS", providerGitHub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := result{
+		checked: []checkedDir{
+			{path: "services/a", rule: &rules[0]},
+			{path: "services/b", rule: &rules[1]},
+			{path: "services/c"},
+			{path: "services/d"},
+		},
+		skipped: []skippedDir{{path: "services/e", reason: "allow_unowned"}},
+		errors: []validationError{
+			{path: "services/c", message: "uncovered"},
+			{path: "services/d", message: "uncovered", severity: severityWarning},
+		},
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "codeowners.prom")
+	if err := writeMetricsFile(path, res); err != nil {
+		t.Fatalf("writeMetricsFile() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(data)
+	for _, want := range []string{
+		"# TYPE requirecodeowners_directories_checked gauge\nrequirecodeowners_directories_checked 4\n",
+		"requirecodeowners_directories_unowned 2\n",
+		"requirecodeowners_directories_skipped 1\n",
+		"requirecodeowners_coverage_ratio 0.5\n",
+		"requirecodeowners_failures 1\n",
+		"requirecodeowners_warnings 1\n",
+		`requirecodeowners_owner_directories{owner="@org/payments"} 2` + "\n",
+		`requirecodeowners_owner_directories{owner="@org/platform"} 1` + "\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("metrics missing %q:\n%s", want, got)
+		}
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("writeMetricsFile() left %d files behind, want only the metrics file", len(entries))
+	}
+}
+
+func TestEscapeLabel(t *testing.T) {
+	if got, want := escapeLabel("a\\b\"c\nd"), `a\\b\"c\nd`; got != want {
+		t.Errorf("escapeLabel() = %q, want %q", got, want)
+	}
+}
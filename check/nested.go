@@ -0,0 +1,145 @@
+package check
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// nestedConfigKeys are what a nested config may set. Everything else applies
+// to the whole repository, so only the root config can.
+var nestedConfigKeys = []string{"defaults", "directories", "allow_unowned", "suppressions"}
+
+// loadNestedConfigs adds the specs, allow_unowned entries, and suppressions
+// of the configs in directories below the working directory to cfg, with
+// their paths made relative to the working directory. A nested config uses
+// its own defaults, not the root config's, and what it says only applies
+// inside its directory. A directory the root config's global excludes leave
+// out, like vendor, is never searched.
+func loadNestedConfigs(cfg *config) error {
+	files, err := findNestedConfigs(cfg.globalExcludes())
+	if err != nil {
+		return fmt.Errorf("finding nested configs: %w", err)
+	}
+	for _, file := range files {
+		if err := addNestedConfig(cfg, file); err != nil {
+			return fmt.Errorf("nested config %s: %w", file, err)
+		}
+		cfg.nested = append(cfg.nested, file)
+	}
+	logger.Info("loaded nested configs", "configs", len(files))
+	return nil
+}
+
+// findNestedConfigs returns the config files git tracks, or would, in the
+// directories below the working directory, with at most one for each: the
+// first of defaultConfigPaths.
+func findNestedConfigs(excludes []string) ([]string, error) {
+	args := []string{"ls-files", "-z", "--cached", "--others", "--exclude-standard", "--"}
+	for _, name := range defaultConfigPaths {
+		args = append(args, ":(glob)**/"+name)
+	}
+	out, err := gitOutput(args...)
+	if err != nil {
+		return nil, err
+	}
+	byDir := make(map[string]string)
+	for _, f := range bytes.Split(out, []byte{0}) {
+		file := string(f)
+		dir := path.Dir(file)
+		if file == "" || dir == "." || excludedDir(dir, excludes) {
+			continue
+		}
+		if _, err := os.Stat(file); err != nil {
+			// Deleted, or outside a sparse checkout.
+			continue
+		}
+		rank := slices.Index(defaultConfigPaths, path.Base(file))
+		if have, ok := byDir[dir]; !ok || rank < slices.Index(defaultConfigPaths, path.Base(have)) {
+			byDir[dir] = file
+		}
+	}
+	files := make([]string, 0, len(byDir))
+	for _, file := range byDir {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// excludedDir reports whether dir or any of its parents matches excludes.
+func excludedDir(dir string, excludes []string) bool {
+	for d := dir; d != "." && d != "/"; d = path.Dir(d) {
+		if isExcluded(d, excludes) {
+			return true
+		}
+	}
+	return false
+}
+
+// addNestedConfig adds what the nested config file says to cfg.
+func addNestedConfig(cfg *config, file string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	nested, doc, err := decodeConfigDocument(data, file, os.ReadFile, cfg.origin)
+	if err != nil {
+		return err
+	}
+	if doc != nil {
+		for i := 0; i+1 < len(doc.Content); i += 2 {
+			if key := doc.Content[i].Value; !slices.Contains(nestedConfigKeys, key) {
+				return fmt.Errorf("%s applies to the whole repository and can only be set in the root config", key)
+			}
+		}
+	}
+
+	dir := path.Dir(filepath.ToSlash(file))
+	scoped := func(p string) (string, error) {
+		joined := path.Join(dir, strings.Trim(filepath.ToSlash(p), "/"))
+		if path.IsAbs(p) || (joined != dir && !strings.HasPrefix(joined, dir+"/")) {
+			return "", fmt.Errorf("%s is outside %s", p, dir)
+		}
+		return joined, nil
+	}
+	for _, spec := range nested.Directories {
+		if spec.Path, err = scoped(spec.Path); err != nil {
+			return fmt.Errorf("directory %w", err)
+		}
+		if spec.Name != "" {
+			spec.Name = dir + ":" + spec.Name
+			if slices.ContainsFunc(cfg.Directories, func(d dirSpec) bool { return d.Name == spec.Name }) {
+				return fmt.Errorf("directory name %q is taken", spec.Name)
+			}
+		}
+		for i, pattern := range spec.Excludes {
+			// Patterns with a slash are matched against the whole path.
+			if strings.Contains(pattern, "/") {
+				spec.Excludes[i] = path.Join(dir, pattern)
+			}
+		}
+		spec.file = filepath.ToSlash(file)
+		cfg.Directories = append(cfg.Directories, spec)
+	}
+	for _, pattern := range nested.AllowUnowned {
+		scopedPattern, err := scoped(pattern)
+		if err != nil {
+			return fmt.Errorf("allow_unowned %w", err)
+		}
+		cfg.AllowUnowned = append(cfg.AllowUnowned, scopedPattern)
+	}
+	for _, s := range nested.Suppressions {
+		if s.Path, err = scoped(s.Path); err != nil {
+			return fmt.Errorf("suppression at line %d: %w", s.line, err)
+		}
+		cfg.Suppressions = append(cfg.Suppressions, s)
+	}
+	cfg.raw = append(cfg.raw, nested.raw...)
+	return nil
+}
@@ -0,0 +1,107 @@
+package check
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestNestedConfigs(t *testing.T) {
+	gitRepo(t, map[string]string{
+		".requirecodeowners.yml": "nested_configs: true\ndirectories:\n  - name: libs\n    path: libs\n",
+		"CODEOWNERS":             "/libs/ @org/libs\n/teams/payments/api/ @org/payments\n",
+		"libs/util.go":           "package libs\n",
+		"teams/payments/.requirecodeowners.yml": `directories:
+  - name: services
+    path: .
+    level: 1
+    excludes: [/tools]
+allow_unowned: [legacy]
+`,
+		"teams/payments/api/main.go":      "package main\n",
+		"teams/payments/web/index.html":   "",
+		"teams/payments/legacy/old.sh":    "",
+		"teams/payments/tools/gen/gen.go": "package gen\n",
+		"teams/search/.requirecodeowners.yml": `directories:
+  - path: .
+  - path: gone
+suppressions:
+  - path: .
+    code: missing-entry
+    reason: Being set up
+`,
+		"teams/search/index.go": "package search\n",
+		// Third-party code's own config is left alone.
+		"vendor/thing/.requirecodeowners.yml": "codeowners: OWNERS\n",
+	})
+
+	res, err := checkRepo(context.Background(), "", nil, specFilter{})
+	if err != nil {
+		t.Fatalf("checkRepo() error = %v", err)
+	}
+	if len(res.errors) != 2 {
+		t.Fatalf("errors = %+v, want teams/payments/web and teams/search/gone", res.errors)
+	}
+	e := res.errors[0]
+	if e.path != "teams/payments/web" || e.code != codeMissingEntry || e.spec != "teams/payments:services" || e.specPath != "teams/payments" {
+		t.Errorf("error = %+v, want a missing entry for teams/payments/web from teams/payments:services", e)
+	}
+	// A finding about the spec itself points at the nested config.
+	e = res.errors[1]
+	if e.path != "teams/search/gone" || e.code != codeNoMatch {
+		t.Errorf("error = %+v, want a no-match for teams/search/gone", e)
+	}
+	if e.file != "teams/search/.requirecodeowners.yml" || e.line != 3 {
+		t.Errorf("error at %s:%d, want teams/search/.requirecodeowners.yml:3", e.file, e.line)
+	}
+	if len(res.skipped) != 1 || res.skipped[0].path != "teams/payments/legacy" {
+		t.Errorf("skipped = %+v, want teams/payments/legacy", res.skipped)
+	}
+	if len(res.suppressed) != 1 || res.suppressed[0].path != "teams/search" {
+		t.Errorf("suppressed = %+v, want teams/search", res.suppressed)
+	}
+	var checked []string
+	for _, d := range res.checked {
+		checked = append(checked, d.path)
+	}
+	if got := strings.Join(checked, ","); got != "libs,teams/payments/api,teams/payments/web,teams/search" {
+		t.Errorf("checked = %s, want libs and the payments services, without tools", got)
+	}
+}
+
+func TestNestedConfigErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		nested  string
+		wantErr string
+	}{
+		{
+			name:    "root setting",
+			nested:  "codeowners: OWNERS\ndirectories:\n  - path: .\n",
+			wantErr: "nested config teams/a/.requirecodeowners.yml: codeowners applies to the whole repository and can only be set in the root config",
+		},
+		{
+			name:    "outside its directory",
+			nested:  "directories:\n  - path: ../b\n",
+			wantErr: "nested config teams/a/.requirecodeowners.yml: directory ../b is outside teams/a",
+		},
+		{
+			name:    "unknown key",
+			nested:  "directories:\n  - path: .\n    levle: 1\n",
+			wantErr: `line 3: directories[0]: unknown key "levle"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gitRepo(t, map[string]string{
+				".requirecodeowners.yml":         "nested_configs: true\ndirectories:\n  - path: teams\n",
+				"teams/a/.requirecodeowners.yml": tt.nested,
+				"teams/b/main.go":                "package main\n",
+			})
+			_, err := loadConfig("")
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("loadConfig() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
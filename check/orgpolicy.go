@@ -0,0 +1,183 @@
+package check
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// orgPolicy is an organization's requirements, given with --policy, that
+// hold for every repository whatever its own config says. Its specs are
+// checked along with the config's, and nothing in the repository exempts
+// what they find; its forbidden owners always fail the check; and so does
+// coverage below its minimum.
+type orgPolicy struct {
+	Directories     []dirSpec `yaml:"directories"`
+	ForbiddenOwners []string  `yaml:"forbidden_owners"`
+
+	// MinCoverage is the percentage of checked directories, 0 to 100, that
+	// must have an owner.
+	MinCoverage float64 `yaml:"min_coverage"`
+
+	source string // where it was read from, for messages
+	raw    []byte
+}
+
+// activePolicy is the org policy from --policy, or nil if there's none.
+var activePolicy *orgPolicy
+
+// loadOrgPolicy reads the org policy at source, a local path, URL, or github:
+// reference. Its specs are checked as a config's are, without the config's
+// defaults.
+func loadOrgPolicy(source string) (*orgPolicy, error) {
+	r := &configReader{local: os.ReadFile}
+	data, err := r.read(source)
+	if err != nil {
+		return nil, fmt.Errorf("reading policy %s: %w", source, err)
+	}
+	converted, err := toYAML(data, sourceExt(source))
+	if err != nil {
+		return nil, fmt.Errorf("parsing policy %s: %w", source, err)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(converted, &doc); err != nil {
+		return nil, fmt.Errorf("parsing policy %s: %w", source, err)
+	}
+	// A policy is written once for many repositories, so it's always
+	// held to its keys.
+	var problems []configProblem
+	checkNode(&doc, reflect.TypeOf(orgPolicy{}), "", &problems)
+	if len(problems) > 0 {
+		var msgs []string
+		for _, p := range problems {
+			if p.line > 0 && isYAML(source) {
+				msgs = append(msgs, fmt.Sprintf("line %d: %s", p.line, p))
+			} else {
+				msgs = append(msgs, p.String())
+			}
+		}
+		return nil, fmt.Errorf("parsing policy %s: %s", source, strings.Join(msgs, "; "))
+	}
+
+	p := &orgPolicy{source: source, raw: data}
+	if len(doc.Content) == 0 {
+		return p, nil
+	}
+	var raw struct {
+		Directories     yaml.Node `yaml:"directories"`
+		ForbiddenOwners []string  `yaml:"forbidden_owners"`
+		MinCoverage     float64   `yaml:"min_coverage"`
+	}
+	if err := doc.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("parsing policy %s: %w", source, err)
+	}
+	var cfg config
+	if !raw.Directories.IsZero() {
+		specs := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{
+			{Kind: yaml.ScalarNode, Tag: "!!str", Value: "directories"},
+			&raw.Directories,
+		}}
+		if err := specs.Decode(&cfg); err != nil {
+			return nil, fmt.Errorf("parsing policy %s: %w", source, err)
+		}
+	}
+	if err := cfg.validate(source); err != nil {
+		return nil, fmt.Errorf("policy %s: %w", source, err)
+	}
+	for i := range cfg.Directories {
+		cfg.Directories[i].mandatory = true
+		if !isYAML(source) {
+			cfg.Directories[i].line = 0
+		}
+	}
+	for i, o := range raw.ForbiddenOwners {
+		if strings.TrimSpace(o) == "" {
+			return nil, fmt.Errorf("policy %s: forbidden owner at index %d is empty", source, i)
+		}
+	}
+	if raw.MinCoverage < 0 || raw.MinCoverage > 100 {
+		return nil, fmt.Errorf("policy %s has invalid min_coverage %g (must be 0 to 100)", source, raw.MinCoverage)
+	}
+	p.Directories = cfg.Directories
+	p.ForbiddenOwners = raw.ForbiddenOwners
+	p.MinCoverage = raw.MinCoverage
+	return p, nil
+}
+
+// applyOrgPolicy adds the active org policy's specs to cfg. None of the
+// config's specs may have the name of one of them, since --only and reports
+// tell specs apart by name.
+func applyOrgPolicy(cfg *config) error {
+	p := activePolicy
+	if p == nil {
+		return nil
+	}
+	for _, spec := range p.Directories {
+		if spec.Name != "" && slices.ContainsFunc(cfg.Directories, func(d dirSpec) bool { return d.Name == spec.Name }) {
+			return fmt.Errorf("directory name %q is taken by a spec in the policy %s", spec.Name, p.source)
+		}
+	}
+	cfg.Directories = append(cfg.Directories, p.Directories...)
+	cfg.raw = append(cfg.raw, p.raw...)
+	return nil
+}
+
+// policySource returns the org policy's source as given, made absolute if
+// it's a local path, so changing directory later doesn't lose it.
+func policySource(source string) (string, error) {
+	if source == "" || isURL(source) || isGitHubSource(source) {
+		return source, nil
+	}
+	return filepath.Abs(source)
+}
+
+// checkMinCoverage reports coverage of the checked directories below the
+// org policy's minimum.
+func (c *checker) checkMinCoverage(checked []checkedDir) (validationError, bool) {
+	owned := 0
+	for _, d := range checked {
+		if d.rule != nil {
+			owned++
+		}
+	}
+	total := len(checked)
+	if total == 0 || percent(owned, total) >= c.org.MinCoverage {
+		return validationError{}, false
+	}
+	return validationError{
+		path: ".",
+		message: fmt.Sprintf("%.1f%% of the checked directories are owned (%d of %d), below the minimum of %g%% in the policy %s. Add owners for more of them in %s.",
+			percent(owned, total), owned, total, c.org.MinCoverage, c.org.source, c.codeownersPath),
+		code:      codeBelowMinCoverage,
+		severity:  severityError,
+		file:      c.codeownersPath,
+		mandatory: true,
+	}, true
+}
+
+// specFile returns the file spec came from: the config, a nested config, or
+// the org policy.
+func (c *checker) specFile(spec dirSpec) string {
+	if spec.mandatory && c.org != nil {
+		return c.org.source
+	}
+	if spec.file != "" {
+		return spec.file
+	}
+	return c.configPath
+}
+
+// expansionFS returns the file system spec expands in. The org policy's
+// specs expand past the config's global excludes, which could otherwise
+// hide what they check.
+func (c *checker) expansionFS(spec dirSpec) fileSystem {
+	if spec.mandatory && c.orgFS != nil {
+		return specFS(c.orgFS, spec)
+	}
+	return specFS(c.fsys, spec)
+}
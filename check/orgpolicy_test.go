@@ -0,0 +1,144 @@
+package check
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestOrgPolicy(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, d := range []string{"services/a", "services/b", "services/c", "platform/x", "platform/y", "platform/z"} {
+		os.MkdirAll(filepath.Join(tmpDir, d), 0755)
+	}
+	os.WriteFile(filepath.Join(tmpDir, "platform/z", unownedMarker), nil, 0644)
+	// The config tries every way it has to get out of the policy.
+	os.WriteFile(filepath.Join(tmpDir, ".requirecodeowners.yml"), []byte(`directories:
+  - name: services
+    path: services
+    level: 1
+allow_unowned: [services/c, "platform/*"]
+global_excludes: [platform]
+policy:
+  severity: warning
+suppressions:
+  - path: platform/y
+    code: missing-entry
+    reason: Not ours
+  - path: "."
+    code: below-min-coverage
+    reason: Not ours either
+`), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "CODEOWNERS"), []byte("/services/a/ @org/a\n/services/b/ @org/everyone\n/platform/x/ @org/platform\n"), 0644)
+	policyPath := filepath.Join(tmpDir, "policy.yml")
+	os.WriteFile(policyPath, []byte(`directories:
+  - name: platform
+    path: platform
+    level: 1
+forbidden_owners: ["@org/everyone"]
+min_coverage: 80
+`), 0644)
+
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+	defer (&configFlags{}).apply()
+	if err := (&configFlags{policy: policyPath}).apply(); err != nil {
+		t.Fatalf("apply() error = %v", err)
+	}
+
+	// --only can't leave the policy's spec out either.
+	res, err := checkRepo(context.Background(), "", nil, specFilter{only: stringList{"services"}})
+	if err != nil {
+		t.Fatalf("checkRepo() error = %v", err)
+	}
+	var got []string
+	for _, e := range res.errors {
+		got = append(got, fmt.Sprintf("%s %s %s", e.code, e.path, e.severity))
+	}
+	sort.Strings(got)
+	want := []string{
+		"RCO001 platform/y ",
+		"RCO001 platform/z ",
+		"RCO022 services/b error",
+		"RCO070 . error",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("errors =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	for _, e := range res.errors {
+		if e.code == codeMissingEntry && e.spec != "platform" {
+			t.Errorf("%s spec = %q, want platform", e.path, e.spec)
+		}
+		if e.code == codeBelowMinCoverage && !strings.HasPrefix(e.message, "60.0% of the checked directories are owned (3 of 5), below the minimum of 80%") {
+			t.Errorf("min coverage message = %q", e.message)
+		}
+	}
+}
+
+func TestLoadOrgPolicy(t *testing.T) {
+	tmpDir := t.TempDir()
+	tests := []struct {
+		name    string
+		content string
+		config  string
+		wantErr string
+	}{
+		{
+			name:    "unknown key",
+			content: "directories:\n  - path: platform\nmin_coverag: 80\n",
+			wantErr: `line 3: unknown key "min_coverag" (did you mean "min_coverage"?)`,
+		},
+		{
+			name:    "invalid spec",
+			content: "directories:\n  - path: platform\n    level: -1\n",
+			wantErr: "directory platform has invalid level -1",
+		},
+		{
+			name:    "invalid min_coverage",
+			content: "min_coverage: 120\n",
+			wantErr: "invalid min_coverage 120 (must be 0 to 100)",
+		},
+		{
+			name:    "taken name",
+			content: "directories:\n  - name: platform\n    path: platform\n",
+			config:  "directories:\n  - name: platform\n    path: libs\n",
+			wantErr: `directory name "platform" is taken by a spec in the policy`,
+		},
+		{
+			name:    "valid",
+			content: "directories:\n  - path: platform\n    level: 1..2\nmin_coverage: 90\n",
+			config:  "directories:\n  - path: services\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(tmpDir, "policy.yml")
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			defer (&configFlags{}).apply()
+			err := (&configFlags{policy: path}).apply()
+			if err == nil && tt.config != "" {
+				var cfg *config
+				cfg, err = parseConfig([]byte(tt.config), ".requirecodeowners.yml")
+				if err == nil && (len(cfg.Directories) != 2 || !cfg.Directories[1].mandatory) {
+					t.Errorf("directories = %+v, want the policy's spec after the config's", cfg.Directories)
+				}
+			}
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,508 @@
+package check
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// reportOptions carries format-specific settings from the command line.
+type reportOptions struct {
+	templatePath string
+	quiet        bool
+	groupByTeam  bool
+	groupBySpec  bool
+	// maxErrors, if set, is how many errors console text and markdown list
+	// before summarizing the rest.
+	maxErrors int
+	// links, when set, links markdown findings to the file to change.
+	links *repoLinks
+}
+
+// formats are the report formats that can be written to stdout with
+// --format. Console text always goes to stderr.
+var formats = map[string]func(w io.Writer, res result, opts reportOptions) error{
+	"badge":      writeBadge,
+	"csv":        writeCSV,
+	"jsonl":      writeJSONL,
+	"markdown":   writeMarkdown,
+	"prometheus": writePrometheus,
+	"rdjson":     writeRDJSON,
+	"template":   writeTemplate,
+}
+
+func formatNames() []string {
+	names := make([]string, 0, len(formats))
+	for name := range formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func writeReport(w io.Writer, format string, res result, opts reportOptions) error {
+	write, ok := formats[format]
+	if !ok {
+		return fmt.Errorf("unknown format %q", format)
+	}
+	return write(w, res, opts)
+}
+
+// reportTarget is a report written to a file rather than stdout.
+type reportTarget struct {
+	format string
+	path   string
+}
+
+// reportFlag collects repeated --report format=path flags.
+type reportFlag []reportTarget
+
+func (f *reportFlag) String() string {
+	parts := make([]string, 0, len(*f))
+	for _, t := range *f {
+		parts = append(parts, t.format+"="+t.path)
+	}
+	return strings.Join(parts, ",")
+}
+
+func (f *reportFlag) Set(value string) error {
+	format, path, ok := strings.Cut(value, "=")
+	if !ok || format == "" || path == "" {
+		return fmt.Errorf("%q is not format=path", value)
+	}
+	if _, ok := formats[format]; !ok {
+		return fmt.Errorf("unknown format %q (want one of %s)", format, strings.Join(formatNames(), ", "))
+	}
+	*f = append(*f, reportTarget{format: format, path: path})
+	return nil
+}
+
+// writeReportFile writes a report to path, replacing anything already there.
+func writeReportFile(path, format string, res result, opts reportOptions) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := writeReport(f, format, res, opts); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// sortErrors sorts by path for consistent output.
+func sortErrors(errors []validationError) {
+	sort.SliceStable(errors, func(i, j int) bool {
+		return errors[i].path < errors[j].path
+	})
+}
+
+// printErrors prints errors as console text on stderr and a markdown report
+// on stdout.
+func printErrors(errors []validationError, opts reportOptions) {
+	sortErrors(errors)
+	if shown := consoleErrors(errors, opts); len(shown) > 0 {
+		printText(os.Stderr, shown, opts.maxErrors)
+	}
+	_ = writeMarkdown(os.Stdout, result{errors: errors}, opts)
+}
+
+// printText prints errors as console text, listing at most limit of them,
+// or all if limit is 0. The totals always count every error.
+func printText(w io.Writer, errors []validationError, limit int) {
+	failures := countFailures(errors)
+	warnings := len(errors) - failures
+
+	fmt.Fprintln(w)
+	printFindings(w, errors, limit)
+	fmt.Fprintln(w)
+	if failures > 0 {
+		fmt.Fprintln(w, console.mark(w, markFail, fmt.Sprintf("%d %s failed CODEOWNERS check", failures, pluralize(failures, "directory", "directories"))))
+	}
+	if warnings > 0 {
+		fmt.Fprintln(w, console.mark(w, markWarn, fmt.Sprintf("%d %s", warnings, pluralize(warnings, "warning", "warnings"))))
+	}
+}
+
+// printFindings lists errors, at most limit of them or all if limit is 0,
+// each under its path.
+func printFindings(w io.Writer, errors []validationError, limit int) {
+	shown, more := limitErrors(errors, limit)
+	for _, e := range shown {
+		m := markFail
+		if e.isWarning() {
+			m = markWarn
+		}
+		label := e.label()
+		if console.useHyperlinks(w) {
+			label = console.link(w, fileURL(e.path), e.path) + strings.TrimPrefix(label, e.path)
+		}
+		fmt.Fprintf(w, "  %s\n", console.mark(w, m, label))
+		fmt.Fprintf(w, "    %s\n", e.codedMessage())
+	}
+	if more > 0 {
+		fmt.Fprintf(w, "  …and %d more\n", more)
+	}
+}
+
+// printTeamSummary prints how many problems each team has, so a failed run
+// ends with who needs to act.
+func printTeamSummary(w io.Writer, errors []validationError) {
+	teams, groups := groupByTeam(errors)
+	fmt.Fprintln(w, "By team:")
+	for _, team := range teams {
+		failures := countFailures(groups[team])
+		warnings := len(groups[team]) - failures
+		var counts []string
+		if failures > 0 {
+			counts = append(counts, fmt.Sprintf("%d %s", failures, pluralize(failures, "failure", "failures")))
+		}
+		if warnings > 0 {
+			counts = append(counts, fmt.Sprintf("%d %s", warnings, pluralize(warnings, "warning", "warnings")))
+		}
+		fmt.Fprintf(w, "  %s: %s\n", team, strings.Join(counts, ", "))
+	}
+}
+
+// printSkipped lists the directories that were skipped, because they're
+// allowed to be unowned or the sparse checkout left them out, and why.
+func printSkipped(w io.Writer, skipped []skippedDir) {
+	fmt.Fprintf(w, "%d %s skipped:\n", len(skipped), pluralize(len(skipped), "directory", "directories"))
+	for _, d := range skipped {
+		fmt.Fprintf(w, "  %s (%s)\n", d.path, d.reason)
+	}
+}
+
+// writeMarkdown writes the report for a GitHub Actions step summary.
+func writeMarkdown(w io.Writer, res result, opts reportOptions) error {
+	errors := res.errors
+	if len(errors) == 0 {
+		if opts.quiet {
+			return nil
+		}
+		if _, err := fmt.Fprintln(w, console.mark(w, markOK, "all directories have CODEOWNERS coverage")); err != nil {
+			return err
+		}
+		return writeMarkdownSkipped(w, res.skipped)
+	}
+
+	failures := countFailures(errors)
+	if failures > 0 {
+		fmt.Fprintln(w, "## ❌ CODEOWNERS Check Failed")
+	} else {
+		fmt.Fprintln(w, "## ⚠️ CODEOWNERS Check Passed with Warnings")
+	}
+	fmt.Fprintln(w)
+	shown, more := limitErrors(errors, opts.maxErrors)
+	if opts.groupBySpec {
+		writeMarkdownSpecs(w, res, shown, opts.links)
+	} else if opts.groupByTeam {
+		teams, groups := groupByTeam(shown)
+		for _, team := range teams {
+			fmt.Fprintf(w, "### %s (%d)\n", team, len(groups[team]))
+			fmt.Fprintln(w)
+			writeMarkdownTable(w, groups[team], opts.links)
+			fmt.Fprintln(w)
+		}
+	} else {
+		writeMarkdownTable(w, shown, opts.links)
+		fmt.Fprintln(w)
+	}
+	if more > 0 {
+		fmt.Fprintf(w, "…and %d more not listed.\n", more)
+		fmt.Fprintln(w)
+	}
+	if _, err := fmt.Fprintf(w, "**%d %s** need attention.\n", len(errors), pluralize(len(errors), "directory", "directories")); err != nil {
+		return err
+	}
+	return writeMarkdownSkipped(w, res.skipped)
+}
+
+// writeMarkdownSpecs writes a collapsible section for each spec, in config
+// order, whose summary line counts the directories that passed and failed.
+// The errors to list are shown, a subset of res.errors with --max-errors.
+// Problems no spec found, like lint findings, get a last section of their
+// own.
+func writeMarkdownSpecs(w io.Writer, res result, shown []validationError, links *repoLinks) {
+	type section struct {
+		name, path string
+		checked    []string
+		failing    map[string]bool
+		warnings   int
+		shown      []validationError
+	}
+	var sections []*section
+	var other *section
+	byKey := make(map[[2]string]*section)
+	get := func(name, path string) *section {
+		key := [2]string{name, path}
+		if byKey[key] == nil {
+			byKey[key] = &section{name: name, path: path, failing: make(map[string]bool)}
+			if name == "" && path == "" {
+				other = byKey[key]
+			} else {
+				sections = append(sections, byKey[key])
+			}
+		}
+		return byKey[key]
+	}
+	for _, spec := range res.specs {
+		get(spec.Name, spec.Path)
+	}
+	for _, d := range res.checked {
+		s := get(d.spec, d.specPath)
+		s.checked = append(s.checked, d.path)
+	}
+	for _, e := range res.errors {
+		s := get(e.spec, e.specPath)
+		if e.isWarning() {
+			s.warnings++
+		} else {
+			s.failing[e.path] = true
+		}
+	}
+	for _, e := range shown {
+		s := get(e.spec, e.specPath)
+		e.spec = "" // the section names it
+		s.shown = append(s.shown, e)
+	}
+	if other != nil {
+		sections = append(sections, other)
+	}
+
+	for _, s := range sections {
+		passed := 0
+		for _, d := range s.checked {
+			if !s.failing[d] {
+				passed++
+			}
+		}
+		var title string
+		switch {
+		case s.name != "":
+			title = fmt.Sprintf("<b>%s</b> <code>%s</code>", html.EscapeString(s.name), html.EscapeString(s.path))
+		case s.path != "":
+			title = fmt.Sprintf("<code>%s</code>", html.EscapeString(s.path))
+		default:
+			title = "<b>Other</b>"
+		}
+		counts := []string{fmt.Sprintf("%d passed", passed)}
+		if len(s.failing) > 0 {
+			counts = append(counts, fmt.Sprintf("%d failed", len(s.failing)))
+		}
+		if s.warnings > 0 {
+			counts = append(counts, fmt.Sprintf("%d %s", s.warnings, pluralize(s.warnings, "warning", "warnings")))
+		}
+		mark := "✅"
+		switch {
+		case len(s.failing) > 0:
+			mark = "❌"
+		case s.warnings > 0:
+			mark = "⚠️"
+		}
+		fmt.Fprintln(w, "<details>")
+		fmt.Fprintf(w, "<summary>%s %s: %s</summary>\n", mark, title, strings.Join(counts, ", "))
+		fmt.Fprintln(w)
+		switch {
+		case len(s.shown) > 0:
+			writeMarkdownTable(w, s.shown, links)
+		case len(s.failing) > 0 || s.warnings > 0:
+			fmt.Fprintln(w, "Problems not listed because of --max-errors.")
+		default:
+			fmt.Fprintln(w, "All directories passed.")
+		}
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "</details>")
+		fmt.Fprintln(w)
+	}
+}
+
+// writeMarkdownSkipped lists the directories that were skipped, so
+// exemptions show up in the report.
+func writeMarkdownSkipped(w io.Writer, skipped []skippedDir) error {
+	if len(skipped) == 0 {
+		return nil
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "%d %s skipped:\n", len(skipped), pluralize(len(skipped), "directory", "directories"))
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "| Path | Reason |")
+	fmt.Fprintln(w, "|------|------------|")
+	for _, d := range skipped {
+		if _, err := fmt.Fprintf(w, "| `%s` | `%s` |\n", d.path, d.reason); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeMarkdownTable writes one row per error. The Spec column is only there
+// when some error came from a named spec. With links, a missing entry links
+// to editing its CODEOWNERS file, and other errors to the line to change.
+func writeMarkdownTable(w io.Writer, errors []validationError, links *repoLinks) {
+	named := false
+	for _, e := range errors {
+		named = named || e.spec != ""
+	}
+	if named {
+		fmt.Fprintln(w, "| Path | Spec | Issue |")
+		fmt.Fprintln(w, "|------|------|-------|")
+	} else {
+		fmt.Fprintln(w, "| Path | Issue |")
+		fmt.Fprintln(w, "|------|-------|")
+	}
+	for _, e := range errors {
+		message := e.message
+		if e.code != "" {
+			message = fmt.Sprintf("`%s` %s", e.code, message)
+		}
+		if e.isWarning() {
+			message = "⚠️ " + message
+		}
+		if link := markdownLink(e, links); link != "" {
+			message += " " + link
+		}
+		if named {
+			fmt.Fprintf(w, "| `%s` | %s | %s |\n", e.path, e.spec, message)
+		} else {
+			fmt.Fprintf(w, "| `%s` | %s |\n", e.path, message)
+		}
+	}
+}
+
+// limitErrors returns the first limit errors, failures before warnings so
+// the ones that fail the check are listed first, and how many are left
+// out. A limit of 0 keeps them all, in order.
+func limitErrors(errors []validationError, limit int) ([]validationError, int) {
+	if limit == 0 || len(errors) <= limit {
+		return errors, 0
+	}
+	shown := make([]validationError, 0, limit)
+	for _, warnings := range []bool{false, true} {
+		for _, e := range errors {
+			if len(shown) < limit && e.isWarning() == warnings {
+				shown = append(shown, e)
+			}
+		}
+	}
+	return shown, len(errors) - limit
+}
+
+// markdownLink returns a link to where e is fixed, or "" if there's nowhere
+// to link to.
+func markdownLink(e validationError, links *repoLinks) string {
+	switch {
+	case links == nil:
+		return ""
+	case e.code == codeMissingEntry:
+		if url := links.edit(e.file); url != "" {
+			return fmt.Sprintf("[Edit %s](%s)", path.Base(filepath.ToSlash(e.file)), url)
+		}
+	case e.line > 0:
+		if url := links.line(e.file, e.line); url != "" {
+			return fmt.Sprintf("[%s:%d](%s)", filepath.ToSlash(e.file), e.line, url)
+		}
+	}
+	return ""
+}
+
+// unassignedTeam groups problems nobody could be attributed to.
+const unassignedTeam = "Unassigned"
+
+// groupByTeam splits errors by responsible team, keeping their order within
+// each team. Teams are sorted by name, with unassigned problems last.
+func groupByTeam(errors []validationError) ([]string, map[string][]validationError) {
+	groups := make(map[string][]validationError)
+	var teams []string
+	for _, e := range errors {
+		team := e.team
+		if team == "" {
+			team = unassignedTeam
+		}
+		if _, ok := groups[team]; !ok && team != unassignedTeam {
+			teams = append(teams, team)
+		}
+		groups[team] = append(groups[team], e)
+	}
+	sort.Strings(teams)
+	if _, ok := groups[unassignedTeam]; ok {
+		teams = append(teams, unassignedTeam)
+	}
+	return teams, groups
+}
+
+// Reviewdog Diagnostic Format (rdjson) types. See
+// https://github.com/reviewdog/reviewdog/tree/master/proto/rdf
+type rdjsonResult struct {
+	Source      rdjsonSource       `json:"source"`
+	Diagnostics []rdjsonDiagnostic `json:"diagnostics"`
+}
+
+type rdjsonSource struct {
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+}
+
+type rdjsonDiagnostic struct {
+	Message  string         `json:"message"`
+	Location rdjsonLocation `json:"location"`
+	Severity string         `json:"severity"`
+	Code     *rdjsonCode    `json:"code,omitempty"`
+}
+
+type rdjsonCode struct {
+	Value string `json:"value"`
+	URL   string `json:"url,omitempty"`
+}
+
+type rdjsonLocation struct {
+	Path  string       `json:"path"`
+	Range *rdjsonRange `json:"range,omitempty"`
+}
+
+type rdjsonRange struct {
+	Start rdjsonPosition `json:"start"`
+}
+
+type rdjsonPosition struct {
+	Line int `json:"line"`
+}
+
+// writeRDJSON writes errors as Reviewdog Diagnostic JSON, located at the
+// config entry or CODEOWNERS rule to change.
+func writeRDJSON(w io.Writer, res result, _ reportOptions) error {
+	out := rdjsonResult{
+		Source: rdjsonSource{
+			Name: "requirecodeowners",
+			URL:  "https://github.com/kpurdon/requirecodeowners",
+		},
+		Diagnostics: []rdjsonDiagnostic{},
+	}
+	for _, e := range res.errors {
+		d := rdjsonDiagnostic{
+			Message:  fmt.Sprintf("%s: %s", e.label(), e.message),
+			Location: rdjsonLocation{Path: e.file},
+			Severity: "ERROR",
+		}
+		if e.isWarning() {
+			d.Severity = "WARNING"
+		}
+		if e.code != "" {
+			d.Code = &rdjsonCode{Value: e.code, URL: codesURL}
+		}
+		if e.line > 0 {
+			d.Location.Range = &rdjsonRange{Start: rdjsonPosition{Line: e.line}}
+		}
+		out.Diagnostics = append(out.Diagnostics, d)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
@@ -0,0 +1,437 @@
+package check
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteRDJSON(t *testing.T) {
+	res := result{errors: []validationError{
+		{path: "services/foo", message: "Not covered by CODEOWNERS.", code: codeMissingEntry, file: ".github/CODEOWNERS"},
+		{path: "legacy", message: "Directory not found.", severity: severityWarning, file: ".requirecodeowners.yml", line: 4},
+	}}
+
+	var buf bytes.Buffer
+	if err := writeRDJSON(&buf, res, reportOptions{}); err != nil {
+		t.Fatalf("writeRDJSON() error = %v", err)
+	}
+
+	var got rdjsonResult
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("writeRDJSON() wrote invalid JSON: %v\n%s", err, buf.String())
+	}
+	if got.Source.Name != "requirecodeowners" {
+		t.Errorf("source name = %q, want requirecodeowners", got.Source.Name)
+	}
+	if len(got.Diagnostics) != 2 {
+		t.Fatalf("got %d diagnostics, want 2", len(got.Diagnostics))
+	}
+
+	first := got.Diagnostics[0]
+	if first.Severity != "ERROR" || first.Location.Path != ".github/CODEOWNERS" || first.Location.Range != nil {
+		t.Errorf("diagnostics[0] = %+v, want ERROR at .github/CODEOWNERS without range", first)
+	}
+	if !strings.HasPrefix(first.Message, "services/foo: ") {
+		t.Errorf("diagnostics[0] message = %q, want it prefixed with the path", first.Message)
+	}
+	if first.Code == nil || first.Code.Value != codeMissingEntry {
+		t.Errorf("diagnostics[0] code = %+v, want %s", first.Code, codeMissingEntry)
+	}
+
+	second := got.Diagnostics[1]
+	if second.Severity != "WARNING" || second.Location.Range == nil || second.Location.Range.Start.Line != 4 {
+		t.Errorf("diagnostics[1] = %+v, want WARNING at line 4", second)
+	}
+	if second.Code != nil {
+		t.Errorf("diagnostics[1] code = %+v, want none", second.Code)
+	}
+}
+
+func TestWriteRDJSONEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeRDJSON(&buf, result{}, reportOptions{}); err != nil {
+		t.Fatalf("writeRDJSON() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), `"diagnostics": []`) {
+		t.Errorf("writeRDJSON() = %s, want an empty diagnostics list", buf.String())
+	}
+}
+
+func TestErrorLocations(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	os.MkdirAll(filepath.Join(tmpDir, "src"), 0755)
+	os.MkdirAll(filepath.Join(tmpDir, "pkg"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, "CODEOWNERS"), []byte("# owners\n/src/ @team-a\n"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, ".requirecodeowners.yml"), []byte(`directories:
+  - path: src
+    min_owners: 2
+  - path: pkg
+  - path: missing
+`), 0644)
+
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	res, err := checkRepo(context.Background(), "", nil, specFilter{})
+	if err != nil {
+		t.Fatalf("checkRepo() error = %v", err)
+	}
+
+	want := map[string]struct {
+		file string
+		line int
+	}{
+		"src":     {"CODEOWNERS", 2},
+		"pkg":     {"CODEOWNERS", 0},
+		"missing": {".requirecodeowners.yml", 5},
+	}
+	if len(res.errors) != len(want) {
+		t.Fatalf("checkRepo() errors = %v, want %d", res.errors, len(want))
+	}
+	for _, e := range res.errors {
+		w := want[e.path]
+		if e.file != w.file || e.line != w.line {
+			t.Errorf("%s located at %s:%d, want %s:%d", e.path, e.file, e.line, w.file, w.line)
+		}
+	}
+}
+
+func TestWriteTemplate(t *testing.T) {
+	tmpDir := t.TempDir()
+	tmplPath := filepath.Join(tmpDir, "report.tmpl")
+	tmpl := `{{.Stats.Owned}}/{{.Stats.Checked}} owned, {{.Stats.Failures}} {{pluralize .Stats.Failures "failure" "failures"}}
+{{range .Errors}}{{.Severity}} {{.Path}} ({{.File}}:{{.Line}})
+{{end}}config={{.Config.Path}} codeowners={{index .Config.Codeowners 0}} specs={{len .Config.Specs}}
+`
+	os.WriteFile(tmplPath, []byte(tmpl), 0644)
+
+	res := result{
+		errors: []validationError{
+			{path: "services/foo", message: "Not covered by CODEOWNERS.", file: ".github/CODEOWNERS"},
+		},
+		checked:    []checkedDir{{path: "services/foo"}, {path: "services/bar", rule: &rule{}}},
+		configPath: ".requirecodeowners.yml",
+		codeowners: []string{".github/CODEOWNERS"},
+		specs:      []dirSpec{{Path: "services", Level: 1}},
+	}
+
+	var buf bytes.Buffer
+	if err := writeTemplate(&buf, res, reportOptions{templatePath: tmplPath}); err != nil {
+		t.Fatalf("writeTemplate() error = %v", err)
+	}
+	want := `1/2 owned, 1 failure
+error services/foo (.github/CODEOWNERS:0)
+config=.requirecodeowners.yml codeowners=.github/CODEOWNERS specs=1
+`
+	if buf.String() != want {
+		t.Errorf("writeTemplate() =\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestWriteTemplateErrors(t *testing.T) {
+	tmpDir := t.TempDir()
+	badPath := filepath.Join(tmpDir, "bad.tmpl")
+	os.WriteFile(badPath, []byte("{{.Stats"), 0644)
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr string
+	}{
+		{name: "no template", path: "", wantErr: "requires --template"},
+		{name: "missing file", path: filepath.Join(tmpDir, "missing.tmpl"), wantErr: "reading template"},
+		{name: "parse error", path: badPath, wantErr: "parsing template"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := writeTemplate(&bytes.Buffer{}, result{}, reportOptions{templatePath: tt.path})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("writeTemplate() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReportFlag(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    reportTarget
+		wantErr bool
+	}{
+		{value: "rdjson=out/report.json", want: reportTarget{format: "rdjson", path: "out/report.json"}},
+		{value: "markdown=a=b.md", want: reportTarget{format: "markdown", path: "a=b.md"}},
+		{value: "rdjson", wantErr: true},
+		{value: "=report.json", wantErr: true},
+		{value: "rdjson=", wantErr: true},
+		{value: "sarif=report.sarif", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			var f reportFlag
+			err := f.Set(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Set(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if !tt.wantErr && (len(f) != 1 || f[0] != tt.want) {
+				t.Errorf("Set(%q) = %v, want [%v]", tt.value, f, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteReportFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.json")
+	os.WriteFile(path, []byte("stale content that is longer than the report"), 0644)
+
+	if err := writeReportFile(path, "rdjson", result{}, reportOptions{}); err != nil {
+		t.Fatalf("writeReportFile() error = %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	var got rdjsonResult
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Errorf("report file is not valid JSON: %v\n%s", err, data)
+	}
+
+	if err := writeReportFile(filepath.Join(t.TempDir(), "missing", "r.json"), "rdjson", result{}, reportOptions{}); err == nil {
+		t.Error("writeReportFile() into a missing directory succeeded, want error")
+	}
+}
+
+func TestWriteMarkdownByTeam(t *testing.T) {
+	res := result{errors: []validationError{
+		{path: "services/a", message: "Not covered by CODEOWNERS.", team: "@org/payments"},
+		{path: "services/b", message: "Not covered by CODEOWNERS."},
+		{path: "services/c", message: "Not covered by CODEOWNERS.", team: "@org/api"},
+		{path: "services/d", message: "Not covered by CODEOWNERS.", team: "@org/payments"},
+	}}
+
+	var buf bytes.Buffer
+	if err := writeMarkdown(&buf, res, reportOptions{groupByTeam: true}); err != nil {
+		t.Fatalf("writeMarkdown() error = %v", err)
+	}
+	out := buf.String()
+
+	headings := []string{"### @org/api (1)", "### @org/payments (2)", "### Unassigned (1)"}
+	last := -1
+	for _, h := range headings {
+		i := strings.Index(out, h)
+		if i < 0 {
+			t.Fatalf("report missing %q:\n%s", h, out)
+		}
+		if i < last {
+			t.Errorf("heading %q out of order:\n%s", h, out)
+		}
+		last = i
+	}
+	payments := out[strings.Index(out, "### @org/payments"):strings.Index(out, "### Unassigned")]
+	if !strings.Contains(payments, "services/a") || !strings.Contains(payments, "services/d") || strings.Contains(payments, "services/b") {
+		t.Errorf("payments section = %q, want services/a and services/d only", payments)
+	}
+}
+
+func TestSpecNamesInOutput(t *testing.T) {
+	res := result{errors: []validationError{
+		{path: "services/foo", message: "Not covered by CODEOWNERS.", spec: "services", file: "CODEOWNERS"},
+		{path: "libs", message: "Directory not found."},
+	}}
+
+	var text bytes.Buffer
+	printText(&text, res.errors, 0)
+	if !strings.Contains(text.String(), "services/foo (services)") {
+		t.Errorf("text output missing the spec name:\n%s", text.String())
+	}
+
+	var md bytes.Buffer
+	writeMarkdown(&md, res, reportOptions{})
+	for _, want := range []string{"| Path | Spec | Issue |", "| `services/foo` | services | Not covered", "| `libs` |  | Directory not found."} {
+		if !strings.Contains(md.String(), want) {
+			t.Errorf("markdown missing %q:\n%s", want, md.String())
+		}
+	}
+
+	var rd bytes.Buffer
+	writeRDJSON(&rd, res, reportOptions{})
+	if !strings.Contains(rd.String(), `"services/foo (services): Not covered by CODEOWNERS."`) {
+		t.Errorf("rdjson missing the spec name:\n%s", rd.String())
+	}
+}
+
+func TestWriteMarkdownSkipped(t *testing.T) {
+	res := result{skipped: []skippedDir{
+		{path: "sandbox", reason: "allow_unowned"},
+		{path: "services/legacy", reason: unownedMarker},
+	}}
+
+	var buf bytes.Buffer
+	if err := writeMarkdown(&buf, res, reportOptions{}); err != nil {
+		t.Fatalf("writeMarkdown() error = %v", err)
+	}
+	for _, want := range []string{
+		"all directories have CODEOWNERS coverage",
+		"2 directories skipped:",
+		"| `sandbox` | `allow_unowned` |",
+		"| `services/legacy` | `.codeowners-ignore` |",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("writeMarkdown() missing %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestPrintTeamSummary(t *testing.T) {
+	var buf bytes.Buffer
+	printTeamSummary(&buf, []validationError{
+		{path: "services/pay", team: "@org/payments"},
+		{path: "services/pay-ui", team: "@org/payments"},
+		{path: "services/web", team: "@org/web", severity: severityWarning},
+		{path: "tools"},
+	})
+	want := "By team:\n  @org/payments: 2 failures\n  @org/web: 1 warning\n  Unassigned: 1 failure\n"
+	if buf.String() != want {
+		t.Errorf("printTeamSummary() = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestWriteMarkdownLinks(t *testing.T) {
+	res := result{errors: []validationError{
+		{path: "services/new", message: "Not covered by CODEOWNERS.", code: codeMissingEntry, file: ".github/CODEOWNERS"},
+		{path: "services/api", message: "Has 1 owner but at least 2 required.", code: codeTooFewOwners, file: ".github/CODEOWNERS", line: 3},
+		{path: "libs", message: "Directory not found.", code: codeDirNotExist},
+		{path: "services/remote", message: "Not covered by CODEOWNERS.", code: codeMissingEntry, file: "https://example.com/CODEOWNERS"},
+	}}
+	links := &repoLinks{base: "https://github.com/org/repo", ref: "feature/x"}
+
+	var buf bytes.Buffer
+	if err := writeMarkdown(&buf, res, reportOptions{links: links}); err != nil {
+		t.Fatalf("writeMarkdown() error = %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"| `services/new` | `RCO001` Not covered by CODEOWNERS. [Edit CODEOWNERS](https://github.com/org/repo/edit/feature/x/.github/CODEOWNERS) |",
+		"| `services/api` | `RCO006` Has 1 owner but at least 2 required. [.github/CODEOWNERS:3](https://github.com/org/repo/blob/feature/x/.github/CODEOWNERS#L3) |",
+		"| `libs` | `RCO002` Directory not found. |",
+		"| `services/remote` | `RCO001` Not covered by CODEOWNERS. |",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("writeMarkdown() missing %q:\n%s", want, out)
+		}
+	}
+
+	buf.Reset()
+	if err := writeMarkdown(&buf, res, reportOptions{}); err != nil {
+		t.Fatalf("writeMarkdown() error = %v", err)
+	}
+	if strings.Contains(buf.String(), "](") {
+		t.Errorf("writeMarkdown() without links = %q, want no links", buf.String())
+	}
+}
+
+func TestMaxErrors(t *testing.T) {
+	var errs []validationError
+	for i := 0; i < 5; i++ {
+		errs = append(errs, validationError{path: fmt.Sprintf("services/w%d", i), message: "Rule hides another.", severity: severityWarning})
+	}
+	errs = append(errs,
+		validationError{path: "services/x", message: "Not covered by CODEOWNERS."},
+		validationError{path: "services/y", message: "Not covered by CODEOWNERS."},
+	)
+
+	var text bytes.Buffer
+	printText(&text, errs, 3)
+	out := text.String()
+	for _, want := range []string{"services/x", "services/y", "services/w0", "…and 4 more", "2 directories failed", "5 warnings"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("printText() missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "services/w1") {
+		t.Errorf("printText() listed more than 3 errors:\n%s", out)
+	}
+
+	var md bytes.Buffer
+	if err := writeMarkdown(&md, result{errors: errs}, reportOptions{maxErrors: 2}); err != nil {
+		t.Fatalf("writeMarkdown() error = %v", err)
+	}
+	out = md.String()
+	for _, want := range []string{"## ❌ CODEOWNERS Check Failed", "| `services/x` |", "| `services/y` |", "…and 5 more not listed.", "**7 directories** need attention."} {
+		if !strings.Contains(out, want) {
+			t.Errorf("writeMarkdown() missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "services/w0") {
+		t.Errorf("writeMarkdown() listed warnings before failures:\n%s", out)
+	}
+
+	md.Reset()
+	if err := writeMarkdown(&md, result{errors: errs}, reportOptions{maxErrors: 7}); err != nil {
+		t.Fatalf("writeMarkdown() error = %v", err)
+	}
+	if strings.Contains(md.String(), "more not listed") {
+		t.Errorf("writeMarkdown() at the limit summarized:\n%s", md.String())
+	}
+}
+
+func TestWriteMarkdownBySpec(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, d := range []string{"services/api", "services/web", "services/jobs", "libs/core"} {
+		os.MkdirAll(filepath.Join(tmpDir, d), 0755)
+	}
+	os.WriteFile(filepath.Join(tmpDir, ".requirecodeowners.yml"), []byte(`directories:
+  - path: services
+    name: services
+    level: 1
+  - path: libs
+    level: 1
+`), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "CODEOWNERS"), []byte("/services/api/ @org/api\n/libs/ @org/libs\n"), 0644)
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	res, err := checkRepo(context.Background(), "", nil, specFilter{})
+	if err != nil {
+		t.Fatalf("checkRepo() error = %v", err)
+	}
+	res.errors = append(res.errors, validationError{path: "CODEOWNERS", message: "Rule order.", code: codeRuleOrder, severity: severityWarning})
+
+	var buf bytes.Buffer
+	if err := writeMarkdown(&buf, res, reportOptions{groupBySpec: true}); err != nil {
+		t.Fatalf("writeMarkdown() error = %v", err)
+	}
+	out := buf.String()
+	sections := []string{
+		"<summary>❌ <b>services</b> <code>services</code>: 1 passed, 2 failed</summary>",
+		"<summary>✅ <code>libs</code>: 1 passed</summary>",
+		"<summary>⚠️ <b>Other</b>: 0 passed, 1 warning</summary>",
+	}
+	last := -1
+	for _, want := range sections {
+		i := strings.Index(out, want)
+		if i < 0 {
+			t.Fatalf("report missing %q:\n%s", want, out)
+		}
+		if i < last {
+			t.Errorf("section %q out of order:\n%s", want, out)
+		}
+		last = i
+	}
+	services := out[strings.Index(out, sections[0]):strings.Index(out, sections[1])]
+	for _, want := range []string{"| Path | Issue |", "| `services/jobs` |", "| `services/web` |"} {
+		if !strings.Contains(services, want) {
+			t.Errorf("services section missing %q:\n%s", want, services)
+		}
+	}
+	if !strings.Contains(out, "All directories passed.") {
+		t.Errorf("libs section doesn't say it passed:\n%s", out)
+	}
+}
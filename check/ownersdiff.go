@@ -0,0 +1,239 @@
+package check
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ownershipChange is a configured directory whose effective owners differ
+// between two CODEOWNERS revisions.
+type ownershipChange struct {
+	Path string `json:"path"`
+	// Kind is "changed" for a directory owned in both with different
+	// owners, "gained" for one that's only owned in the new revision, and
+	// "lost" for one that's only owned in the old.
+	Kind string   `json:"kind"`
+	Old  []string `json:"old_owners"`
+	New  []string `json:"new_owners"`
+}
+
+func runDiff(args []string) int {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: requirecodeowners diff [flags] OLD NEW")
+		fmt.Fprintln(fs.Output(), "OLD and NEW are CODEOWNERS files, or git refs like main or HEAD~1:.github/CODEOWNERS.")
+		fs.PrintDefaults()
+	}
+	var format string
+	var rf repoFlags
+	rf.registerConfig(fs)
+	var lf configFlags
+	lf.register(fs)
+	fs.StringVar(&format, "format", "text", "output format: text or json")
+	var filter specFilter
+	filter.register(fs)
+	var tf traversalFlags
+	tf.register(fs)
+	var gf githubFlags
+	gf.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if err := lf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	if err := tf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return 2
+	}
+	if format != "text" && format != "json" {
+		fmt.Fprintf(os.Stderr, "error: unknown format %q (want text or json)\n", format)
+		return 2
+	}
+	if err := gf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+
+	if err := rf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	ctx := context.Background()
+	current, err := checkRepo(ctx, rf.configPath, nil, filter)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	tmpDir, err := os.MkdirTemp("", "requirecodeowners-diff-")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	defer os.RemoveAll(tmpDir)
+
+	var manifests [2][]ownershipEntry
+	for i, revision := range fs.Args() {
+		file, err := codeownersRevision(revision, current.codeowners, filepath.Join(tmpDir, fmt.Sprint(i)))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return 1
+		}
+		res, err := checkRepo(ctx, rf.configPath, []string{file}, filter)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %s: %v\n", revision, err)
+			return 1
+		}
+		manifests[i] = ownershipManifest(res.checked)
+	}
+
+	changes := diffOwnership(manifests[0], manifests[1])
+	if format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(changes)
+	} else {
+		err = writeOwnershipChanges(os.Stdout, changes)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: writing diff: %v\n", err)
+		return 1
+	}
+	// Lost coverage is a regression, so it fails like the check does.
+	for _, c := range changes {
+		if c.Kind == "lost" {
+			return 1
+		}
+	}
+	return 0
+}
+
+// codeownersRevision returns a local file holding the CODEOWNERS revision
+// named by revision: a file, or else a git ref. A bare ref like main means
+// the CODEOWNERS file the check uses, the last of sources, as of that ref;
+// ref:path names the file too. Refs are read with git show into tmp.
+func codeownersRevision(revision string, sources []string, tmp string) (string, error) {
+	if info, err := os.Stat(revision); err == nil && !info.IsDir() {
+		return revision, nil
+	}
+	object := revision
+	if !strings.Contains(revision, ":") {
+		if len(sources) == 0 {
+			return "", fmt.Errorf("%s isn't a file, and there's no CODEOWNERS file to read at it", revision)
+		}
+		source := sources[len(sources)-1]
+		if isURL(source) || isGitHubSource(source) || filepath.IsAbs(source) {
+			return "", fmt.Errorf("%s isn't a file, and CODEOWNERS comes from %s, which isn't in the repository; use ref:path", revision, source)
+		}
+		object = revision + ":./" + filepath.ToSlash(source)
+	}
+	data, err := gitOutput("show", object)
+	if err != nil {
+		return "", fmt.Errorf("%s isn't a file or a git revision: %w", revision, err)
+	}
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return "", err
+	}
+	return tmp, nil
+}
+
+// diffOwnership compares the owners of each directory in old and new,
+// manifests of the same directories checked against two CODEOWNERS
+// revisions. A directory missing from one, because it was allowed to be
+// unowned there, counts as unowned. Owners are compared as sets, so
+// reordering them isn't a change.
+func diffOwnership(old, new []ownershipEntry) []ownershipChange {
+	owners := func(manifest []ownershipEntry) map[string][]string {
+		m := make(map[string][]string, len(manifest))
+		for _, e := range manifest {
+			sorted := slices.Clone(e.Owners)
+			sort.Strings(sorted)
+			m[e.Path] = sorted
+		}
+		return m
+	}
+	before, after := owners(old), owners(new)
+	var paths []string
+	for p := range before {
+		paths = append(paths, p)
+	}
+	for p := range after {
+		if _, ok := before[p]; !ok {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+
+	changes := []ownershipChange{}
+	for _, p := range paths {
+		o, n := before[p], after[p]
+		c := ownershipChange{Path: p, Old: o, New: n}
+		switch {
+		case slices.Equal(o, n):
+			continue
+		case len(o) == 0:
+			c.Kind = "gained"
+		case len(n) == 0:
+			c.Kind = "lost"
+		default:
+			c.Kind = "changed"
+		}
+		if c.Old == nil {
+			c.Old = []string{}
+		}
+		if c.New == nil {
+			c.New = []string{}
+		}
+		changes = append(changes, c)
+	}
+	return changes
+}
+
+// writeOwnershipChanges lists the changes grouped by kind, lost coverage
+// first since it's the one to worry about.
+func writeOwnershipChanges(w io.Writer, changes []ownershipChange) error {
+	if len(changes) == 0 {
+		_, err := fmt.Fprintln(w, "No configured directory changed owners.")
+		return err
+	}
+	sections := []struct{ kind, title string }{
+		{"lost", "Lost coverage"},
+		{"gained", "Gained coverage"},
+		{"changed", "Changed owners"},
+	}
+	for _, s := range sections {
+		var lines []string
+		for _, c := range changes {
+			if c.Kind != s.kind {
+				continue
+			}
+			switch c.Kind {
+			case "lost":
+				lines = append(lines, fmt.Sprintf("  %s (was %s)", c.Path, strings.Join(c.Old, " ")))
+			case "gained":
+				lines = append(lines, fmt.Sprintf("  %s: %s", c.Path, strings.Join(c.New, " ")))
+			default:
+				lines = append(lines, fmt.Sprintf("  %s: %s → %s", c.Path, strings.Join(c.Old, " "), strings.Join(c.New, " ")))
+			}
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "%s (%d):\n%s\n\n", s.title, len(lines), strings.Join(lines, "\n"))
+	}
+	_, err := fmt.Fprintf(w, "%d %s changed ownership\n", len(changes), pluralize(len(changes), "directory", "directories"))
+	return err
+}
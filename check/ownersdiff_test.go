@@ -0,0 +1,100 @@
+package check
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiffOwnership(t *testing.T) {
+	old := []ownershipEntry{
+		{Path: "services/api", Owners: []string{"@org/api", "@alice"}},
+		{Path: "services/jobs", Owners: []string{"@org/jobs"}},
+		{Path: "services/web", Owners: []string{}},
+		{Path: "services/same", Owners: []string{"@org/a", "@org/b"}},
+	}
+	new := []ownershipEntry{
+		{Path: "services/api", Owners: []string{"@org/api", "@org/platform"}},
+		{Path: "services/jobs", Owners: []string{}},
+		{Path: "services/web", Owners: []string{"@org/web"}},
+		{Path: "services/same", Owners: []string{"@org/b", "@org/a"}},
+		{Path: "services/unlisted", Owners: []string{"@org/new"}},
+	}
+
+	got := diffOwnership(old, new)
+	want := []ownershipChange{
+		{Path: "services/api", Kind: "changed", Old: []string{"@alice", "@org/api"}, New: []string{"@org/api", "@org/platform"}},
+		{Path: "services/jobs", Kind: "lost", Old: []string{"@org/jobs"}, New: []string{}},
+		{Path: "services/unlisted", Kind: "gained", Old: []string{}, New: []string{"@org/new"}},
+		{Path: "services/web", Kind: "gained", Old: []string{}, New: []string{"@org/web"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("diffOwnership() =\n%+v\nwant\n%+v", got, want)
+	}
+
+	var buf bytes.Buffer
+	if err := writeOwnershipChanges(&buf, got); err != nil {
+		t.Fatalf("writeOwnershipChanges() error = %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"Lost coverage (1):\n  services/jobs (was @org/jobs)\n",
+		"Gained coverage (2):\n  services/unlisted: @org/new\n  services/web: @org/web\n",
+		"Changed owners (1):\n  services/api: @alice @org/api → @org/api @org/platform\n",
+		"4 directories changed ownership",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("writeOwnershipChanges() missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Index(out, "Lost") > strings.Index(out, "Gained") {
+		t.Errorf("lost coverage isn't listed first:\n%s", out)
+	}
+}
+
+func TestCodeownersRevision(t *testing.T) {
+	gitRepo(t, map[string]string{
+		".requirecodeowners.yml": "directories:\n  - path: services\n    level: 1\n",
+		".github/CODEOWNERS":     "/services/api/ @org/api\n/services/jobs/ @org/jobs\n",
+		"services/api/main.go":   "package main\n",
+		"services/jobs/run.sh":   "",
+		"services/web/index.js":  "",
+	})
+	os.WriteFile(".github/CODEOWNERS", []byte("/services/api/ @org/platform\n/services/web/ @org/web\n"), 0644)
+	git(t, "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-am", "reassign")
+
+	tmp := t.TempDir()
+	sources := []string{".github/CODEOWNERS"}
+	var manifests [2][]ownershipEntry
+	for i, revision := range []string{"HEAD~1", "HEAD:.github/CODEOWNERS"} {
+		file, err := codeownersRevision(revision, sources, filepath.Join(tmp, []string{"old", "new"}[i]))
+		if err != nil {
+			t.Fatalf("codeownersRevision(%q) error = %v", revision, err)
+		}
+		res, err := checkRepo(context.Background(), "", []string{file}, specFilter{})
+		if err != nil {
+			t.Fatalf("checkRepo() error = %v", err)
+		}
+		manifests[i] = ownershipManifest(res.checked)
+	}
+
+	var kinds []string
+	for _, c := range diffOwnership(manifests[0], manifests[1]) {
+		kinds = append(kinds, c.Path+" "+c.Kind)
+	}
+	want := []string{"services/api changed", "services/jobs lost", "services/web gained"}
+	if !reflect.DeepEqual(kinds, want) {
+		t.Errorf("changes = %v, want %v", kinds, want)
+	}
+
+	if file, err := codeownersRevision(".github/CODEOWNERS", sources, filepath.Join(tmp, "file")); err != nil || file != ".github/CODEOWNERS" {
+		t.Errorf("codeownersRevision(file) = %q, %v, want the file itself", file, err)
+	}
+	if _, err := codeownersRevision("no-such-ref", sources, filepath.Join(tmp, "bad")); err == nil {
+		t.Error("codeownersRevision(no-such-ref) succeeded, want error")
+	}
+}
@@ -0,0 +1,113 @@
+package check
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hmarr/codeowners"
+)
+
+// ownerSpellings maps each owner spelled more than one way across rules,
+// lowercased, to the spelling to use: the most common one, or the first
+// used of those tied. Owners spelled one way aren't in it.
+func ownerSpellings(rules ruleset) map[string]string {
+	counts := make(map[string]int)
+	spellings := make(map[string][]string) // lowercased -> spellings, by first use
+	for _, r := range rules {
+		for _, o := range r.Owners {
+			s := o.String()
+			if counts[s] == 0 {
+				key := strings.ToLower(s)
+				spellings[key] = append(spellings[key], s)
+			}
+			counts[s]++
+		}
+	}
+	canonical := make(map[string]string)
+	for key, ss := range spellings {
+		if len(ss) < 2 {
+			continue
+		}
+		best := ss[0]
+		for _, s := range ss[1:] {
+			if counts[s] > counts[best] {
+				best = s
+			}
+		}
+		canonical[key] = best
+	}
+	return canonical
+}
+
+// checkOwnerSpelling reports owners spelled differently from elsewhere in
+// CODEOWNERS, like @Org/Team for @org/team. GitHub doesn't mind, but tools
+// that compare owners as text do. fmt --write normalizes them.
+func (c *checker) checkOwnerSpelling() []validationError {
+	canonical := ownerSpellings(c.rules)
+	if len(canonical) == 0 {
+		return nil
+	}
+	counts := make(map[string]int)
+	for _, r := range c.rules {
+		for _, o := range r.Owners {
+			counts[o.String()]++
+		}
+	}
+	return c.checkRuleOwners(codeOwnerSpelling, func(_ *rule, o codeowners.Owner) (string, string) {
+		want, ok := canonical[strings.ToLower(o.String())]
+		if !ok || want == o.String() {
+			return "", ""
+		}
+		return fmt.Sprintf("Is spelled %s on %d other %s. Owners match case-insensitively, but mixed spellings break tools that compare them as text. Use %s, or run requirecodeowners fmt --write.",
+			want, counts[want], pluralize(counts[want], "rule", "rules"), want), want
+	})
+}
+
+// normalizeOwners returns the CODEOWNERS data with each owner in canonical,
+// keyed by its lowercased spelling, respelled. Patterns, spacing and
+// comments are left as they are.
+func normalizeOwners(data []byte, canonical map[string]string) []byte {
+	lines := strings.Split(string(data), "\n")
+	for i, line := range lines {
+		lines[i] = mapFields(line, func(n int, field string) string {
+			if n == 0 {
+				return field // the pattern
+			}
+			if want, ok := canonical[strings.ToLower(field)]; ok {
+				return want
+			}
+			return field
+		})
+	}
+	return []byte(strings.Join(lines, "\n"))
+}
+
+// mapFields returns a CODEOWNERS line with each whitespace-separated field
+// before any comment replaced by fn(n, field), n counting from 0. Spacing is
+// kept, and a backslash-escaped space doesn't end a field.
+func mapFields(line string, fn func(n int, field string) string) string {
+	var b strings.Builder
+	n := 0
+	for i := 0; i < len(line); {
+		if line[i] == ' ' || line[i] == '\t' || line[i] == '\r' {
+			b.WriteByte(line[i])
+			i++
+			continue
+		}
+		if line[i] == '#' {
+			b.WriteString(line[i:])
+			break
+		}
+		end := i
+		for end < len(line) && line[end] != ' ' && line[end] != '\t' && line[end] != '\r' {
+			if line[end] == '\\' && end+1 < len(line) {
+				end++
+			}
+			end++
+		}
+		b.WriteString(fn(n, line[i:end]))
+		n++
+		i = end
+	}
+	return b.String()
+}
@@ -0,0 +1,54 @@
+package check
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckOwnerSpelling(t *testing.T) {
+	rules, err := parseCodeowners(strings.NewReader(`/services/ @org/platform
+/services/api/ @org/api @Org/Platform
+/services/web/ @org/web @org/platform
+/libs/ @Jane @jane dev@example.com
+/docs/ @JANE DEV@example.com
+`), "CODEOWNERS", providerGitHub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"@org/platform":   "@org/platform",
+		"@jane":           "@Jane", // tied, so the first spelling wins
+		"dev@example.com": "dev@example.com",
+	}
+	got := ownerSpellings(rules)
+	if len(got) != len(want) {
+		t.Errorf("ownerSpellings() = %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("ownerSpellings()[%s] = %q, want %q", k, got[k], v)
+		}
+	}
+
+	c := &checker{fsys: newTreeFS(nil), rules: rules, policy: policy{ConsistentOwnerSpelling: true}}
+	var found []string
+	for _, e := range c.checkPolicy(nil) {
+		found = append(found, e.path+" "+e.team)
+		if e.code != codeOwnerSpelling || e.file != "CODEOWNERS" || e.line == 0 {
+			t.Errorf("problem %v isn't a %s at its rule", e, codeOwnerSpelling)
+		}
+	}
+	wantFound := "@Org/Platform @org/platform,@jane @Jane,@JANE @Jane,DEV@example.com dev@example.com"
+	if strings.Join(found, ",") != wantFound {
+		t.Errorf("checkPolicy() = %v, want %s", found, wantFound)
+	}
+}
+
+func TestNormalizeOwners(t *testing.T) {
+	canonical := map[string]string{"@org/platform": "@org/platform", "@jane": "@jane"}
+	data := "# @Org/Platform owns services\n/services/  @Org/Platform\t@JANE # ask @JANE first\n/my\\ docs/ @Jane\n\n"
+	want := "# @Org/Platform owns services\n/services/  @org/platform\t@jane # ask @JANE first\n/my\\ docs/ @jane\n\n"
+	if got := string(normalizeOwners([]byte(data), canonical)); got != want {
+		t.Errorf("normalizeOwners() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,211 @@
+package check
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hmarr/codeowners"
+
+	"github.com/kpurdon/requirecodeowners/validate"
+)
+
+// checkPolicy applies the config's policy checks to all checked directories.
+func (c *checker) checkPolicy(checked []checkedDir) []validationError {
+	var errors []validationError
+	if max := c.policy.MaxDirsPerOwner; max > 0 {
+		for _, o := range computeStats(checked).Owners {
+			if o.Directories <= max {
+				continue
+			}
+			errors = append(errors, validationError{
+				path: o.Owner,
+				message: fmt.Sprintf("Owns %d of the checked directories, more than max_dirs_per_owner (%d). Hand some of them to more specific teams in %s.",
+					o.Directories, max, c.codeownersPath),
+				code:     codeTooManyDirs,
+				severity: c.policy.Severity,
+				team:     o.Owner,
+				file:     c.codeownersPath,
+			})
+		}
+	}
+	if len(c.policy.AllowedOwners) > 0 {
+		errors = append(errors, c.checkAllowedOwners()...)
+	}
+	errors = append(errors, c.runValidators(checked)...)
+	if c.policy.RejectAliases {
+		errors = append(errors, c.checkRawAliases()...)
+	}
+	if c.policy.TeamOrg != "" || c.policy.ForbidUserOwners {
+		errors = append(errors, c.checkTeamOwners()...)
+	}
+	if c.policy.ForbidEmailOwners || len(c.policy.AllowedEmailDomains) > 0 {
+		errors = append(errors, c.checkEmailOwners()...)
+	}
+	if c.policy.ParentConflicts {
+		errors = append(errors, c.checkParentConflicts(checked)...)
+	}
+	if c.policy.RuleOrder != "" {
+		errors = append(errors, c.checkRuleOrder()...)
+	}
+	if c.policy.ConsistentOwnerSpelling {
+		errors = append(errors, c.checkOwnerSpelling()...)
+	}
+	if c.policy.AnchoredPatterns {
+		errors = append(errors, c.checkPatternAnchoring()...)
+	}
+	errors = append(errors, c.checkRuleAnnotations(time.Now())...)
+	errors = append(errors, c.checkCodeownersLimits()...)
+	errors = append(errors, c.checkPatternCase()...)
+	return errors
+}
+
+// checkRuleOwners runs check on every owner of every CODEOWNERS rule and
+// reports each problem it describes at that rule, with code. check returns
+// "" for an owner that's fine, and may name the team responsible for the
+// fix.
+func (c *checker) checkRuleOwners(code string, check func(r *rule, o codeowners.Owner) (message, team string)) []validationError {
+	var errors []validationError
+	for i := range c.rules {
+		r := &c.rules[i]
+		for _, o := range r.Owners {
+			message, team := check(r, o)
+			if message == "" {
+				continue
+			}
+			errors = append(errors, validationError{
+				path:     o.String(),
+				message:  message,
+				code:     code,
+				severity: c.policy.Severity,
+				team:     team,
+				file:     r.file,
+				line:     r.LineNumber,
+			})
+		}
+	}
+	return errors
+}
+
+// checkAllowedOwners reports every owner in CODEOWNERS that isn't in the
+// policy's allowed owners. Owners are compared case-insensitively, as GitHub
+// does.
+func (c *checker) checkAllowedOwners() []validationError {
+	allowed := make(map[string]bool, len(c.policy.AllowedOwners))
+	for _, o := range c.policy.AllowedOwners {
+		allowed[strings.ToLower(o)] = true
+	}
+	return c.checkRuleOwners(codeOwnerNotAllowed, func(r *rule, o codeowners.Owner) (string, string) {
+		if allowed[strings.ToLower(o.String())] {
+			return "", ""
+		}
+		return fmt.Sprintf("Not in allowed_owners. Fix the owner in %s: %s, or add it to the allowed owners.", r.location(), r.RawPattern()), ""
+	})
+}
+
+// checkRawAliases reports config aliases used as owners in CODEOWNERS. They
+// only mean something to this tool, so GitHub would ignore them.
+func (c *checker) checkRawAliases() []validationError {
+	return c.checkRuleOwners(codeRawAlias, func(r *rule, o codeowners.Owner) (string, string) {
+		owner, ok := c.aliases[strings.ToLower(o.String())]
+		if !ok {
+			return "", ""
+		}
+		return fmt.Sprintf("Is an alias, which GitHub doesn't resolve. Replace it with %s in %s: %s", owner, r.location(), r.RawPattern()), owner
+	})
+}
+
+// teamSlugPattern is the shape of a GitHub team slug: the team name
+// lowercased, with anything other than letters and digits turned into
+// hyphens, so it never starts or ends with one. GitHub matches slugs
+// case-insensitively.
+var teamSlugPattern = regexp.MustCompile(`(?i)^[a-z0-9]([a-z0-9_-]*[a-z0-9])?$`)
+
+// checkTeamOwners reports team owners outside the policy's team_org or with
+// a malformed slug, and, with forbid_user_owners, bare @name owners, which
+// GitHub treats as users even when a team was meant.
+func (c *checker) checkTeamOwners() []validationError {
+	org := c.policy.TeamOrg
+	return c.checkRuleOwners(codeInvalidTeamOwner, func(r *rule, o codeowners.Owner) (string, string) {
+		switch o.Type {
+		case codeowners.TeamOwner:
+			teamOrg, slug, _ := strings.Cut(o.Value, "/")
+			if org != "" && !strings.EqualFold(teamOrg, org) {
+				return fmt.Sprintf("Team is in %s, not %s. Fix the owner in %s: %s", teamOrg, org, r.location(), r.RawPattern()), ""
+			}
+			if !teamSlugPattern.MatchString(slug) {
+				return fmt.Sprintf("%q is not a valid team slug. Fix the owner in %s: %s", slug, r.location(), r.RawPattern()), ""
+			}
+		case codeowners.UsernameOwner:
+			if c.policy.ForbidUserOwners {
+				want := "@<org>/" + o.Value
+				if org != "" {
+					want = "@" + org + "/" + o.Value
+				}
+				return fmt.Sprintf("User owners aren't allowed. If this is a team, write it as %s in %s: %s", want, r.location(), r.RawPattern()), ""
+			}
+		}
+		return "", ""
+	})
+}
+
+// forbiddenOwnersValidator reports checked directories whose rule lists
+// one of the policy's forbidden owners, or the org policy's, which always
+// fail. Owners are compared case-insensitively.
+func (c *checker) forbiddenOwnersValidator() validate.Validator {
+	forbidden := make(map[string]bool, len(c.policy.ForbiddenOwners)) // true if the org policy forbids it
+	for _, o := range c.policy.ForbiddenOwners {
+		forbidden[strings.ToLower(o)] = false
+	}
+	if c.org != nil {
+		for _, o := range c.org.ForbiddenOwners {
+			forbidden[strings.ToLower(o)] = true
+		}
+	}
+	return validate.Func{N: "forbidden_owners", F: func(_ context.Context, d validate.DirResult) []validate.Finding {
+		var findings []validate.Finding
+		for _, o := range d.Owners {
+			byOrg, ok := forbidden[strings.ToLower(o)]
+			if !ok {
+				continue
+			}
+			f := validate.Finding{
+				Message:  fmt.Sprintf("Owned by %s, which is in forbidden_owners. Move it to another owner in %s:%d: %s", o, d.File, d.Line, d.Rule),
+				Code:     codeForbiddenOwner,
+				Severity: c.policy.Severity,
+				File:     d.File,
+				Line:     d.Line,
+				Team:     o,
+			}
+			if byOrg {
+				f.Message = fmt.Sprintf("Owned by %s, which the policy %s forbids. Move it to another owner in %s:%d: %s", o, c.org.source, d.File, d.Line, d.Rule)
+				f.Severity = severityError
+				f.Mandatory = true
+			}
+			findings = append(findings, f)
+		}
+		return findings
+	}}
+}
+
+// checkEmailOwners reports email owners that the policy doesn't allow: all of
+// them with forbid_email_owners, or those outside allowed_email_domains.
+func (c *checker) checkEmailOwners() []validationError {
+	return c.checkRuleOwners(codeEmailOwner, func(r *rule, o codeowners.Owner) (string, string) {
+		if o.Type != codeowners.EmailOwner {
+			return "", ""
+		}
+		if c.policy.ForbidEmailOwners {
+			return fmt.Sprintf("Email owners aren't allowed. Replace it with a team in %s: %s", r.location(), r.RawPattern()), ""
+		}
+		_, domain, _ := strings.Cut(o.Value, "@")
+		for _, allowed := range c.policy.AllowedEmailDomains {
+			if strings.EqualFold(domain, allowed) {
+				return "", ""
+			}
+		}
+		return fmt.Sprintf("Email domain %s is not in allowed_email_domains. Replace it in %s: %s", domain, r.location(), r.RawPattern()), ""
+	})
+}
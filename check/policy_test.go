@@ -0,0 +1,298 @@
+package check
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidateMaxDirsPerOwner(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	for _, d := range []string{"a", "b", "c", "d"} {
+		os.MkdirAll(filepath.Join(tmpDir, "services", d), 0755)
+	}
+
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	rules, err := parseCodeowners(strings.NewReader(`/services/ @org/catchall
+/services/d/ @org/catchall @org/d
+`), "CODEOWNERS", providerGitHub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		policy   policy
+		wantErrs []string
+		severity string
+	}{
+		{name: "no limit", policy: policy{}},
+		{name: "under the limit", policy: policy{MaxDirsPerOwner: 4}},
+		{name: "over the limit", policy: policy{MaxDirsPerOwner: 3}, wantErrs: []string{"@org/catchall"}},
+		{name: "as a warning", policy: policy{MaxDirsPerOwner: 2, Severity: severityWarning}, wantErrs: []string{"@org/catchall"}, severity: severityWarning},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &checker{fsys: localFS{}, rules: rules, configPath: ".requirecodeowners.yml", codeownersPath: "CODEOWNERS", policy: tt.policy}
+			errs := c.validate([]dirSpec{{Path: "services", Level: 1}}).errors
+			var got []string
+			for _, e := range errs {
+				got = append(got, e.path)
+				if e.severity != tt.severity {
+					t.Errorf("error %s severity = %q, want %q", e.path, e.severity, tt.severity)
+				}
+				if !strings.Contains(e.message, "Owns 4 of the checked directories") {
+					t.Errorf("error message = %q, want the directory count", e.message)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.wantErrs) {
+				t.Errorf("validate() errors for %v, want %v", got, tt.wantErrs)
+			}
+		})
+	}
+}
+
+func TestCheckAllowedOwners(t *testing.T) {
+	rules, err := parseCodeowners(strings.NewReader(`/services/ @org/platform
+/services/api/ @org/platfrom @Org/API
+/docs/ docs@example.com
+`), "CODEOWNERS", providerGitHub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := &checker{fsys: newTreeFS(nil), rules: rules, policy: policy{AllowedOwners: []string{"@org/platform", "@org/api"}}}
+	errs := c.checkPolicy(nil)
+
+	var got []string
+	for _, e := range errs {
+		got = append(got, e.path)
+		if e.file != "CODEOWNERS" || e.line == 0 {
+			t.Errorf("error for %s located at %s:%d, want its CODEOWNERS rule", e.path, e.file, e.line)
+		}
+	}
+	want := []string{"@org/platfrom", "docs@example.com"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("checkPolicy() errors for %v, want %v", got, want)
+	}
+}
+
+func TestLoadOwnersRegistry(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.WriteFile(filepath.Join(tmpDir, "owners-registry.yml"), []byte("owners:\n  - \"@org/api\"\n  - \"@org/web\"\n"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "empty.yml"), []byte("owners: []\n"), 0644)
+
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	tests := []struct {
+		name    string
+		config  string
+		want    []string
+		wantErr string
+	}{
+		{
+			name: "inline and registry merged",
+			config: `policy:
+  allowed_owners: ["@org/platform"]
+  owners_registry: owners-registry.yml
+directories:
+  - path: services
+`,
+			want: []string{"@org/platform", "@org/api", "@org/web"},
+		},
+		{
+			name: "missing registry",
+			config: `policy:
+  owners_registry: missing.yml
+directories:
+  - path: services
+`,
+			wantErr: "reading owners registry",
+		},
+		{
+			name: "empty registry",
+			config: `policy:
+  owners_registry: empty.yml
+directories:
+  - path: services
+`,
+			wantErr: "lists no owners",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.WriteFile(".requirecodeowners.yml", []byte(tt.config), 0644)
+			cfg, err := loadConfig("")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("loadConfig() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadConfig() error = %v", err)
+			}
+			if !reflect.DeepEqual(cfg.Policy.AllowedOwners, tt.want) {
+				t.Errorf("allowed owners = %v, want %v", cfg.Policy.AllowedOwners, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckRawAliases(t *testing.T) {
+	rules, err := parseCodeowners(strings.NewReader(`/services/ @org/payments-core
+/services/api/ @Payments @org/api
+`), "CODEOWNERS", providerGitHub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	aliases := map[string]string{"@payments": "@org/payments-core"}
+
+	c := &checker{fsys: newTreeFS(nil), rules: rules, aliases: aliases}
+	if errs := c.checkPolicy(nil); len(errs) != 0 {
+		t.Errorf("checkPolicy() without reject_aliases = %v, want no errors", errs)
+	}
+
+	c.policy.RejectAliases = true
+	errs := c.checkPolicy(nil)
+	if len(errs) != 1 {
+		t.Fatalf("checkPolicy() = %v, want 1 error", errs)
+	}
+	if errs[0].path != "@Payments" || errs[0].line != 2 || !strings.Contains(errs[0].message, "Replace it with @org/payments-core") {
+		t.Errorf("checkPolicy() error = %+v, want @Payments on line 2 with its replacement", errs[0])
+	}
+}
+
+func TestCheckForbiddenOwners(t *testing.T) {
+	rules, err := parseCodeowners(strings.NewReader(`/services/ @org/legacy
+/services/api/ @org/api @Org/Legacy
+/services/web/ @org/web
+`), "CODEOWNERS", providerGitHub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checked := []checkedDir{
+		{path: "services/api", rule: &rules[1]},
+		{path: "services/web", rule: &rules[2]},
+		{path: "services/new"},
+		{path: "services/api", rule: &rules[1]},
+	}
+
+	c := &checker{fsys: newTreeFS(nil), rules: rules, policy: policy{ForbiddenOwners: []string{"@org/legacy"}}}
+	errs := c.checkPolicy(checked)
+	if len(errs) != 1 {
+		t.Fatalf("checkPolicy() = %v, want 1 error", errs)
+	}
+	if errs[0].path != "services/api" || errs[0].line != 2 || !strings.Contains(errs[0].message, "Owned by @Org/Legacy") {
+		t.Errorf("checkPolicy() error = %+v, want services/api owned by @Org/Legacy on line 2", errs[0])
+	}
+}
+
+func TestCheckTeamOwners(t *testing.T) {
+	rules, err := parseCodeowners(strings.NewReader(`/a/ @my-org/payments @My-Org/API
+/b/ @other-org/payments
+/c/ @payments dev@example.com
+/d/ @my-org/payments-
+`), "CODEOWNERS", providerGitHub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		policy policy
+		want   map[string]string
+	}{
+		{
+			name:   "required org",
+			policy: policy{TeamOrg: "my-org"},
+			want: map[string]string{
+				"@other-org/payments": "Team is in other-org, not my-org",
+				"@my-org/payments-":   `"payments-" is not a valid team slug`,
+			},
+		},
+		{
+			name:   "no user owners",
+			policy: policy{TeamOrg: "my-org", ForbidUserOwners: true},
+			want: map[string]string{
+				"@other-org/payments": "Team is in other-org",
+				"@payments":           "write it as @my-org/payments",
+				"@my-org/payments-":   "not a valid team slug",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &checker{fsys: newTreeFS(nil), rules: rules, policy: tt.policy}
+			errs := c.checkPolicy(nil)
+			if len(errs) != len(tt.want) {
+				t.Fatalf("checkPolicy() = %v, want %d errors", errs, len(tt.want))
+			}
+			for _, e := range errs {
+				if want, ok := tt.want[e.path]; !ok || !strings.Contains(e.message, want) {
+					t.Errorf("error for %s = %q, want it to contain %q", e.path, e.message, want)
+				}
+				if e.line == 0 {
+					t.Errorf("error for %s has no line number", e.path)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckEmailOwners(t *testing.T) {
+	rules, err := parseCodeowners(strings.NewReader(`/a/ @my-org/payments dev@Example.com
+/b/ someone@gmail.com
+`), "CODEOWNERS", providerGitHub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		policy policy
+		want   map[string]string
+	}{
+		{
+			name:   "forbidden",
+			policy: policy{ForbidEmailOwners: true},
+			want: map[string]string{
+				"dev@Example.com":   "Email owners aren't allowed",
+				"someone@gmail.com": "Email owners aren't allowed",
+			},
+		},
+		{
+			name:   "allowed domains",
+			policy: policy{AllowedEmailDomains: []string{"example.com"}},
+			want: map[string]string{
+				"someone@gmail.com": "Email domain gmail.com is not in allowed_email_domains",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &checker{fsys: newTreeFS(nil), rules: rules, policy: tt.policy}
+			errs := c.checkPolicy(nil)
+			if len(errs) != len(tt.want) {
+				t.Fatalf("checkPolicy() = %v, want %d errors", errs, len(tt.want))
+			}
+			for _, e := range errs {
+				if want, ok := tt.want[e.path]; !ok || !strings.Contains(e.message, want) {
+					t.Errorf("error for %s = %q, want it to contain %q", e.path, e.message, want)
+				}
+			}
+		})
+	}
+}
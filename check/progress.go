@@ -0,0 +1,141 @@
+package check
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// progressDelay is how long a run goes before progress is shown, so quick
+// runs stay quiet.
+const progressDelay = 2 * time.Second
+
+// progressInterval is how often the progress line is redrawn.
+const progressInterval = 200 * time.Millisecond
+
+// progressReporter keeps a line on a terminal up to date with how far the
+// check has got. The checker counts into it as it goes. A nil
+// *progressReporter counts nothing and shows nothing.
+type progressReporter struct {
+	w     io.Writer
+	start time.Time
+	specs int
+
+	spec     atomic.Int64 // specs started
+	expanded atomic.Int64 // directories found by expansion
+	checked  atomic.Int64 // directories matched against CODEOWNERS
+	failures atomic.Int64
+
+	mu    sync.Mutex // guards writes to w and shown
+	shown bool       // whether the progress line is on screen
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+// progress is where the checker reports how far it has got, from the main
+// command when stderr is a terminal.
+var progress *progressReporter
+
+// startProgress begins redrawing progress on w, a terminal, once the run
+// has taken progressDelay.
+func startProgress(w io.Writer) *progressReporter {
+	p := &progressReporter{w: w, start: time.Now(), stop: make(chan struct{}), done: make(chan struct{})}
+	go p.run()
+	return p
+}
+
+func (p *progressReporter) run() {
+	defer close(p.done)
+	select {
+	case <-time.After(progressDelay):
+	case <-p.stop:
+		return
+	}
+	tick := time.NewTicker(progressInterval)
+	defer tick.Stop()
+	for {
+		p.draw()
+		select {
+		case <-tick.C:
+		case <-p.stop:
+			return
+		}
+	}
+}
+
+func (p *progressReporter) draw() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	line := fmt.Sprintf("Checking CODEOWNERS coverage: %d expanded, %d checked, %d %s",
+		p.expanded.Load(), p.checked.Load(), p.failures.Load(), pluralize(int(p.failures.Load()), "failure", "failures"))
+	if p.specs > 0 {
+		line = fmt.Sprintf("%s (spec %d of %d)", line, p.spec.Load(), p.specs)
+	}
+	fmt.Fprintf(p.w, "\r\x1b[K%s, %s", line, time.Since(p.start).Round(time.Second))
+	p.shown = true
+}
+
+// clearLine removes the progress line, if it's shown. The caller holds mu.
+func (p *progressReporter) clearLine() {
+	if p.shown {
+		fmt.Fprint(p.w, "\r\x1b[K")
+		p.shown = false
+	}
+}
+
+// finish stops the redraws and clears the line, so what's printed next
+// starts on a clean line.
+func (p *progressReporter) finish() {
+	if p == nil {
+		return
+	}
+	close(p.stop)
+	<-p.done
+	p.mu.Lock()
+	p.clearLine()
+	p.mu.Unlock()
+}
+
+// Write lets other output to the same terminal, such as log records, go
+// through the reporter, which clears the progress line first. The next
+// redraw puts it back underneath.
+func (p *progressReporter) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clearLine()
+	return p.w.Write(b)
+}
+
+func (p *progressReporter) setSpecs(n int) {
+	if p != nil {
+		p.mu.Lock()
+		p.specs = n
+		p.mu.Unlock()
+	}
+}
+
+func (p *progressReporter) startSpec() {
+	if p != nil {
+		p.spec.Add(1)
+	}
+}
+
+func (p *progressReporter) addExpanded(n int) {
+	if p != nil {
+		p.expanded.Add(int64(n))
+	}
+}
+
+func (p *progressReporter) addChecked() {
+	if p != nil {
+		p.checked.Add(1)
+	}
+}
+
+func (p *progressReporter) addFailures(n int) {
+	if p != nil {
+		p.failures.Add(int64(n))
+	}
+}
@@ -0,0 +1,79 @@
+package check
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// syncBuffer is a bytes.Buffer that's safe to write from the progress
+// goroutine while the test reads it.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestProgressReporter(t *testing.T) {
+	var out syncBuffer
+	p := startProgress(&out)
+	p.setSpecs(3)
+	p.startSpec()
+	p.startSpec()
+	p.addExpanded(40)
+	for i := 0; i < 25; i++ {
+		p.addChecked()
+	}
+	p.addFailures(2)
+
+	p.draw()
+	line := out.String()
+	if !strings.HasPrefix(line, "\r\x1b[K") || !strings.Contains(line, "40 expanded, 25 checked, 2 failures (spec 2 of 3)") {
+		t.Errorf("progress line = %q", line)
+	}
+
+	// Other output clears the line first, so it doesn't run into it.
+	p.Write([]byte("level=WARN msg=hello\n"))
+	if got := strings.TrimPrefix(out.String(), line); got != "\r\x1b[Klevel=WARN msg=hello\n" {
+		t.Errorf("log write = %q, want the line cleared before it", got)
+	}
+
+	// Nothing is left on screen after finishing.
+	before := out.String()
+	p.finish()
+	if got := out.String(); got != before {
+		t.Errorf("finish() wrote %q after the line was already cleared", strings.TrimPrefix(got, before))
+	}
+}
+
+func TestProgressReporterQuickRun(t *testing.T) {
+	var out syncBuffer
+	p := startProgress(&out)
+	p.addChecked()
+	p.finish()
+	if got := out.String(); got != "" {
+		t.Errorf("progress for a run shorter than %s = %q, want nothing", progressDelay, got)
+	}
+}
+
+func TestNilProgressReporter(t *testing.T) {
+	var p *progressReporter
+	p.setSpecs(1)
+	p.startSpec()
+	p.addExpanded(1)
+	p.addChecked()
+	p.addFailures(1)
+	p.finish()
+}
@@ -0,0 +1,84 @@
+package check
+
+import (
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+)
+
+// pruneFS hides the subdirectories of fully covered directories from
+// ReadDir, so expansion doesn't descend into subtrees whose ownership can't
+// vary. Each directory it pruned is recorded, to be checked in place of the
+// subtree.
+type pruneFS struct {
+	fileSystem
+	covered func(dir string) bool
+	pruned  []string
+}
+
+func (p *pruneFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := p.fileSystem.ReadDir(name)
+	if err != nil || !p.covered(path.Clean(name)) {
+		return entries, err
+	}
+	kept := entries[:0:0]
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			kept = append(kept, entry)
+		}
+	}
+	if len(kept) < len(entries) {
+		p.pruned = append(p.pruned, path.Clean(name))
+	}
+	return kept, nil
+}
+
+// prunedAny reports whether any directory was pruned. A nil *pruneFS
+// prunes nothing.
+func (p *pruneFS) prunedAny() bool {
+	return p != nil && len(p.pruned) > 0
+}
+
+// fullyCovered reports whether every path below dir is owned by the same
+// rule as dir: the rule that matches it covers its whole subtree, and no
+// later rule can match anything inside it. Only anchored rules without
+// wildcards, like /services/foo/, are recognized as covering a subtree.
+func (c *checker) fullyCovered(dir string) bool {
+	if covered, ok := c.coveredDirs[dir]; ok {
+		return covered
+	}
+	covered := c.coversSubtree(dir)
+	if c.coveredDirs == nil {
+		c.coveredDirs = make(map[string]bool)
+	}
+	c.coveredDirs[dir] = covered
+	return covered
+}
+
+func (c *checker) coversSubtree(dir string) bool {
+	r := matchDirectory(c.matcher(), dir)
+	if r == nil {
+		return false
+	}
+	segs, ok := subtreeRoot(r.RawPattern())
+	if !ok || len(segs) == 0 {
+		return false
+	}
+	dirSegs := strings.Split(dir, "/")
+	if !isPathPrefix(segs, dirSegs) {
+		return false
+	}
+	for i := c.rulePosition(r) + 1; i < len(c.rules); i++ {
+		later := literalPrefix(c.rules[i].RawPattern())
+		if later == nil || isPathPrefix(later, dirSegs) || isPathPrefix(dirSegs, later) {
+			return false
+		}
+	}
+	return true
+}
+
+// isPathPrefix reports whether the path segments prefix start segs.
+func isPathPrefix(prefix, segs []string) bool {
+	return len(prefix) <= len(segs) && slices.Equal(prefix, segs[:len(prefix)])
+}
@@ -0,0 +1,105 @@
+package check
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestCoversSubtree(t *testing.T) {
+	tests := []struct {
+		name       string
+		codeowners string
+		dir        string
+		want       bool
+	}{
+		{"anchored directory rule", "/services/foo/ @org/foo\n", "services/foo", true},
+		{"below the rule", "/services/foo/ @org/foo\n", "services/foo/bar", true},
+		{"rule without slashes around it", "services/foo @org/foo\n", "services/foo", true},
+		{"double star", "/services/foo/** @org/foo\n", "services/foo", true},
+		{"uncovered", "/services/foo/ @org/foo\n", "services/bar", false},
+		{"parent of the rule", "/services/foo/ @org/foo\n", "services", false},
+		{"wildcard rule", "/services/*/ @org/all\n", "services/foo", false},
+		{"unanchored rule", "foo/ @org/foo\n", "services/foo", false},
+		{"later rule inside", "/services/foo/ @org/foo\n/services/foo/internal/ @org/core\n", "services/foo", false},
+		{"later rule with wildcard inside", "/services/foo/ @org/foo\n/services/*/internal/ @org/core\n", "services/foo", false},
+		{"later rule anywhere", "/services/foo/ @org/foo\n*.md @org/docs\n", "services/foo", false},
+		{"later rule elsewhere", "/services/foo/ @org/foo\n/services/bar/ @org/bar\n", "services/foo", true},
+		{"earlier rule inside", "/services/foo/internal/ @org/core\n/services/foo/ @org/foo\n", "services/foo", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := parseCodeowners(strings.NewReader(tt.codeowners), "CODEOWNERS", providerGitHub)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c := &checker{rules: rules}
+			if got := c.fullyCovered(tt.dir); got != tt.want {
+				t.Errorf("fullyCovered(%q) = %v, want %v", tt.dir, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidatePruneCovered(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, d := range []string{"services/foo/a/x", "services/foo/c", "services/bar/x", "services/bar/y"} {
+		os.MkdirAll(filepath.Join(tmpDir, d), 0755)
+	}
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	rules, err := parseCodeowners(strings.NewReader("/services/foo/ @org/foo\n/services/bar/ @org/bar\n/services/bar/x/ @org/x\n"), "CODEOWNERS", providerGitHub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checked := func(res result) []string {
+		var dirs []string
+		for _, d := range res.checked {
+			dirs = append(dirs, d.path)
+		}
+		sort.Strings(dirs)
+		return dirs
+	}
+
+	tests := []struct {
+		name  string
+		prune bool
+		spec  dirSpec
+		want  []string
+	}{
+		{
+			name: "levels without pruning",
+			spec: dirSpec{Path: "services", Level: 2},
+			want: []string{"services/bar/x", "services/bar/y", "services/foo/a", "services/foo/c"},
+		},
+		{
+			name:  "levels with pruning",
+			prune: true,
+			spec:  dirSpec{Path: "services", Level: 2},
+			want:  []string{"services/bar/x", "services/bar/y", "services/foo"},
+		},
+		{
+			name:  "covered spec root",
+			prune: true,
+			spec:  dirSpec{Path: "services/foo", Level: 1},
+			want:  []string{"services/foo"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &checker{fsys: localFS{}, rules: rules, configPath: ".requirecodeowners.yml", pruneCovered: tt.prune}
+			res := c.validate([]dirSpec{tt.spec})
+			if got := checked(res); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("checked = %v, want %v", got, tt.want)
+			}
+			if len(res.errors) != 0 {
+				t.Errorf("errors = %v, want none", res.errors)
+			}
+		})
+	}
+}
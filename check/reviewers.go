@@ -0,0 +1,198 @@
+package check
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// maxHistoryReviewers is how many of a directory's recent committers are
+// suggested as its reviewers.
+const maxHistoryReviewers = 3
+
+// reviewerSuggestion is who should review a change to an unowned directory,
+// since CODEOWNERS won't request anyone: the owners of the nearest owned
+// ancestor (or the spec's default owner), and the people who have committed
+// to it most.
+type reviewerSuggestion struct {
+	dir     string
+	owners  []string
+	authors []gitAuthor
+}
+
+// gitAuthor is someone who committed to a directory, with their most recent
+// commit there, which the API can map to a GitHub login.
+type gitAuthor struct {
+	name    string
+	email   string
+	commit  string
+	commits int
+}
+
+// suggestReviewers returns suggestions for each unowned directory in res,
+// sorted by directory. In diff mode those are the unowned directories the
+// changes touch. History comes from git in the working directory, and is
+// left out if git can't provide it.
+func suggestReviewers(res result) []reviewerSuggestion {
+	owners := make(map[string]string)
+	for _, e := range res.errors {
+		if e.team != "" {
+			if _, ok := owners[e.path]; !ok {
+				owners[e.path] = e.team
+			}
+		}
+	}
+
+	seen := make(map[string]bool)
+	var suggestions []reviewerSuggestion
+	for _, d := range res.checked {
+		if d.rule != nil || seen[d.path] {
+			continue
+		}
+		seen[d.path] = true
+		authors, err := dirAuthors(d.path, maxHistoryReviewers)
+		if err != nil {
+			logger.Warn("reading history for reviewer suggestions", "path", d.path, "error", err)
+		}
+		suggestions = append(suggestions, reviewerSuggestion{
+			dir:     d.path,
+			owners:  strings.Fields(owners[d.path]),
+			authors: authors,
+		})
+	}
+	sort.Slice(suggestions, func(i, j int) bool { return suggestions[i].dir < suggestions[j].dir })
+	return suggestions
+}
+
+// dirAuthors returns the n people with the most of the last 200 non-merge
+// commits to dir, most commits first.
+func dirAuthors(dir string, n int) ([]gitAuthor, error) {
+	out, err := exec.Command("git", "log", "-n", "200", "--no-merges", "--format=%H%x00%an%x00%ae", "--", dir).Output()
+	if err != nil {
+		if ee, ok := err.(*exec.ExitError); ok && len(ee.Stderr) > 0 {
+			return nil, fmt.Errorf("git log: %s", strings.TrimSpace(string(ee.Stderr)))
+		}
+		return nil, fmt.Errorf("git log: %w", err)
+	}
+
+	byEmail := make(map[string]*gitAuthor)
+	var authors []*gitAuthor
+	for _, line := range bytes.Split(out, []byte("\n")) {
+		fields := strings.Split(string(line), "\x00")
+		if len(fields) != 3 {
+			continue
+		}
+		email := strings.ToLower(fields[2])
+		a, ok := byEmail[email]
+		if !ok {
+			// Commits come newest first, so this is the most recent one.
+			a = &gitAuthor{name: fields[1], email: fields[2], commit: fields[0]}
+			byEmail[email] = a
+			authors = append(authors, a)
+		}
+		a.commits++
+	}
+	sort.SliceStable(authors, func(i, j int) bool { return authors[i].commits > authors[j].commits })
+
+	top := make([]gitAuthor, 0, min(n, len(authors)))
+	for _, a := range authors[:min(n, len(authors))] {
+		top = append(top, *a)
+	}
+	return top, nil
+}
+
+// printReviewerSuggestions writes the suggestions as console text.
+func printReviewerSuggestions(w io.Writer, suggestions []reviewerSuggestion) {
+	fmt.Fprintf(w, "\nSuggested reviewers for %d unowned %s:\n", len(suggestions), pluralize(len(suggestions), "directory", "directories"))
+	for _, s := range suggestions {
+		var names []string
+		for _, o := range s.owners {
+			names = append(names, o+" (likely owner)")
+		}
+		for _, a := range s.authors {
+			names = append(names, fmt.Sprintf("%s <%s> (%d %s)", a.name, a.email, a.commits, pluralize(a.commits, "commit", "commits")))
+		}
+		if len(names) == 0 {
+			names = []string{"nobody found"}
+		}
+		fmt.Fprintf(w, "  - %s: %s\n", s.dir, strings.Join(names, ", "))
+	}
+}
+
+// requestReviewers requests the suggested reviewers on pull request number
+// in repo. Team owners become team reviewers. Committers are requested by
+// the GitHub login of their latest commit, and left out if the commit isn't
+// linked to an account. Email owners and the pull request's author can't be
+// requested and are left out too. It returns who was requested.
+func requestReviewers(client *githubClient, repo string, number int, suggestions []reviewerSuggestion) ([]string, error) {
+	var pr struct {
+		User struct {
+			Login string `json:"login"`
+		} `json:"user"`
+	}
+	if err := client.getJSON(fmt.Sprintf("/repos/%s/pulls/%d", repo, number), &pr); err != nil {
+		return nil, fmt.Errorf("reading pull request #%d: %w", number, err)
+	}
+
+	users := make(map[string]bool)
+	teams := make(map[string]bool)
+	logins := make(map[string]string) // by commit
+	for _, s := range suggestions {
+		for _, o := range s.owners {
+			if _, slug, ok := strings.Cut(strings.TrimPrefix(o, "@"), "/"); ok {
+				teams[slug] = true
+			} else if strings.HasPrefix(o, "@") {
+				users[strings.TrimPrefix(o, "@")] = true
+			}
+		}
+		for _, a := range s.authors {
+			login, ok := logins[a.commit]
+			if !ok {
+				var commit struct {
+					Author *struct {
+						Login string `json:"login"`
+					} `json:"author"`
+				}
+				if err := client.getJSON(fmt.Sprintf("/repos/%s/commits/%s", repo, a.commit), &commit); err != nil {
+					logger.Warn("finding the GitHub login of a committer", "email", a.email, "error", err)
+				} else if commit.Author != nil {
+					login = commit.Author.Login
+				}
+				logins[a.commit] = login
+			}
+			if login != "" {
+				users[login] = true
+			}
+		}
+	}
+	delete(users, pr.User.Login)
+
+	req := struct {
+		Reviewers     []string `json:"reviewers"`
+		TeamReviewers []string `json:"team_reviewers"`
+	}{sortedKeys(users), sortedKeys(teams)}
+	if len(req.Reviewers) == 0 && len(req.TeamReviewers) == 0 {
+		return nil, nil
+	}
+	if err := client.sendJSON(http.MethodPost, fmt.Sprintf("/repos/%s/pulls/%d/requested_reviewers", repo, number), req, nil); err != nil {
+		return nil, fmt.Errorf("requesting reviewers on #%d: %w", number, err)
+	}
+	requested := append([]string{}, req.Reviewers...)
+	for _, t := range req.TeamReviewers {
+		requested = append(requested, "team "+t)
+	}
+	return requested, nil
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
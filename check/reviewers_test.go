@@ -0,0 +1,105 @@
+package check
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestSuggestReviewers(t *testing.T) {
+	gitRepo(t, map[string]string{
+		".requirecodeowners.yml":  "directories:\n  - path: services/api\n    level: 1\n    default_owner: \"@org/api\"\n  - path: services/web\n    level: 1\n",
+		".github/CODEOWNERS":      "/docs/ @org/docs\n",
+		"services/api/v1/main.go": "package main\n",
+		"services/web/ui/app.js":  "",
+	})
+	commit := func(name, file string) {
+		writeFiles(t, map[string]string{file: name})
+		git(t, "add", "-A")
+		git(t, "-c", "user.name="+name, "-c", "user.email="+name+"@example.com", "commit", "-q", "-m", "change")
+	}
+	commit("alice", "services/web/ui/app.js")
+	commit("bob", "services/web/ui/app.js")
+	commit("alice", "services/web/ui/app.js")
+	commit("carol", "services/api/v1/main.go")
+
+	old := changes
+	defer func() { changes = old }()
+	writeFiles(t, map[string]string{
+		"services/api/v1/main.go": "package main // changed\n",
+		"services/web/ui/app.js":  "changed",
+	})
+	git(t, "add", "-A")
+	changes.limited, changes.staged = true, true
+
+	res, err := checkRepo(context.Background(), "", nil, specFilter{})
+	if err != nil {
+		t.Fatalf("checkRepo() error = %v", err)
+	}
+	got := suggestReviewers(res)
+	if len(got) != 2 {
+		t.Fatalf("suggestReviewers() = %+v, want services/api/v1 and services/web/ui", got)
+	}
+
+	api := got[0]
+	if api.dir != "services/api/v1" || !reflect.DeepEqual(api.owners, []string{"@org/api"}) {
+		t.Errorf("suggestion for %s = %+v, want @org/api as the default owner", api.dir, api)
+	}
+	web := got[1]
+	if web.dir != "services/web/ui" || len(web.owners) != 0 {
+		t.Errorf("suggestion for %s = %+v, want no nearest owner", web.dir, web)
+	}
+	var authors []string
+	for _, a := range web.authors {
+		authors = append(authors, a.name)
+	}
+	if want := []string{"alice", "bob", "test"}; !reflect.DeepEqual(authors, want) {
+		t.Errorf("authors of services/web/ui = %v, want %v", authors, want)
+	}
+	if web.authors[0].commits != 2 {
+		t.Errorf("alice's commits = %d, want 2", web.authors[0].commits)
+	}
+}
+
+func TestRequestReviewers(t *testing.T) {
+	var requested map[string][]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /repos/org/repo/pulls/7":
+			w.Write([]byte(`{"user": {"login": "author"}}`))
+		case "GET /repos/org/repo/commits/c1":
+			w.Write([]byte(`{"author": {"login": "alice"}}`))
+		case "GET /repos/org/repo/commits/c2":
+			w.Write([]byte(`{"author": null}`))
+		case "GET /repos/org/repo/commits/c3":
+			w.Write([]byte(`{"author": {"login": "author"}}`))
+		case "POST /repos/org/repo/pulls/7/requested_reviewers":
+			json.NewDecoder(r.Body).Decode(&requested)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	t.Setenv("GITHUB_API_URL", srv.URL)
+
+	suggestions := []reviewerSuggestion{
+		{dir: "a", owners: []string{"@org/platform", "@bob", "dev@example.com"}, authors: []gitAuthor{{commit: "c1"}, {commit: "c2"}}},
+		{dir: "b", authors: []gitAuthor{{commit: "c1"}, {commit: "c3"}}},
+	}
+	got, err := requestReviewers(newGitHubClient(), "org/repo", 7, suggestions)
+	if err != nil {
+		t.Fatalf("requestReviewers() error = %v", err)
+	}
+	if want := []string{"alice", "bob", "team platform"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("requestReviewers() = %v, want %v", got, want)
+	}
+	want := map[string][]string{"reviewers": {"alice", "bob"}, "team_reviewers": {"platform"}}
+	if !reflect.DeepEqual(requested, want) {
+		t.Errorf("requested = %v, want %v", requested, want)
+	}
+}
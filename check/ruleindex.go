@@ -0,0 +1,114 @@
+package check
+
+import "strings"
+
+// ruleIndexThreshold is the ruleset size above which matching goes through a
+// ruleIndex. Below it, trying every rule is as fast as walking the index.
+const ruleIndexThreshold = 256
+
+// ruleMatcher finds the rule that applies to a path.
+type ruleMatcher interface {
+	Match(path string) (*rule, error)
+}
+
+// ruleIndex narrows which rules of a large ruleset are tried against a
+// path, returning the same rule as ruleset.Match. Rules anchored to the root
+// are filed in a trie under their leading literal path segments, since they
+// can only match paths that start with them. The rest, which match at any
+// depth or start with a wildcard, are tried for every path.
+type ruleIndex struct {
+	rules ruleset
+	root  *ruleTrie
+}
+
+type ruleTrie struct {
+	children map[string]*ruleTrie
+	rules    []int // indexes into the ruleset, ascending
+}
+
+func newRuleIndex(rules ruleset) *ruleIndex {
+	idx := &ruleIndex{rules: rules, root: &ruleTrie{}}
+	for i := range rules {
+		node := idx.root
+		for _, seg := range literalPrefix(rules[i].RawPattern()) {
+			child, ok := node.children[seg]
+			if !ok {
+				if node.children == nil {
+					node.children = make(map[string]*ruleTrie)
+				}
+				child = &ruleTrie{}
+				node.children[seg] = child
+			}
+			node = child
+		}
+		node.rules = append(node.rules, i)
+	}
+	return idx
+}
+
+// literalPrefix returns the leading path segments every path a CODEOWNERS
+// pattern matches must start with, or nil if it can match anywhere. A
+// pattern is anchored to the root when it starts with a slash or has one
+// before its end, as in gitignore. Segments stop at the first one with a
+// wildcard or escape.
+func literalPrefix(pattern string) []string {
+	segs := strings.Split(pattern, "/")
+	if segs[0] == "" {
+		segs = segs[1:]
+	} else if len(segs) == 1 || (len(segs) == 2 && segs[1] == "") {
+		return nil
+	}
+	var prefix []string
+	for _, seg := range segs {
+		if seg == "" || strings.ContainsAny(seg, `*?\`) {
+			break
+		}
+		prefix = append(prefix, seg)
+	}
+	return prefix
+}
+
+// Match returns the last rule matching path, like ruleset.Match.
+func (idx *ruleIndex) Match(path string) (*rule, error) {
+	lists := [][]int{idx.root.rules}
+	node := idx.root
+	for _, seg := range strings.Split(path, "/") {
+		if node = node.children[seg]; node == nil {
+			break
+		}
+		lists = append(lists, node.rules)
+	}
+
+	// Try the candidates from all the lists latest first, by repeatedly
+	// taking the largest index from the ends of the lists.
+	for {
+		best := -1
+		for i, l := range lists {
+			if len(l) > 0 && (best < 0 || l[len(l)-1] > lists[best][len(lists[best])-1]) {
+				best = i
+			}
+		}
+		if best < 0 {
+			return nil, nil
+		}
+		l := lists[best]
+		r := &idx.rules[l[len(l)-1]]
+		lists[best] = l[:len(l)-1]
+		match, err := r.Match(path)
+		if match || err != nil {
+			return r, err
+		}
+	}
+}
+
+// matcher returns what the checker matches paths with: its rules, indexed
+// once there are enough of them to be worth it.
+func (c *checker) matcher() ruleMatcher {
+	if len(c.rules) <= ruleIndexThreshold {
+		return c.rules
+	}
+	if c.index == nil {
+		c.index = newRuleIndex(c.rules)
+	}
+	return c.index
+}
@@ -0,0 +1,101 @@
+package check
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLiteralPrefix(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"/services/api/", []string{"services", "api"}},
+		{"/services/api", []string{"services", "api"}},
+		{"services/api/", []string{"services", "api"}},
+		{"/services/*/docs/", []string{"services"}},
+		{"/services/**/docs", []string{"services"}},
+		{"/src/fo?/", []string{"src"}},
+		{`/src/\#x`, []string{"src"}},
+		{"/*.md", nil},
+		{"*.md", nil},
+		{"docs/", nil},
+		{"docs", nil},
+		{"**/docs/", nil},
+		{"/", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			if got := literalPrefix(tt.pattern); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("literalPrefix(%q) = %q, want %q", tt.pattern, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuleIndexMatchesRuleset(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("* @org/everyone\n*.md @org/docs\ndocs/ @org/docs\n**/testdata/ @org/qa\n")
+	for i := 0; i < 300; i++ {
+		fmt.Fprintf(&b, "/services/svc%d/ @org/team%d\n", i, i)
+		if i%10 == 0 {
+			fmt.Fprintf(&b, "/services/svc%d/internal @org/core\n", i)
+			fmt.Fprintf(&b, "services/svc%d/*.go @org/go\n", i)
+			fmt.Fprintf(&b, "/services/*/svc%d/ @org/nested\n", i)
+		}
+	}
+	b.WriteString("/services/svc5/ @org/override\n/services/ \n")
+	rules, err := parseCodeowners(strings.NewReader(b.String()), "CODEOWNERS", providerGitHub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx := newRuleIndex(rules)
+
+	paths := []string{
+		"README.md", "docs", "docs/", "docs/guide.md", "services", "services/",
+		"services/file.txt", "services/svc5", "services/svc5/main.go",
+		"services/svc10/internal", "services/svc10/internal/x.go", "services/svc10/main.go",
+		"services/svc10/pkg/main.go", "services/svc1/testdata/in.txt", "services/x/svc20/file.txt",
+		"services/svc299/", "services/svc300/", "services/svc3x/file.txt", "other/svc10/main.go",
+		"a/b/c/testdata/d",
+	}
+	for _, p := range paths {
+		want, _ := rules.Match(p)
+		got, _ := idx.Match(p)
+		if got != want {
+			t.Errorf("index.Match(%q) = %v, want %v", p, describeRule(got), describeRule(want))
+		}
+	}
+}
+
+func describeRule(r *rule) string {
+	if r == nil {
+		return "no rule"
+	}
+	return r.location() + " " + r.RawPattern()
+}
+
+func TestCheckerMatcher(t *testing.T) {
+	var b strings.Builder
+	for i := 0; i <= ruleIndexThreshold; i++ {
+		fmt.Fprintf(&b, "/dir%d/ @org/team\n", i)
+	}
+	rules, err := parseCodeowners(strings.NewReader(b.String()), "CODEOWNERS", providerGitHub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	small := &checker{rules: rules[:ruleIndexThreshold]}
+	if _, ok := small.matcher().(ruleset); !ok {
+		t.Errorf("matcher() for %d rules = %T, want the ruleset", ruleIndexThreshold, small.matcher())
+	}
+	large := &checker{rules: rules}
+	if _, ok := large.matcher().(*ruleIndex); !ok {
+		t.Errorf("matcher() for %d rules = %T, want an index", len(rules), large.matcher())
+	}
+	if r := matchDirectory(large.matcher(), "dir7"); r != &rules[7] {
+		t.Errorf("matchDirectory(dir7) = %v, want /dir7/", describeRule(r))
+	}
+}
@@ -0,0 +1,87 @@
+package check
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Rule orders policy.rule_order can require within each CODEOWNERS file.
+const (
+	ruleOrderSpecificity  = "specificity"  // broad rules before the narrower rules they contain
+	ruleOrderAlphabetical = "alphabetical" // sorted by pattern within each block of consecutive lines
+)
+
+// subtreeRoot returns the directory whose whole subtree pattern owns, as
+// path segments: /services/foo/, services/foo and /services/foo/** all own
+// services/foo. Patterns that match everything, like *, return no segments.
+// ok is false for any other pattern.
+func subtreeRoot(pattern string) (segs []string, ok bool) {
+	switch pattern {
+	case "*", "**", "/**":
+		return []string{}, true
+	}
+	trimmed := strings.TrimSuffix(strings.TrimSuffix(pattern, "**"), "/")
+	segs = literalPrefix(trimmed)
+	if len(segs) == 0 || strings.Join(segs, "/") != strings.TrimPrefix(trimmed, "/") {
+		return nil, false
+	}
+	return segs, true
+}
+
+// checkRuleOrder reports the CODEOWNERS lines that are out of the order
+// the policy requires. Rules in different files aren't compared, since later
+// files are meant to override earlier ones.
+func (c *checker) checkRuleOrder() []validationError {
+	var errors []validationError
+	report := func(r *rule, message string) {
+		errors = append(errors, validationError{
+			path:     r.RawPattern(),
+			message:  fmt.Sprintf("%s %s: %s", message, r.location(), r.RawPattern()),
+			code:     codeRuleOrder,
+			severity: c.policy.Severity,
+			team:     r.ownerNames(),
+			file:     r.file,
+			line:     r.LineNumber,
+		})
+	}
+
+	for j := range c.rules {
+		later := &c.rules[j]
+		switch c.policy.RuleOrder {
+		case ruleOrderSpecificity:
+			root, ok := subtreeRoot(later.RawPattern())
+			if !ok {
+				continue
+			}
+			// An earlier rule that only matches inside the later rule's
+			// subtree never wins.
+			var overridden []*rule
+			for i := 0; i < j; i++ {
+				earlier := &c.rules[i]
+				if earlier.file != later.file {
+					continue
+				}
+				if prefix := literalPrefix(earlier.RawPattern()); len(root) == 0 || (prefix != nil && isPathPrefix(root, prefix)) {
+					overridden = append(overridden, earlier)
+				}
+			}
+			if len(overridden) > 0 {
+				report(later, fmt.Sprintf("Overrides %d earlier %s it contains, such as %s at %s, since the last matching rule wins. Move it above %s in",
+					len(overridden), pluralize(len(overridden), "rule", "rules"), overridden[0].RawPattern(), overridden[0].location(), pluralize(len(overridden), "it", "them")))
+			}
+		case ruleOrderAlphabetical:
+			if j == 0 {
+				continue
+			}
+			prev := &c.rules[j-1]
+			if prev.file != later.file || prev.LineNumber != later.LineNumber-1 {
+				// A blank line or comment starts a new block.
+				continue
+			}
+			if strings.TrimPrefix(later.RawPattern(), "/") < strings.TrimPrefix(prev.RawPattern(), "/") {
+				report(later, fmt.Sprintf("Isn't in alphabetical order: it comes after %s in its block. Sort the block in", prev.RawPattern()))
+			}
+		}
+	}
+	return errors
+}
@@ -0,0 +1,75 @@
+package check
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSubtreeRoot(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+		ok      bool
+	}{
+		{"*", "", true},
+		{"/**", "", true},
+		{"/services/", "services", true},
+		{"services/api", "services/api", true},
+		{"/services/api/**", "services/api", true},
+		{"*.go", "", false},
+		{"/services/*/", "", false},
+		{"docs", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			segs, ok := subtreeRoot(tt.pattern)
+			if ok != tt.ok || strings.Join(segs, "/") != tt.want {
+				t.Errorf("subtreeRoot(%q) = %v, %v, want %q, %v", tt.pattern, segs, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestCheckRuleOrder(t *testing.T) {
+	rules, err := parseCodeowners(strings.NewReader(`/services/api/ @org/api
+/services/web/ @org/web
+/services/ @org/services
+
+/libs/b/ @org/libs
+/libs/a/ @org/libs
+*.go @org/go
+* @org/everyone
+`), "CODEOWNERS", providerGitHub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		order string
+		want  []string
+	}{
+		{ruleOrderSpecificity, []string{"CODEOWNERS:3 /services/", "CODEOWNERS:8 *"}},
+		{ruleOrderAlphabetical, []string{"CODEOWNERS:3 /services/", "CODEOWNERS:6 /libs/a/", "CODEOWNERS:7 *.go", "CODEOWNERS:8 *"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.order, func(t *testing.T) {
+			c := &checker{rules: rules, policy: policy{RuleOrder: tt.order}}
+			var got []string
+			for _, e := range c.checkRuleOrder() {
+				got = append(got, fmt.Sprintf("%s:%d %s", e.file, e.line, e.path))
+				if e.code != codeRuleOrder {
+					t.Errorf("code = %s, want %s", e.code, codeRuleOrder)
+				}
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("problems = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	c := &checker{rules: rules, policy: policy{RuleOrder: ruleOrderSpecificity}}
+	if msg := c.checkRuleOrder()[0].message; !strings.Contains(msg, "Overrides 2 earlier rules") || !strings.Contains(msg, "/services/api/ at CODEOWNERS:1") {
+		t.Errorf("message = %q, want the rules it overrides", msg)
+	}
+}
@@ -0,0 +1,78 @@
+// Package check is requirecodeowners: its checks, reports and command line.
+// The requirecodeowners command is a call to Main. Programs that add checks
+// of their own register them with the validate package, then call Main for
+// the whole command line, or Run to check a repository and handle what it
+// finds themselves.
+package check
+
+import (
+	"context"
+
+	"github.com/kpurdon/requirecodeowners/validate"
+)
+
+// Options say what Run checks, like the check command's flags of the same
+// names.
+type Options struct {
+	// Config is the config file, or "" to look for .requirecodeowners.yml
+	// and the other names --config defaults to.
+	Config string
+
+	// Codeowners are the CODEOWNERS files, URLs and github: sources, merged
+	// in order, or nil for the config's codeowners, or the CODEOWNERS file
+	// where GitHub looks for one.
+	Codeowners []string
+}
+
+// Result is what Run found.
+type Result struct {
+	// Checked are the directories checked, with the rules that own them.
+	Checked []validate.DirResult
+
+	// Findings are the problems found, from the built-in checks and the
+	// registered validators, without the suppressed ones.
+	Findings []validate.Finding
+}
+
+// Failed reports whether any finding is an error rather than a warning,
+// which is when the check command exits 1.
+func (r Result) Failed() bool {
+	for _, f := range r.Findings {
+		if f.Severity != validate.Warning {
+			return true
+		}
+	}
+	return false
+}
+
+// Run checks the repository in the working directory like the check command
+// does, running the validators registered with validate.Register after the
+// built-in checks. ctx ends the check early, with its result so far; the
+// error is for a check that couldn't run, like one with an invalid config.
+func Run(ctx context.Context, opts Options) (Result, error) {
+	res, err := checkRepo(ctx, opts.Config, opts.Codeowners, specFilter{})
+	if err != nil {
+		return Result{}, err
+	}
+	var r Result
+	for _, d := range res.checked {
+		r.Checked = append(r.Checked, dirResult(d))
+	}
+	for _, e := range res.errors {
+		severity := e.severity
+		if severity == "" {
+			severity = validate.Error
+		}
+		r.Findings = append(r.Findings, validate.Finding{
+			Path:      e.path,
+			Message:   e.message,
+			Severity:  severity,
+			Code:      e.code,
+			File:      e.file,
+			Line:      e.line,
+			Team:      e.team,
+			Mandatory: e.mandatory,
+		})
+	}
+	return r, nil
+}
@@ -0,0 +1,60 @@
+package check
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/kpurdon/requirecodeowners/validate"
+)
+
+func TestRun(t *testing.T) {
+	defer func(saved func() []validate.Validator) { registeredValidators = saved }(registeredValidators)
+	ldap := validate.Func{N: "ldap", F: func(_ context.Context, d validate.DirResult) []validate.Finding {
+		if d.Path != "services/api" {
+			return nil
+		}
+		return []validate.Finding{{Message: "Not in LDAP.", Severity: validate.Warning}}
+	}}
+	registeredValidators = func() []validate.Validator { return []validate.Validator{ldap} }
+
+	tmpDir := t.TempDir()
+	for _, d := range []string{"services/api", "services/web"} {
+		os.MkdirAll(filepath.Join(tmpDir, d), 0755)
+	}
+	os.WriteFile(filepath.Join(tmpDir, ".requirecodeowners.yml"), []byte("directories:\n  - path: services\n    level: 1\n"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "CODEOWNERS"), []byte("/services/api/ @org/api\n"), 0644)
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	res, err := Run(context.Background(), Options{})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(res.Checked) != 2 || res.Checked[0].Path != "services/api" || res.Checked[0].Rule != "/services/api/" || res.Checked[1].Rule != "" {
+		t.Errorf("Checked = %+v, want services/api owned and services/web not", res.Checked)
+	}
+	var codes []string
+	for _, f := range res.Findings {
+		codes = append(codes, f.Code+" "+f.Path+" "+f.Severity)
+	}
+	want := []string{"RCO001 services/web error", " services/api warning"}
+	if len(codes) != len(want) {
+		t.Fatalf("Findings = %q, want %q", codes, want)
+	}
+	for _, w := range want {
+		if !slices.Contains(codes, w) {
+			t.Errorf("Findings = %q, want %q among them", codes, w)
+		}
+	}
+	if !res.Failed() {
+		t.Error("Failed() = false, want true for an uncovered directory")
+	}
+
+	if _, err := Run(context.Background(), Options{Config: "missing.yml"}); err == nil {
+		t.Error("Run() with a missing config succeeded, want an error")
+	}
+}
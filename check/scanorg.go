@@ -0,0 +1,254 @@
+package check
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+)
+
+// repoScan is the outcome of checking one repository of an organization.
+type repoScan struct {
+	repo    string
+	links   *repoLinks // where the repository is on GitHub, if known
+	res     result
+	skipped string // why the repository wasn't checked, if it wasn't
+	err     error  // why the check couldn't run, if it couldn't
+}
+
+func runScanOrg(args []string) int {
+	fs := flag.NewFlagSet("scan-org", flag.ContinueOnError)
+	var configPath string
+	var includeArchived bool
+	fs.StringVar(&configPath, "config", "", "config to apply to repositories that don't have their own")
+	var lf configFlags
+	lf.register(fs)
+	fs.BoolVar(&includeArchived, "include-archived", false, "also scan archived repositories")
+	var cf consoleFlags
+	cf.register(fs)
+	var gf githubFlags
+	gf.register(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: requirecodeowners scan-org [flags] <org>")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	if err := lf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	if err := cf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	if err := gf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	if offline {
+		fmt.Fprintln(os.Stderr, "error: scan-org reads repositories through the GitHub API and can't run with --offline")
+		return 2
+	}
+	org := fs.Arg(0)
+
+	var central *config
+	if configPath != "" {
+		cfg, err := loadConfig(configPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return 1
+		}
+		central = cfg
+	}
+
+	client := newGitHubClient()
+	repos, err := client.listOrgRepos(org)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: listing repositories in %s: %v\n", org, err)
+		return 1
+	}
+	sort.Slice(repos, func(i, j int) bool { return repos[i].FullName < repos[j].FullName })
+
+	var scans []repoScan
+	for _, repo := range repos {
+		if repo.Archived && !includeArchived {
+			continue
+		}
+		scans = append(scans, scanRepo(client, repo, central, configPath))
+	}
+
+	return printOrgReport(org, scans)
+}
+
+// scanRepo checks a repository through the API, using its own config if it
+// has one and central otherwise. Relative CODEOWNERS paths in the config are
+// fetched from the repository itself.
+func scanRepo(client *githubClient, repo githubRepo, central *config, centralPath string) repoScan {
+	scan := repoScan{repo: repo.FullName}
+	if repo.HTMLURL != "" {
+		scan.links = &repoLinks{base: repo.HTMLURL, ref: repo.DefaultBranch}
+	}
+
+	cfg, configName, err := fetchRepoConfig(client, repo)
+	if err != nil {
+		scan.err = err
+		return scan
+	}
+	if cfg == nil {
+		if central == nil {
+			scan.skipped = "no config"
+			return scan
+		}
+		cfg, configName = central, centralPath
+	}
+
+	paths, truncated, err := client.getTree(repo.FullName, repo.DefaultBranch)
+	if err != nil {
+		scan.err = fmt.Errorf("listing files: %w", err)
+		return scan
+	}
+
+	var sources []string
+	for _, source := range cfg.Codeowners {
+		if !isURL(source) && !isGitHubSource(source) {
+			source = fmt.Sprintf("%s%s/%s@%s", githubSourcePrefix, repo.FullName, path.Clean(source), repo.DefaultBranch)
+		}
+		sources = append(sources, source)
+	}
+	if len(sources) == 0 {
+		sources = []string{fmt.Sprintf("%s%s@%s", githubSourcePrefix, repo.FullName, repo.DefaultBranch)}
+	}
+	rules, err := loadCodeowners(context.Background(), sources, cfg.Provider)
+	if err != nil {
+		scan.err = err
+		return scan
+	}
+
+	suppressions, err := ruleSuppressions(rules)
+	if err != nil {
+		scan.err = err
+		return scan
+	}
+
+	c := &checker{fsys: withExcludes(newTreeFS(paths), cfg.globalExcludes()), rules: rules, configPath: configName, codeownersPath: sources[len(sources)-1], policy: cfg.Policy, customPolicies: cfg.Policies, aliases: cfg.Aliases, allowUnowned: cfg.AllowUnowned, suppressions: append(cfg.Suppressions, suppressions...), org: activePolicy, pruneCovered: cfg.PruneCovered}
+	if activePolicy != nil {
+		c.orgFS = withExcludes(newTreeFS(paths), defaultGlobalExcludes)
+	}
+	scan.res = c.validate(cfg.Directories)
+	if truncated {
+		scan.res.errors = append(scan.res.errors, validationError{
+			path:     ".",
+			message:  "Repository tree was truncated by the GitHub API, so some directories weren't checked.",
+			code:     codeTruncatedTree,
+			severity: severityWarning,
+		})
+	}
+	return scan
+}
+
+// fetchRepoConfig returns the repository's own config from the first default
+// location that exists, or nil if it has none.
+func fetchRepoConfig(client *githubClient, repo githubRepo) (*config, string, error) {
+	for _, name := range defaultConfigPaths {
+		data, err := client.getContents(repo.FullName, name, repo.DefaultBranch)
+		if isNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("fetching %s: %w", name, err)
+		}
+		read := func(p string) ([]byte, error) {
+			return client.getContents(repo.FullName, filepath.ToSlash(p), repo.DefaultBranch)
+		}
+		cfg, err := decodeConfig(data, name, read, remoteConfig)
+		if err != nil {
+			return nil, "", fmt.Errorf("%s: %w", name, err)
+		}
+		err = loadOwnersRegistry(cfg, read)
+		if err != nil {
+			return nil, "", err
+		}
+		return cfg, name, nil
+	}
+	return nil, "", nil
+}
+
+func printOrgReport(org string, scans []repoScan) int {
+	var owned, total, failed, skipped int
+
+	// Text output to stderr (for console)
+	fmt.Fprintln(os.Stderr)
+	for _, s := range scans {
+		switch {
+		case s.err != nil:
+			failed++
+			fmt.Fprintf(os.Stderr, "  %s\n    Cannot check: %v\n", console.mark(os.Stderr, markFail, s.repo), s.err)
+		case s.skipped != "":
+			skipped++
+			fmt.Fprintf(os.Stderr, "  - %s (skipped: %s)\n", s.repo, s.skipped)
+		default:
+			o, t := s.res.coverage()
+			owned += o
+			total += t
+			m := markOK
+			if countFailures(s.res.errors) > 0 {
+				failed++
+				m = markFail
+			}
+			name, linked := s.repo, func(string) string { return "" }
+			if s.links != nil {
+				name = console.link(os.Stderr, s.links.base, s.repo)
+				linked = s.links.tree
+			}
+			fmt.Fprintf(os.Stderr, "  %s\n", console.mark(os.Stderr, m, fmt.Sprintf("%s (%d/%d owned, %.0f%%)", name, o, t, percent(o, t))))
+			for _, e := range s.res.errors {
+				fmt.Fprintf(os.Stderr, "      %s: %s\n", console.link(os.Stderr, linked(e.path), e.path), e.codedMessage())
+			}
+		}
+	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintf(os.Stderr, "%s: %.1f%% coverage (%d of %d directories owned) across %d %s, %d failed, %d skipped\n",
+		org, percent(owned, total), owned, total, len(scans), pluralize(len(scans), "repository", "repositories"), failed, skipped)
+
+	// Markdown output to stdout (for GitHub Actions summary)
+	fmt.Printf("## CODEOWNERS Coverage for %s\n", org)
+	fmt.Println()
+	fmt.Println("| Repository | Owned | Checked | Coverage | Issues |")
+	fmt.Println("|------------|-------|---------|----------|--------|")
+	for _, s := range scans {
+		switch {
+		case s.err != nil:
+			fmt.Printf("| `%s` | - | - | - | ❌ %v |\n", s.repo, s.err)
+		case s.skipped != "":
+			fmt.Printf("| `%s` | - | - | - | skipped: %s |\n", s.repo, s.skipped)
+		default:
+			o, t := s.res.coverage()
+			fmt.Printf("| `%s` | %d | %d | %.0f%% | %d |\n", s.repo, o, t, percent(o, t), len(s.res.errors))
+		}
+	}
+	fmt.Println()
+	fmt.Printf("**%.1f%%** of %d checked directories are owned across %d %s.\n", percent(owned, total), total, len(scans), pluralize(len(scans), "repository", "repositories"))
+
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// percent returns part as a percentage of total. Nothing to check counts as
+// full coverage.
+func percent(part, total int) float64 {
+	if total == 0 {
+		return 100
+	}
+	return float64(part) / float64(total) * 100
+}
@@ -0,0 +1,164 @@
+package check
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestScanRepo(t *testing.T) {
+	type tree struct {
+		Path string `json:"path"`
+		Type string `json:"type"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/org/own/contents/.requirecodeowners.yml":
+			w.Write([]byte("directories:\n  - path: services\n    level: 1\n"))
+		case "/repos/org/own/contents/.github/CODEOWNERS", "/repos/org/central/contents/.github/CODEOWNERS":
+			w.Write([]byte("/services/foo/ @team-foo\n"))
+		case "/repos/org/own/git/trees/main", "/repos/org/central/git/trees/main":
+			json.NewEncoder(w).Encode(map[string]any{
+				"tree": []tree{
+					{Path: "services", Type: "tree"},
+					{Path: "services/foo", Type: "tree"},
+					{Path: "services/bar", Type: "tree"},
+					{Path: "services/bar/main.go", Type: "blob"},
+				},
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	t.Setenv("GITHUB_API_URL", srv.URL)
+
+	client := newGitHubClient()
+	central := &config{Directories: []dirSpec{{Path: "services", Level: 0}}}
+
+	own := scanRepo(client, githubRepo{FullName: "org/own", DefaultBranch: "main"}, central, "central.yml")
+	if own.err != nil {
+		t.Fatalf("scanRepo(own) error = %v", own.err)
+	}
+	if len(own.res.errors) != 1 || own.res.errors[0].path != "services/bar" {
+		t.Errorf("scanRepo(own) errors = %v, want services/bar uncovered", own.res.errors)
+	}
+
+	withCentral := scanRepo(client, githubRepo{FullName: "org/central", DefaultBranch: "main"}, central, "central.yml")
+	if withCentral.err != nil {
+		t.Fatalf("scanRepo(central) error = %v", withCentral.err)
+	}
+	if len(withCentral.res.errors) != 1 || !strings.Contains(withCentral.res.errors[0].message, "Not covered") {
+		t.Errorf("scanRepo(central) errors = %v, want services uncovered by central config", withCentral.res.errors)
+	}
+
+	skipped := scanRepo(client, githubRepo{FullName: "org/central", DefaultBranch: "main"}, nil, "")
+	if skipped.skipped != "no config" {
+		t.Errorf("scanRepo() without any config skipped = %q, want %q", skipped.skipped, "no config")
+	}
+}
+
+func TestScanRepoLeavesVariables(t *testing.T) {
+	type tree struct {
+		Path string `json:"path"`
+		Type string `json:"type"`
+	}
+	t.Setenv("RCO_SECRET", "hunter2")
+	var requested []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.String())
+		switch r.URL.Path {
+		case "/repos/org/own/contents/.requirecodeowners.yml":
+			w.Write([]byte("extends: base/${RCO_SECRET}.yml\ncodeowners: ${RCO_SECRET}/CODEOWNERS\n"))
+		case "/repos/org/own/contents/base/${RCO_SECRET}.yml":
+			w.Write([]byte("directories:\n  - path: services\n"))
+		case "/repos/org/own/contents/${RCO_SECRET}/CODEOWNERS":
+			w.Write([]byte("/services/ @org/team\n"))
+		case "/repos/org/own/git/trees/main":
+			json.NewEncoder(w).Encode(map[string]any{"tree": []tree{{Path: "services", Type: "tree"}}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	t.Setenv("GITHUB_API_URL", srv.URL)
+
+	scan := scanRepo(newGitHubClient(), githubRepo{FullName: "org/own", DefaultBranch: "main"}, nil, "")
+	if scan.err != nil {
+		t.Fatalf("scanRepo() error = %v", scan.err)
+	}
+	if len(scan.res.errors) != 0 {
+		t.Errorf("scanRepo() errors = %v, want services owned", scan.res.errors)
+	}
+	// A repository's config can't read the environment of whoever scans it.
+	for _, u := range requested {
+		if strings.Contains(u, "hunter2") {
+			t.Errorf("requested %s, which has $RCO_SECRET in it", u)
+		}
+	}
+}
+
+func TestScanRepoGitLab(t *testing.T) {
+	type tree struct {
+		Path string `json:"path"`
+		Type string `json:"type"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/org/gitlab/contents/.requirecodeowners.yml":
+			w.Write([]byte("provider: gitlab\ndirectories:\n  - path: services\n    level: 1\n"))
+		case "/repos/org/github/contents/.requirecodeowners.yml":
+			w.Write([]byte("directories:\n  - path: services\n    level: 1\n"))
+		case "/repos/org/gitlab/contents/.github/CODEOWNERS", "/repos/org/github/contents/.github/CODEOWNERS":
+			w.Write([]byte("[Services]\n/services/foo/ @team-foo\n"))
+		case "/repos/org/gitlab/git/trees/main", "/repos/org/github/git/trees/main":
+			json.NewEncoder(w).Encode(map[string]any{"tree": []tree{{Path: "services", Type: "tree"}, {Path: "services/foo", Type: "tree"}}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	t.Setenv("GITHUB_API_URL", srv.URL)
+
+	old := rulesetCache
+	rulesetCache = newParsedRulesets(8)
+	defer func() { rulesetCache = old }()
+
+	client := newGitHubClient()
+	gitlab := scanRepo(client, githubRepo{FullName: "org/gitlab", DefaultBranch: "main"}, nil, "")
+	if gitlab.err != nil || len(gitlab.res.errors) != 0 {
+		t.Errorf("scanRepo(gitlab) = %v, %v, want services/foo owned", gitlab.err, gitlab.res.errors)
+	}
+	// The same file is GitHub syntax, with a section it can't have, for a
+	// repository whose config doesn't say otherwise.
+	github := scanRepo(client, githubRepo{FullName: "org/github", DefaultBranch: "main"}, nil, "")
+	if github.err == nil || !strings.Contains(github.err.Error(), "unexpected character '['") {
+		t.Errorf("scanRepo(github) error = %v, want the section rejected", github.err)
+	}
+}
+
+func TestListOrgRepos(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var repos []githubRepo
+		if r.URL.Query().Get("page") == "1" {
+			for i := 0; i < 100; i++ {
+				repos = append(repos, githubRepo{FullName: "org/repo"})
+			}
+		} else if r.URL.Query().Get("page") == "2" {
+			repos = append(repos, githubRepo{FullName: "org/last"})
+		}
+		json.NewEncoder(w).Encode(repos)
+	}))
+	defer srv.Close()
+	t.Setenv("GITHUB_API_URL", srv.URL)
+
+	repos, err := newGitHubClient().listOrgRepos("org")
+	if err != nil {
+		t.Fatalf("listOrgRepos() error = %v", err)
+	}
+	if len(repos) != 101 || repos[100].FullName != "org/last" {
+		t.Errorf("listOrgRepos() returned %d repos, want 101 across two pages", len(repos))
+	}
+}
@@ -0,0 +1,289 @@
+package check
+
+import (
+	_ "embed"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configSchema is a JSON Schema for the config, for editors to validate and
+// complete it with.
+//
+//go:embed config.schema.json
+var configSchema []byte
+
+// configProblem is a key or value in the config that doesn't fit the
+// config's types.
+type configProblem struct {
+	line    int    // 0 if unknown
+	key     string // where it is, like directories[0].level
+	message string
+	unknown bool // the key itself isn't one the config has
+}
+
+func (p configProblem) String() string {
+	if p.key == "" {
+		return p.message
+	}
+	return p.key + ": " + p.message
+}
+
+// checkConfigNode reports the keys in the config document node that the
+// config doesn't have, and the values that don't fit their key's type. The
+// config's own decoding accepts both silently, or stops at the first.
+func checkConfigNode(node *yaml.Node) []configProblem {
+	var problems []configProblem
+	checkNode(node, reflect.TypeOf(config{}), "", &problems)
+	sort.SliceStable(problems, func(i, j int) bool { return problems[i].line < problems[j].line })
+	return problems
+}
+
+var (
+	stringListType = reflect.TypeOf(stringList{})
+	dirSpecType    = reflect.TypeOf(dirSpec{})
+)
+
+// checkNode checks node against t, adding what doesn't fit to problems. at
+// is where node is, for messages.
+func checkNode(node *yaml.Node, t reflect.Type, at string, problems *[]configProblem) {
+	for node.Kind == yaml.DocumentNode || node.Kind == yaml.AliasNode {
+		if node.Kind == yaml.AliasNode {
+			node = node.Alias
+		} else if len(node.Content) > 0 {
+			node = node.Content[0]
+		} else {
+			return
+		}
+	}
+	if node.Tag == "!!null" {
+		return
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	bad := func(want string) {
+		*problems = append(*problems, configProblem{line: node.Line, key: at, message: fmt.Sprintf("must be %s, not %s", want, describeNode(node))})
+	}
+
+	switch {
+	case t == stringListType:
+		if node.Kind == yaml.ScalarNode {
+			return
+		}
+		checkNode(node, reflect.TypeOf([]string{}), at, problems)
+	case t.Kind() == reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			bad("a mapping")
+			return
+		}
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.Value == "<<" {
+				// A merge key brings in another mapping's keys.
+				checkNode(value, t, at, problems)
+				continue
+			}
+			f, ok := fields[key.Value]
+			if !ok {
+				msg := fmt.Sprintf("unknown key %q", key.Value)
+				if s := closestKey(key.Value, fields); s != "" {
+					msg += fmt.Sprintf(" (did you mean %q?)", s)
+				}
+				*problems = append(*problems, configProblem{line: key.Line, key: at, message: msg, unknown: true})
+				continue
+			}
+			if t == dirSpecType && key.Value == "level" && value.Kind == yaml.ScalarNode && strings.Contains(value.Value, "..") {
+				continue // a min..max range, which loadConfig checks
+			}
+			checkNode(value, f.Type, joinKey(at, key.Value), problems)
+		}
+	case t.Kind() == reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			bad("a list")
+			return
+		}
+		for i, item := range node.Content {
+			checkNode(item, t.Elem(), fmt.Sprintf("%s[%d]", at, i), problems)
+		}
+	case t.Kind() == reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			bad("a mapping")
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			checkNode(node.Content[i+1], t.Elem(), joinKey(at, node.Content[i].Value), problems)
+		}
+	case t.Kind() == reflect.Interface:
+	default:
+		if node.Kind != yaml.ScalarNode {
+			bad(describeKind(t))
+			return
+		}
+		if err := node.Decode(reflect.New(t).Interface()); err != nil {
+			bad(describeKind(t))
+		}
+	}
+}
+
+// yamlFields maps the YAML keys of struct type t to its fields.
+func yamlFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if f.IsExported() && name != "" && name != "-" {
+			fields[name] = f
+		}
+	}
+	return fields
+}
+
+// closestKey returns the key in fields that key is most likely a typo of,
+// or "" if none is close.
+func closestKey(key string, fields map[string]reflect.StructField) string {
+	best, bestDistance := "", maxTypoDistance(key)+1
+	for name := range fields {
+		if d := editDistance(strings.ToLower(key), name); d < bestDistance || (d == bestDistance && name < best) {
+			best, bestDistance = name, d
+		}
+	}
+	return best
+}
+
+func joinKey(at, key string) string {
+	if at == "" {
+		return key
+	}
+	return at + "." + key
+}
+
+// describeKind names what a value of type t looks like in YAML.
+func describeKind(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "true or false"
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Float64:
+		return "a number"
+	default:
+		return "a string"
+	}
+}
+
+// describeNode says what node holds, for messages.
+func describeNode(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "a mapping"
+	case yaml.SequenceNode:
+		return "a list"
+	}
+	return fmt.Sprintf("%q", node.Value)
+}
+
+// yamlErrorLine finds the line number in a YAML syntax error.
+var yamlErrorLine = regexp.MustCompile(`line (\d+):`)
+
+// validateConfig reads the config file at path and reports its problems:
+// unknown keys, values of the wrong type, and anything else loading it
+// would fail on. Problems in TOML and JSON configs have no line numbers,
+// since they're found in the converted YAML.
+func validateConfig(path string) ([]configProblem, error) {
+	data, err := readConfigFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config file %s: %w", path, err)
+	}
+	converted, err := toYAML(data, filepath.Ext(path))
+	if err != nil {
+		return []configProblem{{message: err.Error()}}, nil
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(converted, &doc); err != nil {
+		p := configProblem{message: strings.TrimPrefix(err.Error(), "yaml: ")}
+		if m := yamlErrorLine.FindStringSubmatch(err.Error()); m != nil && isYAML(path) {
+			fmt.Sscan(m[1], &p.line)
+		}
+		return []configProblem{p}, nil
+	}
+	problems := checkConfigNode(&doc)
+	if !isYAML(path) {
+		for i := range problems {
+			problems[i].line = 0
+		}
+	}
+	if len(problems) > 0 {
+		// Loading would only repeat the first type error.
+		return problems, nil
+	}
+	if _, err := loadConfig(path); err != nil {
+		return []configProblem{{message: strings.TrimPrefix(err.Error(), "parsing config file: ")}}, nil
+	}
+	return nil, nil
+}
+
+func runConfigSchema(args []string) int {
+	fs := flag.NewFlagSet("config schema", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	os.Stdout.Write(configSchema)
+	return 0
+}
+
+func runConfigValidate(args []string) int {
+	fs := flag.NewFlagSet("config validate", flag.ContinueOnError)
+	var rf repoFlags
+	rf.registerConfig(fs)
+	var cf consoleFlags
+	cf.register(fs)
+	var gf githubFlags
+	gf.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if err := cf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	if err := gf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+
+	if err := rf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	if err := enterConfigRoot(rf.configPath); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	path := resolveConfigPath(rf.configPath)
+	problems, err := validateConfig(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	if len(problems) == 0 {
+		fmt.Println(console.mark(os.Stdout, markOK, path+" is valid"))
+		return 0
+	}
+	for _, p := range problems {
+		if p.line > 0 {
+			fmt.Fprintf(os.Stderr, "%s:%d: %s\n", path, p.line, p)
+		} else {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", path, p)
+		}
+	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, console.mark(os.Stderr, markFail, fmt.Sprintf("%d config %s found", len(problems), pluralize(len(problems), "problem", "problems"))))
+	return 1
+}
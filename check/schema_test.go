@@ -0,0 +1,248 @@
+package check
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestCheckConfigNode(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   []string
+	}{
+		{
+			name: "valid",
+			config: `provider: gitlab
+codeowners: CODEOWNERS
+defaults: &defaults
+  level: 1
+directories:
+  - path: services
+    level: 1..3
+    excludes: [services/old]
+  - <<: *defaults
+    path: libs
+    hidden: false
+policy:
+  allowed_owners: ["@org/a"]
+suppressions:
+  - path: services/old
+    reason: Moving
+    expires: 2030-01-01
+hooks:
+  - ./check.sh
+`,
+		},
+		{
+			name: "typos",
+			config: `directories:
+  - path: services
+    lvel: 1
+    exlude: [services/old]
+policy:
+  max_dirs_per_ownr: 3
+colour: red
+`,
+			want: []string{
+				`3 directories[0]: unknown key "lvel" (did you mean "level"?)`,
+				`4 directories[0]: unknown key "exlude" (did you mean "excludes"?)`,
+				`6 policy: unknown key "max_dirs_per_ownr" (did you mean "max_dirs_per_owner"?)`,
+				`7 unknown key "colour"`,
+			},
+		},
+		{
+			name: "types",
+			config: `directories:
+  - path: services
+    min_owners: two
+    excludes: services/old
+    hidden: maybe
+aliases:
+  payments: [a, b]
+policy: true
+`,
+			want: []string{
+				`3 directories[0].min_owners: must be a number, not "two"`,
+				`4 directories[0].excludes: must be a list, not "services/old"`,
+				`5 directories[0].hidden: must be true or false, not "maybe"`,
+				`7 aliases.payments: must be a string, not a list`,
+				`8 policy: must be a mapping, not "true"`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var doc yaml.Node
+			if err := yaml.Unmarshal([]byte(tt.config), &doc); err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, p := range checkConfigNode(&doc) {
+				got = append(got, fmt.Sprintf("%d %s", p.line, p))
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("checkConfigNode() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	tests := []struct {
+		file    string
+		content string
+		want    []string
+	}{
+		{".requirecodeowners.yml", "directories:\n  - path: services\n", nil},
+		{".requirecodeowners.yml", "directories:\n  - path: services\n    lvel: 1\n", []string{`3: directories[0]: unknown key "lvel" (did you mean "level"?)`}},
+		// TOML is checked through the YAML it converts to, whose lines
+		// don't match the file's.
+		{"config.toml", "[[directories]]\npath = \"services\"\nlvel = 1\n", []string{`0: directories[0]: unknown key "lvel" (did you mean "level"?)`}},
+		// What the types allow but loading doesn't is reported too.
+		{".requirecodeowners.yml", "directories:\n  - path: services\n    level: -1\n", []string{"0: directory services has invalid level -1 (must be >= 0)"}},
+		{".requirecodeowners.yml", "directories: [\n", []string{"1: line 1: did not find expected node content"}},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(tmpDir, tt.file)
+		if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		problems, err := validateConfig(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, p := range problems {
+			got = append(got, fmt.Sprintf("%d: %s", p.line, p))
+		}
+		if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+			t.Errorf("validateConfig(%q) =\n%s\nwant\n%s", tt.content, strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+		}
+	}
+}
+
+func TestParseConfigUnknownKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		lax     bool
+		wantErr string
+	}{
+		{
+			name:    "typo",
+			file:    ".requirecodeowners.yml",
+			content: "directories:\n  - path: services\n    exlude: [services/old]\n",
+			wantErr: `parsing config file: line 3: directories[0]: unknown key "exlude" (did you mean "excludes"?) (pass --lax-config to ignore unknown keys)`,
+		},
+		{
+			name:    "several",
+			file:    ".requirecodeowners.yml",
+			content: "colour: red\ndirectories:\n  - path: services\n    lvel: 1\n",
+			wantErr: `parsing config file: line 1: unknown key "colour"; line 4: directories[0]: unknown key "lvel" (did you mean "level"?) (pass --lax-config to ignore unknown keys)`,
+		},
+		{
+			name:    "json has no lines",
+			file:    "config.json",
+			content: `{"directories": [{"path": "services", "lvel": 1}]}`,
+			wantErr: `parsing config file: directories[0]: unknown key "lvel" (did you mean "level"?) (pass --lax-config to ignore unknown keys)`,
+		},
+		{
+			name:    "lax",
+			file:    ".requirecodeowners.yml",
+			content: "directories:\n  - path: services\n    exlude: [services/old]\n",
+			lax:     true,
+		},
+		{
+			// A wrong type is decoding's to report.
+			name:    "wrong type",
+			file:    ".requirecodeowners.yml",
+			content: "directories:\n  - path: services\n    level: [1]\n",
+			wantErr: "cannot unmarshal",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			laxConfig = tt.lax
+			defer func() { laxConfig = false }()
+			_, err := parseConfig([]byte(tt.content), tt.file)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("parseConfig() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseConfig() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestConfigSchema checks that the schema describes exactly the keys the
+// config has, so the two can't drift apart.
+func TestConfigSchema(t *testing.T) {
+	type object struct {
+		Properties map[string]json.RawMessage `json:"properties"`
+		Items      *object                    `json:"items"`
+	}
+	var schema struct {
+		object
+		Defs map[string]object `json:"$defs"`
+	}
+	if err := json.Unmarshal(configSchema, &schema); err != nil {
+		t.Fatalf("config.schema.json isn't valid JSON: %v", err)
+	}
+	keys := func(props map[string]json.RawMessage) []string {
+		var names []string
+		for name := range props {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return names
+	}
+	fields := func(v any) []string {
+		var names []string
+		for name := range yamlFields(reflect.TypeOf(v)) {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	var directories object
+	if err := json.Unmarshal(schema.Properties["directories"], &directories); err != nil {
+		t.Fatal(err)
+	}
+	spec := keys(schema.Defs["specOptions"].Properties)
+	spec = append(spec, keys(directories.Items.Properties)...)
+	sort.Strings(spec)
+
+	tests := []struct {
+		name   string
+		schema []string
+		config any
+	}{
+		{"config", keys(schema.Properties), config{}},
+		{"directories", spec, dirSpec{}},
+		{"policy", keys(schema.Defs["policy"].Properties), policy{}},
+		{"catalog", keys(schema.Defs["catalog"].Properties), catalog{}},
+		{"suppressions", keys(schema.Defs["suppression"].Properties), suppression{}},
+		{"policies", keys(schema.Defs["customPolicy"].Properties), customPolicy{}},
+	}
+	for _, tt := range tests {
+		if want := fields(tt.config); !reflect.DeepEqual(tt.schema, want) {
+			t.Errorf("schema for %s has keys %v, want %v", tt.name, tt.schema, want)
+		}
+	}
+}
@@ -0,0 +1,417 @@
+package check
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// checkRequest is the JSON body of a check request. It names either a
+// repository under the server's root directory or a GitHub repository.
+type checkRequest struct {
+	Path string `json:"path"`
+	Repo string `json:"repo"`
+	Ref  string `json:"ref"`
+}
+
+// checkResponse is the report a check request returns.
+type checkResponse struct {
+	Passed   bool            `json:"passed"`
+	Checked  int             `json:"checked"`
+	Owned    int             `json:"owned"`
+	Coverage float64         `json:"coverage_percent"`
+	Failures int             `json:"failures"`
+	Warnings int             `json:"warnings"`
+	Errors   []responseError `json:"errors"`
+	Skipped  []responseSkip  `json:"skipped"`
+}
+
+type responseError struct {
+	Path     string `json:"path"`
+	Message  string `json:"message"`
+	Severity string `json:"severity"`
+	Code     string `json:"code,omitempty"`
+	Spec     string `json:"spec,omitempty"`
+	Team     string `json:"team,omitempty"`
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+}
+
+type responseSkip struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// server answers check requests over HTTP.
+type server struct {
+	root      string // directory local paths resolve against; "" disables them
+	maxUpload int64
+
+	// allowRepos are the owners and owner/repo names whose GitHub
+	// repositories requests may name. The API isn't authenticated, so
+	// without any, repositories can't be named at all: anyone could read
+	// what the server's token can.
+	allowRepos []string
+
+	// app and webhookSecret enable the GitHub App webhook endpoint, and
+	// pending tracks the checks it has started.
+	app           *githubApp
+	webhookSecret string
+	pending       sync.WaitGroup
+
+	// mu serializes checks, which run in the repository's directory and so
+	// change the process's working directory.
+	mu sync.Mutex
+}
+
+func runServe(args []string) int {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	var addr, root string
+	var allowRepos stringList
+	var maxUpload int64
+	var appID, appKey, webhookSecret string
+	fs.StringVar(&addr, "addr", "localhost:8080", "address to listen on")
+	fs.StringVar(&root, "root", "", "directory that local repository paths in requests resolve against (default: local paths are refused)")
+	fs.Var(&allowRepos, "allow-repos", "owners or owner/repo names whose GitHub repositories requests may check, comma-separated or repeated (default: none)")
+	fs.Int64Var(&maxUpload, "max-upload", 100<<20, "largest tarball accepted, in bytes")
+	fs.StringVar(&appID, "app-id", os.Getenv("GITHUB_APP_ID"), "GitHub App ID, to report check runs for webhook events")
+	fs.StringVar(&appKey, "app-private-key", os.Getenv("GITHUB_APP_PRIVATE_KEY_PATH"), "path to the GitHub App's private key")
+	fs.StringVar(&webhookSecret, "webhook-secret", os.Getenv("GITHUB_WEBHOOK_SECRET"), "secret the GitHub App signs webhook deliveries with")
+	var gf githubFlags
+	gf.register(fs)
+	var tf traversalFlags
+	tf.register(fs)
+	var lf configFlags
+	lf.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if err := gf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	if err := lf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	if err := tf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	if root != "" {
+		abs, err := filepath.Abs(root)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return 2
+		}
+		root = abs
+	}
+
+	s := &server{root: root, maxUpload: maxUpload, allowRepos: splitList(allowRepos), webhookSecret: webhookSecret}
+	if appID != "" || appKey != "" || webhookSecret != "" {
+		if appID == "" || appKey == "" || webhookSecret == "" {
+			fmt.Fprintln(os.Stderr, "error: the webhook endpoint needs all of --app-id, --app-private-key and --webhook-secret")
+			return 2
+		}
+		if offline {
+			fmt.Fprintln(os.Stderr, "error: the webhook endpoint reports through the GitHub API and can't run with --offline")
+			return 2
+		}
+		app, err := loadGitHubApp(appID, appKey)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return 2
+		}
+		s.app = app
+	}
+
+	setupLogging(os.Stderr, logLevel(false, false, false))
+	rulesetCache = newParsedRulesets(256)
+	srv := &http.Server{Addr: addr, Handler: s.handler(), ReadHeaderTimeout: 10 * time.Second}
+	fmt.Fprintf(os.Stderr, "listening on %s\n", addr)
+	if err := srv.ListenAndServe(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func (s *server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc("POST /v1/check", s.handleCheck)
+	if s.app != nil {
+		mux.HandleFunc("POST /v1/webhook", s.handleWebhook)
+	}
+	return mux
+}
+
+// handleCheck checks the repository a request names in its JSON body, or
+// one uploaded as a tarball, optionally gzipped.
+func (s *server) handleCheck(w http.ResponseWriter, r *http.Request) {
+	body := http.MaxBytesReader(w, r.Body, s.maxUpload)
+	var res result
+	var err error
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		var req checkRequest
+		if err := json.NewDecoder(body).Decode(&req); err != nil {
+			writeJSONError(w, http.StatusBadRequest, fmt.Errorf("decoding request: %w", err))
+			return
+		}
+		res, err = s.checkNamed(req)
+	} else {
+		res, err = s.checkTarball(body)
+	}
+	var reqErr *requestError
+	switch {
+	case errors.As(err, &reqErr):
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	case err != nil:
+		writeJSONError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	sortErrors(res.errors)
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(newCheckResponse(res))
+}
+
+// requestError is a problem with the request itself rather than with the
+// repository it names.
+type requestError struct {
+	msg string
+}
+
+func (e *requestError) Error() string { return e.msg }
+
+func writeJSONError(w http.ResponseWriter, code int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
+
+// checkNamed checks a local repository below the server's root, or a GitHub
+// repository at a ref (its default branch if none is given).
+func (s *server) checkNamed(req checkRequest) (result, error) {
+	switch {
+	case req.Path != "" && req.Repo != "":
+		return result{}, &requestError{"set either path or repo, not both"}
+	case req.Path != "":
+		if s.root == "" {
+			return result{}, &requestError{"local paths are disabled; start the server with --root"}
+		}
+		dir, err := s.localRepo(req.Path)
+		if err != nil {
+			return result{}, err
+		}
+		return s.checkDir(dir)
+	case req.Repo != "":
+		if offline {
+			return result{}, &requestError{"GitHub repositories can't be checked with --offline"}
+		}
+		if len(s.allowRepos) == 0 {
+			return result{}, &requestError{"GitHub repositories are disabled; start the server with --allow-repos"}
+		}
+		if !s.repoAllowed(req.Repo) {
+			return result{}, &requestError{fmt.Sprintf("%s isn't in --allow-repos", req.Repo)}
+		}
+		client := newGitHubClient()
+		repo := githubRepo{FullName: req.Repo, DefaultBranch: req.Ref}
+		if repo.DefaultBranch == "" {
+			if err := client.getJSON("/repos/"+req.Repo, &repo); err != nil {
+				return result{}, err
+			}
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		scan := scanRepo(client, repo, nil, "")
+		if scan.skipped != "" {
+			return result{}, fmt.Errorf("%s has %s", req.Repo, scan.skipped)
+		}
+		return scan.res, scan.err
+	}
+	return result{}, &requestError{"set path or repo"}
+}
+
+// localRepo returns the directory below the root that a request's path
+// names. Symlinks are resolved first, so one under the root can't lead the
+// check outside it.
+func (s *server) localRepo(name string) (string, error) {
+	root, err := filepath.EvalSymlinks(s.root)
+	if err != nil {
+		return "", err
+	}
+	dir, err := filepath.EvalSymlinks(filepath.Join(root, filepath.FromSlash(path.Clean("/"+name))))
+	if err != nil {
+		return "", &requestError{fmt.Sprintf("no repository at %s", name)}
+	}
+	if rel, err := filepath.Rel(root, dir); err != nil || !filepath.IsLocal(rel) && rel != "." {
+		return "", &requestError{fmt.Sprintf("%s is outside the server's root", name)}
+	}
+	return dir, nil
+}
+
+// repoAllowed reports whether --allow-repos lets requests name the GitHub
+// repository owner/repo, by itself or by its owner.
+func (s *server) repoAllowed(repo string) bool {
+	owner, name, ok := strings.Cut(repo, "/")
+	// Anything else in the name could send the request to another
+	// repository's URL.
+	for _, part := range []string{owner, name} {
+		if !ok || part == "" || part == "." || part == ".." || strings.ContainsAny(part, "/?#%\\") {
+			return false
+		}
+	}
+	for _, allowed := range s.allowRepos {
+		if strings.EqualFold(allowed, repo) || strings.EqualFold(allowed, owner) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkTarball extracts an uploaded repository and checks it. A single
+// top-level directory, as in GitHub's tarballs, is taken as the root.
+func (s *server) checkTarball(r io.Reader) (result, error) {
+	tmp, err := os.MkdirTemp("", "requirecodeowners-*")
+	if err != nil {
+		return result{}, err
+	}
+	defer os.RemoveAll(tmp)
+	if err := extractTarball(r, tmp); err != nil {
+		return result{}, &requestError{fmt.Sprintf("reading tarball: %v", err)}
+	}
+
+	dir := tmp
+	if entries, err := os.ReadDir(tmp); err == nil && len(entries) == 1 && entries[0].IsDir() {
+		dir = filepath.Join(tmp, entries[0].Name())
+	}
+	return s.checkDir(dir)
+}
+
+// checkDir runs the check in dir with the config at its root, returning to
+// the server's working directory afterwards. Unlike the CLI, it doesn't look
+// for a config in parent directories, which may be outside the repository,
+// and the config is treated as remote: whoever sent it doesn't get to run
+// hooks on the server or read its other files.
+func (s *server) checkDir(dir string) (result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	wd, err := os.Getwd()
+	if err != nil {
+		return result{}, err
+	}
+	defer func() { _ = os.Chdir(wd) }()
+	if err := os.Chdir(dir); err != nil {
+		return result{}, &requestError{fmt.Sprintf("no repository at %s", filepath.Base(dir))}
+	}
+	for _, name := range defaultConfigPaths {
+		if _, err := os.Stat(name); err == nil {
+			return checkRepoFrom(context.Background(), remoteConfig, name, nil, specFilter{})
+		}
+	}
+	return result{}, fmt.Errorf("no config found (looked for %s)", strings.Join(defaultConfigPaths, ", "))
+}
+
+// extractTarball writes the directories and regular files of a tar stream,
+// gzipped or not, below dir. Links are skipped, and names like ../x are
+// kept inside dir.
+func extractTarball(r io.Reader, dir string) error {
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	} else {
+		r = br
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		name := path.Clean("/" + hdr.Name)
+		if name == "/" {
+			continue
+		}
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(f, tr)
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func newCheckResponse(res result) checkResponse {
+	owned, checked := res.coverage()
+	failures := countFailures(res.errors)
+	resp := checkResponse{
+		Passed:   failures == 0,
+		Checked:  checked,
+		Owned:    owned,
+		Coverage: percent(owned, checked),
+		Failures: failures,
+		Warnings: len(res.errors) - failures,
+		Errors:   []responseError{},
+		Skipped:  []responseSkip{},
+	}
+	for _, e := range res.errors {
+		resp.Errors = append(resp.Errors, newResponseError(e))
+	}
+	for _, d := range res.skipped {
+		resp.Skipped = append(resp.Skipped, responseSkip{Path: d.path, Reason: d.reason})
+	}
+	return resp
+}
+
+func newResponseError(e validationError) responseError {
+	severity := e.severity
+	if severity == "" {
+		severity = severityError
+	}
+	return responseError{
+		Path: e.path, Message: e.message, Severity: severity, Code: e.code,
+		Spec: e.spec, Team: e.team, File: e.file, Line: e.line,
+	}
+}
@@ -0,0 +1,269 @@
+package check
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestServeCheckPath(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"repo/.requirecodeowners.yml": "directories:\n  - path: services\n    level: 1\n",
+		"repo/.github/CODEOWNERS":     "/services/api/ @org/api\n",
+		"repo/services/api/main.go":   "",
+		"repo/services/web/index.js":  "",
+	}
+	for name, content := range files {
+		os.MkdirAll(filepath.Join(root, filepath.Dir(name)), 0755)
+		os.WriteFile(filepath.Join(root, name), []byte(content), 0644)
+	}
+	// A checkout outside the root, linked from inside it.
+	outside := t.TempDir()
+	os.WriteFile(filepath.Join(outside, ".requirecodeowners.yml"), []byte("directories:\n  - path: .\n"), 0644)
+	linked := os.Symlink(outside, filepath.Join(root, "link")) == nil
+	wd, _ := os.Getwd()
+
+	old := rulesetCache
+	rulesetCache = newParsedRulesets(8)
+	defer func() { rulesetCache = old }()
+
+	srv := httptest.NewServer((&server{root: root, maxUpload: 1 << 20}).handler())
+	defer srv.Close()
+
+	for i := 0; i < 2; i++ {
+		resp, err := http.Post(srv.URL+"/v1/check", "application/json", strings.NewReader(`{"path": "repo"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got checkResponse
+		json.NewDecoder(resp.Body).Decode(&got)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("status = %s, want 200", resp.Status)
+		}
+		if got.Passed || got.Checked != 2 || got.Owned != 1 || len(got.Errors) != 1 || got.Errors[0].Path != "services/web" {
+			t.Errorf("response = %+v, want services/web uncovered", got)
+		}
+	}
+	if len(rulesetCache.rules) != 1 {
+		t.Errorf("cached rulesets = %d, want the one CODEOWNERS parsed once", len(rulesetCache.rules))
+	}
+	if now, _ := os.Getwd(); now != wd {
+		t.Errorf("working directory after check = %s, want %s", now, wd)
+	}
+
+	tests := []struct {
+		name, body string
+		want       int
+	}{
+		{"escaping the root", `{"path": "../../etc"}`, http.StatusBadRequest},
+		{"symlink out of the root", `{"path": "link"}`, http.StatusBadRequest},
+		{"repositories disabled", `{"repo": "org/repo"}`, http.StatusBadRequest},
+		{"missing repository", `{"path": "nope"}`, http.StatusBadRequest},
+		{"path and repo", `{"path": "repo", "repo": "org/repo"}`, http.StatusBadRequest},
+		{"empty", `{}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if strings.Contains(tt.body, `"link"`) && !linked {
+				t.Skip("can't create symlinks here")
+			}
+			resp, err := http.Post(srv.URL+"/v1/check", "application/json", strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
+
+// tarball returns files as a gzipped tar stream, like GitHub's archives.
+func tarball(files map[string]string) *bytes.Buffer {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg})
+		tw.Write([]byte(content))
+	}
+	tw.Close()
+	gz.Close()
+	return &buf
+}
+
+func TestServeRepoAllowed(t *testing.T) {
+	s := &server{allowRepos: []string{"org", "other/one"}}
+	tests := []struct {
+		repo string
+		want bool
+	}{
+		{"org/repo", true},
+		{"Org/Repo", true},
+		{"other/one", true},
+		{"other/two", false},
+		{"org", false},
+		{"org/../other/two", false},
+		{"org/repo?ref=x", false},
+		{"org/..", false},
+	}
+	for _, tt := range tests {
+		if got := s.repoAllowed(tt.repo); got != tt.want {
+			t.Errorf("repoAllowed(%q) = %v, want %v", tt.repo, got, tt.want)
+		}
+	}
+}
+
+func TestServeCheckTarball(t *testing.T) {
+	srv := httptest.NewServer((&server{maxUpload: 1 << 20}).handler())
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL+"/v1/check", "application/gzip", tarball(map[string]string{
+		"org-repo-abc123/.requirecodeowners.yml": "directories:\n  - path: services\n    level: 1\n",
+		"org-repo-abc123/CODEOWNERS":             "/services/ @org/all\n",
+		"org-repo-abc123/services/api/main.go":   "",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var got checkResponse
+	json.NewDecoder(resp.Body).Decode(&got)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %s, want 200", resp.Status)
+	}
+	if !got.Passed || got.Checked != 1 || got.Owned != 1 {
+		t.Errorf("response = %+v, want services/api owned", got)
+	}
+}
+
+func TestServeUploadedConfig(t *testing.T) {
+	srv := httptest.NewServer((&server{maxUpload: 1 << 20}).handler())
+	defer srv.Close()
+
+	secret := filepath.Join(t.TempDir(), "secret")
+	os.WriteFile(secret, []byte("owners: ['@org/all']\n"), 0644)
+	ran := filepath.Join(t.TempDir(), "ran")
+	tests := []struct {
+		name    string
+		config  string
+		wantErr string
+	}{
+		{
+			name:   "hooks",
+			config: "directories:\n  - path: services\n    level: 1\nhooks: [touch " + filepath.ToSlash(ran) + "]\n",
+		},
+		{
+			name:    "absolute extends",
+			config:  "extends: " + filepath.ToSlash(secret) + "\ndirectories:\n  - path: services\n",
+			wantErr: "is outside the repository",
+		},
+		{
+			name:    "extends outside the repository",
+			config:  "extends: ../../shared.yml\ndirectories:\n  - path: services\n",
+			wantErr: "extends ../../shared.yml: ../../shared.yml is outside the repository",
+		},
+		{
+			name:    "extends a URL",
+			config:  "extends: http://169.254.169.254/latest/meta-data\ndirectories:\n  - path: services\n",
+			wantErr: "a remote config can only extend files in its repository",
+		},
+		{
+			name:    "extends another repository",
+			config:  "extends: github:org/private/base.yml\ndirectories:\n  - path: services\n",
+			wantErr: "a remote config can only extend files in its repository",
+		},
+		{
+			name:    "codeowners from another repository",
+			config:  "codeowners: github:org/private\ndirectories:\n  - path: services\n",
+			wantErr: "codeowners github:org/private isn't in the repository",
+		},
+		{
+			name:    "codeowners from a URL",
+			config:  "codeowners: http://10.0.0.1/CODEOWNERS\ndirectories:\n  - path: services\n",
+			wantErr: "codeowners http://10.0.0.1/CODEOWNERS isn't in the repository",
+		},
+		{
+			name:    "absolute owners registry",
+			config:  "directories:\n  - path: services\npolicy:\n  owners_registry: " + filepath.ToSlash(secret) + "\n",
+			wantErr: "owners registry " + filepath.ToSlash(secret) + " is outside the repository",
+		},
+		{
+			name:    "owners registry outside the repository",
+			config:  "directories:\n  - path: services\npolicy:\n  owners_registry: ../owners.yml\n",
+			wantErr: "owners registry ../owners.yml is outside the repository",
+		},
+		{
+			name:    "directory outside the repository",
+			config:  "directories:\n  - path: ../..\n",
+			wantErr: "directory ../.. is outside the repository",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := http.Post(srv.URL+"/v1/check", "application/gzip", tarball(map[string]string{
+				"repo/.requirecodeowners.yml": tt.config,
+				"repo/CODEOWNERS":             "/services/ @org/all\n",
+				"repo/services/api/main.go":   "",
+			}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			var got struct {
+				checkResponse
+				Error string `json:"error"`
+			}
+			json.NewDecoder(resp.Body).Decode(&got)
+			if tt.wantErr != "" {
+				if resp.StatusCode == http.StatusOK || !strings.Contains(got.Error, tt.wantErr) {
+					t.Errorf("status = %s, error = %q, want %q", resp.Status, got.Error, tt.wantErr)
+				}
+				return
+			}
+			if resp.StatusCode != http.StatusOK || !got.Passed {
+				t.Errorf("status = %s, response = %+v, want it to pass", resp.Status, got)
+			}
+			if _, err := os.Stat(ran); err == nil {
+				t.Errorf("the uploaded config's hook ran on the server")
+			}
+		})
+	}
+}
+
+func TestExtractTarball(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, name := range []string{"repo/a.txt", "../../escape.txt", "/abs.txt"} {
+		tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Typeflag: tar.TypeReg})
+	}
+	tw.WriteHeader(&tar.Header{Name: "repo/link", Linkname: "/etc/passwd", Typeflag: tar.TypeSymlink})
+	tw.Close()
+
+	parent := t.TempDir()
+	dir := filepath.Join(parent, "out")
+	if err := extractTarball(&buf, dir); err != nil {
+		t.Fatalf("extractTarball() error = %v", err)
+	}
+	for _, name := range []string{"repo/a.txt", "escape.txt", "abs.txt"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("%s not extracted inside the directory: %v", name, err)
+		}
+	}
+	if _, err := os.Lstat(filepath.Join(dir, "repo", "link")); err == nil {
+		t.Errorf("symlink was extracted")
+	}
+	if entries, _ := os.ReadDir(parent); len(entries) != 1 {
+		t.Errorf("extractTarball() wrote outside its directory: %v", entries)
+	}
+}
@@ -0,0 +1,88 @@
+package check
+
+import (
+	"bytes"
+	"errors"
+	"path"
+	"strings"
+)
+
+// countFiles counts the files under dir, at any depth, stopping once it
+// reaches limit. Directories the spec doesn't expand into aren't counted.
+func countFiles(fsys fileSystem, dir string, limit int) int {
+	n := 0
+	walkFiles(fsys, dir, func(string) bool {
+		n++
+		return n < limit
+	})
+	return n
+}
+
+// countLines counts the lines of the files under dir, stopping once it
+// reaches limit. ok is false if the contents can't be read, as for a
+// repository checked through the GitHub API.
+func countLines(fsys fileSystem, dir string, limit int) (n int, ok bool) {
+	ok = true
+	walkFiles(fsys, dir, func(name string) bool {
+		data, err := fsys.ReadFile(name)
+		if errors.Is(err, errors.ErrUnsupported) {
+			ok = false
+			return false
+		}
+		if err != nil {
+			logger.Debug("reading file", "path", name, "error", err)
+			return true
+		}
+		n += bytes.Count(data, []byte("\n"))
+		if len(data) > 0 && data[len(data)-1] != '\n' {
+			n++
+		}
+		return n < limit
+	})
+	return n, ok
+}
+
+// containsFile reports whether dir holds a file matching one of patterns, at
+// any depth. Patterns with a slash match the path below dir, others the file
+// name.
+func containsFile(fsys fileSystem, dir string, patterns []string) bool {
+	found := false
+	walkFiles(fsys, dir, func(name string) bool {
+		rel := strings.TrimPrefix(name, dir+"/")
+		for _, pattern := range patterns {
+			target := path.Base(name)
+			if strings.Contains(pattern, "/") {
+				target = rel
+				pattern = strings.TrimPrefix(pattern, "/")
+			}
+			if ok, _ := path.Match(pattern, target); ok {
+				found = true
+				return false
+			}
+		}
+		return true
+	})
+	return found
+}
+
+// walkFiles calls fn with each file under dir until fn returns false.
+func walkFiles(fsys fileSystem, dir string, fn func(name string) bool) bool {
+	entries, err := fsys.ReadDir(dir)
+	if err != nil {
+		logger.Debug("reading directory", "path", dir, "error", err)
+		return true
+	}
+	for _, e := range entries {
+		name := path.Join(dir, e.Name())
+		if e.IsDir() {
+			if !walkFiles(fsys, name, fn) {
+				return false
+			}
+			continue
+		}
+		if !fn(name) {
+			return false
+		}
+	}
+	return true
+}
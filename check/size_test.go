@@ -0,0 +1,121 @@
+package check
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCountFiles(t *testing.T) {
+	tree := newTreeFS(map[string]bool{
+		"services/api/main.go":         false,
+		"services/api/handlers/a.go":   false,
+		"services/api/handlers/b.go":   false,
+		"services/stub/.gitkeep":       false,
+		"services/empty":               true,
+		"services/api/node_modules/x":  false,
+		"services/api/node_modules/y":  false,
+		"services/api/node_modules/zz": false,
+	})
+	fsys := withExcludes(tree, []string{"node_modules"})
+
+	tests := []struct {
+		dir   string
+		limit int
+		want  int
+	}{
+		{"services/api", 10, 3},
+		{"services/api", 2, 2}, // stops at the limit
+		{"services/stub", 10, 1},
+		{"services/empty", 10, 0},
+	}
+	for _, tt := range tests {
+		if got := countFiles(fsys, tt.dir, tt.limit); got != tt.want {
+			t.Errorf("countFiles(%q, %d) = %d, want %d", tt.dir, tt.limit, got, tt.want)
+		}
+	}
+
+	if _, ok := countLines(fsys, "services/api", 10); ok {
+		t.Errorf("countLines() on a tree without contents should not be ok")
+	}
+}
+
+func TestValidateMinSize(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	files := map[string]string{
+		"services/stub/README.md":       "TODO\n",
+		"services/small/main.go":        "package main\n\nfunc main() {}",
+		"services/small/util.go":        "package main\n",
+		"services/big/main.go":          "package main\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Println()\n}\n",
+		"services/big/util.go":          "package main\n",
+		"services/owned/placeholder.md": "",
+	}
+	for name, content := range files {
+		os.MkdirAll(filepath.Join(tmpDir, filepath.Dir(name)), 0755)
+		os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644)
+	}
+	os.MkdirAll(filepath.Join(tmpDir, ".github"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, ".github", "CODEOWNERS"), []byte("/services/owned/ @team-a\n"), 0644)
+
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	ruleset, err := loadCodeowners(context.Background(), nil, providerGitHub)
+	if err != nil {
+		t.Fatalf("loading CODEOWNERS: %v", err)
+	}
+
+	c := &checker{fsys: localFS{}, rules: ruleset, configPath: ".requirecodeowners.yml"}
+	res := c.validate([]dirSpec{{Path: "services", Level: 1, MinFiles: 2, MinLOC: 5}})
+
+	if len(res.errors) != 1 || res.errors[0].path != "services/big" {
+		t.Errorf("validate() errors = %v, want only services/big", res.errors)
+	}
+	want := []skippedDir{
+		{path: "services/small", reason: "min_loc: 4 lines"},
+		{path: "services/stub", reason: "min_files: 1 file"},
+	}
+	if !reflect.DeepEqual(res.skipped, want) {
+		t.Errorf("skipped = %v, want %v", res.skipped, want)
+	}
+}
+
+func TestValidateContains(t *testing.T) {
+	tree := newTreeFS(map[string]bool{
+		"infra/network/main.tf":          false,
+		"infra/dns/modules/zone/main.tf": false,
+		"infra/docs/README.md":           false,
+		"infra/app/Dockerfile":           false,
+		"infra/app/deploy/values.yaml":   false,
+		"infra/charts/deploy/chart.txt":  false,
+	})
+
+	tests := []struct {
+		name     string
+		contains []string
+		want     []string
+	}{
+		{name: "name pattern", contains: []string{"*.tf"}, want: []string{"infra/dns", "infra/network"}},
+		{name: "any pattern", contains: []string{"*.tf", "Dockerfile"}, want: []string{"infra/app", "infra/dns", "infra/network"}},
+		{name: "path pattern", contains: []string{"deploy/*.yaml"}, want: []string{"infra/app"}},
+		{name: "no filter", want: []string{"infra/app", "infra/charts", "infra/dns", "infra/docs", "infra/network"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &checker{fsys: tree, configPath: ".requirecodeowners.yml"}
+			res := c.validate([]dirSpec{{Path: "infra", Level: 1, Contains: tt.contains}})
+			var got []string
+			for _, e := range res.errors {
+				got = append(got, e.path)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("validate() uncovered = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package check
+
+import (
+	"bufio"
+	"bytes"
+	"path/filepath"
+	"strings"
+)
+
+// sparseCheckout tells which paths git's sparse-checkout left out of the
+// working tree, so --sparse-aware can skip them rather than fail them as
+// missing. A path is left out when git has files under it marked
+// skip-worktree, which is how sparse-checkout applies its patterns, in cone
+// mode or not. A nil *sparseCheckout leaves nothing out.
+type sparseCheckout struct {
+	// outside caches the answer for each pattern asked about.
+	outside map[string]bool
+}
+
+// loadSparseCheckout returns the sparse checkout of the repository in the
+// working directory, or nil if it isn't one.
+func loadSparseCheckout() *sparseCheckout {
+	enabled, err := gitLine("config", "--bool", "core.sparseCheckout")
+	if err != nil || enabled != "true" {
+		logger.Info("not a sparse checkout, --sparse-aware has nothing to skip")
+		return nil
+	}
+	return &sparseCheckout{outside: make(map[string]bool)}
+}
+
+// excludes reports whether sparse-checkout left out the directories pattern,
+// a path or glob, names.
+func (s *sparseCheckout) excludes(pattern string) bool {
+	if s == nil {
+		return false
+	}
+	if outside, ok := s.outside[pattern]; ok {
+		return outside
+	}
+	out, err := gitOutput("ls-files", "-t", "--", ":(glob)"+strings.TrimSuffix(filepath.ToSlash(pattern), "/")+"/**")
+	if err != nil {
+		logger.Warn("reading the sparse checkout", "path", pattern, "error", err)
+		return false
+	}
+	outside := false
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		if strings.HasPrefix(scanner.Text(), "S ") {
+			outside = true
+			break
+		}
+	}
+	s.outside[pattern] = outside
+	return outside
+}
+
+// sparseReason is why a directory sparse-checkout left out is skipped.
+const sparseReason = "outside the sparse checkout"
+
+// skipSparse records path, which sparse-checkout left out, as skipped.
+func (c *checker) skipSparse(res *result, path string) {
+	logger.Debug("skipped directory", "path", path, "reason", sparseReason)
+	res.skipped = append(res.skipped, skippedDir{path: path, reason: sparseReason})
+}
@@ -0,0 +1,66 @@
+package check
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestSparseAware(t *testing.T) {
+	gitRepo(t, map[string]string{
+		".requirecodeowners.yml": `directories:
+  - path: services/payments
+  - path: services/search
+  - path: "libs/*"
+  - path: services/gone
+`,
+		"CODEOWNERS":                 "/services/payments/ @org/payments\n",
+		"services/payments/main.go":  "package main\n",
+		"services/search/main.go":    "package main\n",
+		"libs/auth/auth.go":          "package auth\n",
+		"services/payments/README":   "",
+		"services/search/api/api.go": "package api\n",
+	})
+	git(t, "sparse-checkout", "set", "services/payments")
+
+	old := traversal
+	defer func() { traversal = old }()
+	check := func(t *testing.T, f traversalFlags) (errs, skipped []string) {
+		t.Helper()
+		if err := f.apply(); err != nil {
+			t.Fatal(err)
+		}
+		res, err := checkRepo(context.Background(), "", nil, specFilter{})
+		if err != nil {
+			t.Fatalf("checkRepo() error = %v", err)
+		}
+		for _, e := range res.errors {
+			errs = append(errs, fmt.Sprintf("%s %s", e.code, e.path))
+		}
+		for _, d := range res.skipped {
+			skipped = append(skipped, fmt.Sprintf("%s (%s)", d.path, d.reason))
+		}
+		sort.Strings(errs)
+		sort.Strings(skipped)
+		return errs, skipped
+	}
+
+	errs, skipped := check(t, traversalFlags{})
+	if want := []string{"RCO004 libs/*", "RCO004 services/gone", "RCO004 services/search"}; strings.Join(errs, "\n") != strings.Join(want, "\n") {
+		t.Errorf("without --sparse-aware, errors = %v, want %v", errs, want)
+	}
+	if len(skipped) != 0 {
+		t.Errorf("without --sparse-aware, skipped = %v, want none", skipped)
+	}
+
+	errs, skipped = check(t, traversalFlags{sparseAware: true})
+	// services/gone was never committed, so it's missing all the same.
+	if want := []string{"RCO004 services/gone"}; strings.Join(errs, "\n") != strings.Join(want, "\n") {
+		t.Errorf("with --sparse-aware, errors = %v, want %v", errs, want)
+	}
+	if want := []string{"libs/* (outside the sparse checkout)", "services/search (outside the sparse checkout)"}; strings.Join(skipped, "\n") != strings.Join(want, "\n") {
+		t.Errorf("with --sparse-aware, skipped = %v, want %v", skipped, want)
+	}
+}
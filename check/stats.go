@@ -0,0 +1,149 @@
+package check
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+)
+
+// ownershipStats summarizes who owns the checked directories.
+type ownershipStats struct {
+	Checked        int         `json:"checked"`
+	Owned          int         `json:"owned"`
+	Unowned        int         `json:"unowned"`
+	Coverage       float64     `json:"coverage_percent"`
+	DistinctOwners int         `json:"distinct_owners"`
+	Owners         []ownerStat `json:"owners"`
+}
+
+// ownerStat is how many checked directories one owner is listed on. A
+// directory with several owners counts once for each of them.
+type ownerStat struct {
+	Owner       string  `json:"owner"`
+	Directories int     `json:"directories"`
+	Share       float64 `json:"share_percent"`
+}
+
+func runStats(args []string) int {
+	fs := flag.NewFlagSet("stats", flag.ContinueOnError)
+	var format string
+	var rf repoFlags
+	rf.register(fs)
+	var lf configFlags
+	lf.register(fs)
+	fs.StringVar(&format, "format", "text", "output format: text or json")
+	var filter specFilter
+	filter.register(fs)
+	var gf githubFlags
+	gf.register(fs)
+	var tf traversalFlags
+	tf.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if err := lf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	if err := gf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	if err := tf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 2
+	}
+	if format != "text" && format != "json" {
+		fmt.Fprintf(os.Stderr, "error: unknown format %q (want text or json)\n", format)
+		return 2
+	}
+
+	if err := rf.apply(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	res, err := checkRepo(context.Background(), rf.configPath, rf.codeowners, filter)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+
+	stats := computeStats(res.checked)
+	if format == "json" {
+		err = writeStatsJSON(os.Stdout, stats)
+	} else {
+		err = writeStatsText(os.Stdout, stats)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: writing stats: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// computeStats counts ownership across the checked directories. Directories
+// checked by more than one spec are counted once.
+func computeStats(checked []checkedDir) ownershipStats {
+	var stats ownershipStats
+	seen := make(map[string]bool)
+	counts := make(map[string]int)
+	for _, d := range checked {
+		if seen[d.path] {
+			continue
+		}
+		seen[d.path] = true
+		stats.Checked++
+		if d.rule == nil {
+			stats.Unowned++
+			continue
+		}
+		stats.Owned++
+		for _, o := range d.rule.Owners {
+			counts[o.String()]++
+		}
+	}
+	stats.Coverage = percent(stats.Owned, stats.Checked)
+	stats.DistinctOwners = len(counts)
+
+	stats.Owners = make([]ownerStat, 0, len(counts))
+	for owner, n := range counts {
+		stats.Owners = append(stats.Owners, ownerStat{Owner: owner, Directories: n, Share: percent(n, stats.Checked)})
+	}
+	sort.Slice(stats.Owners, func(i, j int) bool {
+		a, b := stats.Owners[i], stats.Owners[j]
+		if a.Directories != b.Directories {
+			return a.Directories > b.Directories
+		}
+		return a.Owner < b.Owner
+	})
+	return stats
+}
+
+func writeStatsText(w io.Writer, stats ownershipStats) error {
+	fmt.Fprintf(w, "Checked directories: %d\n", stats.Checked)
+	fmt.Fprintf(w, "Owned:               %d (%.1f%%)\n", stats.Owned, stats.Coverage)
+	fmt.Fprintf(w, "Unowned:             %d\n", stats.Unowned)
+	fmt.Fprintf(w, "Distinct owners:     %d\n", stats.DistinctOwners)
+	if len(stats.Owners) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(w)
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "OWNER\tDIRECTORIES\tSHARE")
+	for _, o := range stats.Owners {
+		fmt.Fprintf(tw, "%s\t%d\t%.1f%%\n", o.Owner, o.Directories, o.Share)
+	}
+	return tw.Flush()
+}
+
+func writeStatsJSON(w io.Writer, stats ownershipStats) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(stats)
+}
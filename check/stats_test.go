@@ -0,0 +1,72 @@
+package check
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestComputeStats(t *testing.T) {
+	rules, err := parseCodeowners(strings.NewReader(`/services/a/ @org/payments @org/platform
+/services/b/ @org/payments
+/libs/ @org/platform @alice
+`), "CODEOWNERS", providerGitHub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checked := []checkedDir{
+		{path: "services/a", rule: &rules[0]},
+		{path: "services/b", rule: &rules[1]},
+		{path: "services/c"},
+		{path: "libs", rule: &rules[2]},
+		{path: "services/a", rule: &rules[0]}, // checked by two specs
+	}
+
+	got := computeStats(checked)
+	want := ownershipStats{
+		Checked:        4,
+		Owned:          3,
+		Unowned:        1,
+		Coverage:       75,
+		DistinctOwners: 3,
+		Owners: []ownerStat{
+			{Owner: "@org/payments", Directories: 2, Share: 50},
+			{Owner: "@org/platform", Directories: 2, Share: 50},
+			{Owner: "@alice", Directories: 1, Share: 25},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("computeStats() = %+v, want %+v", got, want)
+	}
+}
+
+func TestWriteStats(t *testing.T) {
+	stats := ownershipStats{
+		Checked: 2, Owned: 1, Unowned: 1, Coverage: 50, DistinctOwners: 1,
+		Owners: []ownerStat{{Owner: "@org/payments", Directories: 1, Share: 50}},
+	}
+
+	var text bytes.Buffer
+	if err := writeStatsText(&text, stats); err != nil {
+		t.Fatalf("writeStatsText() error = %v", err)
+	}
+	for _, want := range []string{"Owned:               1 (50.0%)", "@org/payments  1            50.0%"} {
+		if !strings.Contains(text.String(), want) {
+			t.Errorf("text stats missing %q:\n%s", want, text.String())
+		}
+	}
+
+	var out bytes.Buffer
+	if err := writeStatsJSON(&out, stats); err != nil {
+		t.Fatalf("writeStatsJSON() error = %v", err)
+	}
+	var decoded ownershipStats
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatalf("writeStatsJSON() wrote invalid JSON: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, stats) {
+		t.Errorf("JSON round trip = %+v, want %+v", decoded, stats)
+	}
+}
@@ -0,0 +1,126 @@
+package check
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+)
+
+// stdinSource is the --config or --codeowners-path that's read from stdin.
+const stdinSource = "-"
+
+// stdinLabel names stdin in findings and messages.
+const stdinLabel = "<stdin>"
+
+// stdinContent is what was read from stdin for the config and CODEOWNERS
+// given as stdinSource.
+type stdinContent struct {
+	read       bool
+	config     []byte
+	codeowners []byte
+}
+
+// stdinInput is stdin's content. Stdin can only be read once, so it's kept
+// for every load.
+var stdinInput stdinContent
+
+// stdinEnvelope is stdin when both the config and CODEOWNERS come from it.
+type stdinEnvelope struct {
+	// Config is the config's YAML or JSON as a string, or the config itself
+	// as a JSON object.
+	Config     json.RawMessage `json:"config"`
+	Codeowners *string         `json:"codeowners"`
+}
+
+// readStdinSources reads r, unless stdin was read already, when the config
+// or a CODEOWNERS source is stdinSource. Either one alone is all of r. Both
+// at once are a JSON envelope:
+//
+//	{"config": "directories:\n  - path: services\n", "codeowners": "/services/ @org/services\n"}
+func readStdinSources(r io.Reader, configPath string, codeownersPaths []string) error {
+	fromConfig := configPath == stdinSource
+	n := 0
+	for _, p := range codeownersPaths {
+		if p == stdinSource {
+			n++
+		}
+	}
+	if n > 1 {
+		return fmt.Errorf("--codeowners-path %s can only be given once", stdinSource)
+	}
+	if stdinInput.read || !fromConfig && n == 0 {
+		return nil
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("reading stdin: %w", err)
+	}
+	stdinInput.read = true
+	switch {
+	case n == 0:
+		stdinInput.config = data
+	case !fromConfig:
+		stdinInput.codeowners = data
+	default:
+		var env stdinEnvelope
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&env); err != nil {
+			return fmt.Errorf("reading stdin: with --config - and --codeowners-path -, stdin is a JSON object with config and codeowners: %w", err)
+		}
+		if env.Config == nil || env.Codeowners == nil {
+			return errors.New("reading stdin: with --config - and --codeowners-path -, stdin is a JSON object with both config and codeowners")
+		}
+		// A JSON object is YAML already.
+		var text string
+		if err := json.Unmarshal(env.Config, &text); err == nil {
+			stdinInput.config = []byte(text)
+		} else {
+			stdinInput.config = env.Config
+		}
+		stdinInput.codeowners = []byte(*env.Codeowners)
+	}
+	return nil
+}
+
+// readStdinConfig returns the config read from stdin, reading it now if
+// nothing has.
+func readStdinConfig() ([]byte, error) {
+	if err := readStdinSources(os.Stdin, stdinSource, nil); err != nil {
+		return nil, err
+	}
+	if stdinInput.config == nil {
+		return nil, errors.New("stdin was read for CODEOWNERS; pass --config - along with --codeowners-path - to read both from it")
+	}
+	return stdinInput.config, nil
+}
+
+// readStdinCodeowners returns the CODEOWNERS read from stdin, reading it now
+// if nothing has.
+func readStdinCodeowners() ([]byte, error) {
+	if err := readStdinSources(os.Stdin, "", []string{stdinSource}); err != nil {
+		return nil, err
+	}
+	if stdinInput.codeowners == nil {
+		return nil, errors.New("stdin was read for the config; pass --codeowners-path - along with --config - to read both from it")
+	}
+	return stdinInput.codeowners, nil
+}
+
+// sourceLabel returns how path is named in findings: as given, or
+// stdinLabel for stdin.
+func sourceLabel(path string) string {
+	if path == stdinSource {
+		return stdinLabel
+	}
+	return path
+}
+
+// hasStdinSource reports whether any of paths is stdin.
+func hasStdinSource(paths []string) bool {
+	return slices.Contains(paths, stdinSource)
+}
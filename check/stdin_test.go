@@ -0,0 +1,131 @@
+package check
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadStdinSources(t *testing.T) {
+	tests := []struct {
+		name           string
+		stdin          string
+		configPath     string
+		codeowners     []string
+		wantConfig     string
+		wantCodeowners string
+		wantErr        string
+	}{
+		{
+			name:       "config",
+			stdin:      "directories:\n  - path: services\n",
+			configPath: "-",
+			codeowners: []string{"CODEOWNERS"},
+			wantConfig: "directories:\n  - path: services\n",
+		},
+		{
+			name:           "codeowners",
+			stdin:          "/services/ @org/services\n",
+			codeowners:     []string{"-", "teams.owners"},
+			wantCodeowners: "/services/ @org/services\n",
+		},
+		{
+			name:           "envelope",
+			stdin:          `{"config": "directories:\n  - path: services\n", "codeowners": "* @org/all\n"}`,
+			configPath:     "-",
+			codeowners:     []string{"-"},
+			wantConfig:     "directories:\n  - path: services\n",
+			wantCodeowners: "* @org/all\n",
+		},
+		{
+			name:           "envelope with a config object",
+			stdin:          `{"config": {"directories": [{"path": "services"}]}, "codeowners": ""}`,
+			configPath:     "-",
+			codeowners:     []string{"-"},
+			wantConfig:     `{"directories": [{"path": "services"}]}`,
+			wantCodeowners: "",
+		},
+		{
+			name:       "envelope missing codeowners",
+			stdin:      `{"config": "directories: []"}`,
+			configPath: "-",
+			codeowners: []string{"-"},
+			wantErr:    "a JSON object with both config and codeowners",
+		},
+		{
+			name:       "not an envelope",
+			stdin:      "directories:\n  - path: services\n",
+			configPath: "-",
+			codeowners: []string{"-"},
+			wantErr:    "stdin is a JSON object with config and codeowners",
+		},
+		{
+			name:       "unknown envelope key",
+			stdin:      `{"config": "", "codeowners": "", "extra": 1}`,
+			configPath: "-",
+			codeowners: []string{"-"},
+			wantErr:    `unknown field "extra"`,
+		},
+		{
+			name:       "codeowners twice",
+			codeowners: []string{"-", "-"},
+			wantErr:    "--codeowners-path - can only be given once",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() { stdinInput = stdinContent{} }()
+			err := readStdinSources(strings.NewReader(tt.stdin), tt.configPath, tt.codeowners)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if string(stdinInput.config) != tt.wantConfig {
+				t.Errorf("config = %q, want %q", stdinInput.config, tt.wantConfig)
+			}
+			if string(stdinInput.codeowners) != tt.wantCodeowners {
+				t.Errorf("codeowners = %q, want %q", stdinInput.codeowners, tt.wantCodeowners)
+			}
+		})
+	}
+}
+
+func TestCheckRepoStdin(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tmpDir, "services/a"), 0755)
+	os.MkdirAll(filepath.Join(tmpDir, "services/b"), 0755)
+	// A config on disk that stdin takes the place of.
+	os.WriteFile(filepath.Join(tmpDir, ".requirecodeowners.yml"), []byte("directories:\n  - path: libs\n"), 0644)
+
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+	defer func() { stdinInput = stdinContent{} }()
+
+	stdin := `{"config": {"directories": [{"path": "services", "level": 1}]}, "codeowners": "/services/a/ @org/a\n"}`
+	if err := readStdinSources(strings.NewReader(stdin), "-", []string{"-"}); err != nil {
+		t.Fatalf("readStdinSources() error = %v", err)
+	}
+	res, err := checkRepo(context.Background(), "-", []string{"-"}, specFilter{})
+	if err != nil {
+		t.Fatalf("checkRepo() error = %v", err)
+	}
+	if len(res.errors) != 1 || res.errors[0].path != "services/b" || res.errors[0].code != codeMissingEntry {
+		t.Fatalf("errors = %+v, want services/b missing an entry", res.errors)
+	}
+	if res.errors[0].file != stdinLabel {
+		t.Errorf("file = %q, want %s", res.errors[0].file, stdinLabel)
+	}
+	for _, d := range res.checked {
+		if d.rule != nil && d.rule.file != stdinLabel {
+			t.Errorf("%s rule file = %q, want %s", d.path, d.rule.file, stdinLabel)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package check
+
+import (
+	"io/fs"
+	"path"
+	"path/filepath"
+	"slices"
+)
+
+// Submodule modes say what expanding levels and globs does with a git
+// submodule under a spec.
+const (
+	submodulesUnit    = "unit"    // check it as one directory, without expanding into it
+	submodulesDescend = "descend" // expand into it like any other directory
+	submodulesSkip    = "skip"    // leave it out
+)
+
+// submoduleFS applies a submodule mode to expansion. A submodule is a
+// directory, other than the working directory, with a .git in it, as a
+// checked-out submodule has. In unit mode it lists no subdirectories of a
+// submodule, and records each one expansion tried to go into, to be checked
+// in place of its contents. In skip mode it lists no submodules at all. A path named
+// literally in the config is used as is.
+type submoduleFS struct {
+	fileSystem
+	mode string
+
+	known map[string]bool
+	units []string
+}
+
+func (s *submoduleFS) isSubmodule(dir string) bool {
+	dir = path.Clean(filepath.ToSlash(dir))
+	if dir == "." {
+		return false
+	}
+	if is, ok := s.known[dir]; ok {
+		return is
+	}
+	_, err := s.fileSystem.Stat(path.Join(dir, ".git"))
+	if s.known == nil {
+		s.known = make(map[string]bool)
+	}
+	s.known[dir] = err == nil
+	return err == nil
+}
+
+func (s *submoduleFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := s.fileSystem.ReadDir(name)
+	if err != nil {
+		return nil, err
+	}
+	kept := entries[:0:0]
+	switch {
+	case s.mode == submodulesUnit && s.isSubmodule(name):
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				kept = append(kept, entry)
+			}
+		}
+		if dir := path.Clean(filepath.ToSlash(name)); !slices.Contains(s.units, dir) {
+			s.units = append(s.units, dir)
+		}
+	case s.mode == submodulesSkip:
+		for _, entry := range entries {
+			if !entry.IsDir() || !s.isSubmodule(path.Join(name, entry.Name())) {
+				kept = append(kept, entry)
+			}
+		}
+	default:
+		return entries, nil
+	}
+	return kept, nil
+}
+
+// Glob leaves out matches that a wildcard found in or under a skipped
+// submodule. In unit mode, a match under a submodule a wildcard found is
+// replaced with the submodule.
+func (s *submoduleFS) Glob(pattern string) ([]string, error) {
+	matches, err := s.fileSystem.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	kept := matches[:0:0]
+	for _, m := range matches {
+		sub := ""
+		for _, p := range expandedPrefixes(pattern, m) {
+			if s.isSubmodule(p) {
+				sub = p
+				break
+			}
+		}
+		switch {
+		case sub == "":
+			kept = append(kept, m)
+		case s.mode == submodulesUnit && !slices.Contains(kept, sub):
+			kept = append(kept, sub)
+		}
+	}
+	return kept, nil
+}
+
+// cutAny reports whether any submodule was checked in place of its
+// contents. A nil *submoduleFS cuts nothing.
+func (s *submoduleFS) cutAny() bool {
+	return s != nil && len(s.units) > 0
+}
+
+// submoduleMode returns how the spec expands submodules.
+func (d dirSpec) submoduleMode() string {
+	if d.Submodules == "" {
+		return submodulesUnit
+	}
+	return d.Submodules
+}
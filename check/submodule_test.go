@@ -0,0 +1,102 @@
+package check
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestSubmodules(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, d := range []string{"services/api/cmd", "services/vendored/lib", "services/vendored/docs"} {
+		os.MkdirAll(filepath.Join(tmpDir, d), 0755)
+	}
+	// A checked-out submodule has a .git file pointing into the parent's.
+	os.WriteFile(filepath.Join(tmpDir, "services/vendored/.git"), []byte("gitdir: ../../.git/modules/vendored\n"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "CODEOWNERS"), []byte("* @org/all\n"), 0644)
+
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	tests := []struct {
+		mode    string
+		want    map[string][]string
+		wantErr string
+	}{
+		{
+			mode: "",
+			want: map[string][]string{
+				"levels": {"services/api/cmd", "services/vendored"},
+				"glob":   {"services/api", "services/vendored"},
+				"inside": {"services/vendored"},
+			},
+		},
+		{
+			mode: submodulesDescend,
+			want: map[string][]string{
+				"levels": {"services/api/cmd", "services/vendored/docs", "services/vendored/lib"},
+				"glob":   {"services/api", "services/vendored"},
+				"inside": {"services/vendored/lib"},
+			},
+		},
+		{
+			mode: submodulesSkip,
+			want: map[string][]string{
+				"levels": {"services/api/cmd"},
+				"glob":   {"services/api"},
+			},
+		},
+		{
+			mode:    "flatten",
+			wantErr: `directory services has invalid submodules "flatten" (must be "unit", "descend", or "skip")`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("mode %q", tt.mode), func(t *testing.T) {
+			config := fmt.Sprintf(`defaults:
+  submodules: %q
+directories:
+  - name: levels
+    path: services
+    level: 2
+  - name: glob
+    path: "services/*"
+  - name: inside
+    path: "services/*/lib"
+`, tt.mode)
+			if err := os.WriteFile(".requirecodeowners.yml", []byte(config), 0644); err != nil {
+				t.Fatal(err)
+			}
+			res, err := checkRepo(context.Background(), "", nil, specFilter{})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("checkRepo() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("checkRepo() error = %v", err)
+			}
+			got := make(map[string][]string)
+			for _, d := range res.checked {
+				got[d.spec] = append(got[d.spec], d.path)
+			}
+			for _, dirs := range got {
+				sort.Strings(dirs)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("checked = %v, want %v", got, tt.want)
+			}
+			// Skipping a submodule leaves nothing for the glob inside it.
+			if tt.mode == submodulesSkip && (len(res.errors) != 1 || res.errors[0].code != codeNoMatch || res.errors[0].spec != "inside") {
+				t.Errorf("errors = %+v, want a no-match for inside", res.errors)
+			}
+		})
+	}
+}
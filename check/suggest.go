@@ -0,0 +1,95 @@
+package check
+
+import (
+	"path"
+	"strings"
+)
+
+// suggestRule returns the rule dir was most likely meant to match, for a
+// "did you mean" hint when nothing covers it: one whose pattern is a typo
+// away from dir, or one for a directory with the same name somewhere else.
+// Patterns are compared segment by segment, so a typo has to be in a name at
+// the same depth, and a rule for a parent or child of dir is never taken for
+// one. It returns nil if no rule is close.
+func (c *checker) suggestRule(dir string) *rule {
+	segments := strings.Split(dir, "/")
+	// Any typo ranks above a directory with the same name elsewhere.
+	sameName := 1
+	for _, s := range segments {
+		sameName += maxTypoDistance(s)
+	}
+
+	var best *rule
+	bestScore := -1
+	for i := range c.rules {
+		r := &c.rules[i]
+		if len(r.Owners) == 0 || isWildcardRule(r) {
+			continue
+		}
+		pattern := strings.Trim(strings.TrimSuffix(r.RawPattern(), "/**"), "/")
+		if pattern == "" || strings.HasPrefix(pattern, dir+"/") || strings.HasPrefix(dir, pattern+"/") {
+			continue
+		}
+		score := typoDistance(strings.Split(pattern, "/"), segments)
+		if score < 0 && len(path.Base(dir)) >= 3 && path.Base(pattern) == path.Base(dir) {
+			score = sameName
+		}
+		if score >= 0 && (best == nil || score < bestScore) {
+			best, bestScore = r, score
+		}
+	}
+	return best
+}
+
+// typoDistance returns how many edits turn the path segments of a pattern
+// into those of dir, or -1 if they differ in depth or any segment is more
+// than a typo away from dir's.
+func typoDistance(pattern, dir []string) int {
+	if len(pattern) != len(dir) {
+		return -1
+	}
+	total := 0
+	for i := range dir {
+		d := editDistance(pattern[i], dir[i])
+		if d > maxTypoDistance(dir[i]) {
+			return -1
+		}
+		total += d
+	}
+	return total
+}
+
+// maxTypoDistance is how many edits away from name another may be and still
+// count as a typo of it. Short names get less leeway, since
+// one or two edits can turn them into unrelated names.
+func maxTypoDistance(name string) int {
+	switch n := len(name); {
+	case n < 3:
+		return 0
+	case n < 6:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// editDistance returns the Levenshtein distance between a and b, in bytes.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,88 @@
+package check
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"services/foo", "services/foo", 0},
+		{"services/fooo", "services/foo", 1},
+		{"service/foo", "services/foo", 1},
+		{"services/fo", "services/bar", 3},
+		{"", "abc", 3},
+	}
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestSuggestRule(t *testing.T) {
+	rules, err := parseCodeowners(strings.NewReader(`* @org/everyone
+/services/payment/ @org/payments
+/servics/search/ @org/search
+/apps/billing/ @org/billing
+/libs/io/ @org/io
+/services/unowned/
+/services/a/ @org/a
+`), "CODEOWNERS", providerGitHub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &checker{rules: rules}
+
+	tests := []struct {
+		dir  string
+		want string
+	}{
+		{"services/payments", "/services/payment/"},
+		{"services/search", "/servics/search/"},
+		{"services/billing", "/apps/billing/"},
+		{"services/io", ""},
+		{"services/unowned", ""},
+		{"services/accounts", ""},
+		// Rules for a parent or child are a different directory, not a typo.
+		{"services", ""},
+		{"apps/billing/billing", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dir, func(t *testing.T) {
+			got := ""
+			if r := c.suggestRule(tt.dir); r != nil {
+				got = r.RawPattern()
+			}
+			if got != tt.want {
+				t.Errorf("suggestRule(%q) = %q, want %q", tt.dir, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckRepoSuggestsRule(t *testing.T) {
+	tmpDir := t.TempDir()
+	os.MkdirAll(filepath.Join(tmpDir, "services/foo"), 0755)
+	os.WriteFile(filepath.Join(tmpDir, ".requirecodeowners.yml"), []byte("directories:\n  - path: services\n    level: 1\n"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "CODEOWNERS"), []byte("/services/fooo/ @org/foo\n"), 0644)
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	res, err := checkRepo(context.Background(), "", nil, specFilter{})
+	if err != nil {
+		t.Fatalf("checkRepo() error = %v", err)
+	}
+	want := "Not covered by CODEOWNERS. Did you mean CODEOWNERS:1: /services/fooo/? Otherwise add: /services/foo/ @your-team"
+	if len(res.errors) != 1 || res.errors[0].message != want {
+		t.Errorf("errors = %v, want %q", res.errors, want)
+	}
+}
@@ -0,0 +1,186 @@
+package check
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// suppressionDirective starts a suppression in a CODEOWNERS rule's trailing
+// comment.
+const suppressionDirective = "requirecodeowners:ignore"
+
+// suppressionDateLayout is how expiry dates are written.
+const suppressionDateLayout = "2006-01-02"
+
+// suppression is a temporary exception: problems with its codes at its path
+// are left out of the report until it expires. It comes from the config's
+// suppressions block, or from a comment on a CODEOWNERS rule, in which case
+// it applies to problems located at that rule.
+type suppression struct {
+	Codes   stringList `yaml:"code"`
+	Path    string     `yaml:"path"`
+	Reason  string     `yaml:"reason"`
+	Expires string     `yaml:"expires"`
+
+	// file and line are where the suppression is written. For a CODEOWNERS
+	// comment, they're also what it applies to.
+	file    string
+	line    int
+	rule    bool
+	expires time.Time // the last day it applies; zero for never
+}
+
+// location returns a file:line reference for the suppression.
+func (s *suppression) location() string {
+	if s.line == 0 {
+		return s.file
+	}
+	return fmt.Sprintf("%s:%d", s.file, s.line)
+}
+
+// validate normalizes the codes and parses the expiry date.
+func (s *suppression) validate() error {
+	if len(s.Codes) == 0 {
+		return fmt.Errorf("needs a code")
+	}
+	for i, c := range s.Codes {
+		code, ok := lookupCode(c)
+		if !ok {
+			return fmt.Errorf("unknown code %q", c)
+		}
+		s.Codes[i] = code
+	}
+	if s.Expires != "" {
+		t, err := time.Parse(suppressionDateLayout, s.Expires)
+		if err != nil {
+			return fmt.Errorf("expires %q is not a date like 2006-01-02", s.Expires)
+		}
+		s.expires = t
+	}
+	return nil
+}
+
+// expired reports whether the suppression no longer applies at now.
+func (s *suppression) expired(now time.Time) bool {
+	return !s.expires.IsZero() && !now.Before(s.expires.AddDate(0, 0, 1))
+}
+
+// matches reports whether the suppression covers e, ignoring its expiry.
+func (s *suppression) matches(e validationError) bool {
+	if !slices.Contains(s.Codes, e.code) {
+		return false
+	}
+	if s.rule {
+		return e.file == s.file && e.line == s.line
+	}
+	// Like allow_unowned, a path covers the problems below it.
+	for p := path.Clean(filepath.ToSlash(e.path)); p != "." && p != "/"; p = path.Dir(p) {
+		if ok, _ := path.Match(strings.Trim(s.Path, "/"), p); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ruleSuppressions returns the suppressions written in the trailing comments
+// of rules, like:
+//
+//	/services/legacy/ @org/legacy # requirecodeowners:ignore RCO006 expires:2026-12-31 hiring a second owner
+//
+// Several codes are separated by commas. Anything after the codes and the
+// expiry is the reason.
+func ruleSuppressions(rules ruleset) ([]suppression, error) {
+	var suppressions []suppression
+	for _, r := range rules {
+		_, directive, ok := strings.Cut(r.Comment, suppressionDirective)
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(directive)
+		s := suppression{file: r.file, line: r.LineNumber, rule: true}
+		if len(fields) > 0 {
+			s.Codes = strings.Split(fields[0], ",")
+			fields = fields[1:]
+		}
+		if len(fields) > 0 {
+			if date, ok := strings.CutPrefix(fields[0], "expires:"); ok {
+				s.Expires = date
+				fields = fields[1:]
+			}
+		}
+		s.Reason = strings.Join(fields, " ")
+		if err := s.validate(); err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", r.location(), suppressionDirective, err)
+		}
+		suppressions = append(suppressions, s)
+	}
+	return suppressions, nil
+}
+
+// suppressedError is a problem that a suppression left out of the report.
+type suppressedError struct {
+	validationError
+	by *suppression
+}
+
+// suppress drops the problems in errs that are disabled with --disable or
+// covered by one of r's suppressions, in place, and returns what's left.
+// Suppressed problems are kept in r.suppressed so they can be listed. A
+// problem whose suppression has expired is kept, and says so.
+func (r *result) suppress(errs []validationError) []validationError {
+	now := time.Now()
+	kept := errs[:0]
+	for _, e := range errs {
+		if e.mandatory {
+			kept = append(kept, e)
+			continue
+		}
+		if disabledCodes[e.code] {
+			continue
+		}
+		var by, expired *suppression
+		for i := range r.suppressions {
+			s := &r.suppressions[i]
+			if !s.matches(e) {
+				continue
+			}
+			if !s.expired(now) {
+				by = s
+				break
+			}
+			if expired == nil {
+				expired = s
+			}
+		}
+		if by == nil && expired != nil {
+			e.message = fmt.Sprintf("%s A suppression for this expired on %s. Fix the problem, or extend it in %s.", e.message, expired.Expires, expired.location())
+		}
+		if by != nil {
+			r.suppressed = append(r.suppressed, suppressedError{validationError: e, by: by})
+			continue
+		}
+		kept = append(kept, e)
+	}
+	return kept
+}
+
+// printSuppressed lists the problems that were suppressed, and by what, so
+// exceptions stay visible.
+func printSuppressed(w io.Writer, suppressed []suppressedError) {
+	fmt.Fprintf(w, "%d %s suppressed:\n", len(suppressed), pluralize(len(suppressed), "problem", "problems"))
+	for _, e := range suppressed {
+		detail := e.by.location()
+		if e.by.Expires != "" {
+			detail += ", until " + e.by.Expires
+		}
+		if e.by.Reason != "" {
+			detail += ": " + e.by.Reason
+		}
+		fmt.Fprintf(w, "  %s %s (%s)\n", e.path, e.code, detail)
+	}
+}
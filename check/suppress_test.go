@@ -0,0 +1,117 @@
+package check
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRuleSuppressions(t *testing.T) {
+	rules, err := parseCodeowners(strings.NewReader(`/services/ @org/services
+/services/legacy/ @org/legacy # requirecodeowners:ignore RCO006,forbidden-owner expires:2026-12-31 hiring a second owner
+/libs/ @org/libs # requirecodeowners:ignore too-few-owners
+`), "CODEOWNERS", providerGitHub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ruleSuppressions(rules)
+	if err != nil {
+		t.Fatalf("ruleSuppressions() error = %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("ruleSuppressions() = %+v, want 2", got)
+	}
+	if s := got[0]; strings.Join(s.Codes, ",") != "RCO006,RCO022" || s.Expires != "2026-12-31" || s.Reason != "hiring a second owner" || s.location() != "CODEOWNERS:2" {
+		t.Errorf("suppressions[0] = %+v", s)
+	}
+	if s := got[1]; strings.Join(s.Codes, ",") != "RCO006" || !s.expires.IsZero() || s.Reason != "" {
+		t.Errorf("suppressions[1] = %+v", s)
+	}
+
+	rules, _ = parseCodeowners(strings.NewReader("/libs/ @org/libs # requirecodeowners:ignore expires:2026-12-31\n"), "CODEOWNERS", providerGitHub)
+	if _, err := ruleSuppressions(rules); err == nil || !strings.Contains(err.Error(), "CODEOWNERS:1") {
+		t.Errorf("ruleSuppressions() error = %v, want an unknown code at CODEOWNERS:1", err)
+	}
+}
+
+func TestResultSuppress(t *testing.T) {
+	future := time.Now().AddDate(1, 0, 0).Format(suppressionDateLayout)
+	today := time.Now().Format(suppressionDateLayout)
+	suppressions := []suppression{
+		{Codes: []string{codeMissingEntry}, Path: "services/legacy", Reason: "moving to payments", Expires: future, file: ".requirecodeowners.yml", line: 8},
+		{Codes: []string{codeMissingEntry}, Path: "services/old-*", Reason: "being deleted", Expires: "2020-01-31", file: ".requirecodeowners.yml", line: 12},
+		{Codes: []string{codeMissingEntry}, Path: "services/today", Reason: "last day", Expires: today, file: ".requirecodeowners.yml", line: 16},
+		{Codes: []string{codeTooFewOwners}, file: "CODEOWNERS", line: 3, rule: true},
+	}
+	for i := range suppressions {
+		if err := suppressions[i].validate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	errs := []validationError{
+		{path: "services/legacy/api", message: "Not covered.", code: codeMissingEntry},
+		{path: "services/legacy", message: "Directory not found.", code: codeDirNotExist},
+		{path: "services/old-web", message: "Not covered.", code: codeMissingEntry},
+		{path: "services/today", message: "Not covered.", code: codeMissingEntry},
+		{path: "libs/util", message: "Has 1 owner.", code: codeTooFewOwners, file: "CODEOWNERS", line: 3},
+		{path: "libs/io", message: "Has 1 owner.", code: codeTooFewOwners, file: "CODEOWNERS", line: 4},
+	}
+	res := result{suppressions: suppressions}
+	kept := res.suppress(errs)
+
+	var paths []string
+	for _, e := range kept {
+		paths = append(paths, e.path)
+	}
+	if got, want := strings.Join(paths, ","), "services/legacy,services/old-web,libs/io"; got != want {
+		t.Errorf("kept = %s, want %s", got, want)
+	}
+	if !strings.Contains(kept[1].message, "expired on 2020-01-31") || !strings.Contains(kept[1].message, ".requirecodeowners.yml:12") {
+		t.Errorf("expired message = %q, want the expiry and where to extend it", kept[1].message)
+	}
+	if len(res.suppressed) != 3 {
+		t.Fatalf("suppressed = %v, want 3", res.suppressed)
+	}
+
+	var buf bytes.Buffer
+	printSuppressed(&buf, res.suppressed)
+	if want := "  services/legacy/api RCO001 (.requirecodeowners.yml:8, until " + future + ": moving to payments)\n"; !strings.Contains(buf.String(), want) {
+		t.Errorf("printSuppressed() = %q, want it to contain %q", buf.String(), want)
+	}
+}
+
+func TestCheckRepoSuppressions(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, d := range []string{"services/api", "services/legacy"} {
+		os.MkdirAll(filepath.Join(tmpDir, d), 0755)
+	}
+	os.WriteFile(filepath.Join(tmpDir, ".requirecodeowners.yml"), []byte(`directories:
+  - path: services
+    level: 1
+    min_owners: 2
+suppressions:
+  - code: missing-entry
+    path: services/legacy
+    reason: moving to payments
+`), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "CODEOWNERS"), []byte("/services/api/ @org/api # requirecodeowners:ignore RCO006\n"), 0644)
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	res, err := checkRepo(context.Background(), "", nil, specFilter{})
+	if err != nil {
+		t.Fatalf("checkRepo() error = %v", err)
+	}
+	if len(res.errors) != 0 {
+		t.Errorf("errors = %v, want all suppressed", res.errors)
+	}
+	if len(res.suppressed) != 2 || res.suppressed[0].by.location() != "CODEOWNERS:1" || res.suppressed[1].by.location() != ".requirecodeowners.yml:6" {
+		t.Errorf("suppressed = %+v, want the CODEOWNERS and config suppressions", res.suppressed)
+	}
+}
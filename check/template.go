@@ -0,0 +1,128 @@
+package check
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"text/template"
+)
+
+// templateData is what a --template report can refer to.
+type templateData struct {
+	Errors []templateError
+	Stats  templateStats
+	Config templateConfig
+}
+
+type templateError struct {
+	Path     string
+	Message  string
+	Severity string
+	Code     string
+	Spec     string
+	Team     string
+	File     string
+	Line     int
+}
+
+type templateStats struct {
+	Checked  int
+	Owned    int
+	Unowned  int
+	Skipped  int // uncovered directories allowed to be unowned
+	Failures int
+	Warnings int
+	Coverage float64 // percentage of checked directories that are owned
+}
+
+type templateConfig struct {
+	Path       string
+	Codeowners []string
+	Specs      []templateSpec
+}
+
+type templateSpec struct {
+	Name         string
+	Path         string
+	Mode         string
+	Level        int
+	Levels       []int // every level checked, shallowest first
+	Excludes     []string
+	Severity     string
+	MinOwners    int
+	DefaultOwner string
+	Tags         []string
+}
+
+var templateFuncs = template.FuncMap{
+	"pluralize": pluralize,
+}
+
+// writeTemplate renders the report through the text/template at
+// opts.templatePath.
+func writeTemplate(w io.Writer, res result, opts reportOptions) error {
+	if opts.templatePath == "" {
+		return fmt.Errorf("--format template requires --template")
+	}
+	text, err := os.ReadFile(opts.templatePath)
+	if err != nil {
+		return fmt.Errorf("reading template: %w", err)
+	}
+	tmpl, err := template.New(filepath.Base(opts.templatePath)).Funcs(templateFuncs).Parse(string(text))
+	if err != nil {
+		return fmt.Errorf("parsing template: %w", err)
+	}
+	return tmpl.Execute(w, newTemplateData(res))
+}
+
+func newTemplateData(res result) templateData {
+	owned, checked := res.coverage()
+	failures := countFailures(res.errors)
+	data := templateData{
+		Stats: templateStats{
+			Checked:  checked,
+			Owned:    owned,
+			Unowned:  checked - owned,
+			Skipped:  len(res.skipped),
+			Failures: failures,
+			Warnings: len(res.errors) - failures,
+			Coverage: percent(owned, checked),
+		},
+		Config: templateConfig{
+			Path:       res.configPath,
+			Codeowners: res.codeowners,
+		},
+	}
+	for _, e := range res.errors {
+		severity := e.severity
+		if severity == "" {
+			severity = severityError
+		}
+		data.Errors = append(data.Errors, templateError{
+			Path:     e.path,
+			Message:  e.message,
+			Severity: severity,
+			Code:     e.code,
+			Spec:     e.spec,
+			Team:     e.team,
+			File:     e.file,
+			Line:     e.line,
+		})
+	}
+	for _, spec := range res.specs {
+		data.Config.Specs = append(data.Config.Specs, templateSpec{
+			Name:         spec.Name,
+			Path:         spec.Path,
+			Mode:         spec.Mode,
+			Level:        spec.Level,
+			Levels:       spec.depths(),
+			Excludes:     spec.Excludes,
+			Severity:     spec.Severity,
+			MinOwners:    spec.MinOwners,
+			DefaultOwner: spec.DefaultOwner,
+			Tags:         spec.Tags,
+		})
+	}
+	return data
+}
@@ -0,0 +1,38 @@
+package check
+
+import (
+	"io/fs"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// terraformRootBlock matches the blocks that make a Terraform module a root
+// module: a provider configuration, or a backend or cloud block in the
+// terraform settings.
+var terraformRootBlock = regexp.MustCompile(`(?m)^\s*(provider\s+"[^"]+"|backend\s+"[^"]+"|cloud)\s*\{`)
+
+// terraformModules returns the directories at or below root that hold *.tf
+// files. With rootsOnly, only root modules count: those whose files
+// configure a provider or backend. A module whose files can't be read counts
+// as a root, so it isn't silently left unchecked.
+func terraformModules(fsys fileSystem, root string, rootsOnly bool) ([]string, error) {
+	return findDirs(fsys, root, func(dir string, entries []fs.DirEntry) bool {
+		var files []string
+		for _, e := range entries {
+			if !e.IsDir() && strings.HasSuffix(e.Name(), ".tf") {
+				files = append(files, path.Join(dir, e.Name()))
+			}
+		}
+		if len(files) == 0 || !rootsOnly {
+			return len(files) > 0
+		}
+		for _, name := range files {
+			data, err := fsys.ReadFile(name)
+			if err != nil || terraformRootBlock.Match(data) {
+				return true
+			}
+		}
+		return false
+	})
+}
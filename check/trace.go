@@ -0,0 +1,197 @@
+package check
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer records OpenTelemetry spans for one run and exports them in
+// batches over OTLP/HTTP. The exporter, batching and sampling follow the
+// standard OTEL_* variables, and a TRACEPARENT in the environment, as CI
+// systems set for a job, makes the run part of that trace. The check runs
+// on a single goroutine, so each new span is a child of the innermost span
+// that's still open.
+type tracer struct {
+	provider *sdktrace.TracerProvider
+	tracer   trace.Tracer
+	parent   context.Context // carries the TRACEPARENT span, if any
+	open     []*span
+	started  map[string]int // spans started, by name
+	dropped  map[string]int // spans not recorded past maxSpansPerName, by name
+}
+
+// span is one timed step of a run.
+type span struct {
+	t    *tracer
+	ctx  context.Context
+	span trace.Span
+}
+
+// maxSpansPerName caps the spans recorded for each step name. Steps that
+// run for every directory a spec matches, like expand and match, would
+// otherwise send thousands of spans for a large tree; the ones past the
+// cap are counted on the run's root span instead.
+const maxSpansPerName = 100
+
+// tracing is the tracer for this run, or nil unless tracingEnabled.
+var tracing *tracer
+
+// tracingEnabled reports whether the run should be traced: with
+// --otel-endpoint, or when the environment names an OTLP endpoint and
+// doesn't turn tracing off.
+func tracingEnabled(endpoint string) bool {
+	if os.Getenv("OTEL_SDK_DISABLED") == "true" || os.Getenv("OTEL_TRACES_EXPORTER") == "none" {
+		return false
+	}
+	return endpoint != "" || os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" || os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != ""
+}
+
+// newTracer returns a tracer that exports to the OTLP/HTTP endpoint, such as
+// http://localhost:4318, or to the one the OTEL_EXPORTER_OTLP_* variables
+// name if endpoint is "". Headers come from OTEL_EXPORTER_OTLP_HEADERS.
+func newTracer(ctx context.Context, endpoint string) (*tracer, error) {
+	var opts []otlptracehttp.Option
+	if endpoint != "" {
+		opts = append(opts, otlptracehttp.WithEndpointURL(strings.TrimSuffix(endpoint, "/")+"/v1/traces"))
+	}
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	// The environment's service name and attributes win over the default.
+	res, err := resource.New(ctx,
+		resource.WithAttributes(attribute.String("service.name", "requirecodeowners")),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		logger.Warn("exporting traces", "error", err)
+	}))
+	provider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res))
+	carrier := propagation.MapCarrier{"traceparent": os.Getenv("TRACEPARENT"), "tracestate": os.Getenv("TRACESTATE")}
+	return &tracer{
+		provider: provider,
+		tracer:   provider.Tracer("requirecodeowners"),
+		parent:   propagation.TraceContext{}.Extract(context.Background(), carrier),
+		started:  make(map[string]int),
+		dropped:  make(map[string]int),
+	}, nil
+}
+
+// startSpan opens a span named name, with attributes as alternating keys
+// and values like slog's. It does nothing when tracing is off, or when
+// maxSpansPerName spans of this name were already started.
+func startSpan(name string, attrs ...any) *span {
+	t := tracing
+	if t == nil {
+		return nil
+	}
+	if t.started[name] >= maxSpansPerName {
+		t.dropped[name]++
+		return nil
+	}
+	t.started[name]++
+	ctx := t.parent
+	if len(t.open) > 0 {
+		ctx = t.open[len(t.open)-1].ctx
+	}
+	ctx, sp := t.tracer.Start(ctx, name, trace.WithAttributes(spanAttributes(attrs)...))
+	s := &span{t: t, ctx: ctx, span: sp}
+	t.open = append(t.open, s)
+	return s
+}
+
+// set adds an attribute to the span, for what's only known once the step
+// is done.
+func (s *span) set(key string, value any) {
+	if s != nil {
+		s.span.SetAttributes(spanAttributes([]any{key, value})...)
+	}
+}
+
+// finish ends the span, along with any spans opened inside it that weren't
+// ended.
+func (s *span) finish() {
+	if s == nil {
+		return
+	}
+	t := s.t
+	for i := len(t.open) - 1; i >= 0; i-- {
+		if t.open[i] != s {
+			continue
+		}
+		now := time.Now()
+		for j := len(t.open) - 1; j >= i; j-- {
+			t.open[j].span.End(trace.WithTimestamp(now))
+		}
+		t.open = t.open[:i]
+		return
+	}
+}
+
+// traceparent returns the W3C traceparent of the innermost open span, for
+// passing the trace on to commands the run starts, or "" when tracing is
+// off.
+func traceparent() string {
+	t := tracing
+	if t == nil || len(t.open) == 0 {
+		return ""
+	}
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(t.open[len(t.open)-1].ctx, carrier)
+	return carrier["traceparent"]
+}
+
+// spanAttributes converts alternating keys and values to span attributes.
+func spanAttributes(kvs []any) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	for i := 0; i+1 < len(kvs); i += 2 {
+		key := fmt.Sprint(kvs[i])
+		switch v := kvs[i+1].(type) {
+		case string:
+			attrs = append(attrs, attribute.String(key, v))
+		case int:
+			attrs = append(attrs, attribute.Int(key, v))
+		case bool:
+			attrs = append(attrs, attribute.Bool(key, v))
+		case float64:
+			attrs = append(attrs, attribute.Float64(key, v))
+		default:
+			attrs = append(attrs, attribute.String(key, fmt.Sprint(v)))
+		}
+	}
+	return attrs
+}
+
+// finishTrace ends the run's root span, noting the spans the cap left out,
+// and flushes the trace to the collector, warning rather than failing the
+// check if it can't be reached.
+func finishTrace(root *span) {
+	t := tracing
+	if t == nil {
+		return
+	}
+	for name, n := range t.dropped {
+		root.set("spans.dropped."+name, n)
+	}
+	root.finish()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := t.provider.Shutdown(ctx); err != nil {
+		logger.Warn("exporting traces", "error", err)
+	}
+}
@@ -0,0 +1,133 @@
+package check
+
+import (
+	"context"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestTraceExport(t *testing.T) {
+	var got []*tracepb.Span
+	var gotHeader string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" {
+			t.Errorf("export path = %s, want /v1/traces", r.URL.Path)
+		}
+		gotHeader = r.Header.Get("Authorization")
+		body, _ := io.ReadAll(r.Body)
+		var req coltracepb.ExportTraceServiceRequest
+		if err := proto.Unmarshal(body, &req); err != nil {
+			t.Errorf("export body is not an OTLP request: %v", err)
+		}
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				got = append(got, ss.Spans...)
+			}
+		}
+	}))
+	defer srv.Close()
+	t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "Authorization=Bearer token")
+	t.Setenv("TRACEPARENT", "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+
+	tmpDir := t.TempDir()
+	for _, dir := range []string{"services/api", "services/web"} {
+		os.MkdirAll(filepath.Join(tmpDir, dir), 0755)
+	}
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	rules, err := parseCodeowners(strings.NewReader("/services/api/ @org/api\n"), "CODEOWNERS", providerGitHub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tr, err := newTracer(context.Background(), srv.URL+"/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracing = tr
+	defer func() { tracing = nil }()
+
+	run := startSpan("requirecodeowners")
+	c := &checker{fsys: localFS{}, rules: rules, configPath: ".requirecodeowners.yml"}
+	c.validate([]dirSpec{{Path: "services", Level: 1}})
+	finishTrace(run)
+
+	if gotHeader != "Bearer token" {
+		t.Errorf("Authorization header = %q, want the configured header", gotHeader)
+	}
+	byName := make(map[string]*tracepb.Span)
+	ids := make(map[string]string)
+	for _, s := range got {
+		byName[s.Name] = s
+		ids[string(s.SpanId)] = s.Name
+		if id := hex.EncodeToString(s.TraceId); id != "0af7651916cd43dd8448eb211c80319c" {
+			t.Errorf("span %s has trace ID %s, want the one from TRACEPARENT", s.Name, id)
+		}
+	}
+	parents := map[string]string{
+		"spec":   "requirecodeowners",
+		"match":  "spec",
+		"policy": "requirecodeowners",
+	}
+	for name, parent := range parents {
+		s, ok := byName[name]
+		if !ok {
+			t.Errorf("no %s span in %v", name, got)
+			continue
+		}
+		if ids[string(s.ParentSpanId)] != parent {
+			t.Errorf("%s span's parent = %q, want %q", name, ids[string(s.ParentSpanId)], parent)
+		}
+	}
+	if root := byName["requirecodeowners"]; hex.EncodeToString(root.GetParentSpanId()) != "b7ad6b7169203331" {
+		t.Errorf("root span's parent = %x, want the span from TRACEPARENT", root.GetParentSpanId())
+	}
+}
+
+func TestStartSpanDisabled(t *testing.T) {
+	sp := startSpan("noop", "key", "value")
+	if sp != nil {
+		t.Fatalf("startSpan() with tracing off = %v, want nil", sp)
+	}
+	// A nil span is safe to use.
+	sp.set("key", 1)
+	sp.finish()
+	finishTrace(sp)
+}
+
+func TestStartSpanCapped(t *testing.T) {
+	tr, err := newTracer(context.Background(), "http://127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracing = tr
+	defer func() { tracing = nil }()
+
+	root := startSpan("requirecodeowners")
+	for i := 0; i < maxSpansPerName+5; i++ {
+		sp := startSpan("match")
+		if (sp == nil) != (i >= maxSpansPerName) {
+			t.Fatalf("startSpan() %d = %v, want spans only up to the cap", i, sp)
+		}
+		sp.finish()
+	}
+	if tr.dropped["match"] != 5 {
+		t.Errorf("dropped = %v, want 5 match spans", tr.dropped)
+	}
+	if tp := traceparent(); !strings.HasPrefix(tp, "00-") {
+		t.Errorf("traceparent() = %q, want the root span's", tp)
+	}
+	root.finish()
+}
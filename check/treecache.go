@@ -0,0 +1,318 @@
+package check
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// subtreeCacheDir is where the results of checking unchanged subtrees are
+// kept between runs, from --cache-dir. "" disables the cache.
+var subtreeCacheDir string
+
+// subtreeCacheMaxAge is how long an entry that no run has used is kept.
+const subtreeCacheMaxAge = 7 * 24 * time.Hour
+
+// subtreeCacheVersion is part of every key, so a release that checks
+// differently doesn't reuse results from an older one.
+const subtreeCacheVersion = "3"
+
+// subtreeCache stores what checking each directory a spec matched found,
+// keyed by the directory's git tree hash and everything else the result
+// depends on: the config, the CODEOWNERS rules and the spec. A directory
+// whose tree is unchanged then skips expansion and matching. Directories with
+// uncommitted changes have no usable tree hash and are always checked. A nil
+// *subtreeCache caches nothing.
+type subtreeCache struct {
+	dir   string
+	base  string            // hash of the config and rules
+	trees map[string]string // tree hash by clean directory
+}
+
+// subtreeCacheEntry is a cached result. Rules are stored as their index in
+// the ruleset, which the key pins.
+type subtreeCacheEntry struct {
+	Checked []cachedCheck `json:"checked"`
+	Skipped []cachedSkip  `json:"skipped"`
+	Errors  []cachedError `json:"errors"`
+}
+
+type cachedCheck struct {
+	Path string `json:"path"`
+	Rule int    `json:"rule"` // -1 for none
+}
+
+type cachedSkip struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+type cachedError struct {
+	Path     string `json:"path"`
+	Message  string `json:"message"`
+	Code     string `json:"code,omitempty"`
+	Severity string `json:"severity,omitempty"`
+	Team     string `json:"team,omitempty"`
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+}
+
+// newSubtreeCache opens the cache in dir for the repository in the working
+// directory, checked with rules and the config whose content, with the
+// configs it extends and the org policy, is config. It fails if git can't
+// list the repository's trees.
+func newSubtreeCache(dir string, config []byte, rules ruleset) (*subtreeCache, error) {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%+v\n", subtreeCacheVersion, traversal)
+	h.Write(config)
+	for _, r := range rules {
+		fmt.Fprintf(h, "\n%s:%d %s %s", r.file, r.LineNumber, r.RawPattern(), r.ownerNames())
+	}
+	// A .gitignore applies below its directory, so one outside a subtree can
+	// change what's expanded in it.
+	ignores, err := gitOutput("ls-files", "-s", "-z", "--", ":(glob)**/"+gitignoreFile)
+	if err != nil {
+		return nil, err
+	}
+	h.Write(ignores)
+	exclude, _ := os.ReadFile(gitExcludeFile)
+	h.Write(exclude)
+	if traversal.sparse {
+		// A directory's tree is the same in or out of the sparse checkout,
+		// but whether it's skipped isn't.
+		cones, _ := gitOutput("sparse-checkout", "list")
+		h.Write(cones)
+	}
+
+	trees, err := cleanTrees()
+	if err != nil {
+		return nil, err
+	}
+	return &subtreeCache{dir: dir, base: hex.EncodeToString(h.Sum(nil)), trees: trees}, nil
+}
+
+// cleanTrees returns the tree hash of the working directory and every
+// directory below it in HEAD, leaving out those with uncommitted changes,
+// including untracked files, at any depth.
+func cleanTrees() (map[string]string, error) {
+	root, err := gitOutput("rev-parse", "HEAD:./")
+	if err != nil {
+		return nil, err
+	}
+	trees := map[string]string{".": strings.TrimSpace(string(root))}
+	out, err := gitOutput("ls-tree", "-r", "-d", "-z", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range bytes.Split(out, []byte{0}) {
+		// <mode> tree <hash>\t<path>
+		info, name, ok := strings.Cut(string(entry), "\t")
+		if fields := strings.Fields(info); ok && len(fields) == 3 {
+			trees[path.Clean(name)] = fields[2]
+		}
+	}
+
+	modified, err := gitOutput("diff", "--name-only", "-z", "--no-renames", "--relative", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := gitOutput("ls-files", "--others", "--exclude-standard", "-z")
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range bytes.Split(append(append(modified, 0), untracked...), []byte{0}) {
+		if len(name) == 0 {
+			continue
+		}
+		if path.Base(string(name)) == gitignoreFile {
+			return nil, fmt.Errorf("%s has uncommitted changes", name)
+		}
+		for d := path.Dir(string(name)); ; d = path.Dir(d) {
+			delete(trees, d)
+			if d == "." || d == "/" {
+				break
+			}
+		}
+	}
+	return trees, nil
+}
+
+func gitOutput(args ...string) ([]byte, error) {
+	out, err := exec.Command("git", args...).Output()
+	if err != nil {
+		if ee, ok := err.(*exec.ExitError); ok && len(ee.Stderr) > 0 {
+			return nil, fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(string(ee.Stderr)))
+		}
+		return nil, fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return out, nil
+}
+
+// key returns the entry name for checking dir with spec, or false if dir
+// has no clean tree to key on.
+func (sc *subtreeCache) key(dir string, spec dirSpec) (string, bool) {
+	if sc == nil {
+		return "", false
+	}
+	tree, ok := sc.trees[path.Clean(dir)]
+	if !ok {
+		return "", false
+	}
+	specJSON, err := json.Marshal(spec)
+	if err != nil {
+		return "", false
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\n%s\n%s\n%d\n%s", sc.base, dir, tree, spec.line, specJSON)))
+	return hex.EncodeToString(sum[:]), true
+}
+
+// load returns the entry stored under key. Using an entry keeps it from
+// being pruned.
+func (sc *subtreeCache) load(key string) (subtreeCacheEntry, bool) {
+	var e subtreeCacheEntry
+	name := filepath.Join(sc.dir, key+".json")
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return e, false
+	}
+	if err := json.Unmarshal(data, &e); err != nil {
+		logger.Warn("ignoring subtree cache entry", "path", name, "error", err)
+		return e, false
+	}
+	now := time.Now()
+	_ = os.Chtimes(name, now, now)
+	return e, true
+}
+
+// store writes an entry atomically, so a concurrent run never reads half of
+// one. Failing to store only costs speed, so it's logged rather than
+// returned.
+func (sc *subtreeCache) store(key string, e subtreeCacheEntry) {
+	if err := sc.write(key, e); err != nil {
+		logger.Warn("writing subtree cache entry", "dir", sc.dir, "error", err)
+	}
+}
+
+func (sc *subtreeCache) write(key string, e subtreeCacheEntry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(sc.dir, 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(sc.dir, "."+key+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), filepath.Join(sc.dir, key+".json"))
+}
+
+// prune removes entries no run has used for subtreeCacheMaxAge, so the
+// cache doesn't grow without bound as the repository changes.
+func (sc *subtreeCache) prune() {
+	if sc == nil {
+		return
+	}
+	entries, err := os.ReadDir(sc.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	if err != nil {
+		logger.Warn("pruning subtree cache", "dir", sc.dir, "error", err)
+		return
+	}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || !strings.HasSuffix(entry.Name(), ".json") || time.Since(info.ModTime()) < subtreeCacheMaxAge {
+			continue
+		}
+		_ = os.Remove(filepath.Join(sc.dir, entry.Name()))
+	}
+}
+
+// validateDirectoryCached is validateDirectory, reusing the stored result
+// when dir's tree hasn't changed since it was cached.
+func (c *checker) validateDirectoryCached(res *result, dir string, spec dirSpec) []validationError {
+	key, ok := c.cache.key(dir, spec)
+	if !ok {
+		return c.validateDirectory(res, dir, spec)
+	}
+	if e, hit := c.cache.load(key); hit {
+		logger.Debug("subtree cache hit", "path", dir, "spec", spec.Path)
+		return c.applyCached(res, e)
+	}
+
+	var scratch result
+	errs := c.validateDirectory(&scratch, dir, spec)
+	res.checked = append(res.checked, scratch.checked...)
+	res.skipped = append(res.skipped, scratch.skipped...)
+	if c.stopped() {
+		// A subtree that wasn't finished can't stand in for a full check.
+		return errs
+	}
+
+	var e subtreeCacheEntry
+	for _, d := range scratch.checked {
+		e.Checked = append(e.Checked, cachedCheck{Path: d.path, Rule: c.rulePosition(d.rule)})
+	}
+	for _, d := range scratch.skipped {
+		e.Skipped = append(e.Skipped, cachedSkip{Path: d.path, Reason: d.reason})
+	}
+	for _, err := range errs {
+		e.Errors = append(e.Errors, cachedError{Path: err.path, Message: err.message, Code: err.code, Severity: err.severity, Team: err.team, File: err.file, Line: err.line})
+	}
+	c.cache.store(key, e)
+	return errs
+}
+
+func (c *checker) applyCached(res *result, e subtreeCacheEntry) []validationError {
+	for _, d := range e.Checked {
+		var r *rule
+		if d.Rule >= 0 && d.Rule < len(c.rules) {
+			r = &c.rules[d.Rule]
+		}
+		res.checked = append(res.checked, checkedDir{path: d.Path, rule: r})
+		progress.addChecked()
+	}
+	progress.addExpanded(len(e.Checked) + len(e.Skipped))
+	for _, d := range e.Skipped {
+		res.skipped = append(res.skipped, skippedDir{path: d.Path, reason: d.Reason})
+	}
+	var errs []validationError
+	for _, err := range e.Errors {
+		errs = append(errs, validationError{path: err.Path, message: err.Message, code: err.Code, severity: err.Severity, team: err.Team, file: err.File, line: err.Line})
+	}
+	return errs
+}
+
+// rulePosition returns r's position in the checker's rules, or -1 for nil.
+func (c *checker) rulePosition(r *rule) int {
+	if c.positions == nil {
+		c.positions = make(map[*rule]int, len(c.rules))
+		for i := range c.rules {
+			c.positions[&c.rules[i]] = i
+		}
+	}
+	if i, ok := c.positions[r]; ok {
+		return i
+	}
+	return -1
+}
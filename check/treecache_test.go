@@ -0,0 +1,100 @@
+package check
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSubtreeCache(t *testing.T) {
+	gitRepo(t, map[string]string{
+		".requirecodeowners.yml":  "directories:\n  - path: services\n    level: 1\n  - path: libs\n    level: 1\n",
+		".github/CODEOWNERS":      "/services/api/ @org/api\n/libs/ @org/libs\n",
+		"services/api/main.go":    "package main\n",
+		"services/web/index.html": "",
+		"libs/util/util.go":       "package util\n",
+	})
+	cacheDir := t.TempDir()
+	old := subtreeCacheDir
+	subtreeCacheDir = cacheDir
+	defer func() { subtreeCacheDir = old }()
+
+	check := func(t *testing.T) result {
+		t.Helper()
+		res, err := checkRepo(context.Background(), "", nil, specFilter{})
+		if err != nil {
+			t.Fatalf("checkRepo() error = %v", err)
+		}
+		return res
+	}
+
+	first := check(t)
+	entries, _ := filepath.Glob(filepath.Join(cacheDir, "*.json"))
+	if len(entries) != 2 {
+		t.Fatalf("cache entries = %v, want one per spec directory", entries)
+	}
+
+	// Mark every entry, so results that came from the cache can be told apart.
+	for _, name := range entries {
+		data, _ := os.ReadFile(name)
+		os.WriteFile(name, []byte(strings.ReplaceAll(string(data), "Not covered", "Cached: not covered")), 0644)
+	}
+	second := check(t)
+	if len(second.errors) != 1 || !strings.HasPrefix(second.errors[0].message, "Cached:") {
+		t.Errorf("second run errors = %v, want the cached services/web error", second.errors)
+	}
+	owned, total := second.coverage()
+	if wantOwned, wantTotal := first.coverage(); owned != wantOwned || total != wantTotal {
+		t.Errorf("second run coverage = %d/%d, want %d/%d", owned, total, wantOwned, wantTotal)
+	}
+	for _, d := range second.checked {
+		if d.path == "services/api" && (d.rule == nil || d.rule.ownerNames() != "@org/api") {
+			t.Errorf("cached rule for services/api = %v, want /services/api/", d.rule)
+		}
+	}
+
+	// An untracked directory changes what services expands to.
+	writeFiles(t, map[string]string{"services/jobs/run.sh": ""})
+	third := check(t)
+	var uncovered []string
+	for _, e := range third.errors {
+		if strings.HasPrefix(e.message, "Cached:") {
+			t.Errorf("error %v came from the cache, want services rechecked", e)
+		}
+		uncovered = append(uncovered, e.path)
+	}
+	if strings.Join(uncovered, ",") != "services/jobs,services/web" {
+		t.Errorf("third run uncovered = %v, want services/jobs and services/web", uncovered)
+	}
+}
+
+func TestCleanTrees(t *testing.T) {
+	gitRepo(t, map[string]string{
+		"a/b/c/file.txt": "",
+		"a/d/file.txt":   "",
+		"e/file.txt":     "",
+	})
+	writeFiles(t, map[string]string{"a/d/file.txt": "changed"})
+
+	trees, err := cleanTrees()
+	if err != nil {
+		t.Fatalf("cleanTrees() error = %v", err)
+	}
+	for _, dir := range []string{"a/b", "a/b/c", "e"} {
+		if trees[dir] == "" {
+			t.Errorf("cleanTrees() has no tree for unchanged %s", dir)
+		}
+	}
+	for _, dir := range []string{".", "a", "a/d"} {
+		if _, ok := trees[dir]; ok {
+			t.Errorf("cleanTrees() has a tree for %s, which has changes", dir)
+		}
+	}
+
+	writeFiles(t, map[string]string{"e/.gitignore": "*.log\n"})
+	if _, err := cleanTrees(); err == nil {
+		t.Error("cleanTrees() with an uncommitted .gitignore succeeded, want an error")
+	}
+}
@@ -0,0 +1,146 @@
+package check
+
+import (
+	"context"
+
+	"github.com/kpurdon/requirecodeowners/validate"
+)
+
+// registeredValidators returns the validators registered with
+// validate.Register, which run in every check after the built-in ones.
+var registeredValidators = validate.Registered
+
+// validators returns the validators the config asks for, followed by the
+// registered ones. Validators may keep state across directories, so these
+// are only good for one check.
+func (c *checker) validators() []validate.Validator {
+	var vs []validate.Validator
+	if len(c.policy.ForbiddenOwners) > 0 || (c.org != nil && len(c.org.ForbiddenOwners) > 0) {
+		vs = append(vs, c.forbiddenOwnersValidator())
+	}
+	if len(c.customPolicies) > 0 {
+		vs = append(vs, c.customPoliciesValidator())
+	}
+	if len(c.hooks) > 0 {
+		vs = append(vs, c.hooksValidator())
+	}
+	return append(vs, registeredValidators()...)
+}
+
+// dirValidators returns the built-in checks spec makes of each directory
+// it checks: coverage, then min_owners, min_approvals and exclusive_rules
+// for the owned ones.
+func (c *checker) dirValidators(spec dirSpec) []validate.Validator {
+	vs := []validate.Validator{c.coverageValidator(), c.minOwnersValidator(spec.MinOwners)}
+	if spec.MinApprovals > 0 {
+		vs = append(vs, c.approvalsValidator(spec.MinApprovals))
+	}
+	if spec.ExclusiveRules {
+		vs = append(vs, c.exclusiveValidator())
+	}
+	return vs
+}
+
+// runValidators runs the validators on each checked directory once.
+func (c *checker) runValidators(checked []checkedDir) []validationError {
+	vs := c.validators()
+	if len(vs) == 0 {
+		return nil
+	}
+	var errors []validationError
+	seen := make(map[string]bool)
+	for _, d := range checked {
+		if seen[d.path] {
+			continue
+		}
+		seen[d.path] = true
+		errors = append(errors, c.checkDir(vs, d)...)
+		if c.stopped() {
+			break
+		}
+	}
+	return errors
+}
+
+// checkDir runs vs on d, returning the problems they find.
+func (c *checker) checkDir(vs []validate.Validator, d checkedDir) []validationError {
+	ctx := c.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	var errors []validationError
+	dr := dirResult(d)
+	for _, v := range vs {
+		if c.stopped() {
+			break
+		}
+		findings := v.Check(ctx, dr)
+		logger.Debug("ran validator", "validator", v.Name(), "path", d.path, "problems", len(findings))
+		for _, f := range findings {
+			errors = append(errors, c.findingError(d, f))
+		}
+	}
+	return errors
+}
+
+// dirResult describes d to validators.
+func dirResult(d checkedDir) validate.DirResult {
+	dr := validate.DirResult{Path: d.path, Owners: []string{}}
+	if d.rule != nil {
+		for _, o := range d.rule.Owners {
+			dr.Owners = append(dr.Owners, o.String())
+		}
+		dr.Rule, dr.File, dr.Line = d.rule.RawPattern(), d.rule.file, d.rule.LineNumber
+	}
+	return dr
+}
+
+// ruleFor returns the rule that owns d, or nil if none does, for built-in
+// validators that need more of it than d describes.
+func (c *checker) ruleFor(d validate.DirResult) *rule {
+	if d.Rule == "" {
+		return nil
+	}
+	if c.locations == nil {
+		c.locations = make(map[ruleLocation]*rule, len(c.rules))
+		for i := range c.rules {
+			r := &c.rules[i]
+			c.locations[ruleLocation{r.file, r.LineNumber}] = r
+		}
+	}
+	return c.locations[ruleLocation{d.File, d.Line}]
+}
+
+// ruleLocation is where a rule is, for ruleFor.
+type ruleLocation struct {
+	file string
+	line int
+}
+
+// findingError is the problem f a validator found with d, filled in with
+// what the finding leaves to d.
+func (c *checker) findingError(d checkedDir, f validate.Finding) validationError {
+	e := validationError{
+		path:      f.Path,
+		message:   f.Message,
+		code:      f.Code,
+		severity:  f.Severity,
+		file:      f.File,
+		line:      f.Line,
+		team:      f.Team,
+		mandatory: f.Mandatory,
+	}
+	if e.path == "" {
+		e.path = d.path
+	}
+	if e.file == "" {
+		e.file = c.configPath
+		if d.rule != nil {
+			e.file, e.line = d.rule.file, d.rule.LineNumber
+			if e.team == "" {
+				e.team = d.rule.ownerNames()
+			}
+		}
+	}
+	return e
+}
@@ -0,0 +1,79 @@
+package check
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/kpurdon/requirecodeowners/validate"
+)
+
+func TestRegisterValidator(t *testing.T) {
+	defer func(saved func() []validate.Validator) { registeredValidators = saved }(registeredValidators)
+	var seen []string
+	ldap := validate.Func{N: "ldap", F: func(_ context.Context, d validate.DirResult) []validate.Finding {
+		seen = append(seen, d.Path)
+		if d.Path != "services/web" {
+			return nil
+		}
+		return []validate.Finding{{Message: d.Owners[0] + " is not in LDAP.", Severity: validate.Warning}}
+	}}
+	registeredValidators = func() []validate.Validator { return []validate.Validator{ldap} }
+
+	tmpDir := t.TempDir()
+	for _, d := range []string{"services/api", "services/web"} {
+		os.MkdirAll(filepath.Join(tmpDir, d), 0755)
+	}
+	os.WriteFile(filepath.Join(tmpDir, ".requirecodeowners.yml"), []byte("directories:\n  - path: services\n    level: 1\n  - path: services/*\n"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "CODEOWNERS"), []byte("/services/ @org/services\n"), 0644)
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	res, err := checkRepo(context.Background(), "", nil, specFilter{})
+	if err != nil {
+		t.Fatalf("checkRepo() error = %v", err)
+	}
+	if len(seen) != 2 {
+		t.Errorf("validator saw %v, want each directory once", seen)
+	}
+	if len(res.errors) != 1 {
+		t.Fatalf("errors = %v, want the validator's finding", res.errors)
+	}
+	// What the finding leaves out is the directory's.
+	e := res.errors[0]
+	if e.path != "services/web" || e.message != "@org/services is not in LDAP." || !e.isWarning() || e.file != "CODEOWNERS" || e.line != 1 || e.team != "@org/services" {
+		t.Errorf("error = %+v, want a warning for services/web at CODEOWNERS:1", e)
+	}
+}
+
+func TestRunValidatorsStopped(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c := &checker{ctx: ctx, policy: policy{ForbiddenOwners: []string{"@org/a"}}}
+	if errs := c.runValidators([]checkedDir{{path: "a"}}); len(errs) != 0 {
+		t.Errorf("runValidators() after cancel = %v, want none", errs)
+	}
+}
+
+func TestDirValidators(t *testing.T) {
+	tests := []struct {
+		spec dirSpec
+		want []string
+	}{
+		{dirSpec{MinOwners: 1}, []string{"coverage", "min_owners"}},
+		{dirSpec{MinOwners: 2, MinApprovals: 2, ExclusiveRules: true}, []string{"coverage", "min_owners", "min_approvals", "exclusive_rules"}},
+	}
+	c := &checker{}
+	for _, tt := range tests {
+		var got []string
+		for _, v := range c.dirValidators(tt.spec) {
+			got = append(got, v.Name())
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("dirValidators(%+v) = %v, want %v", tt.spec, got, tt.want)
+		}
+	}
+}
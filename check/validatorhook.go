@@ -0,0 +1,116 @@
+package check
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/kpurdon/requirecodeowners/validate"
+)
+
+// hookOutput is the JSON a validator hook may write on stdout. Nothing, or
+// no findings, means the directory passed.
+type hookOutput struct {
+	Findings []hookFinding `json:"findings"`
+}
+
+type hookFinding struct {
+	Message  string `json:"message"`
+	Severity string `json:"severity"`
+}
+
+// hooksValidator runs each of the config's hooks for a checked directory,
+// reporting the findings they return, or a failure when one exits nonzero
+// without any. A hook that can't run at all is reported once and not run
+// again.
+func (c *checker) hooksValidator() validate.Validator {
+	broken := make(map[string]bool)
+	return validate.Func{N: "hooks", F: func(ctx context.Context, d validate.DirResult) []validate.Finding {
+		var findings []validate.Finding
+		for _, hook := range c.hooks {
+			if broken[hook] || ctx.Err() != nil {
+				continue
+			}
+			out, failure, err := runValidatorHook(ctx, hook, d)
+			if err != nil {
+				if ctx.Err() != nil {
+					break
+				}
+				broken[hook] = true
+				findings = append(findings, validate.Finding{
+					Path:    hook,
+					Message: fmt.Sprintf("Hook couldn't run: %v. Fix hooks in %s.", err, c.configPath),
+					Code:    codeHookFailed,
+					File:    c.configPath,
+				})
+				continue
+			}
+			for _, f := range out {
+				findings = append(findings, validate.Finding{
+					Message:  fmt.Sprintf("%s (hook %s)", f.Message, hook),
+					Severity: f.Severity,
+					Code:     codeHookFailed,
+				})
+			}
+			if failure != "" {
+				findings = append(findings, validate.Finding{
+					Message: fmt.Sprintf("Hook %s failed: %s", hook, failure),
+					Code:    codeHookFailed,
+				})
+			}
+		}
+		return findings
+	}}
+}
+
+// runValidatorHook runs the command line hook with in on stdin, returning
+// the findings it writes. When it exits nonzero without any, failure says
+// how, with the last line of its stderr. err is for a hook that couldn't
+// run, or wrote something other than findings.
+func runValidatorHook(ctx context.Context, hook string, in validate.DirResult) (findings []hookFinding, failure string, err error) {
+	args := strings.Fields(hook)
+	input, err := json.Marshal(in)
+	if err != nil {
+		return nil, "", err
+	}
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdin = bytes.NewReader(input)
+	if tp := traceparent(); tp != "" {
+		cmd.Env = append(os.Environ(), "TRACEPARENT="+tp)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	runErr := cmd.Run()
+	var exitErr *exec.ExitError
+	if runErr != nil && !errors.As(runErr, &exitErr) {
+		return nil, "", runErr
+	}
+
+	var out hookOutput
+	if b := bytes.TrimSpace(stdout.Bytes()); len(b) > 0 {
+		if err := json.Unmarshal(b, &out); err != nil {
+			return nil, "", fmt.Errorf("reading its output: %w", err)
+		}
+	}
+	for i, f := range out.Findings {
+		if f.Message == "" {
+			return nil, "", fmt.Errorf("finding %d has no message", i)
+		}
+		if !validSeverity(f.Severity) {
+			return nil, "", fmt.Errorf("finding %d has invalid severity %q (must be %q or %q)", i, f.Severity, severityError, severityWarning)
+		}
+	}
+	if runErr != nil && len(out.Findings) == 0 {
+		failure = runErr.Error()
+		lines := strings.Split(strings.TrimSpace(stderr.String()), "\n")
+		if last := lines[len(lines)-1]; last != "" {
+			failure += ": " + last
+		}
+	}
+	return out.Findings, failure, nil
+}
@@ -0,0 +1,90 @@
+package check
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kpurdon/requirecodeowners/validate"
+)
+
+func TestCheckRepoValidatorHooks(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not installed")
+	}
+	tmpDir := t.TempDir()
+	for _, d := range []string{"services/api", "services/web", "services/legacy"} {
+		os.MkdirAll(filepath.Join(tmpDir, d), 0755)
+	}
+	// The hook flags @org/legacy as unknown, fails outright for
+	// services/web, and passes everything else.
+	os.WriteFile(filepath.Join(tmpDir, "check-owner.sh"), []byte(`#!/bin/sh
+input=$(cat)
+case "$input" in
+*'"@org/legacy"'*) echo '{"findings": [{"message": "@org/legacy is not in the directory.", "severity": "warning"}]}' ;;
+*'"path":"services/web"'*) echo "ldap unreachable" >&2; exit 3 ;;
+esac
+`), 0755)
+	os.WriteFile(filepath.Join(tmpDir, ".requirecodeowners.yml"), []byte(`directories:
+  - path: services
+    level: 1
+hooks:
+  - sh check-owner.sh
+  - ./missing-hook
+`), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "CODEOWNERS"), []byte("/services/ @org/services\n/services/legacy @org/legacy\n"), 0644)
+	oldWd, _ := os.Getwd()
+	os.Chdir(tmpDir)
+	defer os.Chdir(oldWd)
+
+	res, err := checkRepo(context.Background(), "", nil, specFilter{})
+	if err != nil {
+		t.Fatalf("checkRepo() error = %v", err)
+	}
+	var got []string
+	for _, e := range res.errors {
+		if e.code != codeHookFailed {
+			t.Errorf("code = %s, want %s", e.code, codeHookFailed)
+		}
+		got = append(got, e.path+": "+e.message)
+	}
+	want := []string{
+		"./missing-hook: Hook couldn't run:",
+		"services/legacy: @org/legacy is not in the directory. (hook sh check-owner.sh)",
+		"services/web: Hook sh check-owner.sh failed: exit status 3: ldap unreachable",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("errors = %q, want %q", got, want)
+	}
+	for i := range want {
+		if !strings.HasPrefix(got[i], want[i]) {
+			t.Errorf("errors[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+	if len(res.errors) == 3 && !res.errors[1].isWarning() {
+		t.Errorf("finding severity = %q, want the warning the hook asked for", res.errors[1].severity)
+	}
+}
+
+func TestRunValidatorHookBadOutput(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not installed")
+	}
+	dir := t.TempDir()
+	tests := map[string]string{
+		"echo not json": "reading its output",
+		`echo '{"findings": [{"severity": "warning"}]}'`:               "finding 0 has no message",
+		`echo '{"findings": [{"message": "x", "severity": "fatal"}]}'`: `invalid severity "fatal"`,
+	}
+	for script, want := range tests {
+		path := filepath.Join(dir, "hook.sh")
+		os.WriteFile(path, []byte(script+"\n"), 0644)
+		_, _, err := runValidatorHook(context.Background(), "sh "+path, validate.DirResult{Path: "services/api"})
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("runValidatorHook(%s) error = %v, want %q", script, err, want)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package check
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hmarr/codeowners"
+)
+
+// verifyOwners checks through the GitHub API that the owners of the checked
+// directories are real: users must exist, and teams must exist and have at
+// least minMembers members. Each owner is checked once, and problems point
+// at the first rule that lists it. Results are read from and added to cache,
+// which may be nil. If GitHub rate-limits the lookups, or a lookup fails
+// some other way, like a network error or a bad token, the remaining owners
+// are reported as unverified in a single warning rather than failing: the
+// CODEOWNERS may well be right.
+func verifyOwners(client *githubClient, checked []checkedDir, minMembers int, cache *ownerCache) []validationError {
+	var errors []validationError
+	seen := make(map[string]bool)
+	var unverified []string
+	var lookupErr error // why unverified owners weren't looked up
+	for _, d := range checked {
+		if d.rule == nil {
+			continue
+		}
+		for _, o := range d.rule.Owners {
+			if o.Type == codeowners.EmailOwner || seen[o.Value] {
+				continue
+			}
+			seen[o.Value] = true
+			if len(unverified) > 0 {
+				unverified = append(unverified, o.String())
+				continue
+			}
+
+			key := fmt.Sprintf("%s %s %d", client.baseURL, o.String(), minMembers)
+			problem, ok := cache.get(key)
+			if !ok {
+				var err error
+				problem, err = verifyOwner(client, o, minMembers)
+				if err != nil {
+					lookupErr = err
+					unverified = append(unverified, o.String())
+					continue
+				}
+				cache.put(key, problem)
+			}
+			if problem != "" {
+				errors = append(errors, validationError{
+					path:    o.String(),
+					message: fmt.Sprintf("%s Fix or replace it in %s: %s", problem, d.rule.location(), d.rule.RawPattern()),
+					code:    codeInvalidOwner,
+					team:    o.String(),
+					file:    d.rule.file,
+					line:    d.rule.LineNumber,
+				})
+			}
+		}
+	}
+
+	if len(unverified) > 0 {
+		owners := fmt.Sprintf("%d %s weren't verified: %s", len(unverified), pluralize(len(unverified), "owner", "owners"), strings.Join(unverified, ", "))
+		e := validationError{
+			path:     "GitHub API",
+			message:  fmt.Sprintf("Rate limit reached, so %s. Verified results are cached, so the next run picks up where this one stopped.", owners),
+			code:     codeRateLimited,
+			severity: severityWarning,
+		}
+		if !isRateLimited(lookupErr) {
+			e.message = fmt.Sprintf("Cannot look up owners (%v), so %s. Check GITHUB_TOKEN and the connection to GitHub.", lookupErr, owners)
+			e.code = codeVerifyFailed
+		}
+		errors = append(errors, e)
+	}
+	return errors
+}
+
+// verifyOwner returns what's wrong with o, or "" if nothing is. err is set
+// when o couldn't be looked up.
+func verifyOwner(client *githubClient, o codeowners.Owner, minMembers int) (string, error) {
+	if o.Type == codeowners.TeamOwner {
+		org, slug, _ := strings.Cut(o.Value, "/")
+		n, err := client.countTeamMembers(org, slug, minMembers)
+		if isNotFound(err) {
+			return "Team not found, or not visible to GITHUB_TOKEN.", nil
+		}
+		if err != nil {
+			return "", err
+		}
+		if n < minMembers {
+			return fmt.Sprintf("Team has %d %s but at least %d required, so nobody may get review requests.", n, pluralize(n, "member", "members"), minMembers), nil
+		}
+		return "", nil
+	}
+
+	ok, err := client.userExists(o.Value)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "User not found.", nil
+	}
+	return "", nil
+}
+
+// ownerCache stores owner verification results on disk between runs, so CI
+// doesn't spend its API rate limit checking the same teams every time. A
+// nil *ownerCache caches nothing.
+type ownerCache struct {
+	path    string
+	ttl     time.Duration
+	entries map[string]ownerCacheEntry
+	changed bool
+}
+
+type ownerCacheEntry struct {
+	Problem string    `json:"problem"`
+	Checked time.Time `json:"checked"`
+}
+
+// defaultOwnerCachePath is where the cache lives unless --cache-file says
+// otherwise, or "" if there's no user cache directory.
+func defaultOwnerCachePath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "requirecodeowners", "owners.json")
+}
+
+// loadOwnerCache reads the cache at path. A missing or unreadable cache
+// starts empty; it only speeds things up, so it's never an error.
+func loadOwnerCache(path string, ttl time.Duration) *ownerCache {
+	c := &ownerCache{path: path, ttl: ttl, entries: make(map[string]ownerCacheEntry)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c
+	}
+	if err == nil {
+		err = json.Unmarshal(data, &c.entries)
+	}
+	if err != nil {
+		logger.Warn("ignoring owner cache", "path", path, "error", err)
+		c.entries = make(map[string]ownerCacheEntry)
+	}
+	return c
+}
+
+func (c *ownerCache) get(key string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	e, ok := c.entries[key]
+	if !ok || time.Since(e.Checked) > c.ttl {
+		return "", false
+	}
+	logger.Debug("owner cache hit", "key", key)
+	return e.Problem, true
+}
+
+func (c *ownerCache) put(key, problem string) {
+	if c == nil {
+		return
+	}
+	c.entries[key] = ownerCacheEntry{Problem: problem, Checked: time.Now()}
+	c.changed = true
+}
+
+// save writes the cache back if anything was added, dropping expired
+// entries.
+func (c *ownerCache) save() error {
+	if c == nil || !c.changed {
+		return nil
+	}
+	for key, e := range c.entries {
+		if time.Since(e.Checked) > c.ttl {
+			delete(c.entries, key)
+		}
+	}
+	data, err := json.MarshalIndent(c.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, data, 0o644)
+}
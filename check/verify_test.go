@@ -0,0 +1,192 @@
+package check
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerifyOwners(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/orgs/org/teams/payments/members":
+			json.NewEncoder(w).Encode([]map[string]string{{"login": "alice"}, {"login": "bob"}})
+		case "/orgs/org/teams/empty/members":
+			json.NewEncoder(w).Encode([]map[string]string{})
+		case "/users/alice":
+			json.NewEncoder(w).Encode(map[string]string{"login": "alice"})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	t.Setenv("GITHUB_API_URL", srv.URL)
+
+	rules, err := parseCodeowners(strings.NewReader(`/a/ @org/payments @alice
+/b/ @org/empty dev@example.com
+/c/ @org/missing @ghost
+/d/ @org/payments
+`), "CODEOWNERS", providerGitHub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checked := []checkedDir{
+		{path: "a", rule: &rules[0]},
+		{path: "b", rule: &rules[1]},
+		{path: "c", rule: &rules[2]},
+		{path: "d", rule: &rules[3]},
+		{path: "e"},
+	}
+
+	tests := []struct {
+		name       string
+		minMembers int
+		want       map[string]string
+	}{
+		{
+			name:       "at least one member",
+			minMembers: 1,
+			want: map[string]string{
+				"@org/empty":   "Team has 0 members but at least 1 required",
+				"@org/missing": "Team not found",
+				"@ghost":       "User not found",
+			},
+		},
+		{
+			name:       "at least three members",
+			minMembers: 3,
+			want: map[string]string{
+				"@org/payments": "Team has 2 members but at least 3 required",
+				"@org/empty":    "Team has 0 members",
+				"@org/missing":  "Team not found",
+				"@ghost":        "User not found",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := verifyOwners(newGitHubClient(), checked, tt.minMembers, nil)
+			if len(errs) != len(tt.want) {
+				t.Fatalf("verifyOwners() = %v, want %d errors", errs, len(tt.want))
+			}
+			for _, e := range errs {
+				want, ok := tt.want[e.path]
+				if !ok || !strings.Contains(e.message, want) {
+					t.Errorf("verifyOwners() error for %s = %q, want it to contain %q", e.path, e.message, want)
+				}
+				if e.file != "CODEOWNERS" || e.line == 0 {
+					t.Errorf("verifyOwners() error for %s located at %s:%d, want the CODEOWNERS rule", e.path, e.file, e.line)
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyOwnersCache(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		json.NewEncoder(w).Encode([]map[string]string{})
+	}))
+	defer srv.Close()
+	t.Setenv("GITHUB_API_URL", srv.URL)
+
+	rules, err := parseCodeowners(strings.NewReader("/a/ @org/empty\n"), "CODEOWNERS", providerGitHub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checked := []checkedDir{{path: "a", rule: &rules[0]}}
+	path := filepath.Join(t.TempDir(), "cache", "owners.json")
+
+	cache := loadOwnerCache(path, time.Hour)
+	if errs := verifyOwners(newGitHubClient(), checked, 1, cache); len(errs) != 1 {
+		t.Fatalf("first run errors = %v, want 1", errs)
+	}
+	if err := cache.save(); err != nil {
+		t.Fatalf("save() error = %v", err)
+	}
+
+	errs := verifyOwners(newGitHubClient(), checked, 1, loadOwnerCache(path, time.Hour))
+	if len(errs) != 1 || !strings.Contains(errs[0].message, "Team has 0 members") {
+		t.Errorf("cached run errors = %v, want the cached problem", errs)
+	}
+	if requests != 1 {
+		t.Errorf("API requests = %d, want 1 with a warm cache", requests)
+	}
+
+	verifyOwners(newGitHubClient(), checked, 1, loadOwnerCache(path, 0))
+	if requests != 2 {
+		t.Errorf("API requests = %d, want an expired entry to be fetched again", requests)
+	}
+
+	os.WriteFile(path, []byte("not json"), 0644)
+	if c := loadOwnerCache(path, time.Hour); len(c.entries) != 0 {
+		t.Errorf("corrupt cache loaded %d entries, want an empty cache", len(c.entries))
+	}
+}
+
+func TestVerifyOwnersRateLimited(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/users/alice" {
+			json.NewEncoder(w).Encode(map[string]string{"login": "alice"})
+			return
+		}
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		http.Error(w, "API rate limit exceeded", http.StatusForbidden)
+	}))
+	defer srv.Close()
+	t.Setenv("GITHUB_API_URL", srv.URL)
+
+	rules, err := parseCodeowners(strings.NewReader("/a/ @alice @org/one @org/two\n"), "CODEOWNERS", providerGitHub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs := verifyOwners(newGitHubClient(), []checkedDir{{path: "a", rule: &rules[0]}}, 1, nil)
+	if len(errs) != 1 {
+		t.Fatalf("verifyOwners() = %v, want a single rate limit warning", errs)
+	}
+	if !errs[0].isWarning() || !strings.Contains(errs[0].message, "2 owners weren't verified: @org/one, @org/two") {
+		t.Errorf("verifyOwners() = %+v, want a warning naming the unverified owners", errs[0])
+	}
+}
+
+func TestVerifyOwnersLookupFailed(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+	}{
+		{"server error", http.StatusInternalServerError},
+		{"bad token", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "nope", tt.status)
+			}))
+			defer srv.Close()
+			t.Setenv("GITHUB_API_URL", srv.URL)
+
+			rules, err := parseCodeowners(strings.NewReader("/a/ @alice @org/one\n"), "CODEOWNERS", providerGitHub)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cache := loadOwnerCache(filepath.Join(t.TempDir(), "owners.json"), time.Hour)
+			errs := verifyOwners(newGitHubClient(), []checkedDir{{path: "a", rule: &rules[0]}}, 1, cache)
+			if len(errs) != 1 {
+				t.Fatalf("verifyOwners() = %v, want a single warning", errs)
+			}
+			if e := errs[0]; !e.isWarning() || e.code != codeVerifyFailed || !strings.Contains(e.message, "2 owners weren't verified: @alice, @org/one") {
+				t.Errorf("verifyOwners() = %+v, want a %s warning naming the unverified owners", e, codeVerifyFailed)
+			}
+			if len(cache.entries) != 0 {
+				t.Errorf("cache has %d entries, want failed lookups left out", len(cache.entries))
+			}
+		})
+	}
+}
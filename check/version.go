@@ -0,0 +1,114 @@
+package check
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"runtime/debug"
+)
+
+// Build metadata, set by the release build with -ldflags "-X
+// github.com/kpurdon/requirecodeowners/check.version=...", and commit and
+// date the same way, as .goreleaser.yml does. Builds that
+// don't set them, like go install, fall back to what the Go toolchain
+// recorded.
+var (
+	version = ""
+	commit  = ""
+	date    = ""
+)
+
+// codeownersModule is the CODEOWNERS parser, whose version decides how
+// patterns match, so it's part of the version report.
+const codeownersModule = "github.com/hmarr/codeowners"
+
+// buildInfo is what --version reports about the running binary.
+type buildInfo struct {
+	version, commit, date string
+	goVersion, platform   string
+	parser                string // the codeowners module's version
+}
+
+func runVersion(args []string) int {
+	fs := flag.NewFlagSet("version", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: requirecodeowners version")
+		fmt.Fprintln(fs.Output(), "Prints the version, commit and build date, and the CODEOWNERS parser's version.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return 2
+	}
+	info, _ := debug.ReadBuildInfo()
+	writeVersion(os.Stdout, currentBuild(info))
+	return 0
+}
+
+// currentBuild fills in the build metadata, taking what ldflags didn't set
+// from info, the toolchain's record of the build, if there is one.
+func currentBuild(info *debug.BuildInfo) buildInfo {
+	b := buildInfo{
+		version:   version,
+		commit:    commit,
+		date:      date,
+		goVersion: runtime.Version(),
+		platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	if info == nil {
+		return b
+	}
+	if b.version == "" && info.Main.Version != "(devel)" {
+		b.version = info.Main.Version
+	}
+	modified := false
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			if b.commit == "" {
+				b.commit = s.Value
+			}
+		case "vcs.time":
+			if b.date == "" {
+				b.date = s.Value
+			}
+		case "vcs.modified":
+			modified = s.Value == "true"
+		}
+	}
+	if modified && commit == "" && b.commit != "" {
+		b.commit += "-dirty"
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == codeownersModule {
+			b.parser = dep.Version
+			if dep.Replace != nil {
+				b.parser = dep.Replace.Path + " " + dep.Replace.Version
+			}
+		}
+	}
+	return b
+}
+
+func writeVersion(w io.Writer, b buildInfo) {
+	orUnknown := func(s string) string {
+		if s == "" {
+			return "unknown"
+		}
+		return s
+	}
+	v := b.version
+	if v == "" {
+		v = "devel"
+	}
+	fmt.Fprintf(w, "requirecodeowners %s\n", v)
+	fmt.Fprintf(w, "  commit:     %s\n", orUnknown(b.commit))
+	fmt.Fprintf(w, "  built:      %s\n", orUnknown(b.date))
+	fmt.Fprintf(w, "  go:         %s %s\n", b.goVersion, b.platform)
+	fmt.Fprintf(w, "  codeowners: %s %s\n", codeownersModule, orUnknown(b.parser))
+}
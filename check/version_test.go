@@ -0,0 +1,70 @@
+package check
+
+import (
+	"bytes"
+	"runtime/debug"
+	"testing"
+)
+
+func TestCurrentBuild(t *testing.T) {
+	defer func(v, c, d string) { version, commit, date = v, c, d }(version, commit, date)
+	info := &debug.BuildInfo{
+		Main: debug.Module{Version: "v1.4.0"},
+		Deps: []*debug.Module{
+			{Path: "gopkg.in/yaml.v3", Version: "v3.0.1"},
+			{Path: codeownersModule, Version: "v1.2.1"},
+		},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "0123abcd"},
+			{Key: "vcs.time", Value: "2026-01-02T03:04:05Z"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	}
+
+	tests := []struct {
+		name                  string
+		version, commit, date string
+		info                  *debug.BuildInfo
+		want                  buildInfo
+	}{
+		{
+			name: "from the toolchain",
+			info: info,
+			want: buildInfo{version: "v1.4.0", commit: "0123abcd-dirty", date: "2026-01-02T03:04:05Z", parser: "v1.2.1"},
+		},
+		{
+			name:    "from ldflags",
+			version: "v1.5.0", commit: "feedface", date: "2026-02-03T00:00:00Z",
+			info: info,
+			want: buildInfo{version: "v1.5.0", commit: "feedface", date: "2026-02-03T00:00:00Z", parser: "v1.2.1"},
+		},
+		{
+			name: "no build info",
+			want: buildInfo{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			version, commit, date = tt.version, tt.commit, tt.date
+			got := currentBuild(tt.info)
+			got.goVersion, got.platform = "", ""
+			if got != tt.want {
+				t.Errorf("currentBuild() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteVersion(t *testing.T) {
+	var b bytes.Buffer
+	writeVersion(&b, buildInfo{goVersion: "go1.23.4", platform: "linux/amd64", parser: "v1.2.1"})
+	want := `requirecodeowners devel
+  commit:     unknown
+  built:      unknown
+  go:         go1.23.4 linux/amd64
+  codeowners: github.com/hmarr/codeowners v1.2.1
+`
+	if b.String() != want {
+		t.Errorf("writeVersion() =\n%s\nwant\n%s", b.String(), want)
+	}
+}
@@ -0,0 +1,128 @@
+package check
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// workspacePackages returns the workspace packages of the JavaScript
+// monorepo at root: the directories with a package.json that match the
+// workspace globs in root's package.json, or in pnpm-workspace.yaml for pnpm.
+// Globs starting with "!" leave directories out.
+func workspacePackages(fsys fileSystem, root string) ([]string, error) {
+	globs, err := workspaceGlobs(fsys, root)
+	if err != nil {
+		return nil, err
+	}
+
+	var include, exclude []*regexp.Regexp
+	for _, glob := range globs {
+		negate := strings.HasPrefix(glob, "!")
+		glob = strings.TrimPrefix(glob, "!")
+		glob = strings.Trim(strings.TrimPrefix(glob, "./"), "/")
+		re, err := regexp.Compile("^" + globToRegexp(glob) + "$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid workspace glob %q: %w", glob, err)
+		}
+		if negate {
+			exclude = append(exclude, re)
+		} else {
+			include = append(include, re)
+		}
+	}
+	matches := func(rel string) bool {
+		for _, re := range exclude {
+			if re.MatchString(rel) {
+				return false
+			}
+		}
+		for _, re := range include {
+			if re.MatchString(rel) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var pkgs []string
+	var walk func(dir, rel string) error
+	walk = func(dir, rel string) error {
+		entries, err := fsys.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if e.IsDir() && e.Name() != "node_modules" {
+				sub, subRel := path.Join(dir, e.Name()), path.Join(rel, e.Name())
+				if hasFile(fsys, path.Join(sub, "package.json")) && matches(subRel) {
+					pkgs = append(pkgs, sub)
+				}
+				if err := walk(sub, subRel); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err := walk(root, ""); err != nil {
+		return nil, err
+	}
+	return pkgs, nil
+}
+
+// workspaceGlobs reads the workspace globs declared at root, from
+// pnpm-workspace.yaml if there is one, otherwise from the "workspaces" field
+// of package.json, which is either a list or an object with a "packages"
+// list.
+func workspaceGlobs(fsys fileSystem, root string) ([]string, error) {
+	if data, err := fsys.ReadFile(path.Join(root, "pnpm-workspace.yaml")); err == nil {
+		var ws struct {
+			Packages []string `yaml:"packages"`
+		}
+		if err := yaml.Unmarshal(data, &ws); err != nil {
+			return nil, fmt.Errorf("parsing pnpm-workspace.yaml: %w", err)
+		}
+		return ws.Packages, nil
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("reading pnpm-workspace.yaml: %w", err)
+	}
+
+	name := path.Join(root, "package.json")
+	data, err := fsys.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("reading package.json: %w", err)
+	}
+	var pkg struct {
+		Workspaces json.RawMessage `json:"workspaces"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", name, err)
+	}
+	if len(pkg.Workspaces) == 0 {
+		return nil, fmt.Errorf("%s declares no workspaces", name)
+	}
+	var globs []string
+	if err := json.Unmarshal(pkg.Workspaces, &globs); err == nil {
+		return globs, nil
+	}
+	var obj struct {
+		Packages []string `json:"packages"`
+	}
+	if err := json.Unmarshal(pkg.Workspaces, &obj); err != nil {
+		return nil, fmt.Errorf("parsing workspaces in %s: %w", name, err)
+	}
+	return obj.Packages, nil
+}
+
+// hasFile reports whether name exists and isn't a directory.
+func hasFile(fsys fileSystem, name string) bool {
+	info, err := fsys.Stat(name)
+	return err == nil && !info.IsDir()
+}
@@ -0,0 +1,88 @@
+package check
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWorkspacePackages(t *testing.T) {
+	layout := []string{
+		"packages/ui/package.json",
+		"packages/utils/package.json",
+		"packages/utils/node_modules/dep/package.json",
+		"packages/legacy/package.json",
+		"packages/notes/README.md",
+		"apps/web/package.json",
+		"apps/mobile/ios/package.json",
+		"tools/lint/package.json",
+	}
+
+	tests := []struct {
+		name    string
+		files   map[string]string
+		want    []string
+		wantErr string
+	}{
+		{
+			name:  "package.json list",
+			files: map[string]string{"package.json": `{"workspaces": ["packages/*", "apps/**", "!packages/legacy"]}`},
+			want:  []string{"apps/mobile/ios", "apps/web", "packages/ui", "packages/utils"},
+		},
+		{
+			name:  "package.json object",
+			files: map[string]string{"package.json": `{"workspaces": {"packages": ["./tools/*"], "nohoist": ["**"]}}`},
+			want:  []string{"tools/lint"},
+		},
+		{
+			name: "pnpm",
+			files: map[string]string{
+				"package.json":        `{"workspaces": ["tools/*"]}`,
+				"pnpm-workspace.yaml": "packages:\n  - 'packages/*'\n  - '!**/legacy'\n",
+			},
+			want: []string{"packages/ui", "packages/utils"},
+		},
+		{
+			name:    "no workspaces",
+			files:   map[string]string{"package.json": `{"name": "app"}`},
+			wantErr: "declares no workspaces",
+		},
+		{
+			name:    "no package.json",
+			wantErr: "reading package.json",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			for _, name := range layout {
+				os.MkdirAll(filepath.Join(tmpDir, filepath.Dir(name)), 0755)
+				os.WriteFile(filepath.Join(tmpDir, name), []byte("{}"), 0644)
+			}
+			for name, content := range tt.files {
+				os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644)
+			}
+
+			oldWd, _ := os.Getwd()
+			os.Chdir(tmpDir)
+			defer os.Chdir(oldWd)
+
+			got, err := workspacePackages(localFS{}, ".")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("workspacePackages() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("workspacePackages() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("workspacePackages() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+// Package validate lets programs built on requirecodeowners add checks of
+// their own, such as against an internal directory of teams. A Validator
+// registered from init runs on every directory a check covers, after the
+// built-in checks, which are Validators too. The program then runs the
+// checks through the check package, with check.Main or check.Run.
+package validate
+
+import (
+	"context"
+	"sync"
+)
+
+// Severities a Finding can have.
+const (
+	Error   = "error"
+	Warning = "warning"
+)
+
+// DirResult is a checked directory and the CODEOWNERS rule that owns it.
+// Validator hooks get it on stdin as JSON.
+type DirResult struct {
+	// Path is the directory, slash-separated and relative to the
+	// repository root.
+	Path string `json:"path"`
+
+	// Owners are the owning rule's owners, empty if nothing owns it.
+	Owners []string `json:"owners"`
+
+	// Rule is the owning rule's pattern, and File and Line where it is.
+	// They're empty if nothing owns the directory.
+	Rule string `json:"rule,omitempty"`
+	File string `json:"file,omitempty"`
+	Line int    `json:"line,omitempty"`
+}
+
+// Finding is a problem a Validator found.
+type Finding struct {
+	// Path is what the finding is about. It defaults to the directory.
+	Path string
+
+	Message string
+
+	// Severity is Error, the default, or Warning.
+	Severity string
+
+	// Code identifies the kind of problem in reports, like RCO050.
+	Code string
+
+	// File and Line are where to fix it, and Team whose problem it is.
+	// Without a File, they're the owning rule's, or the config's if
+	// nothing owns the directory.
+	File string
+	Line int
+	Team string
+
+	// Mandatory findings can't be suppressed.
+	Mandatory bool
+}
+
+// A Validator is a check run on each checked directory.
+type Validator interface {
+	// Name identifies the check in logs.
+	Name() string
+
+	// Check returns the problems it finds with d. It should stop early
+	// once ctx is done.
+	Check(ctx context.Context, d DirResult) []Finding
+}
+
+// Func is a Validator made of a function.
+type Func struct {
+	N string
+	F func(ctx context.Context, d DirResult) []Finding
+}
+
+// Name returns v.N.
+func (v Func) Name() string { return v.N }
+
+// Check calls v.F.
+func (v Func) Check(ctx context.Context, d DirResult) []Finding {
+	return v.F(ctx, d)
+}
+
+var (
+	mu         sync.Mutex
+	registered []Validator
+)
+
+// Register adds v to every check. It's meant to be called from init,
+// before any check runs.
+func Register(v Validator) {
+	mu.Lock()
+	defer mu.Unlock()
+	registered = append(registered, v)
+}
+
+// Registered returns the registered validators, in the order they were
+// registered.
+func Registered() []Validator {
+	mu.Lock()
+	defer mu.Unlock()
+	return append([]Validator(nil), registered...)
+}
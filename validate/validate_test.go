@@ -0,0 +1,31 @@
+package validate
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRegister(t *testing.T) {
+	defer func(saved []Validator) { registered = saved }(registered)
+	registered = nil
+
+	ldap := Func{"ldap", func(_ context.Context, d DirResult) []Finding {
+		return []Finding{{Message: d.Path + " has no team in LDAP.", Severity: Warning}}
+	}}
+	Register(ldap)
+	Register(Func{"other", func(context.Context, DirResult) []Finding { return nil }})
+
+	vs := Registered()
+	if len(vs) != 2 || vs[0].Name() != "ldap" || vs[1].Name() != "other" {
+		t.Fatalf("Registered() = %v, want ldap and other in order", vs)
+	}
+	got := vs[0].Check(context.Background(), DirResult{Path: "services/web"})
+	if len(got) != 1 || got[0].Message != "services/web has no team in LDAP." {
+		t.Errorf("Check() = %+v, want ldap's finding", got)
+	}
+	// Changing the slice returned doesn't change what's registered.
+	vs[0] = nil
+	if Registered()[0] == nil {
+		t.Error("Registered() shares its slice with callers")
+	}
+}